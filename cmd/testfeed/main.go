@@ -0,0 +1,238 @@
+// Command testfeed serves simulated EventStore atom feeds over HTTP, so that
+// test suites written in other languages, API collections and manual
+// exploration can use the simulator.
+//
+// Serve 100 generated events of the stream "orders" on port 2113:
+//
+//	testfeed -addr :2113 -stream orders -events 100 -types OrderPlaced,OrderShipped
+//
+// Serve the stream described by a fixture over TLS, writing the certificate of
+// the generated certificate authority for clients to trust:
+//
+//	testfeed -fixture orders.json -tls -ca-out ca.pem
+//
+// Faults are scripted with -fail and -drop, which can be repeated:
+//
+//	testfeed -fail '/metadata$=503' -drop 'head/backward=100'
+//
+// Streams, appends, faults and users can instead be described by a scenario
+// file, see LoadScenario:
+//
+//	testfeed -scenario orders-scenario.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/feedsim"
+)
+
+// config holds the command line flags.
+type config struct {
+	addr     string
+	fixture  string
+	scenario string
+	stream   string
+	events   int
+	types    string
+	trickle  int
+	auth     string
+	tls      bool
+	caOut    string
+	metrics  bool
+	failures ruleList
+	drops    ruleList
+}
+
+// ruleList is a repeatable flag of PATTERN=N rules.
+type ruleList []string
+
+func (r *ruleList) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *ruleList) Set(v string) error {
+	if _, _, err := splitRule(v); err != nil {
+		return err
+	}
+	*r = append(*r, v)
+	return nil
+}
+
+// splitRule splits a PATTERN=N rule at its last equals sign.
+func splitRule(v string) (string, int, error) {
+	i := strings.LastIndex(v, "=")
+	if i < 0 {
+		return "", 0, fmt.Errorf("rule %q must have the form PATTERN=N", v)
+	}
+	n, err := strconv.Atoi(v[i+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("rule %q must have the form PATTERN=N", v)
+	}
+	return v[:i], n, nil
+}
+
+func parseFlags(args []string, output io.Writer) (*config, error) {
+	cfg := &config{}
+	fs := flag.NewFlagSet("testfeed", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&cfg.addr, "addr", "127.0.0.1:2113", "address to listen on")
+	fs.StringVar(&cfg.fixture, "fixture", "", "JSON stream fixture to serve instead of generated events")
+	fs.StringVar(&cfg.scenario, "scenario", "", "JSON scenario to serve instead of generated events")
+	fs.StringVar(&cfg.stream, "stream", "astream", "name of the stream of generated events")
+	fs.IntVar(&cfg.events, "events", 100, "number of generated events")
+	fs.StringVar(&cfg.types, "types", "EventTypeA,EventTypeB", "comma separated event types of generated events")
+	fs.IntVar(&cfg.trickle, "trickle", -1, "number of events visible before the rest trickle in to long polls, -1 for all")
+	fs.StringVar(&cfg.auth, "auth", "", "require basic auth with the credentials USER:PASSWORD")
+	fs.BoolVar(&cfg.tls, "tls", false, "serve HTTPS with a certificate signed by a generated certificate authority")
+	fs.StringVar(&cfg.caOut, "ca-out", "", "file to write the PEM certificate of the generated certificate authority to")
+	fs.BoolVar(&cfg.metrics, "metrics", false, "serve Prometheus metrics at /metrics")
+	fs.Var(&cfg.failures, "fail", "fail requests whose url matches PATTERN with the status code N, as PATTERN=N")
+	fs.Var(&cfg.drops, "drop", "drop the connection after N bytes of the responses to requests whose url matches PATTERN, as PATTERN=N")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if cfg.fixture != "" && cfg.scenario != "" {
+		return nil, fmt.Errorf("-fixture and -scenario cannot be used together")
+	}
+	if cfg.auth != "" && !strings.Contains(cfg.auth, ":") {
+		return nil, fmt.Errorf("-auth must have the form USER:PASSWORD")
+	}
+	return cfg, nil
+}
+
+// newHandler returns the handler serving the simulator configured by cfg with
+// the base url baseURL.
+func newHandler(cfg *config, baseURL *url.URL) (http.Handler, error) {
+	opts := []feedsim.Option{
+		feedsim.WithBaseURL(baseURL),
+		feedsim.WithRequestHostLinks(),
+		feedsim.WithTrickle(cfg.trickle),
+	}
+	if cfg.auth != "" {
+		i := strings.Index(cfg.auth, ":")
+		opts = append(opts, feedsim.WithBasicAuth(cfg.auth[:i], cfg.auth[i+1:]))
+	}
+	if cfg.metrics {
+		opts = append(opts, feedsim.WithMetrics())
+	}
+
+	var sim *feedsim.AtomFeedSimulator
+	var fi *faults.FaultInjector
+	var err error
+	switch {
+	case cfg.scenario != "":
+		var sc *feedsim.Scenario
+		sc, err = feedsim.LoadScenario(cfg.scenario, opts...)
+		if sc != nil {
+			sim, fi = sc.Simulator, sc.Faults
+		}
+	case cfg.fixture != "":
+		sim, err = feedsim.LoadStreamFixture(cfg.fixture, opts...)
+	default:
+		es := eventdata.CreateTestEvents(cfg.events, cfg.stream, baseURL.String(), strings.Split(cfg.types, ",")...)
+		sim, err = feedsim.NewAtomFeedSimulator(append(opts, feedsim.WithEvents(es...))...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if fi == nil {
+		if len(cfg.failures) == 0 && len(cfg.drops) == 0 {
+			return sim, nil
+		}
+		fi = faults.NewFaultInjector(sim)
+	}
+	for _, v := range cfg.failures {
+		pattern, status, _ := splitRule(v)
+		if err := fi.FailMatching(pattern, status); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range cfg.drops {
+		pattern, n, _ := splitRule(v)
+		if err := fi.DropConnectionMatching(pattern, n); err != nil {
+			return nil, err
+		}
+	}
+	return fi, nil
+}
+
+func run(args []string, stderr io.Writer) error {
+	cfg, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", cfg.addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	u := &url.URL{Scheme: "http", Host: l.Addr().String()}
+	srv := &http.Server{}
+
+	if cfg.tls {
+		tlsConfig, caPEM, err := feedsim.SelfSignedTLS()
+		if err != nil {
+			return err
+		}
+		if cfg.caOut != "" {
+			if err := ioutil.WriteFile(cfg.caOut, caPEM, 0644); err != nil {
+				return err
+			}
+		}
+		u.Scheme = "https"
+		srv.TLSConfig = tlsConfig
+	}
+
+	h, err := newHandler(cfg, u)
+	if err != nil {
+		return err
+	}
+	srv.Handler = h
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(stderr, "serving simulated feeds at %s\n", u)
+	if cfg.tls {
+		err = srv.ServeTLS(l, "", "")
+	} else {
+		err = srv.Serve(l)
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+		log.Fatal(err)
+	}
+}
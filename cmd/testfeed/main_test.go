@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+type MainSuite struct{}
+
+var _ = Suite(&MainSuite{})
+
+func Test(t *testing.T) { TestingT(t) }
+
+func (s *MainSuite) serve(c *C, args ...string) *httptest.Server {
+	cfg, err := parseFlags(args, ioutil.Discard)
+	c.Assert(err, IsNil)
+	srv := httptest.NewUnstartedServer(nil)
+	u, _ := url.Parse("http://" + srv.Listener.Addr().String())
+	h, err := newHandler(cfg, u)
+	c.Assert(err, IsNil)
+	srv.Config.Handler = h
+	srv.Start()
+	return srv
+}
+
+func (s *MainSuite) TestServesGeneratedEvents(c *C) {
+	srv := s.serve(c, "-stream", "orders", "-events", "30", "-types", "OrderPlaced")
+	defer srv.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/orders", srv.URL))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	f := &atom.Feed{}
+	c.Assert(xml.NewDecoder(resp.Body).Decode(f), IsNil)
+	c.Assert(f.Entry, HasLen, 20)
+	c.Assert(f.Link[0].Href, Equals, fmt.Sprintf("%s/streams/orders", srv.URL))
+}
+
+func (s *MainSuite) TestServesFaultsAndAuth(c *C) {
+	srv := s.serve(c, "-stream", "orders", "-events", "3", "-auth", "admin:changeit", "-fail", "/metadata$=503")
+	defer srv.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/orders", srv.URL))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusUnauthorized)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/orders", srv.URL), nil)
+	req.SetBasicAuth("admin", "changeit")
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("%s/streams/orders/metadata", srv.URL), nil)
+	req.SetBasicAuth("admin", "changeit")
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusServiceUnavailable)
+}
+
+func (s *MainSuite) TestRunClosesListenerOnError(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	addr := l.Addr().String()
+	l.Close()
+
+	caOut := filepath.Join(c.MkDir(), "missing", "ca.pem")
+	err = run([]string{"-addr", addr, "-tls", "-ca-out", caOut}, ioutil.Discard)
+	c.Assert(err, NotNil)
+
+	l, err = net.Listen("tcp", addr)
+	c.Assert(err, IsNil)
+	l.Close()
+}
+
+func (s *MainSuite) TestParseFlagsErrors(c *C) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-fixture", "a.json", "-scenario", "b.json"}, "-fixture and -scenario cannot be used together"},
+		{[]string{"-auth", "admin"}, "-auth must have the form USER:PASSWORD"},
+		{[]string{"-fail", "/metadata"}, `invalid value "/metadata" for flag -fail: rule "/metadata" must have the form PATTERN=N`},
+		{[]string{"-drop", "head=x"}, `invalid value "head=x" for flag -drop: rule "head=x" must have the form PATTERN=N`},
+	}
+	for _, tt := range tests {
+		_, err := parseFlags(tt.args, ioutil.Discard)
+		c.Assert(err, ErrorMatches, tt.err)
+	}
+}
+
+func (s *MainSuite) TestServesScenario(c *C) {
+	srv := s.serve(c, "-scenario", filepath.Join("..", "..", "feedsim", "testdata", "scenario.json"), "-fail", "/1$=502")
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/streams/orders-2", http.StatusOK},
+		{"/streams/orders-2/metadata", http.StatusServiceUnavailable},
+		{"/streams/orders-2/1", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", srv.URL+tt.path, nil)
+		req.SetBasicAuth("admin", "changeit")
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, tt.status, Commentf(tt.path))
+	}
+}
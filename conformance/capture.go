@@ -0,0 +1,163 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// EnvCapture is the environment variable holding the directory that the feed
+// documents captured from the server at EnvURL are written to.
+const EnvCapture = "EVENTSTORE_CAPTURE"
+
+// ProvenanceFile is the name of the file recording where the documents in a
+// capture directory came from.
+const ProvenanceFile = "provenance.json"
+
+// Provenance records the server that a set of feed documents were captured
+// from and the requests that produced them.
+type Provenance struct {
+	Server    string     `json:"server"`
+	Version   string     `json:"version"`
+	Captured  time.Time  `json:"captured"`
+	Stream    string     `json:"stream"`
+	EventType string     `json:"eventType"`
+	Events    int        `json:"events"`
+	Documents []Document `json:"documents"`
+}
+
+// Document is a feed document captured from the server.
+type Document struct {
+	// Path is the path of the request relative to the stream, e.g.
+	// "5/forward/20", or empty for the head of the stream.
+	Path         string `json:"path"`
+	File         string `json:"file"`
+	ETag         string `json:"etag"`
+	CacheControl string `json:"cacheControl"`
+}
+
+// StrictDocuments are the pages captured for the tests of the strict head of
+// stream mode of the simulator, keyed by the file they are written to.
+var StrictDocuments = map[string]string{
+	"head.xml":         "",
+	"5-forward-20.xml": "5/forward/20",
+	"0-forward-2.xml":  "0/forward/2",
+}
+
+// Capture writes count events of type eventType to a new stream on the server
+// at baseURL, requests the pages docs of the stream as Atom XML and writes
+// each response body to the file it is keyed by in dir, together with a
+// provenance file describing the capture.
+func Capture(client *http.Client, baseURL, dir string, count int, eventType string, docs map[string]string) (*Provenance, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	u.User = nil
+	server := strings.TrimRight(u.String(), "/")
+
+	stream := fmt.Sprintf("capture-%d", time.Now().UnixNano())
+	es := eventdata.CreateTestEvents(count, stream, server, eventType)
+	if err := WriteEvents(client, baseURL, stream, es); err != nil {
+		return nil, err
+	}
+
+	p := &Provenance{
+		Server:    server,
+		Captured:  time.Now().UTC(),
+		Stream:    stream,
+		EventType: eventType,
+		Events:    count,
+	}
+	if p.Version, err = serverVersion(client, baseURL); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	for _, file := range sortedFiles(docs) {
+		d := Document{Path: docs[file], File: file}
+		b, h, err := get(client, baseURL, streamPath(stream, d.Path), "application/atom+xml")
+		if err != nil {
+			return nil, err
+		}
+		d.ETag = h.Get("ETag")
+		d.CacheControl = h.Get("Cache-Control")
+		if err := ioutil.WriteFile(filepath.Join(dir, file), b, 0644); err != nil {
+			return nil, err
+		}
+		p.Documents = append(p.Documents, d)
+	}
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return p, ioutil.WriteFile(filepath.Join(dir, ProvenanceFile), append(b, '\n'), 0644)
+}
+
+// serverVersion returns the version the server at baseURL reports at /info.
+func serverVersion(client *http.Client, baseURL string) (string, error) {
+	b, _, err := get(client, baseURL, "/info", "application/json")
+	if err != nil {
+		return "", err
+	}
+	var info struct {
+		ESVersion string `json:"esVersion"`
+	}
+	if err := json.Unmarshal(b, &info); err != nil {
+		return "", fmt.Errorf("reading server version: %v", err)
+	}
+	return info.ESVersion, nil
+}
+
+// get requests path from the server at baseURL and returns the body and
+// headers of a successful response.
+func get(client *http.Client, baseURL, path, accept string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(baseURL, "/")+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("requesting %s: %s", path, resp.Status)
+	}
+	return b, resp.Header, nil
+}
+
+// streamPath returns the path of the page of stream at path relative to the
+// stream.
+func streamPath(stream, path string) string {
+	if path == "" {
+		return "/streams/" + stream
+	}
+	return "/streams/" + stream + "/" + path
+}
+
+func sortedFiles(docs map[string]string) []string {
+	files := make([]string, 0, len(docs))
+	for f := range docs {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,29 @@
+// Package mock simulates the Atom feeds of a GetEventStore server so that
+// clients reading streams over HTTP can be tested without a server.
+//
+// The simulator is split into three packages, each of which can be imported
+// on its own.
+//
+// Package feedsim serves simulated streams. AtomFeedSimulator is the
+// http.Handler serving a stream, created with NewAtomFeedSimulator and
+// configured with options such as WithEvents, WithBaseURL, WithTrickle,
+// WithAppendSchedule, WithPagingMode, WithLatency and WithRateLimit.
+// StreamRouter serves several streams and runs projections, LoadStreamFixture,
+// LoadRouterFixture and LoadScenario load streams from files, ReaderHarness
+// checks the delivery guarantees of a catch-up reader, and StartServer,
+// NewCluster, WithGRPC and StartTCPServer serve simulators to clients under
+// test.
+//
+// Package eventdata builds the events the simulator serves: CreateTestEvents
+// and its variants, EventGenerator for control over ids, types and sizes,
+// Faker for realistic data and CreateCausalChain for correlated events.
+//
+// Package faults fails requests in front of a simulator. FaultInjector fails
+// or drops scripted requests, ScenarioRunner moves a node through healthy,
+// unreachable and degraded phases, and Latency values delay the requests
+// matched by feedsim.WithLatency.
+//
+// Package mock re-exports the types, constants, variables and functions of
+// all three packages, so code written before the split keeps compiling. New
+// code should import the packages it uses directly.
+package mock
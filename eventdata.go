@@ -0,0 +1,173 @@
+package mock
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// Types of package eventdata, aliased for code written against package mock.
+type (
+	Event             = eventdata.Event
+	Link              = eventdata.Link
+	TimeStr           = eventdata.TimeStr
+	EventResponse     = eventdata.EventResponse
+	EventAtomResponse = eventdata.EventAtomResponse
+	Faker             = eventdata.Faker
+	EventGenerator    = eventdata.EventGenerator
+	UUIDProvider      = eventdata.UUIDProvider
+	TypeCount         = eventdata.TypeCount
+	SizeDistribution  = eventdata.SizeDistribution
+	ProtoField        = eventdata.ProtoField
+	ProtoMessage      = eventdata.ProtoMessage
+	AvroSchema        = eventdata.AvroSchema
+)
+
+// Constants of package eventdata.
+const (
+	CheckpointEventType = eventdata.CheckpointEventType
+	CorrelationIDKey    = eventdata.CorrelationIDKey
+	CausationIDKey      = eventdata.CausationIDKey
+)
+
+// CheckpointStreamName calls eventdata.CheckpointStreamName.
+func CheckpointStreamName(stream, group string) string {
+	return eventdata.CheckpointStreamName(stream, group)
+}
+
+// CreateCheckpointEvents calls eventdata.CreateCheckpointEvents.
+func CreateCheckpointEvents(stream, group, server string, positions ...int) []*Event {
+	return eventdata.CreateCheckpointEvents(stream, group, server, positions...)
+}
+
+// CausedBy calls eventdata.CausedBy.
+func CausedBy(e, cause *Event) *Event {
+	return eventdata.CausedBy(e, cause)
+}
+
+// CreateTestEventFromData calls eventdata.CreateTestEventFromData.
+func CreateTestEventFromData(stream, server string, eventNumber int, data interface{}, meta interface{}) *Event {
+	return eventdata.CreateTestEventFromData(stream, server, eventNumber, data, meta)
+}
+
+// CreateTestEvent calls eventdata.CreateTestEvent.
+func CreateTestEvent(stream, server, eventType string, eventNumber int, data *json.RawMessage, meta *json.RawMessage) *Event {
+	return eventdata.CreateTestEvent(stream, server, eventType, eventNumber, data, meta)
+}
+
+// CreateTestEvents calls eventdata.CreateTestEvents.
+func CreateTestEvents(numEvents int, stream string, server string, eventTypes ...string) []*Event {
+	return eventdata.CreateTestEvents(numEvents, stream, server, eventTypes...)
+}
+
+// CreateInterleavedEvents calls eventdata.CreateInterleavedEvents.
+func CreateInterleavedEvents(repeats int, stream, server string, pattern ...TypeCount) []*Event {
+	return eventdata.CreateInterleavedEvents(repeats, stream, server, pattern...)
+}
+
+// CreateWeightedEvents calls eventdata.CreateWeightedEvents.
+func CreateWeightedEvents(count int, stream, server string, weights map[string]int) []*Event {
+	return eventdata.CreateWeightedEvents(count, stream, server, weights)
+}
+
+// CreateFakeEvents calls eventdata.CreateFakeEvents.
+func CreateFakeEvents(count int, stream, server string, eventTypes ...string) []*Event {
+	return eventdata.CreateFakeEvents(count, stream, server, eventTypes...)
+}
+
+// CreateTestEventsFromData calls eventdata.CreateTestEventsFromData.
+func CreateTestEventsFromData(stream, server string, data ...interface{}) []*Event {
+	return eventdata.CreateTestEventsFromData(stream, server, data...)
+}
+
+// CreateTestEventsWith calls eventdata.CreateTestEventsWith.
+func CreateTestEventsWith(count int, stream, server string, gen func(i int) (eventType string, data, meta interface{})) []*Event {
+	return eventdata.CreateTestEventsWith(count, stream, server, gen)
+}
+
+// CreateProtobufEvents calls eventdata.CreateProtobufEvents.
+func CreateProtobufEvents(count int, stream, server string, messages ...ProtoMessage) ([]*Event, error) {
+	return eventdata.CreateProtobufEvents(count, stream, server, messages...)
+}
+
+// CreateAvroEvents calls eventdata.CreateAvroEvents.
+func CreateAvroEvents(count int, stream, server string, schemas ...*AvroSchema) ([]*Event, error) {
+	return eventdata.CreateAvroEvents(count, stream, server, schemas...)
+}
+
+// CreateCausalChain calls eventdata.CreateCausalChain.
+func CreateCausalChain(stream, server string, eventTypes ...string) []*Event {
+	return eventdata.CreateCausalChain(stream, server, eventTypes...)
+}
+
+// Time calls eventdata.Time.
+func Time(t time.Time) TimeStr {
+	return eventdata.Time(t)
+}
+
+// CreateTestEventResponse calls eventdata.CreateTestEventResponse.
+func CreateTestEventResponse(e *Event, tm *TimeStr) *EventResponse {
+	return eventdata.CreateTestEventResponse(e, tm)
+}
+
+// CreateTestEventResponses calls eventdata.CreateTestEventResponses.
+func CreateTestEventResponses(events []*Event, tm *TimeStr) []*EventResponse {
+	return eventdata.CreateTestEventResponses(events, tm)
+}
+
+// CreateTestEventAtomResponse calls eventdata.CreateTestEventAtomResponse.
+func CreateTestEventAtomResponse(e *Event, tm *TimeStr) (*EventAtomResponse, error) {
+	return eventdata.CreateTestEventAtomResponse(e, tm)
+}
+
+// NewEventGenerator calls eventdata.NewEventGenerator.
+func NewEventGenerator(src rand.Source) *EventGenerator {
+	return eventdata.NewEventGenerator(src)
+}
+
+// SequentialUUIDs calls eventdata.SequentialUUIDs.
+func SequentialUUIDs() UUIDProvider {
+	return eventdata.SequentialUUIDs()
+}
+
+// NameBasedUUIDs calls eventdata.NameBasedUUIDs.
+func NameBasedUUIDs(namespace string) UUIDProvider {
+	return eventdata.NameBasedUUIDs(namespace)
+}
+
+// FixedSize calls eventdata.FixedSize.
+func FixedSize(n int) SizeDistribution {
+	return eventdata.FixedSize(n)
+}
+
+// UniformSize calls eventdata.UniformSize.
+func UniformSize(min, max int) SizeDistribution {
+	return eventdata.UniformSize(min, max)
+}
+
+// OccasionalSize calls eventdata.OccasionalSize.
+func OccasionalSize(every, size int, otherwise SizeDistribution) SizeDistribution {
+	return eventdata.OccasionalSize(every, size, otherwise)
+}
+
+// EventLinks calls eventdata.EventLinks.
+func EventLinks(stream, server string, eventNumber int) []Link {
+	return eventdata.EventLinks(stream, server, eventNumber)
+}
+
+// EncodeProtobuf calls eventdata.EncodeProtobuf.
+func EncodeProtobuf(m ProtoMessage, values map[string]interface{}) ([]byte, error) {
+	return eventdata.EncodeProtobuf(m, values)
+}
+
+// ParseAvroSchema calls eventdata.ParseAvroSchema.
+func ParseAvroSchema(b []byte) (*AvroSchema, error) {
+	return eventdata.ParseAvroSchema(b)
+}
+
+// EncodeAvro calls eventdata.EncodeAvro.
+func EncodeAvro(s *AvroSchema, values map[string]interface{}) ([]byte, error) {
+	return eventdata.EncodeAvro(s, values)
+}
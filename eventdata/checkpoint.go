@@ -0,0 +1,41 @@
+package eventdata
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// CheckpointEventType is the type of the events in which the server records
+// the checkpoints of a persistent subscription.
+const CheckpointEventType = "$SubscriptionCheckpoint"
+
+// CheckpointStreamName returns the name of the stream in which the server
+// records the checkpoints of the persistent subscription group to stream.
+func CheckpointStreamName(stream, group string) string {
+	return "$persistentsubscription-" + stream + "::" + group + "-checkpoint"
+}
+
+// CreateCheckpointEvents returns the events of the checkpoint stream of the
+// persistent subscription group to stream after the subscription checkpointed
+// each of positions in turn. The data of each event is the position, as
+// written by the server.
+//
+// Persistent subscription groups served by a feedsim.AtomFeedSimulator write
+// their own checkpoint streams as consumers settle events. The events
+// returned describe a checkpoint stream up front, served alone or alongside
+// the subscribed stream with a feedsim.StreamRouter, so code that monitors
+// subscriptions by reading their checkpoint streams can be tested without
+// consuming the subscription.
+//
+//	es := eventdata.CreateCheckpointEvents("orders", "billing", server.URL, 10, 25, 40)
+//	sim, _ := feedsim.NewAtomFeedSimulator(feedsim.WithEvents(es...),
+//		feedsim.WithStream(eventdata.CheckpointStreamName("orders", "billing")))
+func CreateCheckpointEvents(stream, group, server string, positions ...int) []*Event {
+	name := CheckpointStreamName(stream, group)
+	es := make([]*Event, len(positions))
+	for i, p := range positions {
+		data := json.RawMessage(strconv.Itoa(p))
+		es[i] = CreateTestEvent(name, server, CheckpointEventType, i, &data, nil)
+	}
+	return es
+}
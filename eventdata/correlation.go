@@ -0,0 +1,72 @@
+package eventdata
+
+import "encoding/json"
+
+// Keys of the correlation and causation ids in the metadata of events. The
+// $by_correlation_id projection of the server reads CorrelationIDKey by
+// default.
+const (
+	CorrelationIDKey = "$correlationId"
+	CausationIDKey   = "$causationId"
+)
+
+// CreateCausalChain returns one event for each of eventTypes, numbered from 0,
+// as a chain of events each caused by the one before. See CreateCausalChain.
+func (g *EventGenerator) CreateCausalChain(stream, server string, eventTypes ...string) []*Event {
+	se := []*Event{}
+	for i, t := range eventTypes {
+		e := g.createRandomEvent(stream, server, t, i)
+		if i == 0 {
+			setCorrelation(e, e.EventID, e.EventID)
+		} else {
+			CausedBy(e, se[i-1])
+		}
+		se = append(se, e)
+	}
+	return se
+}
+
+// CausedBy sets the metadata of e to record that it was caused by the event
+// cause: its CausationIDKey is the id of cause and its CorrelationIDKey the
+// correlation id of cause, or the id of cause if cause has none. Other keys of
+// the metadata of e are kept if it is a json object. It returns e, so chains
+// can span streams:
+//
+//	placed := eventdata.CreateCausalChain("orders", server.URL, "OrderPlaced")[0]
+//	charged := eventdata.CausedBy(eventdata.CreateTestEvents(1, "payments", server.URL, "CardCharged")[0], placed)
+func CausedBy(e, cause *Event) *Event {
+	correlation := cause.EventID
+	var meta map[string]interface{}
+	if cause.MetaData != nil && unmarshalData(cause.MetaData, &meta) == nil {
+		if id, ok := meta[CorrelationIDKey].(string); ok && id != "" {
+			correlation = id
+		}
+	}
+	setCorrelation(e, correlation, cause.EventID)
+	return e
+}
+
+// setCorrelation sets the correlation and causation ids in the metadata of e.
+func setCorrelation(e *Event, correlation, causation string) {
+	meta := map[string]interface{}{}
+	if e.MetaData != nil {
+		var m map[string]interface{}
+		if unmarshalData(e.MetaData, &m) == nil && m != nil {
+			meta = m
+		}
+	}
+	meta[CorrelationIDKey] = correlation
+	meta[CausationIDKey] = causation
+	b, _ := json.Marshal(meta)
+	raw := json.RawMessage(b)
+	e.MetaData = &raw
+}
+
+// unmarshalData unmarshals the data or metadata d of an event into v.
+func unmarshalData(d interface{}, v interface{}) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
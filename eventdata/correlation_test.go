@@ -0,0 +1,30 @@
+package eventdata
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *EventDataSuite) TestCreateCausalChainLinksEvents(c *C) {
+	es := CreateCausalChain("orders", server, "OrderPlaced", "OrderPaid", "OrderShipped")
+	c.Assert(es, HasLen, 3)
+	c.Assert(es[2].EventNumber, Equals, 2)
+	c.Assert(es[1].EventType, Equals, "OrderPaid")
+
+	meta := func(e *Event) map[string]string {
+		var m map[string]string
+		c.Assert(unmarshalData(e.MetaData, &m), IsNil)
+		return m
+	}
+	c.Assert(meta(es[0])[CorrelationIDKey], Equals, es[0].EventID)
+	c.Assert(meta(es[0])[CausationIDKey], Equals, es[0].EventID)
+	c.Assert(meta(es[2])[CorrelationIDKey], Equals, es[0].EventID)
+	c.Assert(meta(es[2])[CausationIDKey], Equals, es[1].EventID)
+	c.Assert(meta(es[2])["bar"], Equals, es[2].EventID)
+
+	refund := CausedBy(CreateTestEvents(1, "payments", server, "Refunded")[0], es[2])
+	c.Assert(meta(refund)[CorrelationIDKey], Equals, es[0].EventID)
+	c.Assert(meta(refund)[CausationIDKey], Equals, es[2].EventID)
+
+	first := CausedBy(CreateTestEvent("payments", server, "Charged", 0, nil, nil), &Event{EventID: "cause"})
+	c.Assert(meta(first)[CorrelationIDKey], Equals, "cause")
+}
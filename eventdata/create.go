@@ -0,0 +1,138 @@
+package eventdata
+
+import "encoding/json"
+
+// CreateTestEventFromData returns test events derived from the user specified data
+//
+// Should be used where you require the simulator to return events of your own type
+// with your own content.
+func CreateTestEventFromData(stream, server string, eventNumber int, data interface{}, meta interface{}) *Event {
+	return defaultGenerator.CreateTestEventFromData(stream, server, eventNumber, data, meta)
+}
+
+// CreateTestEvent will generate a test event.
+//
+// The event data and meta will be a *json.RawMessage.
+// The type of the event returned will be derived from the eventType argument.
+// The event will have a single field named Foo which will contain random content
+// which is simply a uuid string.
+// The meta returned will contain a single field named Bar which will also contain
+// a uuid string.
+func CreateTestEvent(stream, server, eventType string, eventNumber int, data *json.RawMessage, meta *json.RawMessage) *Event {
+	return defaultGenerator.CreateTestEvent(stream, server, eventType, eventNumber, data, meta)
+}
+
+// CreateTestEvents will return a slice of random test events.
+//
+// The types of the events will be randomly selected from the event type names passed in to the
+// variadic argument eventTypes
+//
+// Use an EventGenerator created with a seeded source to create the same events
+// on every run.
+func CreateTestEvents(numEvents int, stream string, server string, eventTypes ...string) []*Event {
+	return defaultGenerator.CreateTestEvents(numEvents, stream, server, eventTypes...)
+}
+
+// CreateInterleavedEvents will return a slice of test events whose event types
+// follow the pattern, repeated repeats times. For example
+//
+//	es := eventdata.CreateInterleavedEvents(10, "orders", server.URL,
+//		eventdata.TypeCount{EventType: "OrderCreated", Count: 1},
+//		eventdata.TypeCount{EventType: "ItemAdded", Count: 3},
+//		eventdata.TypeCount{EventType: "OrderPaid", Count: 1})
+//
+// returns fifty events describing ten orders of three items each.
+func CreateInterleavedEvents(repeats int, stream, server string, pattern ...TypeCount) []*Event {
+	return defaultGenerator.CreateInterleavedEvents(repeats, stream, server, pattern...)
+}
+
+// CreateWeightedEvents will return a slice of count test events whose event
+// types are chosen at random in proportion to their weights. For example a
+// weight of 1 for "OrderCreated" and 5 for "ItemAdded" produces around five
+// times as many ItemAdded events as OrderCreated events.
+func CreateWeightedEvents(count int, stream, server string, weights map[string]int) []*Event {
+	return defaultGenerator.CreateWeightedEvents(count, stream, server, weights)
+}
+
+// CreateFakeEvents will return a slice of count test events whose data looks
+// like a real order, with a customer name, email, address, product, amount of
+// money and ISO 8601 timestamp. The event types are chosen at random from
+// eventTypes.
+//
+// Use the Faker of an EventGenerator to build realistic payloads of your own.
+func CreateFakeEvents(count int, stream, server string, eventTypes ...string) []*Event {
+	return defaultGenerator.CreateFakeEvents(count, stream, server, eventTypes...)
+}
+
+// CreateTestEventsFromData will return a slice of events, one for each of the
+// values in data, numbered sequentially from 0.
+//
+// The values are marshalled to json and the event type of each event is the
+// name of the type of its value, so domain event types can be served without
+// any conversion code. Use an EventGenerator with an EventType func to derive
+// the event types differently.
+//
+//	es := eventdata.CreateTestEventsFromData("orders", server.URL,
+//		&OrderCreated{OrderID: "1"},
+//		&ItemAdded{OrderID: "1", Quantity: 2},
+//		&OrderPaid{OrderID: "1"})
+func CreateTestEventsFromData(stream, server string, data ...interface{}) []*Event {
+	return defaultGenerator.CreateTestEventsFromData(stream, server, data...)
+}
+
+// CreateTestEventsWith will return a slice of count test events generated by
+// gen, which is called with the number of each event and returns its event
+// type, data and metadata.
+//
+// The data and metadata are marshalled to json, so your own domain event types
+// can be used to generate realistic events. If gen returns nil metadata the
+// event has no metadata.
+//
+//	es := eventdata.CreateTestEventsWith(10, "orders", server.URL, func(i int) (string, interface{}, interface{}) {
+//		return "ItemAdded", &ItemAdded{OrderID: "1", Quantity: i}, nil
+//	})
+func CreateTestEventsWith(count int, stream, server string, gen func(i int) (eventType string, data, meta interface{})) []*Event {
+	return defaultGenerator.CreateTestEventsWith(count, stream, server, gen)
+}
+
+// CreateProtobufEvents will return a slice of count test events whose data
+// are protocol buffers messages chosen at random from messages and filled with
+// random values. The event type of each event is the name of its message.
+//
+// The data of the events are []byte, which makes them binary events, as events
+// written with isJson false are. Binary data is served as base64 in json
+// responses and as raw bytes over gRPC, with the content type
+// application/octet-stream, and TCP. Use EncodeProtobuf to build messages
+// with values of your own.
+//
+// An error is returned if no messages are given or a message is not valid. A
+// message must have a name, and each of its fields a name, a positive number
+// used by no other field and a supported type.
+func CreateProtobufEvents(count int, stream, server string, messages ...ProtoMessage) ([]*Event, error) {
+	return defaultGenerator.CreateProtobufEvents(count, stream, server, messages...)
+}
+
+// CreateAvroEvents will return a slice of count test events whose data are
+// Avro records of schemas chosen at random and filled with random values. The
+// event type of each event is the name of its record. As with
+// CreateProtobufEvents the events are binary events. Use EncodeAvro to build
+// records with values of your own. An error is returned if no schemas are
+// given or a schema was not created by ParseAvroSchema.
+//
+//	s, _ := eventdata.ParseAvroSchema([]byte(`{"type": "record", "name": "OrderPlaced",
+//		"fields": [{"name": "orderId", "type": "string"}, {"name": "total", "type": "double"}]}`))
+//	es, err := eventdata.CreateAvroEvents(10, "orders", server.URL, s)
+func CreateAvroEvents(count int, stream, server string, schemas ...*AvroSchema) ([]*Event, error) {
+	return defaultGenerator.CreateAvroEvents(count, stream, server, schemas...)
+}
+
+// CreateCausalChain will return one event for each of eventTypes, numbered
+// sequentially from 0, whose metadata link each event to the event before it,
+// as messages handled by a saga or process manager are linked. The first event
+// is its own correlation and causation; every later event carries the
+// correlation id of the first event as its $correlationId and the id of the
+// event before it as its $causationId. Use CausedBy to continue a chain in
+// another stream.
+func CreateCausalChain(stream, server string, eventTypes ...string) []*Event {
+	return defaultGenerator.CreateCausalChain(stream, server, eventTypes...)
+}
@@ -0,0 +1,8 @@
+// Package eventdata builds the events served by the feed simulator.
+//
+// CreateTestEvents and its variants create streams of events with links
+// pointing at a server. An EventGenerator gives control over ids, event types
+// and payload sizes, Faker fills events with realistic data, and
+// CreateProtobufEvents and CreateAvroEvents create events with binary data.
+// CreateCausalChain creates events linked by correlation and causation ids.
+package eventdata
@@ -0,0 +1,186 @@
+package eventdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event encapsulates the data of an eventstore event.
+//
+// EventStreamID is the id returned in the event atom response.
+// EventNumber represents the stream version for this event.
+// EventType describes the event type.
+// EventID is the guid of the event.
+// Data contains the data of the event.
+// Links contains the urls of the event on the evenstore
+// MetaData contains the metadata for the event.
+// Created is the time at which the event was written. It is reported as the
+// updated time of the event and is not part of the json representation.
+type Event struct {
+	EventStreamID string      `json:"eventStreamId,omitempty"`
+	EventNumber   int         `json:"eventNumber,omitempty"`
+	EventType     string      `json:"eventType,omitempty"`
+	EventID       string      `json:"eventId,omitempty"`
+	Data          interface{} `json:"data"`
+	Links         []Link      `json:"links,omitempty"`
+	MetaData      interface{} `json:"metadata,omitempty"`
+	Created       time.Time   `json:"-"`
+}
+
+// PrettyPrint renders an indented json view of the Event object.
+func (e *Event) PrettyPrint() string {
+	b, err := json.MarshalIndent(e, "", "	")
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// Link encapsulates url data for events.
+type Link struct {
+	URI      string `json:"uri"`
+	Relation string `json:"relation"`
+}
+
+// TimeStr is a type used to format feed dates.
+type TimeStr string
+
+// Time returns a TimeStr version of the time.Time argument t.
+func Time(t time.Time) TimeStr {
+	return TimeStr(t.Format("2006-01-02T15:04:05-07:00"))
+}
+
+// EventResponse encapsulates the response for an event reflecting the atom
+// response returned from the server which contains data in addition to the
+// actual event when requested as content type application/vnd.eventstore.atom+json
+//
+// For more information on the server response see:
+// http://docs.geteventstore.com/http-api/3.7.0/reading-streams/
+type EventResponse struct {
+	Title   string
+	ID      string
+	Updated TimeStr
+	Summary string
+	Event   *Event
+}
+
+// PrettyPrint renders an indented json view of the EventResponse.
+func (e *EventResponse) PrettyPrint() string {
+
+	b, err := json.MarshalIndent(e, "", "	")
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+
+}
+
+// EventAtomResponse is used internally to unmarshall the raw response
+type EventAtomResponse struct {
+	Title   string      `json:"title"`
+	ID      string      `json:"id"`
+	Updated TimeStr     `json:"updated"`
+	Summary string      `json:"summary"`
+	Content interface{} `json:"content"`
+}
+
+// PrettyPrint renders and indented json view of the eventAtomResponse
+func (e *EventAtomResponse) PrettyPrint() string {
+
+	b, err := json.MarshalIndent(e, "", "	")
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// Event returns the event carried in the content of the atom response e, as
+// built by CreateTestEventAtomResponse or decoded from a response from the
+// simulator to a request for application/vnd.eventstore.atom+json.
+func (e *EventAtomResponse) Event() (*Event, error) {
+	var b []byte
+	switch c := e.Content.(type) {
+	case nil:
+		return nil, errors.New("event atom response has no content")
+	case *json.RawMessage:
+		b = *c
+	case json.RawMessage:
+		b = c
+	default:
+		var err error
+		if b, err = json.Marshal(c); err != nil {
+			return nil, err
+		}
+	}
+	ev := &Event{}
+	if err := json.Unmarshal(b, ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// CreateTestEventResponse will return an *EventResponse containing the event provided in the
+// argument e.
+//
+// The Updated field of the EventResponse will be set to the value of ht TimeString tm if it is
+// provided otherwise it will be set to time.Now
+func CreateTestEventResponse(e *Event, tm *TimeStr) *EventResponse {
+
+	timeStr := Time(time.Now())
+	if tm != nil {
+		timeStr = *tm
+	}
+
+	r := &EventResponse{
+		Title:   fmt.Sprintf("%d@%s", e.EventNumber, e.EventStreamID),
+		ID:      e.Links[0].URI,
+		Updated: timeStr,
+		Summary: e.EventType,
+		Event:   e,
+	}
+
+	return r
+}
+
+// CreateTestEventResponses will return a slice of *EventResponse containing the events provided in the
+// argument events.
+//
+// The Updated field of the EventResponse will be set to the value of ht TimeString tm if it is
+// provided otherwise it will be set to time.Now
+func CreateTestEventResponses(events []*Event, tm *TimeStr) []*EventResponse {
+	ret := make([]*EventResponse, len(events))
+	for k, v := range events {
+		ret[k] = CreateTestEventResponse(v, tm)
+	}
+	return ret
+}
+
+// CreateTestEventAtomResponse returns an *eventAtomResponse derived from the *Event argument e.
+//
+// The updated time of the response will be set to the value of the *TimeStr argument tm. If tm is
+// nil then the updated time will be set to now.
+func CreateTestEventAtomResponse(e *Event, tm *TimeStr) (*EventAtomResponse, error) {
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(b)
+
+	timeStr := Time(time.Now())
+	if tm != nil {
+		timeStr = *tm
+	}
+
+	r := &EventAtomResponse{
+		Title:   fmt.Sprintf("%d@%s", e.EventNumber, e.EventStreamID),
+		ID:      e.Links[0].URI,
+		Updated: timeStr,
+		Summary: e.EventType,
+		Content: &raw,
+	}
+
+	return r, nil
+}
@@ -0,0 +1,26 @@
+package eventdata
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+type EventDataSuite struct{}
+
+var _ = Suite(&EventDataSuite{})
+
+func Test(t *testing.T) { TestingT(t) }
+
+// server is the url of the server in the links of the events created by the
+// tests.
+const server = "https://localhost:2113"
+
+func (s *EventDataSuite) TestCreateEvents(c *C) {
+	es := CreateTestEvents(100, "astream", server, "EventTypeX")
+
+	c.Assert(es, HasLen, 100)
+	for i := 0; i <= 99; i++ {
+		c.Assert(es[i].EventNumber, Equals, i)
+	}
+}
@@ -0,0 +1,122 @@
+package eventdata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	fakeFirstNames = []string{"Alice", "Ben", "Chloe", "Daniel", "Emma", "Farid", "Grace", "Hiro", "Isla", "Jamal", "Kate", "Luca", "Maya", "Noah", "Olivia", "Priya", "Quinn", "Ravi", "Sofia", "Tom"}
+	fakeLastNames  = []string{"Anderson", "Brown", "Chen", "Dubois", "Evans", "Fischer", "Garcia", "Hughes", "Ito", "Johnson", "Khan", "Lopez", "Martin", "Nowak", "O'Brien", "Patel", "Rossi", "Smith", "Taylor", "Walker"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net", "mail.example.com"}
+	fakeStreets    = []string{"High Street", "Station Road", "Main Street", "Park Avenue", "Church Lane", "Mill Road", "Victoria Street", "Green Lane"}
+	fakeCities     = []string{"London", "Manchester", "Dublin", "Berlin", "Paris", "Madrid", "Amsterdam", "Lisbon"}
+	fakeProducts   = []string{"Coffee Beans", "Espresso Cup", "Teapot", "Notebook", "Desk Lamp", "Headphones", "Backpack", "Water Bottle"}
+	fakeCurrencies = []string{"GBP", "EUR", "USD"}
+
+	// fakeEpoch is the start of the year in which fake timestamps fall.
+	fakeEpoch = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Faker produces realistic looking values for event payloads, for demo feeds
+// and readable test output. Its values are taken from the generator that
+// created it, so a Faker of a seeded generator produces the same values on
+// every run.
+type Faker struct {
+	intn func(n int) int
+}
+
+// Faker returns a Faker taking its random values from g.
+func (g *EventGenerator) Faker() *Faker {
+	return &Faker{intn: g.Intn}
+}
+
+func (f *Faker) pick(values []string) string {
+	return values[f.intn(len(values))]
+}
+
+// FirstName returns a first name.
+func (f *Faker) FirstName() string {
+	return f.pick(fakeFirstNames)
+}
+
+// LastName returns a last name.
+func (f *Faker) LastName() string {
+	return f.pick(fakeLastNames)
+}
+
+// Name returns a full name.
+func (f *Faker) Name() string {
+	return f.FirstName() + " " + f.LastName()
+}
+
+// Email returns an email address at a reserved example domain.
+func (f *Faker) Email() string {
+	local := strings.ToLower(f.FirstName() + "." + strings.Replace(f.LastName(), "'", "", -1))
+	return fmt.Sprintf("%s%d@%s", local, f.intn(100), f.pick(fakeDomains))
+}
+
+// Address returns a street address.
+func (f *Faker) Address() string {
+	return fmt.Sprintf("%d %s, %s", 1+f.intn(200), f.pick(fakeStreets), f.pick(fakeCities))
+}
+
+// Product returns a product name.
+func (f *Faker) Product() string {
+	return f.pick(fakeProducts)
+}
+
+// Amount returns an amount of money between 0.01 and max with two decimal
+// places.
+func (f *Faker) Amount(max float64) float64 {
+	cents := int(max * 100)
+	if cents < 1 {
+		cents = 1
+	}
+	return float64(1+f.intn(cents)) / 100
+}
+
+// Currency returns an ISO 4217 currency code.
+func (f *Faker) Currency() string {
+	return f.pick(fakeCurrencies)
+}
+
+// Timestamp returns an ISO 8601 timestamp in 2016.
+func (f *Faker) Timestamp() string {
+	return fakeEpoch.Add(time.Duration(f.intn(366*24*60*60)) * time.Second).Format(time.RFC3339)
+}
+
+// fakeOrder is the payload of the events created by CreateFakeEvents.
+type fakeOrder struct {
+	OrderID   string  `json:"orderId"`
+	Customer  string  `json:"customer"`
+	Email     string  `json:"email"`
+	Address   string  `json:"address"`
+	Product   string  `json:"product"`
+	Quantity  int     `json:"quantity"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	Timestamp string  `json:"timestamp"`
+}
+
+// CreateFakeEvents returns count events with realistic looking order data,
+// with event types chosen at random from eventTypes. See CreateFakeEvents.
+func (g *EventGenerator) CreateFakeEvents(count int, stream, server string, eventTypes ...string) []*Event {
+	f := g.Faker()
+	return g.CreateTestEventsWith(count, stream, server, func(i int) (string, interface{}, interface{}) {
+		eventType := eventTypes[g.Intn(len(eventTypes))]
+		data := &fakeOrder{
+			OrderID:   fmt.Sprintf("ORD-%06d", 1+f.intn(999999)),
+			Customer:  f.Name(),
+			Email:     f.Email(),
+			Address:   f.Address(),
+			Product:   f.Product(),
+			Quantity:  1 + f.intn(5),
+			Amount:    f.Amount(500),
+			Currency:  f.Currency(),
+			Timestamp: f.Timestamp(),
+		}
+		return eventType, data, nil
+	})
+}
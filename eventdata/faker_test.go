@@ -0,0 +1,35 @@
+package eventdata
+
+import (
+	"encoding/json"
+	"math/rand"
+	"regexp"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *EventDataSuite) TestFakerValues(c *C) {
+	f := NewEventGenerator(rand.NewSource(3)).Faker()
+	email := regexp.MustCompile(`^[a-z]+\.[a-z]+\d+@[a-z.]+$`)
+	for i := 0; i < 50; i++ {
+		c.Assert(email.MatchString(f.Email()), Equals, true)
+		a := f.Amount(10)
+		c.Assert(a >= 0.01 && a <= 10, Equals, true)
+		ts, err := time.Parse(time.RFC3339, f.Timestamp())
+		c.Assert(err, IsNil)
+		c.Assert(ts.Year(), Equals, 2016)
+	}
+}
+
+func (s *EventDataSuite) TestCreateFakeEvents(c *C) {
+	a := NewEventGenerator(rand.NewSource(5)).CreateFakeEvents(5, "orders", server, "OrderPlaced", "OrderShipped")
+	b := NewEventGenerator(rand.NewSource(5)).CreateFakeEvents(5, "orders", server, "OrderPlaced", "OrderShipped")
+	c.Assert(a, DeepEquals, b)
+
+	var order map[string]interface{}
+	c.Assert(json.Unmarshal(*a[0].Data.(*json.RawMessage), &order), IsNil)
+	for _, k := range []string{"orderId", "customer", "email", "address", "product", "quantity", "amount", "currency", "timestamp"} {
+		c.Assert(order[k], NotNil, Commentf(k))
+	}
+}
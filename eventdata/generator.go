@@ -0,0 +1,358 @@
+package eventdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+)
+
+// EventGenerator creates test events.
+//
+// A generator created with a seeded source generates the same event ids,
+// event types and payloads every time it is used in the same way, so golden
+// files can be checked in and failures can be reproduced.
+//
+//	g := eventdata.NewEventGenerator(rand.NewSource(42))
+//	es := g.CreateTestEvents(10, "astream", server.URL, "EventTypeA", "EventTypeB")
+//
+// The ids of events can instead be provided by a UUIDProvider, so that they are
+// stable across runs and can be asserted on directly.
+//
+//	g := &eventdata.EventGenerator{NewUUID: eventdata.SequentialUUIDs()}
+//
+// The zero value takes its random values from the math/rand package and
+// generates time based uuids. An EventGenerator is safe for concurrent use.
+type EventGenerator struct {
+	// NewUUID, if set, provides the ids of the events generated.
+	NewUUID UUIDProvider
+
+	// EventType, if set, returns the event type of events created from
+	// data. By default the name of the type of the data is used, after
+	// dereferencing pointers.
+	EventType func(data interface{}) string
+
+	// PayloadSize, if set, gives the size in bytes of the data of the events
+	// generated with random payloads by CreateTestEvents,
+	// CreateInterleavedEvents and CreateWeightedEvents. The data is padded
+	// to the size, which can be used to test clients with large events.
+	PayloadSize SizeDistribution
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewEventGenerator returns an EventGenerator that takes its random values,
+// including the ids of events, from src.
+func NewEventGenerator(src rand.Source) *EventGenerator {
+	return &EventGenerator{rand: rand.New(src)}
+}
+
+// UUIDProvider returns the id of the event eventNumber of stream.
+type UUIDProvider func(stream string, eventNumber int) string
+
+// SequentialUUIDs returns a UUIDProvider that numbers ids sequentially from 1
+// in the order they are requested, e.g. 00000000-0000-4000-8000-000000000001.
+// The provider is safe for concurrent use.
+func SequentialUUIDs() UUIDProvider {
+	var mu sync.Mutex
+	n := 0
+	return func(stream string, eventNumber int) string {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		return fmt.Sprintf("00000000-0000-4000-8000-%012x", n)
+	}
+}
+
+// NameBasedUUIDs returns a UUIDProvider that derives version 5 uuids from the
+// stream and event number within namespace, so an event always has the same
+// id however and whenever it is generated.
+func NameBasedUUIDs(namespace string) UUIDProvider {
+	ns := uuid.NewV5(uuid.NamespaceURL, namespace)
+	return func(stream string, eventNumber int) string {
+		return uuid.NewV5(ns, fmt.Sprintf("%s/%d", stream, eventNumber)).String()
+	}
+}
+
+// defaultGenerator is used by the package level functions. It takes its random
+// values from the math/rand package and generates time based uuids.
+var defaultGenerator = &EventGenerator{}
+
+// CreateTestEventFromData returns a test event derived from the user specified
+// data. See CreateTestEventFromData.
+func (g *EventGenerator) CreateTestEventFromData(stream, server string, eventNumber int, data interface{}, meta interface{}) *Event {
+	e := Event{}
+	e.EventStreamID = stream
+	e.EventNumber = eventNumber
+	e.EventType = g.eventType(data)
+	e.EventID = g.newUUID(stream, eventNumber)
+
+	b, _ := json.Marshal(data)
+	var d json.RawMessage
+	d = json.RawMessage(b)
+	e.Data = &d
+
+	e.Links = EventLinks(stream, server, eventNumber)
+
+	if meta != nil {
+		mb, _ := json.Marshal(meta)
+		var m json.RawMessage
+		m = json.RawMessage(mb)
+		e.MetaData = &m
+	} else {
+		m := "\"\""
+		mraw := json.RawMessage(m)
+		e.MetaData = &mraw
+	}
+	return &e
+}
+
+// CreateTestEvent will generate a test event. See CreateTestEvent.
+func (g *EventGenerator) CreateTestEvent(stream, server, eventType string, eventNumber int, data *json.RawMessage, meta *json.RawMessage) *Event {
+	e := Event{}
+	e.EventStreamID = stream
+	e.EventNumber = eventNumber
+	e.EventType = eventType
+	e.EventID = g.newUUID(stream, eventNumber)
+
+	e.Data = data
+
+	e.Links = EventLinks(stream, server, eventNumber)
+
+	if meta != nil {
+		e.MetaData = meta
+	} else {
+		m := "\"\""
+		mraw := json.RawMessage(m)
+		e.MetaData = &mraw
+	}
+	return &e
+}
+
+// CreateTestEvents will return a slice of random test events. See
+// CreateTestEvents.
+func (g *EventGenerator) CreateTestEvents(numEvents int, stream string, server string, eventTypes ...string) []*Event {
+	se := []*Event{}
+	for i := 0; i < numEvents; i++ {
+		r := g.Intn(len(eventTypes))
+		eventType := eventTypes[r]
+
+		se = append(se, g.createRandomEvent(stream, server, eventType, i))
+	}
+	return se
+}
+
+// TypeCount is a number of consecutive events of an event type.
+type TypeCount struct {
+	EventType string
+	Count     int
+}
+
+// CreateInterleavedEvents returns the events of the pattern repeated repeats
+// times. See CreateInterleavedEvents.
+func (g *EventGenerator) CreateInterleavedEvents(repeats int, stream, server string, pattern ...TypeCount) []*Event {
+	se := []*Event{}
+	for r := 0; r < repeats; r++ {
+		for _, tc := range pattern {
+			for j := 0; j < tc.Count; j++ {
+				se = append(se, g.createRandomEvent(stream, server, tc.EventType, len(se)))
+			}
+		}
+	}
+	return se
+}
+
+// CreateWeightedEvents returns count events whose event types are chosen at
+// random in proportion to their weights. See CreateWeightedEvents.
+func (g *EventGenerator) CreateWeightedEvents(count int, stream, server string, weights map[string]int) []*Event {
+	types := make([]string, 0, len(weights))
+	total := 0
+	for t, w := range weights {
+		if w > 0 {
+			types = append(types, t)
+			total += w
+		}
+	}
+	sort.Strings(types)
+
+	se := []*Event{}
+	if total == 0 {
+		return se
+	}
+	for i := 0; i < count; i++ {
+		n := g.Intn(total)
+		for _, t := range types {
+			if n < weights[t] {
+				se = append(se, g.createRandomEvent(stream, server, t, i))
+				break
+			}
+			n -= weights[t]
+		}
+	}
+	return se
+}
+
+// createRandomEvent returns an event whose data and metadata contain the id of
+// the event.
+func (g *EventGenerator) createRandomEvent(stream, server, eventType string, eventNumber int) *Event {
+	e := g.CreateTestEvent(stream, server, eventType, eventNumber, nil, nil)
+
+	d := fmt.Sprintf("{ \"foo\" : \"%s\" }", e.EventID)
+	if g.PayloadSize != nil {
+		d = paddedPayload(e.EventID, g.PayloadSize(eventNumber, g.Intn))
+	}
+	raw := json.RawMessage(d)
+	e.Data = &raw
+
+	m := fmt.Sprintf("{\"bar\": \"%s\"}", e.EventID)
+	mraw := json.RawMessage(m)
+	e.MetaData = &mraw
+
+	return e
+}
+
+// CreateTestEventsWith returns count test events whose event types, data and
+// metadata are provided by gen. See CreateTestEventsWith.
+func (g *EventGenerator) CreateTestEventsWith(count int, stream, server string, gen func(i int) (eventType string, data, meta interface{})) []*Event {
+	se := []*Event{}
+	for i := 0; i < count; i++ {
+		eventType, data, meta := gen(i)
+
+		b, _ := json.Marshal(data)
+		raw := json.RawMessage(b)
+
+		var mraw *json.RawMessage
+		if meta != nil {
+			mb, _ := json.Marshal(meta)
+			m := json.RawMessage(mb)
+			mraw = &m
+		}
+
+		se = append(se, g.CreateTestEvent(stream, server, eventType, i, &raw, mraw))
+	}
+	return se
+}
+
+// CreateTestEventsFromData returns an event for each of the values in data,
+// numbered sequentially from 0. See CreateTestEventsFromData.
+func (g *EventGenerator) CreateTestEventsFromData(stream, server string, data ...interface{}) []*Event {
+	se := []*Event{}
+	for i, d := range data {
+		se = append(se, g.CreateTestEventFromData(stream, server, i, d, nil))
+	}
+	return se
+}
+
+// eventType returns the event type of an event created from data.
+func (g *EventGenerator) eventType(data interface{}) string {
+	if g.EventType != nil {
+		return g.EventType(data)
+	}
+	t := reflect.TypeOf(data)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
+// SizeDistribution returns the size in bytes of the data of the event
+// eventNumber. intn returns a random number in [0, n) taken from the generator,
+// so seeded generators produce the same sizes on every run.
+type SizeDistribution func(eventNumber int, intn func(n int) int) int
+
+// FixedSize returns a SizeDistribution giving every event n bytes of data.
+func FixedSize(n int) SizeDistribution {
+	return func(eventNumber int, intn func(n int) int) int {
+		return n
+	}
+}
+
+// UniformSize returns a SizeDistribution giving each event between min and
+// max bytes of data.
+func UniformSize(min, max int) SizeDistribution {
+	return func(eventNumber int, intn func(n int) int) int {
+		if max <= min {
+			return min
+		}
+		return min + intn(max-min+1)
+	}
+}
+
+// OccasionalSize returns a SizeDistribution giving every event whose number
+// is a multiple of every size bytes of data and the other events a size from
+// otherwise, e.g. OccasionalSize(100, 4<<20, FixedSize(200)) for a stream of
+// small events with a 4MB event every hundred events.
+func OccasionalSize(every, size int, otherwise SizeDistribution) SizeDistribution {
+	return func(eventNumber int, intn func(n int) int) int {
+		if every > 0 && eventNumber%every == 0 {
+			return size
+		}
+		return otherwise(eventNumber, intn)
+	}
+}
+
+// paddedPayload returns the json data of an event containing id, padded to
+// size bytes where size allows.
+func paddedPayload(id string, size int) string {
+	const (
+		prefix  = "{ \"foo\" : \""
+		middle  = "\", \"padding\" : \""
+		suffix  = "\" }"
+		pattern = "abcdefghijklmnopqrstuvwxyz"
+	)
+	n := size - len(prefix) - len(id) - len(middle) - len(suffix)
+	if n < 0 {
+		n = 0
+	}
+	pad := strings.Repeat(pattern, n/len(pattern)+1)[:n]
+	return prefix + id + middle + pad + suffix
+}
+
+// EventLinks returns the edit and alternate links of the event numbered
+// eventNumber in stream on server, as events created by the package have.
+func EventLinks(stream, server string, eventNumber int) []Link {
+	u := fmt.Sprintf("%s/streams/%s", server, url.PathEscape(stream))
+	eu := fmt.Sprintf("%s/%d/", u, eventNumber)
+	l1 := Link{URI: eu, Relation: "edit"}
+	l2 := Link{URI: eu, Relation: "alternate"}
+	return []Link{l1, l2}
+}
+
+// Intn returns a random number in [0, n) from the source of the generator, so
+// that values chosen alongside the events it creates are reproducible too.
+func (g *EventGenerator) Intn(n int) int {
+	if g.rand == nil {
+		return rand.Intn(n)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rand.Intn(n)
+}
+
+// newUUID returns the id of the event eventNumber of stream. Generators with a
+// source and no UUIDProvider generate version 4 uuids from the source.
+func (g *EventGenerator) newUUID(stream string, eventNumber int) string {
+	if g.NewUUID != nil {
+		return g.NewUUID(stream, eventNumber)
+	}
+	if g.rand == nil {
+		return uuid.NewUUID()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var u uuid.UUID
+	g.rand.Read(u[:])
+	u.SetVersion(4)
+	u.SetVariant()
+	return u.String()
+}
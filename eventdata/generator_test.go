@@ -0,0 +1,146 @@
+package eventdata
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *EventDataSuite) TestSeededGeneratorIsReproducible(c *C) {
+	stream := "seeded-stream"
+	a := NewEventGenerator(rand.NewSource(42)).CreateTestEvents(20, stream, server, "EventTypeA", "EventTypeB", "EventTypeC")
+	b := NewEventGenerator(rand.NewSource(42)).CreateTestEvents(20, stream, server, "EventTypeA", "EventTypeB", "EventTypeC")
+	c.Assert(a, DeepEquals, b)
+
+	v4 := regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")
+	for _, e := range a {
+		c.Assert(v4.MatchString(e.EventID), Equals, true, Commentf(e.EventID))
+	}
+
+	d := NewEventGenerator(rand.NewSource(43)).CreateTestEvents(20, stream, server, "EventTypeA", "EventTypeB", "EventTypeC")
+	c.Assert(d[0].EventID, Not(Equals), a[0].EventID)
+}
+
+func (s *EventDataSuite) TestGeneratorUUIDProviders(c *C) {
+	stream := "uuid-stream"
+	g := &EventGenerator{NewUUID: SequentialUUIDs()}
+	es := g.CreateTestEvents(3, stream, server, "EventTypeX")
+	c.Assert(es[0].EventID, Equals, "00000000-0000-4000-8000-000000000001")
+	c.Assert(es[2].EventID, Equals, "00000000-0000-4000-8000-000000000003")
+
+	g = &EventGenerator{NewUUID: NameBasedUUIDs("suite")}
+	a := g.CreateTestEvents(3, stream, server, "EventTypeX")
+	b := g.CreateTestEvents(3, stream, server, "EventTypeX")
+	c.Assert(a[1].EventID, Equals, b[1].EventID)
+	c.Assert(a[1].EventID, Not(Equals), a[2].EventID)
+	c.Assert(a[1].EventID[14:15], Equals, "5")
+
+	other := (&EventGenerator{NewUUID: NameBasedUUIDs("other-suite")}).CreateTestEvents(1, stream, server, "EventTypeX")
+	c.Assert(other[0].EventID, Not(Equals), a[0].EventID)
+}
+
+func (s *EventDataSuite) TestCreateTestEventsWith(c *C) {
+	type itemAdded struct {
+		OrderID  string `json:"orderId"`
+		Quantity int    `json:"quantity"`
+	}
+	stream := "orders"
+	es := CreateTestEventsWith(3, stream, server, func(i int) (string, interface{}, interface{}) {
+		if i == 0 {
+			return "OrderCreated", map[string]string{"orderId": "1"}, map[string]string{"user": "bob"}
+		}
+		return "ItemAdded", &itemAdded{OrderID: "1", Quantity: i}, nil
+	})
+
+	c.Assert(es, HasLen, 3)
+	c.Assert(es[0].EventType, Equals, "OrderCreated")
+	c.Assert(string(*es[0].MetaData.(*json.RawMessage)), Equals, `{"user":"bob"}`)
+	c.Assert(es[2].EventType, Equals, "ItemAdded")
+	c.Assert(es[2].EventNumber, Equals, 2)
+	c.Assert(string(*es[2].Data.(*json.RawMessage)), Equals, `{"orderId":"1","quantity":2}`)
+	c.Assert(string(*es[2].MetaData.(*json.RawMessage)), Equals, `""`)
+}
+
+type orderCreated struct {
+	OrderID string `json:"orderId"`
+}
+
+type orderPaid struct {
+	OrderID string  `json:"orderId"`
+	Amount  float64 `json:"amount"`
+}
+
+func (s *EventDataSuite) TestCreateTestEventsFromData(c *C) {
+	stream := "orders"
+	es := CreateTestEventsFromData(stream, server, &orderCreated{OrderID: "1"}, orderPaid{OrderID: "1", Amount: 9.5})
+
+	c.Assert(es, HasLen, 2)
+	c.Assert(es[0].EventType, Equals, "orderCreated")
+	c.Assert(es[1].EventType, Equals, "orderPaid")
+	c.Assert(es[1].EventNumber, Equals, 1)
+	c.Assert(string(*es[1].Data.(*json.RawMessage)), Equals, `{"orderId":"1","amount":9.5}`)
+
+	g := &EventGenerator{EventType: func(data interface{}) string {
+		n := reflect.TypeOf(data).Elem().Name()
+		return "Orders." + strings.ToUpper(n[:1]) + n[1:]
+	}}
+	es = g.CreateTestEventsFromData(stream, server, &orderCreated{OrderID: "1"})
+	c.Assert(es[0].EventType, Equals, "Orders.OrderCreated")
+}
+
+func (s *EventDataSuite) TestCreateInterleavedEvents(c *C) {
+	es := CreateInterleavedEvents(2, "orders", server,
+		TypeCount{EventType: "OrderCreated", Count: 1},
+		TypeCount{EventType: "ItemAdded", Count: 2},
+		TypeCount{EventType: "OrderPaid", Count: 1})
+
+	c.Assert(es, HasLen, 8)
+	types := []string{}
+	for i, e := range es {
+		c.Assert(e.EventNumber, Equals, i)
+		types = append(types, e.EventType)
+	}
+	c.Assert(types, DeepEquals, []string{
+		"OrderCreated", "ItemAdded", "ItemAdded", "OrderPaid",
+		"OrderCreated", "ItemAdded", "ItemAdded", "OrderPaid",
+	})
+}
+
+func (s *EventDataSuite) TestCreateWeightedEvents(c *C) {
+	g := NewEventGenerator(rand.NewSource(7))
+	es := g.CreateWeightedEvents(6000, "orders", server, map[string]int{"OrderCreated": 1, "ItemAdded": 5, "Ignored": 0})
+
+	counts := map[string]int{}
+	for _, e := range es {
+		counts[e.EventType]++
+	}
+	c.Assert(counts["Ignored"], Equals, 0)
+	c.Assert(counts["OrderCreated"]+counts["ItemAdded"], Equals, 6000)
+	c.Assert(counts["OrderCreated"] > 800 && counts["OrderCreated"] < 1200, Equals, true, Commentf("%v", counts))
+
+	c.Assert(g.CreateWeightedEvents(5, "orders", server, nil), HasLen, 0)
+}
+
+func (s *EventDataSuite) TestPayloadSizes(c *C) {
+	g := NewEventGenerator(rand.NewSource(1))
+	g.PayloadSize = OccasionalSize(3, 4<<20, UniformSize(200, 300))
+	es := g.CreateTestEvents(6, "large-stream", server, "EventTypeX")
+
+	for i, e := range es {
+		data := *e.Data.(*json.RawMessage)
+		c.Assert(json.Valid(data), Equals, true)
+		if i%3 == 0 {
+			c.Assert(len(data), Equals, 4<<20)
+			continue
+		}
+		c.Assert(len(data) >= 200 && len(data) <= 300, Equals, true, Commentf("%d", len(data)))
+	}
+
+	g.PayloadSize = FixedSize(1)
+	es = g.CreateTestEvents(1, "small-stream", server, "EventTypeX")
+	c.Assert(json.Valid(*es[0].Data.(*json.RawMessage)), Equals, true)
+}
@@ -0,0 +1,512 @@
+package eventdata
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+)
+
+// ProtoField describes a field of a protocol buffers message. Type is one of
+// the scalar types double, float, int32, int64, uint32, uint64, sint32,
+// sint64, fixed32, fixed64, sfixed32, sfixed64, bool, string and bytes.
+type ProtoField struct {
+	Name   string
+	Number int
+	Type   string
+}
+
+// ProtoMessage describes a protocol buffers message, standing in for the
+// descriptor generated from a .proto file. Name is used as the event type of
+// events generated from the message.
+//
+//	placed := eventdata.ProtoMessage{Name: "OrderPlaced", Fields: []eventdata.ProtoField{
+//		{Name: "order_id", Number: 1, Type: "string"},
+//		{Name: "total", Number: 2, Type: "double"},
+//	}}
+type ProtoMessage struct {
+	Name   string
+	Fields []ProtoField
+}
+
+// EncodeProtobuf encodes values, keyed by field name, as the message m in the
+// protocol buffers wire format. Fields without a value are omitted, as fields
+// holding their default value are.
+func EncodeProtobuf(m ProtoMessage, values map[string]interface{}) ([]byte, error) {
+	fields := make(map[string]ProtoField, len(m.Fields))
+	for _, f := range m.Fields {
+		fields[f.Name] = f
+	}
+	for name := range values {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("message %s has no field %q", m.Name, name)
+		}
+	}
+
+	var b []byte
+	for _, f := range m.Fields {
+		v, ok := values[f.Name]
+		if !ok {
+			continue
+		}
+		var err error
+		if b, err = appendProtoField(b, f, v); err != nil {
+			return nil, fmt.Errorf("field %s of message %s: %v", f.Name, m.Name, err)
+		}
+	}
+	return b, nil
+}
+
+// appendProtoField appends the field f with the value v to b.
+func appendProtoField(b []byte, f ProtoField, v interface{}) ([]byte, error) {
+	if f.Number <= 0 {
+		return nil, fmt.Errorf("invalid field number %d", f.Number)
+	}
+	switch f.Type {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return protowire.AppendString(b, f.Number, s), nil
+	case "bytes":
+		p, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("expected a []byte, got %T", v)
+		}
+		return protowire.AppendBytes(b, f.Number, p), nil
+	case "bool":
+		t, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a bool, got %T", v)
+		}
+		n := uint64(0)
+		if t {
+			n = 1
+		}
+		return protowire.AppendUint(b, f.Number, n), nil
+	case "double", "float":
+		x, ok := floatValue(v)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %T", v)
+		}
+		if f.Type == "float" {
+			return protowire.AppendFixed32(b, f.Number, math.Float32bits(float32(x))), nil
+		}
+		return protowire.AppendFixed64(b, f.Number, math.Float64bits(x)), nil
+	}
+
+	n, ok := intValue(v)
+	if !ok {
+		return nil, fmt.Errorf("expected an integer, got %T", v)
+	}
+	switch f.Type {
+	case "int32", "int64", "uint32", "uint64":
+		return protowire.AppendUint(b, f.Number, uint64(n)), nil
+	case "sint32", "sint64":
+		return protowire.AppendUint(b, f.Number, zigzag(n)), nil
+	case "fixed32", "sfixed32":
+		return protowire.AppendFixed32(b, f.Number, uint32(n)), nil
+	case "fixed64", "sfixed64":
+		return protowire.AppendFixed64(b, f.Number, uint64(n)), nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", f.Type)
+}
+
+// AvroSchema is a parsed Avro schema.
+type AvroSchema struct {
+	Name string
+	t    *avroType
+}
+
+// avroType is an Avro type: a primitive type, a record, an enum, an array or
+// a union.
+type avroType struct {
+	kind    string
+	fields  []avroField
+	symbols []string
+	items   *avroType
+	union   []*avroType
+}
+
+type avroField struct {
+	name string
+	t    *avroType
+}
+
+// ParseAvroSchema parses the Avro schema b of a record. Records may hold
+// fields of the primitive types, records, enums, arrays and unions; maps,
+// fixed and named type references are not supported. The name of the record
+// is used as the event type of events generated from the schema.
+func ParseAvroSchema(b []byte) (*AvroSchema, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("avro schema: %v", err)
+	}
+	t, err := parseAvroType(v)
+	if err != nil {
+		return nil, fmt.Errorf("avro schema: %v", err)
+	}
+	if t.kind != "record" {
+		return nil, fmt.Errorf("avro schema: expected a record, got %s", t.kind)
+	}
+	m := v.(map[string]interface{})
+	name, _ := m["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("avro schema: a record must have a name")
+	}
+	return &AvroSchema{Name: name, t: t}, nil
+}
+
+func parseAvroType(v interface{}) (*avroType, error) {
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroType{kind: v}, nil
+		}
+		return nil, fmt.Errorf("unsupported type %q", v)
+	case []interface{}:
+		t := &avroType{kind: "union"}
+		for _, b := range v {
+			bt, err := parseAvroType(b)
+			if err != nil {
+				return nil, err
+			}
+			t.union = append(t.union, bt)
+		}
+		if len(t.union) == 0 {
+			return nil, fmt.Errorf("a union must have one or more branches")
+		}
+		return t, nil
+	case map[string]interface{}:
+		kind, _ := v["type"].(string)
+		switch kind {
+		case "record":
+			t := &avroType{kind: kind}
+			fields, _ := v["fields"].([]interface{})
+			for _, f := range fields {
+				fm, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("the fields of a record must be objects")
+				}
+				name, _ := fm["name"].(string)
+				if name == "" {
+					return nil, fmt.Errorf("a field must have a name")
+				}
+				ft, err := parseAvroType(fm["type"])
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %v", name, err)
+				}
+				t.fields = append(t.fields, avroField{name: name, t: ft})
+			}
+			return t, nil
+		case "enum":
+			t := &avroType{kind: kind}
+			symbols, _ := v["symbols"].([]interface{})
+			for _, s := range symbols {
+				name, ok := s.(string)
+				if !ok {
+					return nil, fmt.Errorf("the symbols of an enum must be strings")
+				}
+				t.symbols = append(t.symbols, name)
+			}
+			if len(t.symbols) == 0 {
+				return nil, fmt.Errorf("an enum must have one or more symbols")
+			}
+			return t, nil
+		case "array":
+			items, err := parseAvroType(v["items"])
+			if err != nil {
+				return nil, err
+			}
+			return &avroType{kind: kind, items: items}, nil
+		}
+		return parseAvroType(v["type"])
+	}
+	return nil, fmt.Errorf("invalid type %v", v)
+}
+
+// EncodeAvro encodes values, keyed by field name, as a record of the schema s
+// in the Avro binary encoding, without a header or schema fingerprint. The
+// values of records are maps keyed by field name, of enums their symbols and
+// of arrays slices. A value of a union is encoded as the first branch it
+// matches, so nil is encoded as null.
+func EncodeAvro(s *AvroSchema, values map[string]interface{}) ([]byte, error) {
+	return appendAvro(nil, s.t, values)
+}
+
+func appendAvro(b []byte, t *avroType, v interface{}) ([]byte, error) {
+	switch t.kind {
+	case "null":
+		if v != nil {
+			return nil, fmt.Errorf("expected null, got %T", v)
+		}
+		return b, nil
+	case "boolean":
+		x, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a bool, got %T", v)
+		}
+		if x {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case "int", "long":
+		n, ok := intValue(v)
+		if !ok {
+			return nil, fmt.Errorf("expected an integer, got %T", v)
+		}
+		return protowire.AppendVarint(b, zigzag(n)), nil
+	case "float":
+		x, ok := floatValue(v)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %T", v)
+		}
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x))), nil
+	case "double":
+		x, ok := floatValue(v)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %T", v)
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+	case "bytes":
+		p, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("expected a []byte, got %T", v)
+		}
+		return append(protowire.AppendVarint(b, zigzag(int64(len(p)))), p...), nil
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return append(protowire.AppendVarint(b, zigzag(int64(len(s)))), s...), nil
+	case "enum":
+		s, ok := v.(string)
+		if ok {
+			for i, sym := range t.symbols {
+				if sym == s {
+					return protowire.AppendVarint(b, zigzag(int64(i))), nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%v is not a symbol of the enum", v)
+	case "array":
+		rv := reflect.ValueOf(v)
+		if v == nil || rv.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("expected a slice, got %T", v)
+		}
+		if rv.Len() > 0 {
+			b = protowire.AppendVarint(b, zigzag(int64(rv.Len())))
+			for i := 0; i < rv.Len(); i++ {
+				var err error
+				if b, err = appendAvro(b, t.items, rv.Index(i).Interface()); err != nil {
+					return nil, fmt.Errorf("item %d: %v", i, err)
+				}
+			}
+		}
+		return append(b, 0), nil
+	case "union":
+		for i, u := range t.union {
+			if e, err := appendAvro(nil, u, v); err == nil {
+				return append(protowire.AppendVarint(b, zigzag(int64(i))), e...), nil
+			}
+		}
+		return nil, fmt.Errorf("%T matches no branch of the union", v)
+	case "record":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a map[string]interface{}, got %T", v)
+		}
+		for _, f := range t.fields {
+			var err error
+			if b, err = appendAvro(b, f.t, m[f.name]); err != nil {
+				return nil, fmt.Errorf("field %s: %v", f.name, err)
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", t.kind)
+}
+
+// CreateProtobufEvents returns count events whose data are messages, chosen
+// at random from messages, holding random values. See CreateProtobufEvents.
+func (g *EventGenerator) CreateProtobufEvents(count int, stream, server string, messages ...ProtoMessage) ([]*Event, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("no protocol buffers messages to create events from")
+	}
+	for _, m := range messages {
+		if err := validateProtoMessage(m); err != nil {
+			return nil, err
+		}
+	}
+	f := g.Faker()
+	se := []*Event{}
+	for i := 0; i < count; i++ {
+		m := messages[g.Intn(len(messages))]
+		values := map[string]interface{}{}
+		for _, field := range m.Fields {
+			values[field.Name] = g.protoValue(f, field.Type)
+		}
+		data, err := EncodeProtobuf(m, values)
+		if err != nil {
+			return nil, err
+		}
+		e := g.createRandomEvent(stream, server, m.Name, i)
+		e.Data = data
+		se = append(se, e)
+	}
+	return se, nil
+}
+
+// validateProtoMessage returns an error if the message m has no name, or has
+// a field without a name, with a number that is not positive or is used by
+// another field, or of a type that is not supported.
+func validateProtoMessage(m ProtoMessage) error {
+	if m.Name == "" {
+		return errors.New("a protocol buffers message must have a name")
+	}
+	names := map[string]bool{}
+	numbers := map[int]bool{}
+	for _, f := range m.Fields {
+		switch {
+		case f.Name == "":
+			return fmt.Errorf("message %s has a field without a name", m.Name)
+		case names[f.Name]:
+			return fmt.Errorf("message %s has more than one field %q", m.Name, f.Name)
+		case f.Number <= 0:
+			return fmt.Errorf("field %s of message %s: invalid field number %d", f.Name, m.Name, f.Number)
+		case numbers[f.Number]:
+			return fmt.Errorf("field %s of message %s: field number %d is already used", f.Name, m.Name, f.Number)
+		}
+		switch f.Type {
+		case "double", "float", "int32", "int64", "uint32", "uint64", "sint32", "sint64",
+			"fixed32", "fixed64", "sfixed32", "sfixed64", "bool", "string", "bytes":
+		default:
+			return fmt.Errorf("field %s of message %s: unsupported type %q", f.Name, m.Name, f.Type)
+		}
+		names[f.Name] = true
+		numbers[f.Number] = true
+	}
+	return nil
+}
+
+// protoValue returns a random value of a field of the protocol buffers type
+// t.
+func (g *EventGenerator) protoValue(f *Faker, t string) interface{} {
+	switch t {
+	case "string":
+		return f.Product()
+	case "bytes":
+		return g.randomBytes(8)
+	case "bool":
+		return g.Intn(2) == 1
+	case "double", "float":
+		return f.Amount(1000)
+	case "sint32", "sint64", "sfixed32", "sfixed64":
+		return g.Intn(2001) - 1000
+	}
+	return g.Intn(1000)
+}
+
+// CreateAvroEvents returns count events whose data are records of schemas,
+// chosen at random, holding random values. See CreateAvroEvents.
+func (g *EventGenerator) CreateAvroEvents(count int, stream, server string, schemas ...*AvroSchema) ([]*Event, error) {
+	if len(schemas) == 0 {
+		return nil, errors.New("no avro schemas to create events from")
+	}
+	for _, s := range schemas {
+		if s == nil || s.t == nil {
+			return nil, errors.New("avro schemas must be created by ParseAvroSchema")
+		}
+	}
+	f := g.Faker()
+	se := []*Event{}
+	for i := 0; i < count; i++ {
+		s := schemas[g.Intn(len(schemas))]
+		data, err := EncodeAvro(s, g.avroValue(f, s.t).(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		e := g.createRandomEvent(stream, server, s.Name, i)
+		e.Data = data
+		se = append(se, e)
+	}
+	return se, nil
+}
+
+// avroValue returns a random value of the Avro type t.
+func (g *EventGenerator) avroValue(f *Faker, t *avroType) interface{} {
+	switch t.kind {
+	case "null":
+		return nil
+	case "boolean":
+		return g.Intn(2) == 1
+	case "int", "long":
+		return g.Intn(1000)
+	case "float", "double":
+		return f.Amount(1000)
+	case "bytes":
+		return g.randomBytes(8)
+	case "string":
+		return f.Product()
+	case "enum":
+		return t.symbols[g.Intn(len(t.symbols))]
+	case "array":
+		items := make([]interface{}, g.Intn(4))
+		for i := range items {
+			items[i] = g.avroValue(f, t.items)
+		}
+		return items
+	case "union":
+		return g.avroValue(f, t.union[g.Intn(len(t.union))])
+	}
+	m := map[string]interface{}{}
+	for _, field := range t.fields {
+		m[field.name] = g.avroValue(f, field.t)
+	}
+	return m
+}
+
+// randomBytes returns n random bytes.
+func (g *EventGenerator) randomBytes(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(g.Intn(256))
+	}
+	return b
+}
+
+// zigzag returns the zigzag encoding of n, which keeps the varints of small
+// negative numbers short.
+func zigzag(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
+
+// intValue returns the value of the integer v.
+func intValue(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// floatValue returns the value of the number v.
+func floatValue(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	n, ok := intValue(v)
+	return float64(n), ok
+}
@@ -0,0 +1,81 @@
+package eventdata
+
+import (
+	"math"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	. "gopkg.in/check.v1"
+)
+
+var orderPlacedMessage = ProtoMessage{Name: "OrderPlaced", Fields: []ProtoField{
+	{Name: "order_id", Number: 1, Type: "string"},
+	{Name: "total", Number: 2, Type: "double"},
+	{Name: "delta", Number: 3, Type: "sint32"},
+	{Name: "paid", Number: 4, Type: "bool"},
+}}
+
+func (s *EventDataSuite) TestEncodeProtobuf(c *C) {
+	b, err := EncodeProtobuf(orderPlacedMessage, map[string]interface{}{
+		"order_id": "o-1", "total": 12.5, "delta": -3, "paid": true,
+	})
+	c.Assert(err, IsNil)
+	m, err := protowire.Parse(b)
+	c.Assert(err, IsNil)
+	c.Assert(m.String(1), Equals, "o-1")
+	c.Assert(math.Float64frombits(m.Uint(2)), Equals, 12.5)
+	c.Assert(m.Uint(3), Equals, uint64(5))
+	c.Assert(m.Uint(4), Equals, uint64(1))
+
+	_, err = EncodeProtobuf(orderPlacedMessage, map[string]interface{}{"customer": "bob"})
+	c.Assert(err, ErrorMatches, `message OrderPlaced has no field "customer"`)
+	_, err = EncodeProtobuf(orderPlacedMessage, map[string]interface{}{"total": "12"})
+	c.Assert(err, ErrorMatches, "field total of message OrderPlaced: expected a number, got string")
+}
+
+func (s *EventDataSuite) TestEncodeAvro(c *C) {
+	schema, err := ParseAvroSchema([]byte(`{"type": "record", "name": "OrderPlaced", "fields": [
+		{"name": "orderId", "type": "string"},
+		{"name": "delta", "type": "long"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["OPEN", "PAID"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "note", "type": ["null", "string"]}
+	]}`))
+	c.Assert(err, IsNil)
+	c.Assert(schema.Name, Equals, "OrderPlaced")
+
+	b, err := EncodeAvro(schema, map[string]interface{}{
+		"orderId": "a", "delta": -2, "status": "PAID", "tags": []string{"x"}, "note": nil,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(b, DeepEquals, []byte{0x02, 'a', 0x03, 0x02, 0x02, 0x02, 'x', 0x00, 0x00})
+
+	b, err = EncodeAvro(schema, map[string]interface{}{
+		"orderId": "a", "delta": 0, "status": "OPEN", "tags": []string{}, "note": "hi",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(b, DeepEquals, []byte{0x02, 'a', 0x00, 0x00, 0x00, 0x02, 0x04, 'h', 'i'})
+
+	_, err = EncodeAvro(schema, map[string]interface{}{"orderId": "a", "delta": 0, "status": "LOST"})
+	c.Assert(err, ErrorMatches, "field status: LOST is not a symbol of the enum")
+	_, err = ParseAvroSchema([]byte(`{"type": "record", "name": "R", "fields": [{"name": "m", "type": {"type": "map", "values": "string"}}]}`))
+	c.Assert(err, ErrorMatches, "avro schema: field m: .*")
+}
+
+func (s *EventDataSuite) TestCreateBinaryEventsValidatesDescriptors(c *C) {
+	_, err := CreateProtobufEvents(1, "orders", "https://localhost:2113")
+	c.Assert(err, ErrorMatches, "no protocol buffers messages to create events from")
+	_, err = CreateProtobufEvents(1, "orders", "https://localhost:2113", ProtoMessage{Name: "OrderPlaced", Fields: []ProtoField{
+		{Name: "order_id", Number: 1, Type: "string"},
+		{Name: "total", Number: 1, Type: "double"},
+	}})
+	c.Assert(err, ErrorMatches, "field total of message OrderPlaced: field number 1 is already used")
+	_, err = CreateProtobufEvents(1, "orders", "https://localhost:2113", ProtoMessage{Name: "OrderPlaced", Fields: []ProtoField{
+		{Name: "lines", Number: 1, Type: "repeated string"},
+	}})
+	c.Assert(err, ErrorMatches, `field lines of message OrderPlaced: unsupported type "repeated string"`)
+
+	_, err = CreateAvroEvents(1, "orders", "https://localhost:2113", &AvroSchema{Name: "OrderPlaced"})
+	c.Assert(err, ErrorMatches, "avro schemas must be created by ParseAvroSchema")
+	_, err = CreateAvroEvents(1, "orders", "https://localhost:2113")
+	c.Assert(err, ErrorMatches, "no avro schemas to create events from")
+}
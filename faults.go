@@ -0,0 +1,47 @@
+package mock
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+)
+
+// Types of package faults, aliased for code written against package mock.
+type (
+	FaultInjector  = faults.FaultInjector
+	FaultRecorder  = faults.FaultRecorder
+	CannedResponse = faults.CannedResponse
+	Window         = faults.Window
+	Latency        = faults.Latency
+	NodeState      = faults.NodeState
+	Phase          = faults.Phase
+	ScenarioRunner = faults.ScenarioRunner
+)
+
+// Constants of package faults.
+const (
+	Healthy     = faults.Healthy
+	Unreachable = faults.Unreachable
+	Degraded    = faults.Degraded
+)
+
+// NewFaultInjector calls faults.NewFaultInjector.
+func NewFaultInjector(h http.Handler) *FaultInjector {
+	return faults.NewFaultInjector(h)
+}
+
+// FixedLatency calls faults.FixedLatency.
+func FixedLatency(d time.Duration) Latency {
+	return faults.FixedLatency(d)
+}
+
+// JitterLatency calls faults.JitterLatency.
+func JitterLatency(min, max time.Duration) Latency {
+	return faults.JitterLatency(min, max)
+}
+
+// NewScenarioRunner calls faults.NewScenarioRunner.
+func NewScenarioRunner(h http.Handler, phases []Phase, loop bool) (*ScenarioRunner, error) {
+	return faults.NewScenarioRunner(h, phases, loop)
+}
@@ -0,0 +1,8 @@
+// Package faults simulates failures of the network and of the server in
+// front of an http.Handler, typically a feedsim.AtomFeedSimulator.
+//
+// FaultInjector fails, drops or answers with canned responses the requests
+// scripted by the test. ScenarioRunner serves a handler through phases in
+// which the node is healthy, unreachable or degraded. Latency values describe
+// the delays applied by feedsim.WithLatency.
+package faults
@@ -0,0 +1,307 @@
+package faults
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FaultInjector is an http.Handler that wraps another handler, typically a
+// feedsim.AtomFeedSimulator, and fails requests according to a script of
+// faults.
+//
+// Faults are checked in the order they were added and the first fault that
+// applies to a request determines how it fails. Requests that are not failed
+// are passed on to the wrapped handler. Typical status codes for failed
+// requests are 408, 500, 502 and 503, which clients are expected to retry.
+type FaultInjector struct {
+	sync.Mutex
+	Handler  http.Handler
+	faults   []fault
+	requests int
+}
+
+// FaultRecorder is implemented by handlers that count the faults injected in
+// front of them, such as a feedsim.AtomFeedSimulator serving metrics. The
+// FaultInjector reports each request it fails to the handler it wraps.
+type FaultRecorder interface {
+	RecordFault(kind string)
+}
+
+// action responds to a request in place of the wrapped handler.
+type action func(f *FaultInjector, w http.ResponseWriter, r *http.Request)
+
+// fault returns the action to take for the request numbered n or nil if the
+// fault does not apply to the request. Faults are called with the lock held.
+type fault func(r *http.Request, n int, now time.Time) action
+
+// matcher decides whether the request numbered n should be failed.
+type matcher func(r *http.Request, n int, now time.Time) bool
+
+// CannedResponse is a response returned by the FaultInjector in place of the
+// response of the wrapped handler. A zero Status passes the request on to the
+// wrapped handler.
+type CannedResponse struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// NewFaultInjector returns a FaultInjector wrapping the handler h.
+func NewFaultInjector(h http.Handler) *FaultInjector {
+	return &FaultInjector{Handler: h}
+}
+
+// FailNth fails the nth request received by the FaultInjector with the status
+// code provided. Requests are numbered from 1.
+func (f *FaultInjector) FailNth(n int, status int) {
+	f.addFault(when(nth(n), failWith(status)))
+}
+
+// FailMatching fails every request whose url matches the regular expression
+// pattern with the status code provided.
+func (f *FaultInjector) FailMatching(pattern string, status int) error {
+	m, err := matching(pattern)
+	if err != nil {
+		return err
+	}
+	f.addFault(when(m, failWith(status)))
+	return nil
+}
+
+// FailFor fails every request received during the duration d, starting now,
+// with the status code provided.
+func (f *FaultInjector) FailFor(d time.Duration, status int) {
+	until := time.Now().Add(d)
+	f.addFault(when(func(r *http.Request, i int, now time.Time) bool {
+		return now.Before(until)
+	}, failWith(status)))
+}
+
+// DropConnectionNth truncates the response to the nth request received by the
+// FaultInjector. See DropConnectionMatching.
+func (f *FaultInjector) DropConnectionNth(n int, bytes int) {
+	f.addFault(when(nth(n), dropAfter(bytes)))
+}
+
+// DropConnectionMatching truncates the responses to every request whose url
+// matches the regular expression pattern.
+//
+// The response headers, including a Content-Length for the complete body, and
+// the first bytes of the body are written before the TCP connection is closed,
+// so the client sees an unexpected EOF part way through reading the response.
+func (f *FaultInjector) DropConnectionMatching(pattern string, bytes int) error {
+	m, err := matching(pattern)
+	if err != nil {
+		return err
+	}
+	f.addFault(when(m, dropAfter(bytes)))
+	return nil
+}
+
+// RespondInSequence answers successive requests whose url matches the regular
+// expression pattern with the responses provided, in order. Once the responses
+// have been used up the matching requests are served normally again.
+//
+// For example a route that fails twice before recovering can be scripted with
+//
+//	fi.RespondInSequence("/streams/foo", CannedResponse{Status: 503}, CannedResponse{Status: 503})
+func (f *FaultInjector) RespondInSequence(pattern string, responses ...CannedResponse) error {
+	m, err := matching(pattern)
+	if err != nil {
+		return err
+	}
+	next := 0
+	f.addFault(func(r *http.Request, n int, now time.Time) action {
+		if next >= len(responses) || !m(r, n, now) {
+			return nil
+		}
+		resp := responses[next]
+		next++
+		return respondWith(resp)
+	})
+	return nil
+}
+
+// Window describes a fault active for a window of time, as written in
+// scenario files. The fault starts After the time it is added from and lasts
+// For the duration given, or indefinitely when For is zero. Match restricts
+// it to requests whose url matches a regular expression and Nth to the nth
+// such request. Requests are failed with Status or, when DropAfter is set,
+// have their connection dropped after that many bytes of the body.
+type Window struct {
+	After     time.Duration
+	For       time.Duration
+	Match     string
+	Nth       int
+	Status    int
+	DropAfter *int
+}
+
+// FailWindow adds the fault described by w, timed from start.
+func (f *FaultInjector) FailWindow(start time.Time, w Window) error {
+	var m matcher
+	if w.Match != "" {
+		var err error
+		if m, err = matching(w.Match); err != nil {
+			return err
+		}
+	}
+	from := start.Add(w.After)
+	until := from.Add(w.For)
+	a := failWith(w.Status)
+	if w.DropAfter != nil {
+		a = dropAfter(*w.DropAfter)
+	}
+
+	matched := 0
+	f.addFault(func(r *http.Request, n int, now time.Time) action {
+		if now.Before(from) || (w.For > 0 && !now.Before(until)) {
+			return nil
+		}
+		if m != nil && !m(r, n, now) {
+			return nil
+		}
+		matched++
+		if w.Nth > 0 && matched != w.Nth {
+			return nil
+		}
+		return a
+	})
+	return nil
+}
+
+// Reset removes all scripted faults and restarts request numbering.
+func (f *FaultInjector) Reset() {
+	f.Lock()
+	defer f.Unlock()
+	f.faults = nil
+	f.requests = 0
+}
+
+func (f *FaultInjector) addFault(v fault) {
+	f.Lock()
+	defer f.Unlock()
+	f.faults = append(f.faults, v)
+}
+
+// when returns a fault taking the action a for requests matched by m.
+func when(m matcher, a action) fault {
+	return func(r *http.Request, n int, now time.Time) action {
+		if m(r, n, now) {
+			return a
+		}
+		return nil
+	}
+}
+
+func nth(n int) matcher {
+	return func(r *http.Request, i int, now time.Time) bool {
+		return i == n
+	}
+}
+
+func matching(pattern string) (matcher, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *http.Request, i int, now time.Time) bool {
+		return re.MatchString(r.URL.String())
+	}, nil
+}
+
+// failWith responds to the request with the status code provided.
+func failWith(status int) action {
+	return func(f *FaultInjector, w http.ResponseWriter, r *http.Request) {
+		f.recordFault()
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// respondWith responds to the request with the canned response provided. A
+// response with a zero status passes the request on and is not recorded as a
+// fault.
+func respondWith(resp CannedResponse) action {
+	return func(f *FaultInjector, w http.ResponseWriter, r *http.Request) {
+		if resp.Status == 0 {
+			f.Handler.ServeHTTP(w, r)
+			return
+		}
+		f.recordFault()
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.Status)
+		io.WriteString(w, resp.Body)
+	}
+}
+
+// dropAfter serves the request using the wrapped handler but closes the
+// connection once the given number of bytes of the body have been written.
+func dropAfter(bytes int) action {
+	return func(f *FaultInjector, w http.ResponseWriter, r *http.Request) {
+		f.recordFault()
+		rec := httptest.NewRecorder()
+		f.Handler.ServeHTTP(rec, r)
+
+		body := rec.Body.Bytes()
+		n := bytes
+		if n > len(body) {
+			n = len(body)
+		}
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rec.Code)
+		w.Write(body[:n])
+		if fl, ok := w.(http.Flusher); ok {
+			fl.Flush()
+		}
+
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			return
+		}
+		conn, _, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}
+}
+
+// ServeHTTP fails the request if a scripted fault applies to it and otherwise
+// passes it on to the wrapped handler.
+func (f *FaultInjector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	f.requests++
+	n := f.requests
+	now := time.Now()
+	var a action
+	for _, v := range f.faults {
+		if a = v(r, n, now); a != nil {
+			break
+		}
+	}
+	f.Unlock()
+
+	if a != nil {
+		a(f, w, r)
+		return
+	}
+	f.Handler.ServeHTTP(w, r)
+}
+
+// recordFault reports a fault injected in front of the wrapped handler if it
+// is a FaultRecorder.
+func (f *FaultInjector) recordFault() {
+	if fr, ok := f.Handler.(FaultRecorder); ok {
+		fr.RecordFault("injected")
+	}
+}
@@ -0,0 +1,165 @@
+package faults
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type FaultsSuite struct {
+	server *httptest.Server
+}
+
+var _ = Suite(&FaultsSuite{})
+
+func Test(t *testing.T) { TestingT(t) }
+
+func (s *FaultsSuite) TearDownTest(c *C) {
+	if s.server != nil {
+		s.server.Close()
+		s.server = nil
+	}
+}
+
+// body is served by the handler wrapped in the tests, long enough for a
+// connection to be dropped part way through it.
+var body = strings.Repeat("event data ", 32)
+
+// newFaultInjector serves a FaultInjector wrapping a handler that answers
+// every request with body and returns the url of stream on the test server.
+func (s *FaultsSuite) newFaultInjector(c *C, stream string) (*FaultInjector, string) {
+	fi := NewFaultInjector(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	s.server = httptest.NewServer(fi)
+	return fi, fmt.Sprintf("%s/streams/%s", s.server.URL, stream)
+}
+
+func getStatus(c *C, u string) int {
+	resp, err := http.Get(u)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (s *FaultsSuite) TestFaultInjectorFailsNthRequest(c *C) {
+	stream := "faulty-stream"
+	fi, u := s.newFaultInjector(c, stream)
+	fi.FailNth(2, http.StatusServiceUnavailable)
+
+	c.Assert(getStatus(c, u), Equals, http.StatusOK)
+	c.Assert(getStatus(c, u), Equals, http.StatusServiceUnavailable)
+	c.Assert(getStatus(c, u), Equals, http.StatusOK)
+}
+
+func (s *FaultsSuite) TestFaultInjectorFailsMatchingRequests(c *C) {
+	stream := "faulty-stream"
+	fi, u := s.newFaultInjector(c, stream)
+	err := fi.FailMatching("/metadata$", http.StatusBadGateway)
+	c.Assert(err, IsNil)
+
+	c.Assert(getStatus(c, u+"/metadata"), Equals, http.StatusBadGateway)
+	c.Assert(getStatus(c, u+"/metadata"), Equals, http.StatusBadGateway)
+	c.Assert(getStatus(c, u+"/1"), Equals, http.StatusOK)
+
+	c.Assert(fi.FailMatching("(", http.StatusBadGateway), NotNil)
+}
+
+func (s *FaultsSuite) TestFaultInjectorFailsForDuration(c *C) {
+	stream := "faulty-stream"
+	fi, u := s.newFaultInjector(c, stream)
+	fi.FailFor(50*time.Millisecond, http.StatusRequestTimeout)
+
+	c.Assert(getStatus(c, u), Equals, http.StatusRequestTimeout)
+	time.Sleep(60 * time.Millisecond)
+	c.Assert(getStatus(c, u), Equals, http.StatusOK)
+}
+
+func (s *FaultsSuite) TestFaultInjectorReset(c *C) {
+	stream := "faulty-stream"
+	fi, u := s.newFaultInjector(c, stream)
+	fi.FailMatching(".*", http.StatusInternalServerError)
+	c.Assert(getStatus(c, u), Equals, http.StatusInternalServerError)
+
+	fi.Reset()
+
+	c.Assert(getStatus(c, u), Equals, http.StatusOK)
+}
+
+func (s *FaultsSuite) TestFaultInjectorDropsConnectionMidBody(c *C) {
+	stream := "faulty-stream"
+	fi, u := s.newFaultInjector(c, stream)
+	err := fi.DropConnectionMatching("/streams/[^/]+$", 100)
+	c.Assert(err, IsNil)
+
+	resp, err := http.Get(u)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+	c.Assert(b, HasLen, 100)
+}
+
+func (s *FaultsSuite) TestFaultInjectorRespondsInSequence(c *C) {
+	stream := "faulty-stream"
+	fi, u := s.newFaultInjector(c, stream)
+	err := fi.RespondInSequence("/streams/"+stream+"$",
+		CannedResponse{Status: http.StatusServiceUnavailable},
+		CannedResponse{},
+		CannedResponse{Status: http.StatusTeapot, Header: http.Header{"X-Foo": []string{"bar"}}, Body: "short and stout"})
+	c.Assert(err, IsNil)
+
+	c.Assert(getStatus(c, u+"/1"), Equals, http.StatusOK)
+	c.Assert(getStatus(c, u), Equals, http.StatusServiceUnavailable)
+	c.Assert(getStatus(c, u), Equals, http.StatusOK)
+
+	resp, err := http.Get(u)
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusTeapot)
+	c.Assert(resp.Header.Get("X-Foo"), Equals, "bar")
+	c.Assert(string(b), Equals, "short and stout")
+
+	c.Assert(getStatus(c, u), Equals, http.StatusOK)
+}
+
+// faultCounter is a handler counting the faults recorded in front of it.
+type faultCounter struct {
+	http.Handler
+	faults int
+}
+
+func (fc *faultCounter) RecordFault(kind string) {
+	fc.faults++
+}
+
+func (s *FaultsSuite) TestRespondInSequenceRecordsOnlyCannedResponses(c *C) {
+	fc := &faultCounter{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	})}
+	fi := NewFaultInjector(fc)
+	c.Assert(fi.RespondInSequence("/streams/", CannedResponse{}, CannedResponse{Status: http.StatusServiceUnavailable}, CannedResponse{}), IsNil)
+
+	for _, want := range []struct{ status, faults int }{
+		{http.StatusOK, 0},
+		{http.StatusServiceUnavailable, 1},
+		{http.StatusOK, 1},
+		{http.StatusOK, 1},
+	} {
+		rec := httptest.NewRecorder()
+		fi.ServeHTTP(rec, httptest.NewRequest("GET", "/streams/faulty-stream", nil))
+		c.Assert(rec.Code, Equals, want.status)
+		c.Assert(fc.faults, Equals, want.faults)
+	}
+}
@@ -0,0 +1,28 @@
+package faults
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Latency returns the delay the simulator applies before responding to the
+// request r.
+type Latency func(r *http.Request) time.Duration
+
+// FixedLatency delays every request by d.
+func FixedLatency(d time.Duration) Latency {
+	return func(r *http.Request) time.Duration {
+		return d
+	}
+}
+
+// JitterLatency delays each request by a random duration between min and max.
+func JitterLatency(min, max time.Duration) Latency {
+	return func(r *http.Request) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(rand.Int63n(int64(max-min)))
+	}
+}
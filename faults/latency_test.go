@@ -0,0 +1,15 @@
+package faults
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *FaultsSuite) TestJitterLatencyWithinRange(c *C) {
+	l := JitterLatency(10*time.Millisecond, 20*time.Millisecond)
+	for i := 0; i < 100; i++ {
+		d := l(nil)
+		c.Assert(d >= 10*time.Millisecond && d < 20*time.Millisecond, Equals, true)
+	}
+}
@@ -0,0 +1,232 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NodeState is the state of the simulated node during a phase of a scenario.
+type NodeState int
+
+const (
+	// Healthy nodes serve every request normally.
+	Healthy NodeState = iota
+	// Unreachable nodes refuse connections. Open connections are closed
+	// when the phase starts.
+	Unreachable
+	// Degraded nodes delay each request by the latency of the phase and
+	// then fail it with the status of the phase, or serve it normally if
+	// the status is zero.
+	Degraded
+)
+
+// Phase is a period of a scenario during which the node is in a given state.
+type Phase struct {
+	State    NodeState
+	Duration time.Duration
+	Latency  time.Duration
+	Status   int
+}
+
+// ScenarioRunner serves a handler, typically a feedsim.AtomFeedSimulator, on a
+// local address and moves the node through a timeline of healthy, unreachable
+// and degraded phases. It can be used to soak test the reconnect logic of
+// clients.
+type ScenarioRunner struct {
+	sync.Mutex
+	Handler http.Handler
+	phases  []Phase
+	loop    bool
+	addr    string
+	server  *http.Server
+	current Phase
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	// err is the error that stopped the timeline.
+	err error
+}
+
+// NewScenarioRunner creates a ScenarioRunner serving h through the phases
+// provided. If loop is true the timeline is repeated until the runner is
+// closed, otherwise the node stays in the state of the last phase.
+//
+// The runner listens on an ephemeral port on the loopback interface which is
+// kept for the life of the runner, so clients can reconnect to URL after the
+// node has been unreachable.
+func NewScenarioRunner(h http.Handler, phases []Phase, loop bool) (*ScenarioRunner, error) {
+	if len(phases) == 0 {
+		return nil, errors.New("scenario must have one or more phases")
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &ScenarioRunner{
+		Handler: h,
+		phases:  phases,
+		loop:    loop,
+		addr:    l.Addr().String(),
+		stop:    make(chan struct{}),
+	}
+	l.Close()
+	return s, nil
+}
+
+// URL returns the base url of the node, e.g. http://127.0.0.1:4567
+func (s *ScenarioRunner) URL() string {
+	return "http://" + s.addr
+}
+
+// Start runs the timeline. It returns once the first phase has started.
+func (s *ScenarioRunner) Start() error {
+	if err := s.enter(s.phases[0]); err != nil {
+		return err
+	}
+	s.done = make(chan struct{})
+	go s.run()
+	return nil
+}
+
+// Wait blocks until the timeline ends, because it is not looped and has
+// reached its last phase, because the runner is closed or because the node
+// could not enter a phase, and returns Err.
+func (s *ScenarioRunner) Wait() error {
+	if s.done != nil {
+		<-s.done
+	}
+	return s.Err()
+}
+
+// Err returns the error that stopped the timeline, such as the address of the
+// node having been taken by another listener while it was unreachable, or nil
+// if the timeline is running or ended normally.
+func (s *ScenarioRunner) Err() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
+
+// Close stops the timeline and the server.
+func (s *ScenarioRunner) Close() {
+	s.stopTimeline()
+	s.Lock()
+	defer s.Unlock()
+	s.closeServer()
+}
+
+// Shutdown stops the timeline and gracefully shuts down the server. Requests
+// delayed by a degraded phase return immediately and Shutdown waits for the
+// other requests in flight to complete. It returns the error of the context
+// if the context is done first.
+//
+// Requests held by a feedsim.AtomFeedSimulator are released by shutting down
+// the simulator first.
+func (s *ScenarioRunner) Shutdown(ctx context.Context) error {
+	s.stopTimeline()
+	s.Lock()
+	srv := s.server
+	s.server = nil
+	s.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// stopTimeline stops the timeline and waits for it to return.
+func (s *ScenarioRunner) stopTimeline() {
+	s.once.Do(func() { close(s.stop) })
+	if s.done != nil {
+		<-s.done
+	}
+}
+
+func (s *ScenarioRunner) run() {
+	defer close(s.done)
+	i := 0
+	for {
+		t := time.NewTimer(s.phases[i].Duration)
+		select {
+		case <-s.stop:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		i++
+		if i == len(s.phases) {
+			if !s.loop {
+				return
+			}
+			i = 0
+		}
+		if err := s.enter(s.phases[i]); err != nil {
+			s.Lock()
+			s.err = fmt.Errorf("entering phase %d: %v", i, err)
+			s.Unlock()
+			return
+		}
+	}
+}
+
+// enter moves the node into the state of the phase p.
+func (s *ScenarioRunner) enter(p Phase) error {
+	s.Lock()
+	defer s.Unlock()
+	s.current = p
+	if p.State == Unreachable {
+		s.closeServer()
+		return nil
+	}
+	if s.server != nil {
+		return nil
+	}
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serve)}
+	go s.server.Serve(l)
+	return nil
+}
+
+// closeServer closes the listener and any open connections. The caller must
+// hold the lock.
+func (s *ScenarioRunner) closeServer() {
+	if s.server != nil {
+		s.server.Close()
+		s.server = nil
+	}
+}
+
+func (s *ScenarioRunner) serve(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	p := s.current
+	s.Unlock()
+
+	if p.State == Degraded {
+		t := time.NewTimer(p.Latency)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		case <-s.stop:
+			t.Stop()
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if p.Status != 0 {
+			http.Error(w, http.StatusText(p.Status), p.Status)
+			return
+		}
+	}
+	s.Handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,116 @@
+package faults
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *FaultsSuite) TestScenarioRunnerMovesThroughPhases(c *C) {
+	stream := "flapping-stream"
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	})
+
+	sr, err := NewScenarioRunner(h, []Phase{
+		{State: Healthy, Duration: 100 * time.Millisecond},
+		{State: Unreachable, Duration: 100 * time.Millisecond},
+		{State: Degraded, Duration: 100 * time.Millisecond, Status: http.StatusServiceUnavailable},
+		{State: Healthy},
+	}, false)
+	c.Assert(err, IsNil)
+	c.Assert(sr.Start(), IsNil)
+	defer sr.Close()
+
+	u := fmt.Sprintf("%s/streams/%s", sr.URL(), stream)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func() (int, error) {
+		resp, err := client.Get(u)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	status, err := get()
+	c.Assert(err, IsNil)
+	c.Assert(status, Equals, http.StatusOK)
+
+	time.Sleep(150 * time.Millisecond)
+	_, err = get()
+	c.Assert(err, NotNil)
+
+	time.Sleep(100 * time.Millisecond)
+	status, err = get()
+	c.Assert(err, IsNil)
+	c.Assert(status, Equals, http.StatusServiceUnavailable)
+
+	time.Sleep(100 * time.Millisecond)
+	status, err = get()
+	c.Assert(err, IsNil)
+	c.Assert(status, Equals, http.StatusOK)
+}
+
+func (s *FaultsSuite) TestScenarioRunnerReportsFailedPhase(c *C) {
+	sr, err := NewScenarioRunner(http.NotFoundHandler(), []Phase{
+		{State: Healthy, Duration: 50 * time.Millisecond},
+		{State: Unreachable, Duration: 200 * time.Millisecond},
+		{State: Healthy},
+	}, false)
+	c.Assert(err, IsNil)
+	c.Assert(sr.Start(), IsNil)
+	defer sr.Close()
+	c.Assert(sr.Err(), IsNil)
+
+	// Take the address of the node while it is unreachable.
+	addr := strings.TrimPrefix(sr.URL(), "http://")
+	var l net.Listener
+	for l == nil {
+		l, _ = net.Listen("tcp", addr)
+		time.Sleep(5 * time.Millisecond)
+	}
+	defer l.Close()
+
+	c.Assert(sr.Wait(), ErrorMatches, "entering phase 2: .*")
+	c.Assert(sr.Err(), Equals, sr.Wait())
+}
+
+func (s *FaultsSuite) TestScenarioRunnerRequiresPhases(c *C) {
+	sr, err := NewScenarioRunner(http.NotFoundHandler(), nil, false)
+
+	c.Assert(sr, IsNil)
+	c.Assert(err, NotNil)
+}
+
+func (s *FaultsSuite) TestScenarioRunnerShutdownReleasesDegradedRequests(c *C) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	sr, err := NewScenarioRunner(h, []Phase{
+		{State: Degraded, Duration: time.Minute, Latency: time.Minute},
+	}, false)
+	c.Assert(err, IsNil)
+	c.Assert(sr.Start(), IsNil)
+
+	statuses := make(chan int)
+	go func() {
+		resp, err := http.Get(sr.URL())
+		if err != nil {
+			statuses <- 0
+			return
+		}
+		resp.Body.Close()
+		statuses <- resp.StatusCode
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.Assert(sr.Shutdown(ctx), IsNil)
+	c.Assert(<-statuses, Equals, http.StatusServiceUnavailable)
+}
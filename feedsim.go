@@ -0,0 +1,521 @@
+package mock
+
+import (
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/feedsim"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// Types of package feedsim, aliased for code written against package mock.
+type (
+	StreamACL                      = feedsim.StreamACL
+	Settings                       = feedsim.Settings
+	Clock                          = feedsim.Clock
+	ClockFunc                      = feedsim.ClockFunc
+	Cluster                        = feedsim.Cluster
+	ErrorFormat                    = feedsim.ErrorFormat
+	InvalidVersionError            = feedsim.InvalidVersionError
+	InvalidPageSizeError           = feedsim.InvalidPageSizeError
+	MalformedVersionError          = feedsim.MalformedVersionError
+	MalformedPageSizeError         = feedsim.MalformedPageSizeError
+	InvalidDirectionError          = feedsim.InvalidDirectionError
+	InvalidURLError                = feedsim.InvalidURLError
+	EventNotFoundError             = feedsim.EventNotFoundError
+	EventIDNotFoundError           = feedsim.EventIDNotFoundError
+	StreamNotFoundError            = feedsim.StreamNotFoundError
+	StreamDeletedError             = feedsim.StreamDeletedError
+	UnsupportedServerVersionError  = feedsim.UnsupportedServerVersionError
+	FeedFormat                     = feedsim.FeedFormat
+	AtomFeedSimulator              = feedsim.AtomFeedSimulator
+	FeedWindow                     = feedsim.FeedWindow
+	StreamURL                      = feedsim.StreamURL
+	CheckpointedReader             = feedsim.CheckpointedReader
+	ReaderFunc                     = feedsim.ReaderFunc
+	DeliveryGuarantee              = feedsim.DeliveryGuarantee
+	ReaderStep                     = feedsim.ReaderStep
+	ReaderHarness                  = feedsim.ReaderHarness
+	ReaderHarnessError             = feedsim.ReaderHarnessError
+	Delivery                       = feedsim.Delivery
+	RequestDetails                 = feedsim.RequestDetails
+	LogEntry                       = feedsim.LogEntry
+	Logger                         = feedsim.Logger
+	LoggerFunc                     = feedsim.LoggerFunc
+	Feed                           = feedsim.Feed
+	FeedEntry                      = feedsim.FeedEntry
+	FeedLink                       = feedsim.FeedLink
+	FeedPerson                     = feedsim.FeedPerson
+	FeedText                       = feedsim.FeedText
+	FeedTime                       = feedsim.FeedTime
+	Option                         = feedsim.Option
+	PageCase                       = feedsim.PageCase
+	LinkBuilder                    = feedsim.LinkBuilder
+	PagingMode                     = feedsim.PagingMode
+	StreamPermission               = feedsim.StreamPermission
+	PersistentSubscriptionSettings = feedsim.PersistentSubscriptionSettings
+	TestReporter                   = feedsim.TestReporter
+	Projection                     = feedsim.Projection
+	StreamSpec                     = feedsim.StreamSpec
+	RecordedRequest                = feedsim.RecordedRequest
+	RequestMatcher                 = feedsim.RequestMatcher
+	StreamRouter                   = feedsim.StreamRouter
+	Scenario                       = feedsim.Scenario
+	AppendStep                     = feedsim.AppendStep
+	SchemaViolation                = feedsim.SchemaViolation
+	SimulatorServer                = feedsim.SimulatorServer
+	Snapshot                       = feedsim.Snapshot
+	TCPServer                      = feedsim.TCPServer
+)
+
+// Constants of package feedsim.
+const (
+	SettingsStream              = feedsim.SettingsStream
+	SettingsEventType           = feedsim.SettingsEventType
+	RoleAll                     = feedsim.RoleAll
+	RoleAdmins                  = feedsim.RoleAdmins
+	EncodingGzip                = feedsim.EncodingGzip
+	EncodingDeflate             = feedsim.EncodingDeflate
+	AtLeastOnce                 = feedsim.AtLeastOnce
+	ExactlyOnce                 = feedsim.ExactlyOnce
+	RouteFeed                   = feedsim.RouteFeed
+	RouteEvent                  = feedsim.RouteEvent
+	RouteMetadata               = feedsim.RouteMetadata
+	LegacyPaging                = feedsim.LegacyPaging
+	ServerPaging                = feedsim.ServerPaging
+	CleanPaging                 = feedsim.CleanPaging
+	StartFromEnd                = feedsim.StartFromEnd
+	ExpectedVersionAny          = feedsim.ExpectedVersionAny
+	ExpectedVersionNoStream     = feedsim.ExpectedVersionNoStream
+	ExpectedVersionStreamExists = feedsim.ExpectedVersionStreamExists
+)
+
+// Variables of package feedsim.
+var (
+	ErrNoEvents        = feedsim.ErrNoEvents
+	ErrEmptyStreamName = feedsim.ErrEmptyStreamName
+	ErrShutdown        = feedsim.ErrShutdown
+)
+
+// WithStrictAccept calls feedsim.WithStrictAccept.
+func WithStrictAccept() Option {
+	return feedsim.WithStrictAccept()
+}
+
+// WithSettings calls feedsim.WithSettings.
+func WithSettings(s Settings) Option {
+	return feedsim.WithSettings(s)
+}
+
+// WithBasicAuth calls feedsim.WithBasicAuth.
+func WithBasicAuth(username, password string) Option {
+	return feedsim.WithBasicAuth(username, password)
+}
+
+// WithBackpressure calls feedsim.WithBackpressure.
+func WithBackpressure(load, polls int) Option {
+	return feedsim.WithBackpressure(load, polls)
+}
+
+// WithBandwidth calls feedsim.WithBandwidth.
+func WithBandwidth(bytesPerSecond int) Option {
+	return feedsim.WithBandwidth(bytesPerSecond)
+}
+
+// WithChunkedTransfer calls feedsim.WithChunkedTransfer.
+func WithChunkedTransfer(chunkSize int) Option {
+	return feedsim.WithChunkedTransfer(chunkSize)
+}
+
+// SteppingClock calls feedsim.SteppingClock.
+func SteppingClock(start time.Time, step time.Duration) Clock {
+	return feedsim.SteppingClock(start, step)
+}
+
+// WithClock calls feedsim.WithClock.
+func WithClock(c Clock) Option {
+	return feedsim.WithClock(c)
+}
+
+// WithClockSkew calls feedsim.WithClockSkew.
+func WithClockSkew(skew time.Duration) Option {
+	return feedsim.WithClockSkew(skew)
+}
+
+// NewCluster calls feedsim.NewCluster.
+func NewCluster(nodes int, lag time.Duration, opts ...Option) (*Cluster, error) {
+	return feedsim.NewCluster(nodes, lag, opts...)
+}
+
+// WithCompression calls feedsim.WithCompression.
+func WithCompression() Option {
+	return feedsim.WithCompression()
+}
+
+// WithForcedCompression calls feedsim.WithForcedCompression.
+func WithForcedCompression(encoding string) Option {
+	return feedsim.WithForcedCompression(encoding)
+}
+
+// WithCloseAfter calls feedsim.WithCloseAfter.
+func WithCloseAfter(n int) Option {
+	return feedsim.WithCloseAfter(n)
+}
+
+// WithoutKeepAlives calls feedsim.WithoutKeepAlives.
+func WithoutKeepAlives() Option {
+	return feedsim.WithoutKeepAlives()
+}
+
+// WithHTTP2 calls feedsim.WithHTTP2.
+func WithHTTP2() Option {
+	return feedsim.WithHTTP2()
+}
+
+// ByCorrelationID calls feedsim.ByCorrelationID.
+func ByCorrelationID(key string) Projection {
+	return feedsim.ByCorrelationID(key)
+}
+
+// WithDuplicateDelivery calls feedsim.WithDuplicateDelivery.
+func WithDuplicateDelivery(overlap int) Option {
+	return feedsim.WithDuplicateDelivery(overlap)
+}
+
+// WithErrorFormat calls feedsim.WithErrorFormat.
+func WithErrorFormat(f ErrorFormat) Option {
+	return feedsim.WithErrorFormat(f)
+}
+
+// JSONErrors calls feedsim.JSONErrors.
+func JSONErrors(status int, message string) (string, []byte) {
+	return feedsim.JSONErrors(status, message)
+}
+
+// ProblemDetails calls feedsim.ProblemDetails.
+func ProblemDetails(status int, message string) (string, []byte) {
+	return feedsim.ProblemDetails(status, message)
+}
+
+// LoadStreamFixture calls feedsim.LoadStreamFixture.
+func LoadStreamFixture(path string, opts ...Option) (*AtomFeedSimulator, error) {
+	return feedsim.LoadStreamFixture(path, opts...)
+}
+
+// LoadRouterFixture calls feedsim.LoadRouterFixture.
+func LoadRouterFixture(path string, opts ...Option) (*StreamRouter, error) {
+	return feedsim.LoadRouterFixture(path, opts...)
+}
+
+// WithFeedFormat calls feedsim.WithFeedFormat.
+func WithFeedFormat(ff FeedFormat) Option {
+	return feedsim.WithFeedFormat(ff)
+}
+
+// WithGRPC calls feedsim.WithGRPC.
+func WithGRPC() Option {
+	return feedsim.WithGRPC()
+}
+
+// NewAtomFeedSimulator calls feedsim.NewAtomFeedSimulator.
+func NewAtomFeedSimulator(opts ...Option) (*AtomFeedSimulator, error) {
+	return feedsim.NewAtomFeedSimulator(opts...)
+}
+
+// NewAtomFeedSimulatorFromEvents calls feedsim.NewAtomFeedSimulatorFromEvents.
+func NewAtomFeedSimulatorFromEvents(events []*eventdata.Event, baseURL *url.URL, streamMeta *eventdata.Event, trickleAfter int, opts ...Option) (*AtomFeedSimulator, error) {
+	return feedsim.NewAtomFeedSimulatorFromEvents(events, baseURL, streamMeta, trickleAfter, opts...)
+}
+
+// CreateTestFeed calls feedsim.CreateTestFeed.
+func CreateTestFeed(es []*eventdata.Event, feedURL string) (*atom.Feed, error) {
+	return feedsim.CreateTestFeed(es, feedURL)
+}
+
+// ParseStreamURL calls feedsim.ParseStreamURL.
+func ParseStreamURL(u string) (*StreamURL, error) {
+	return feedsim.ParseStreamURL(u)
+}
+
+// OnRequest calls feedsim.OnRequest.
+func OnRequest(fn func(d RequestDetails)) Option {
+	return feedsim.OnRequest(fn)
+}
+
+// OnFeedServed calls feedsim.OnFeedServed.
+func OnFeedServed(fn func(d RequestDetails, events []*eventdata.Event)) Option {
+	return feedsim.OnFeedServed(fn)
+}
+
+// OnEventServed calls feedsim.OnEventServed.
+func OnEventServed(fn func(d RequestDetails, e *eventdata.Event)) Option {
+	return feedsim.OnEventServed(fn)
+}
+
+// OnError calls feedsim.OnError.
+func OnError(fn func(d RequestDetails, err error)) Option {
+	return feedsim.OnError(fn)
+}
+
+// WithLatency calls feedsim.WithLatency.
+func WithLatency(pattern string, l faults.Latency) Option {
+	return feedsim.WithLatency(pattern, l)
+}
+
+// WithRelativeLinks calls feedsim.WithRelativeLinks.
+func WithRelativeLinks() Option {
+	return feedsim.WithRelativeLinks()
+}
+
+// WithRequestHostLinks calls feedsim.WithRequestHostLinks.
+func WithRequestHostLinks() Option {
+	return feedsim.WithRequestHostLinks()
+}
+
+// NewAtomFeedSimulatorFromChannel calls feedsim.NewAtomFeedSimulatorFromChannel.
+func NewAtomFeedSimulatorFromChannel(ch <-chan *eventdata.Event, opts ...Option) (*AtomFeedSimulator, error) {
+	return feedsim.NewAtomFeedSimulatorFromChannel(ch, opts...)
+}
+
+// WithMaxInFlight calls feedsim.WithMaxInFlight.
+func WithMaxInFlight(max int, retryAfter time.Duration) Option {
+	return feedsim.WithMaxInFlight(max, retryAfter)
+}
+
+// SlogLogger calls feedsim.SlogLogger.
+func SlogLogger(l *slog.Logger) Logger {
+	return feedsim.SlogLogger(l)
+}
+
+// WithLogger calls feedsim.WithLogger.
+func WithLogger(l Logger) Option {
+	return feedsim.WithLogger(l)
+}
+
+// WithMetrics calls feedsim.WithMetrics.
+func WithMetrics() Option {
+	return feedsim.WithMetrics()
+}
+
+// DecodeFeed calls feedsim.DecodeFeed.
+func DecodeFeed(r io.Reader) (*Feed, error) {
+	return feedsim.DecodeFeed(r)
+}
+
+// WithEvents calls feedsim.WithEvents.
+func WithEvents(events ...*eventdata.Event) Option {
+	return feedsim.WithEvents(events...)
+}
+
+// WithBaseURL calls feedsim.WithBaseURL.
+func WithBaseURL(u *url.URL) Option {
+	return feedsim.WithBaseURL(u)
+}
+
+// WithMetaData calls feedsim.WithMetaData.
+func WithMetaData(m *eventdata.Event) Option {
+	return feedsim.WithMetaData(m)
+}
+
+// WithStream calls feedsim.WithStream.
+func WithStream(stream string) Option {
+	return feedsim.WithStream(stream)
+}
+
+// WithTrickle calls feedsim.WithTrickle.
+func WithTrickle(after int) Option {
+	return feedsim.WithTrickle(after)
+}
+
+// WithPageCache calls feedsim.WithPageCache.
+func WithPageCache() Option {
+	return feedsim.WithPageCache()
+}
+
+// PageBoundaryCases calls feedsim.PageBoundaryCases.
+func PageBoundaryCases(length, pageSize int) []PageCase {
+	return feedsim.PageBoundaryCases(length, pageSize)
+}
+
+// WithPageSizeLimits calls feedsim.WithPageSizeLimits.
+func WithPageSizeLimits(min, max int, clamp bool) Option {
+	return feedsim.WithPageSizeLimits(min, max, clamp)
+}
+
+// NewLinkBuilder calls feedsim.NewLinkBuilder.
+func NewLinkBuilder(u *StreamURL) LinkBuilder {
+	return feedsim.NewLinkBuilder(u)
+}
+
+// WithPagingMode calls feedsim.WithPagingMode.
+func WithPagingMode(m PagingMode) Option {
+	return feedsim.WithPagingMode(m)
+}
+
+// WithStreamPermissions calls feedsim.WithStreamPermissions.
+func WithStreamPermissions(perms ...StreamPermission) Option {
+	return feedsim.WithStreamPermissions(perms...)
+}
+
+// WithPersistentSubscription calls feedsim.WithPersistentSubscription.
+func WithPersistentSubscription(stream, group string, s PersistentSubscriptionSettings) Option {
+	return feedsim.WithPersistentSubscription(stream, group, s)
+}
+
+// GenerateStreamSpec calls feedsim.GenerateStreamSpec.
+func GenerateStreamSpec(r *rand.Rand, size int) StreamSpec {
+	return feedsim.GenerateStreamSpec(r, size)
+}
+
+// WithRateLimit calls feedsim.WithRateLimit.
+func WithRateLimit(rate float64, burst int) Option {
+	return feedsim.WithRateLimit(rate, burst)
+}
+
+// MatchURL calls feedsim.MatchURL.
+func MatchURL(pattern string) RequestMatcher {
+	return feedsim.MatchURL(pattern)
+}
+
+// MatchMethod calls feedsim.MatchMethod.
+func MatchMethod(method string) RequestMatcher {
+	return feedsim.MatchMethod(method)
+}
+
+// MatchHeader calls feedsim.MatchHeader.
+func MatchHeader(name, value string) RequestMatcher {
+	return feedsim.MatchHeader(name, value)
+}
+
+// MatchLongPoll calls feedsim.MatchLongPoll.
+func MatchLongPoll() RequestMatcher {
+	return feedsim.MatchLongPoll()
+}
+
+// WithReplicaLag calls feedsim.WithReplicaLag.
+func WithReplicaLag(every, lag int) Option {
+	return feedsim.WithReplicaLag(every, lag)
+}
+
+// NewStreamRouter calls feedsim.NewStreamRouter.
+func NewStreamRouter(sims ...*AtomFeedSimulator) (*StreamRouter, error) {
+	return feedsim.NewStreamRouter(sims...)
+}
+
+// LoadScenario calls feedsim.LoadScenario.
+func LoadScenario(path string, opts ...Option) (*Scenario, error) {
+	return feedsim.LoadScenario(path, opts...)
+}
+
+// WithAppendSchedule calls feedsim.WithAppendSchedule.
+func WithAppendSchedule(steps []AppendStep) Option {
+	return feedsim.WithAppendSchedule(steps)
+}
+
+// WithSchema calls feedsim.WithSchema.
+func WithSchema(eventType string, schema []byte) Option {
+	return feedsim.WithSchema(eventType, schema)
+}
+
+// WithStrictSchemas calls feedsim.WithStrictSchemas.
+func WithStrictSchemas() Option {
+	return feedsim.WithStrictSchemas()
+}
+
+// NewTLSSimulatorServer calls feedsim.NewTLSSimulatorServer.
+func NewTLSSimulatorServer(opts ...Option) (*SimulatorServer, error) {
+	return feedsim.NewTLSSimulatorServer(opts...)
+}
+
+// NewTLSSimulatorServerAt calls feedsim.NewTLSSimulatorServerAt.
+func NewTLSSimulatorServerAt(addr string, opts ...Option) (*SimulatorServer, error) {
+	return feedsim.NewTLSSimulatorServerAt(addr, opts...)
+}
+
+// SelfSignedTLS calls feedsim.SelfSignedTLS.
+func SelfSignedTLS() (*tls.Config, []byte, error) {
+	return feedsim.SelfSignedTLS()
+}
+
+// WithServerSentEvents calls feedsim.WithServerSentEvents.
+func WithServerSentEvents(keepAlive time.Duration) Option {
+	return feedsim.WithServerSentEvents(keepAlive)
+}
+
+// WithMissingStream calls feedsim.WithMissingStream.
+func WithMissingStream(stream string) Option {
+	return feedsim.WithMissingStream(stream)
+}
+
+// WithDeletedStream calls feedsim.WithDeletedStream.
+func WithDeletedStream(stream string) Option {
+	return feedsim.WithDeletedStream(stream)
+}
+
+// WithEmptyStream calls feedsim.WithEmptyStream.
+func WithEmptyStream(stream string) Option {
+	return feedsim.WithEmptyStream(stream)
+}
+
+// WithStrictHeadOfStream calls feedsim.WithStrictHeadOfStream.
+func WithStrictHeadOfStream() Option {
+	return feedsim.WithStrictHeadOfStream()
+}
+
+// StartTCPServer calls feedsim.StartTCPServer.
+func StartTCPServer(sim *AtomFeedSimulator, addr string, heartbeat time.Duration) (*TCPServer, error) {
+	return feedsim.StartTCPServer(sim, addr, heartbeat)
+}
+
+// StartServer calls feedsim.StartServer.
+func StartServer(t testing.TB, opts ...Option) *SimulatorServer {
+	return feedsim.StartServer(t, opts...)
+}
+
+// StartServerAt calls feedsim.StartServerAt.
+func StartServerAt(t testing.TB, addr string, opts ...Option) *SimulatorServer {
+	return feedsim.StartServerAt(t, addr, opts...)
+}
+
+// WithTokenAuth calls feedsim.WithTokenAuth.
+func WithTokenAuth(ttl time.Duration) Option {
+	return feedsim.WithTokenAuth(ttl)
+}
+
+// NewTransport calls feedsim.NewTransport.
+func NewTransport(h http.Handler) http.RoundTripper {
+	return feedsim.NewTransport(h)
+}
+
+// WithServerVersion calls feedsim.WithServerVersion.
+func WithServerVersion(version string) Option {
+	return feedsim.WithServerVersion(version)
+}
+
+// WithVirtualStream calls feedsim.WithVirtualStream.
+func WithVirtualStream(count int, gen func(eventNumber int) *eventdata.Event) Option {
+	return feedsim.WithVirtualStream(count, gen)
+}
+
+// VirtualEvents calls feedsim.VirtualEvents.
+func VirtualEvents(stream, server string, eventTypes ...string) func(eventNumber int) *eventdata.Event {
+	return feedsim.VirtualEvents(stream, server, eventTypes...)
+}
+
+// WithWriteLimits calls feedsim.WithWriteLimits.
+func WithWriteLimits(maxBytes int64, maxEvents int) Option {
+	return feedsim.WithWriteLimits(maxBytes, maxEvents)
+}
+
+// WithWriteLatency calls feedsim.WithWriteLatency.
+func WithWriteLatency(l faults.Latency, timeout time.Duration, commit bool) Option {
+	return feedsim.WithWriteLatency(l, timeout, commit)
+}
@@ -0,0 +1,69 @@
+package feedsim
+
+import (
+	"net/http"
+	"strings"
+)
+
+const mediaTypeAtomXML = "application/atom+xml"
+
+// WithStrictAccept makes the simulator as particular about the Accept header
+// as EventStore, so clients that send a missing or wrong Accept header fail
+// in tests rather than in production.
+//
+// Requests for feed pages must accept application/atom+xml and requests for
+// events and metadata must accept one of the json representations of an
+// event, application/vnd.eventstore.atom+json,
+// application/vnd.eventstore.event+json or application/json. Wildcards such as
+// */* are acceptable. Other requests, including requests for events that only
+// accept a feed media type, receive 406 Not Acceptable. Feed pages are served
+// with a Content-Type of application/atom+xml.
+//
+// By default requests are served whatever their Accept header.
+func WithStrictAccept() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.strictAccept = true
+		return nil
+	}
+}
+
+// mediaTypes lists the media types acceptable for requests to each route.
+var mediaTypes = map[string][]string{
+	RouteFeed:     {mediaTypeAtomXML},
+	RouteEvent:    {mediaTypeAtomJSON, mediaTypeEventJSON, mediaTypeJSON},
+	RouteMetadata: {mediaTypeAtomJSON, mediaTypeEventJSON, mediaTypeJSON},
+}
+
+// checkAccept writes a 406 response if the simulator is strict about the
+// Accept header and the request r for route d.Route does not accept any of
+// the media types of the route. It reports whether the request may proceed.
+func (h *AtomFeedSimulator) checkAccept(w http.ResponseWriter, r *http.Request, d RequestDetails) bool {
+	if !h.strictAccept || r.Method == http.MethodPost {
+		return true
+	}
+	types, ok := mediaTypes[d.Route]
+	if !ok || accepts(r.Header.Get("Accept"), types) {
+		if d.Route == RouteFeed {
+			w.Header().Set("Content-Type", mediaTypeAtomXML+"; charset=utf-8")
+		}
+		return true
+	}
+	h.writeError(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+	return false
+}
+
+// accepts reports whether the Accept header accept accepts any of types.
+func accepts(accept string, types []string) bool {
+	for _, v := range strings.Split(accept, ",") {
+		mt := strings.ToLower(strings.TrimSpace(strings.Split(v, ";")[0]))
+		if mt == "*/*" {
+			return true
+		}
+		for _, t := range types {
+			if mt == t || (strings.HasSuffix(mt, "/*") && strings.HasPrefix(t, strings.TrimSuffix(mt, "*"))) {
+				return true
+			}
+		}
+	}
+	return false
+}
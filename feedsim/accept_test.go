@@ -0,0 +1,48 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestStrictAccept(c *C) {
+	stream := "strict-accept-stream"
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")...),
+		WithStrictAccept())
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	tests := []struct {
+		url    string
+		accept string
+		want   int
+	}{
+		{streamURL, "", http.StatusNotAcceptable},
+		{streamURL, "application/json", http.StatusNotAcceptable},
+		{streamURL, "application/atom+xml", http.StatusOK},
+		{streamURL, "text/html, application/atom+xml;q=0.9", http.StatusOK},
+		{streamURL + "/0/forward/20", "*/*", http.StatusOK},
+		{streamURL + "/1", "", http.StatusNotAcceptable},
+		{streamURL + "/1", "application/atom+xml", http.StatusNotAcceptable},
+		{streamURL + "/1", "application/vnd.eventstore.atom+json", http.StatusOK},
+		{streamURL + "/1", "application/*", http.StatusOK},
+		{streamURL + "/metadata", "text/plain", http.StatusNotAcceptable},
+		{streamURL + "/metadata", "application/vnd.eventstore.atom+json", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		c.Assert(rec.Code, Equals, tt.want, Commentf("%s with Accept %q", tt.url, tt.accept))
+		if rec.Code == http.StatusOK && tt.url == streamURL {
+			c.Assert(rec.Header().Get("Content-Type"), Equals, "application/atom+xml; charset=utf-8")
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// SettingsStream is the name of the stream holding the default access control
+// lists of the server.
+const SettingsStream = "$settings"
+
+// SettingsEventType is the type of the event in which the simulator records
+// the settings it is created with.
+const SettingsEventType = "$settings"
+
+// Roles that can be granted access by an access control list.
+const (
+	// RoleAll grants access to every user, including anonymous users.
+	RoleAll = "$all"
+
+	// RoleAdmins grants access to administrators. The user admin is the only
+	// administrator, as on a newly installed server, and is granted access to
+	// every stream whatever its access control list.
+	RoleAdmins = "$admins"
+)
+
+// StreamACL is the access control list of a stream, naming the users and
+// roles allowed to read and write it. It is written in stream metadata as
+// $acl and in the settings of the server as $userStreamAcl and
+// $systemStreamAcl. A nil list falls back to the default for the stream.
+type StreamACL struct {
+	Read  []string
+	Write []string
+}
+
+type streamACLJSON struct {
+	Read  json.RawMessage `json:"$r,omitempty"`
+	Write json.RawMessage `json:"$w,omitempty"`
+}
+
+// MarshalJSON writes the access control list as the server does.
+func (a StreamACL) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Read  []string `json:"$r,omitempty"`
+		Write []string `json:"$w,omitempty"`
+	}{a.Read, a.Write}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON reads an access control list whose roles are either a single
+// string or an array of strings.
+func (a *StreamACL) UnmarshalJSON(b []byte) error {
+	var v streamACLJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var err error
+	if a.Read, err = unmarshalRoles(v.Read); err != nil {
+		return err
+	}
+	a.Write, err = unmarshalRoles(v.Write)
+	return err
+}
+
+func unmarshalRoles(b json.RawMessage) ([]string, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		return []string{s}, nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Settings are the default access control lists of the server, held in the
+// $settings stream. UserStreamACL applies to streams whose metadata has no
+// $acl and SystemStreamACL to such streams whose names begin with $.
+type Settings struct {
+	UserStreamACL   *StreamACL `json:"$userStreamAcl,omitempty"`
+	SystemStreamACL *StreamACL `json:"$systemStreamAcl,omitempty"`
+}
+
+// defaultACLs are the access control lists of streams on a newly installed
+// server.
+var defaultACLs = Settings{
+	UserStreamACL:   &StreamACL{Read: []string{RoleAll}, Write: []string{RoleAll}},
+	SystemStreamACL: &StreamACL{Read: []string{RoleAdmins}, Write: []string{RoleAdmins}},
+}
+
+// settingsStream serves the $settings stream of a simulator.
+type settingsStream struct {
+	initial Settings
+	sim     *AtomFeedSimulator
+}
+
+// WithSettings enforces access control lists as the server does, starting
+// with the default access control lists of s. The $settings stream can be
+// read and written by administrators, and the most recent event written to
+// it whose data are valid settings replaces the defaults, so admin tooling
+// that adjusts default ACLs can be tested.
+//
+// Requests are allowed or denied by the $acl in the metadata of the stream,
+// falling back to the defaults. Requests without credentials are made as an
+// anonymous user, and requests that are denied receive 401 Unauthorized.
+// Users are given with WithBasicAuth.
+func WithSettings(s Settings) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.settings = &settingsStream{initial: s}
+		return nil
+	}
+}
+
+// start creates the simulator serving the $settings stream of h.
+func (ss *settingsStream) start(h *AtomFeedSimulator) error {
+	b, err := json.Marshal(ss.initial)
+	if err != nil {
+		return err
+	}
+	data := json.RawMessage(b)
+	e := eventdata.CreateTestEvent(SettingsStream, h.server(), SettingsEventType, 0, &data, nil)
+
+	sim, err := h.newSystemStream(SettingsStream, e)
+	if err != nil {
+		return err
+	}
+	ss.sim = sim
+	return nil
+}
+
+// current returns the settings in the most recent event of the $settings
+// stream whose data are valid settings.
+func (ss *settingsStream) current() Settings {
+	ss.sim.RLock()
+	defer ss.sim.RUnlock()
+	for i := len(ss.sim.Events) - 1; i >= 0; i-- {
+		var s Settings
+		if unmarshalData(ss.sim.Events[i].Data, &s) == nil {
+			return s
+		}
+	}
+	return Settings{}
+}
+
+// unmarshalData unmarshals the data or metadata d of an event into v.
+func unmarshalData(d interface{}, v interface{}) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// acl returns the access control list that applies to stream.
+func (h *AtomFeedSimulator) acl(stream string) StreamACL {
+	if stream == SettingsStream {
+		return *defaultACLs.SystemStreamACL
+	}
+
+	h.RLock()
+	meta := h.MetaData
+	h.RUnlock()
+	var a StreamACL
+	if meta != nil {
+		var m struct {
+			ACL *StreamACL `json:"$acl"`
+		}
+		if unmarshalData(meta.Data, &m) == nil && m.ACL != nil {
+			a = *m.ACL
+		}
+	}
+
+	s := h.settings.current()
+	for _, d := range []*StreamACL{s.defaultACL(stream), defaultACLs.defaultACL(stream)} {
+		if d == nil {
+			continue
+		}
+		if a.Read == nil {
+			a.Read = d.Read
+		}
+		if a.Write == nil {
+			a.Write = d.Write
+		}
+	}
+	return a
+}
+
+// defaultACL returns the default access control list of stream given by s.
+func (s Settings) defaultACL(stream string) *StreamACL {
+	if strings.HasPrefix(stream, "$") {
+		return s.SystemStreamACL
+	}
+	return s.UserStreamACL
+}
+
+// authorize writes a 401 response if the access control lists enforced by
+// the simulator deny the request r for the url u. It reports whether the
+// request may proceed. Requests that are not for a stream are allowed.
+func (h *AtomFeedSimulator) authorize(w http.ResponseWriter, r *http.Request, u *url.URL) bool {
+	if h.settings == nil {
+		return true
+	}
+	stream := streamFromURL(u)
+	if stream == "" {
+		return true
+	}
+
+	a := h.acl(stream)
+	roles := a.Read
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		roles = a.Write
+	}
+	username, _, ok := r.BasicAuth()
+	if granted(roles, username, ok) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="ES"`)
+	h.writeError(w, "Access denied", http.StatusUnauthorized)
+	return false
+}
+
+// granted reports whether roles grant access to the user username, who is
+// anonymous unless authenticated is true. Administrators are granted access
+// to every stream.
+func granted(roles []string, username string, authenticated bool) bool {
+	if authenticated && username == "admin" {
+		return true
+	}
+	for _, v := range roles {
+		if v == RoleAll || (authenticated && v == username) {
+			return true
+		}
+	}
+	return false
+}
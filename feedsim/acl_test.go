@@ -0,0 +1,108 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func aclRequest(h http.Handler, method, u, username, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, u, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/vnd.eventstore.events+json")
+	if username != "" {
+		req.SetBasicAuth(username, username+"-password")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *MockSuite) newACLSimulator(c *C, stream string, opts ...Option) *AtomFeedSimulator {
+	u, _ := url.Parse(server.URL)
+	o := []Option{
+		WithEvents(eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")...),
+		WithBaseURL(u),
+		WithBasicAuth("admin", "admin-password"),
+		WithBasicAuth("ops", "ops-password"),
+		WithBasicAuth("bob", "bob-password"),
+	}
+	h, err := NewAtomFeedSimulator(append(o, opts...)...)
+	c.Assert(err, IsNil)
+	return h
+}
+
+func (s *MockSuite) TestSettingsDefaultACLs(c *C) {
+	stream := "acl-stream"
+	h := s.newACLSimulator(c, stream, WithSettings(Settings{
+		UserStreamACL: &StreamACL{Read: []string{"ops"}, Write: []string{RoleAdmins}},
+	}))
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	write := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+
+	rec := aclRequest(h, "GET", streamURL, "", "")
+	c.Assert(rec.Code, Equals, http.StatusUnauthorized)
+	c.Assert(rec.Header().Get("WWW-Authenticate"), Equals, `Basic realm="ES"`)
+	c.Assert(aclRequest(h, "GET", streamURL, "bob", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(aclRequest(h, "GET", streamURL, "ops", "").Code, Equals, http.StatusOK)
+	c.Assert(aclRequest(h, "POST", streamURL, "ops", write).Code, Equals, http.StatusUnauthorized)
+	c.Assert(aclRequest(h, "POST", streamURL, "admin", write).Code, Equals, http.StatusCreated)
+
+	settingsURL := fmt.Sprintf("%s/streams/%s", server.URL, url.PathEscape(SettingsStream))
+	c.Assert(aclRequest(h, "GET", settingsURL, "ops", "").Code, Equals, http.StatusUnauthorized)
+	rec = aclRequest(h, "GET", settingsURL+"/0", "admin", "")
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	c.Assert(rec.Body.String(), Matches, `(?s).*"\$userStreamAcl".*`)
+
+	rec = aclRequest(h, "POST", settingsURL, "admin",
+		`[{"eventId": "0f9fad5b-d9cb-469f-a165-70867728950e", "eventType": "settings", "data": {"$userStreamAcl": {"$r": "$all", "$w": ["ops"]}}}]`)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("Location"), Equals, settingsURL+"/1")
+
+	c.Assert(aclRequest(h, "GET", streamURL, "", "").Code, Equals, http.StatusOK)
+	c.Assert(aclRequest(h, "POST", streamURL, "ops", strings.Replace(write, "fbf4a1a1", "ebf4a1a1", 1)).Code, Equals, http.StatusCreated)
+
+	rec = aclRequest(h, "GET", settingsURL, "admin", "")
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	f, err := DecodeFeed(rec.Body)
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 2)
+}
+
+func (s *MockSuite) TestStreamMetadataACL(c *C) {
+	stream := "meta-acl-stream"
+	meta := json.RawMessage(`{"$acl": {"$r": "ops", "$w": ["ops", "bob"]}}`)
+	h := s.newACLSimulator(c, stream, WithSettings(Settings{}),
+		WithMetaData(eventdata.CreateTestEvent("$$"+stream, server.URL, "$metadata", 0, &meta, nil)))
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	c.Assert(aclRequest(h, "GET", streamURL, "", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(aclRequest(h, "GET", streamURL, "bob", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(aclRequest(h, "GET", streamURL, "ops", "").Code, Equals, http.StatusOK)
+	c.Assert(aclRequest(h, "GET", streamURL, "admin", "").Code, Equals, http.StatusOK)
+	c.Assert(aclRequest(h, "POST", streamURL, "bob",
+		`[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`).Code, Equals, http.StatusCreated)
+
+	req := httptest.NewRequest("GET", streamURL, nil)
+	req.SetBasicAuth("ops", "wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusUnauthorized)
+}
+
+func (s *MockSuite) TestSettingsDefaultsWithoutUsers(c *C) {
+	stream := "open-stream"
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")...),
+		WithBaseURL(u), WithSettings(Settings{}))
+	c.Assert(err, IsNil)
+
+	c.Assert(aclRequest(h, "GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), "", "").Code, Equals, http.StatusOK)
+	c.Assert(aclRequest(h, "GET", fmt.Sprintf("%s/streams/%s", server.URL, url.PathEscape(SettingsStream)), "", "").Code,
+		Equals, http.StatusUnauthorized)
+}
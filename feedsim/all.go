@@ -0,0 +1,223 @@
+package feedsim
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+)
+
+// defaultCheckpointInterval is the number of events a filtered subscription to
+// $all reads between checkpoints if its window is not given by a max.
+const defaultCheckpointInterval = 32
+
+// eventFilter is the server side filter of a read of $all, matching events by
+// the prefixes or a regular expression of their stream or event type.
+type eventFilter struct {
+	byStream bool
+	prefixes []string
+	regex    *regexp.Regexp
+
+	// checkpoint is the number of events read between the checkpoints of a
+	// subscription.
+	checkpoint int
+}
+
+// parseEventFilter returns the filter of the ReadReq options opts, or nil if
+// the read is not filtered.
+func parseEventFilter(opts protowire.Message) (*eventFilter, *grpcStatus) {
+	if !opts.Has(7) {
+		return nil, nil
+	}
+	fo, err := opts.Message(7)
+	if err != nil {
+		return nil, grpcMessageError(err)
+	}
+	f := &eventFilter{byStream: fo.Has(1)}
+	num := 2
+	if f.byStream {
+		num = 1
+	}
+	expr, err := fo.Message(num)
+	if err != nil {
+		return nil, grpcMessageError(err)
+	}
+	for _, p := range expr {
+		if p.Num == 2 {
+			f.prefixes = append(f.prefixes, string(p.Bytes))
+		}
+	}
+	if re := expr.String(1); re != "" {
+		if f.regex, err = regexp.Compile(re); err != nil {
+			return nil, grpcError(grpcInvalidArgument, "invalid filter regex %q: %v", re, err)
+		}
+	}
+
+	f.checkpoint = defaultCheckpointInterval
+	if fo.Has(3) && fo.Uint(3) > 0 {
+		f.checkpoint = int(fo.Uint(3))
+	}
+	if m := int(fo.Uint(5)); m > 0 {
+		f.checkpoint *= m
+	}
+	return f, nil
+}
+
+// matches reports whether the event e of stream passes the filter.
+func (f *eventFilter) matches(e *eventdata.Event, stream string) bool {
+	if f == nil {
+		return true
+	}
+	v := e.EventType
+	if f.byStream {
+		v = stream
+	}
+	for _, p := range f.prefixes {
+		if strings.HasPrefix(v, p) {
+			return true
+		}
+	}
+	return f.regex != nil && f.regex.MatchString(v)
+}
+
+// allStream returns the name of the stream of the event e of $all.
+func (h *AtomFeedSimulator) allStream(e *eventdata.Event) string {
+	if e.EventStreamID != "" {
+		return e.EventStreamID
+	}
+	return h.stream
+}
+
+// grpcReadAll serves a Streams.Read of $all, which holds the events of the
+// simulator. It reads count events from a position forwards or backwards, or
+// subscribes to $all, passing only the events that match the filter of the
+// read if it has one.
+func (h *AtomFeedSimulator) grpcReadAll(w http.ResponseWriter, r *http.Request, opts protowire.Message, structured bool) *grpcStatus {
+	ao, err := opts.Message(2)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	f, status := parseEventFilter(opts)
+	if status != nil {
+		return status
+	}
+	if opts.Has(6) {
+		return h.grpcSubscribeAll(w, r, ao, f, structured)
+	}
+
+	es := h.streamEvents(h.stream)
+	backwards := opts.Uint(3) == 1
+	from := 0
+	switch {
+	case ao.Has(1):
+		p, err := ao.Message(1)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		from = int(p.Uint(1))
+	case ao.Has(3):
+		from = int(^uint(0) >> 1)
+		if !backwards && len(es) > 0 {
+			from = es[len(es)-1].EventNumber + 1
+		}
+	}
+	count := opts.Uint(5)
+
+	var page []*eventdata.Event
+	if backwards {
+		for i := len(es) - 1; i >= 0 && uint64(len(page)) < count; i-- {
+			if es[i].EventNumber < from && f.matches(es[i], h.allStream(es[i])) {
+				page = append(page, es[i])
+			}
+		}
+	} else {
+		for i := 0; i < len(es) && uint64(len(page)) < count; i++ {
+			if es[i].EventNumber >= from && f.matches(es[i], h.allStream(es[i])) {
+				page = append(page, es[i])
+			}
+		}
+	}
+
+	for _, e := range page {
+		stream := h.allStream(e)
+		b, err := readResp(e, stream, structured)
+		if err != nil {
+			return grpcError(grpcInternal, "%v", err)
+		}
+		writeGRPCMessage(w, b)
+		h.served(stream, e.EventNumber)
+	}
+	return nil
+}
+
+// grpcSubscribeAll serves a Streams.Read subscribing to $all. It confirms the
+// subscription and then writes the events after the position of the
+// subscription that match its filter, followed by matching events as they are
+// appended, until the client cancels the call or the simulator is shut down.
+// Filtered subscriptions are sent a checkpoint holding the position of the
+// last event read each time the interval of the filter has been read, so
+// that clients can record their progress through events that do not match.
+func (h *AtomFeedSimulator) grpcSubscribeAll(w http.ResponseWriter, r *http.Request, ao protowire.Message, f *eventFilter, structured bool) *grpcStatus {
+	next := 0
+	switch {
+	case ao.Has(1):
+		p, err := ao.Message(1)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		next = int(p.Uint(1)) + 1
+	case ao.Has(3):
+		if es := h.streamEvents(h.stream); len(es) > 0 {
+			next = es[len(es)-1].EventNumber + 1
+		}
+	}
+
+	var confirmation []byte
+	confirmation = protowire.AppendString(confirmation, 1, uuid.NewUUID())
+	writeGRPCMessage(w, protowire.AppendBytes(nil, 2, confirmation))
+
+	read := 0
+	for {
+		appended := h.appendNotification()
+
+		for _, e := range h.streamEvents(h.stream) {
+			if e.EventNumber < next {
+				continue
+			}
+			next = e.EventNumber + 1
+			if stream := h.allStream(e); f.matches(e, stream) {
+				b, err := readResp(e, stream, structured)
+				if err != nil {
+					return grpcError(grpcInternal, "%v", err)
+				}
+				writeGRPCMessage(w, b)
+				h.served(stream, e.EventNumber)
+			}
+			if read++; f != nil && read%f.checkpoint == 0 {
+				writeGRPCMessage(w, appendPosition(nil, 3, e.EventNumber))
+			}
+		}
+
+		var wake <-chan time.Time
+		var t *time.Timer
+		if at, ok := h.nextScheduledAppend(); ok {
+			t = time.NewTimer(time.Until(at))
+			wake = t.C
+		}
+		select {
+		case <-appended:
+		case <-wake:
+		case <-r.Context().Done():
+			return nil
+		case <-h.done:
+			return grpcError(grpcUnavailable, "Server is shutting down.")
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
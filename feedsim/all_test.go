@@ -0,0 +1,132 @@
+package feedsim
+
+import (
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	. "gopkg.in/check.v1"
+)
+
+// readAllReq returns a ReadReq of count events of $all from the position pos,
+// or from the start if pos is negative, with the filter options filter.
+func readAllReq(pos int, backwards bool, count uint64, filter []byte) []byte {
+	var ao []byte
+	if pos < 0 {
+		ao = protowire.AppendBytes(ao, 2, empty)
+	} else {
+		ao = appendPosition(ao, 1, pos)
+	}
+	var o []byte
+	o = protowire.AppendBytes(o, 2, ao)
+	if backwards {
+		o = protowire.AppendUint(o, 3, 1)
+	}
+	o = protowire.AppendUint(o, 5, count)
+	if filter != nil {
+		o = protowire.AppendBytes(o, 7, filter)
+	} else {
+		o = protowire.AppendBytes(o, 8, empty)
+	}
+	return protowire.AppendBytes(nil, 1, o)
+}
+
+// eventTypeFilter returns the filter options matching event types by the
+// regular expression regex and the prefixes.
+func eventTypeFilter(regex string, prefixes ...string) []byte {
+	var expr []byte
+	if regex != "" {
+		expr = protowire.AppendString(expr, 1, regex)
+	}
+	for _, p := range prefixes {
+		expr = protowire.AppendString(expr, 2, p)
+	}
+	var f []byte
+	f = protowire.AppendBytes(f, 2, expr)
+	f = protowire.AppendUint(f, 3, 2)
+	return protowire.AppendUint(f, 5, 1)
+}
+
+func eventNumbersOf(c *C, resps []protowire.Message) []int {
+	var ns []int
+	for _, re := range readEventsOf(c, resps) {
+		ns = append(ns, int(re.Uint(3)))
+	}
+	return ns
+}
+
+func (s *MockSuite) TestGRPCReadAllFiltered(c *C) {
+	stream := "grpc-all"
+	es := eventdata.CreateTestEvents(6, stream, "https://localhost:2113", "EventTypeX")
+	for i, t := range []string{"OrderPlaced", "OrderShipped", "CustomerCreated", "OrderPlaced", "$metadata", "CustomerMoved"} {
+		es[i].EventType = t
+	}
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	resps, trailer := grpcCall(c, srv, "Read", readAllReq(-1, false, 10, nil))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	c.Assert(eventNumbersOf(c, resps), DeepEquals, []int{0, 1, 2, 3, 4, 5})
+	re := readEventsOf(c, resps)[0]
+	id, err := re.Message(2)
+	c.Assert(err, IsNil)
+	c.Assert(grpcStreamName(id), Equals, stream)
+
+	resps, _ = grpcCall(c, srv, "Read", readAllReq(-1, false, 10, eventTypeFilter("", "Order")))
+	c.Assert(eventNumbersOf(c, resps), DeepEquals, []int{0, 1, 3})
+
+	resps, _ = grpcCall(c, srv, "Read", readAllReq(2, false, 10, eventTypeFilter("^Customer")))
+	c.Assert(eventNumbersOf(c, resps), DeepEquals, []int{2, 5})
+
+	resps, _ = grpcCall(c, srv, "Read", readAllReq(4, true, 2, eventTypeFilter("", "Order", "Customer")))
+	c.Assert(eventNumbersOf(c, resps), DeepEquals, []int{3, 2})
+
+	var expr, f []byte
+	expr = protowire.AppendString(expr, 2, "other-")
+	f = protowire.AppendBytes(f, 1, expr)
+	f = protowire.AppendUint(f, 4, 1)
+	resps, trailer = grpcCall(c, srv, "Read", readAllReq(-1, false, 10, f))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	c.Assert(resps, HasLen, 0)
+
+	_, trailer = grpcCall(c, srv, "Read", readAllReq(-1, false, 10, eventTypeFilter("(")))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "3")
+}
+
+func (s *MockSuite) TestGRPCSubscribeToAllFiltered(c *C) {
+	stream := "grpc-all-subscription"
+	es := eventdata.CreateTestEvents(3, stream, "https://localhost:2113", "Ignored")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	var ao, o []byte
+	ao = protowire.AppendBytes(ao, 2, empty)
+	o = protowire.AppendBytes(o, 2, ao)
+	o = protowire.AppendBytes(o, 6, empty)
+	o = protowire.AppendBytes(o, 7, eventTypeFilter("", "Wanted"))
+	resp := startGRPCCall(c, srv, "Read", protowire.AppendBytes(nil, 1, o))
+	defer resp.Body.Close()
+
+	m, err := readGRPCMessage(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(m.Has(2), Equals, true)
+
+	// The filter checkpoints every second event read, whether it matches or
+	// not.
+	m, err = readGRPCMessage(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(m.Has(3), Equals, true)
+	cp, err := m.Message(3)
+	c.Assert(err, IsNil)
+	c.Assert(cp.Uint(1), Equals, uint64(1))
+
+	srv.Simulator.Append(eventdata.CreateTestEvent(stream, "https://localhost:2113", "WantedType", 3, nil, nil))
+	m, err = readGRPCMessage(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(eventNumbersOf(c, []protowire.Message{m}), DeepEquals, []int{3})
+	m, err = readGRPCMessage(resp.Body)
+	c.Assert(err, IsNil)
+	cp, err = m.Message(3)
+	c.Assert(err, IsNil)
+	c.Assert(cp.Uint(1), Equals, uint64(3))
+}
@@ -0,0 +1,62 @@
+package feedsim
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// WithBasicAuth requires requests to the simulator to carry the credentials of
+// username and password using HTTP basic authentication, as EventStore does
+// for streams restricted by their ACLs. Requests without valid credentials
+// receive 401 Unauthorized. The option can be given once for each user.
+func WithBasicAuth(username, password string) Option {
+	return func(h *AtomFeedSimulator) error {
+		if username == "" {
+			return errors.New("basic auth requires a username")
+		}
+		if h.users == nil {
+			h.users = map[string]string{}
+		}
+		h.users[username] = password
+		return nil
+	}
+}
+
+// authenticate writes a 401 response if the simulator requires
+// authentication and the request r does not carry valid credentials, a bearer
+// token when tokens are required and otherwise basic auth credentials. It
+// reports whether the request may proceed.
+func (h *AtomFeedSimulator) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if h.tokenTTL > 0 {
+		return h.authenticateToken(w, r)
+	}
+	return h.authenticateBasic(w, r)
+}
+
+// authenticateBasic writes a 401 response if the simulator has users and the
+// request r does not carry the basic auth credentials of one of them. It
+// reports whether the request may proceed. When access control lists are
+// enforced requests without credentials proceed as an anonymous user.
+func (h *AtomFeedSimulator) authenticateBasic(w http.ResponseWriter, r *http.Request) bool {
+	if h.users == nil && h.settings == nil {
+		return true
+	}
+	username, password, ok := r.BasicAuth()
+	if !ok && h.settings != nil {
+		return true
+	}
+	if ok && h.validCredentials(username, password) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="ES"`)
+	h.writeError(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return false
+}
+
+// validCredentials reports whether username and password are the credentials
+// of a user of the simulator.
+func (h *AtomFeedSimulator) validCredentials(username, password string) bool {
+	want, found := h.users[username]
+	return found && subtle.ConstantTimeCompare([]byte(password), []byte(want)) == 1
+}
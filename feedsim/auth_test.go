@@ -0,0 +1,45 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestBasicAuth(c *C) {
+	stream := "secured-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBasicAuth("admin", "changeit"), WithBasicAuth("ops", "secret"))
+	c.Assert(err, IsNil)
+
+	tests := []struct {
+		username string
+		password string
+		status   int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"admin", "wrong", http.StatusUnauthorized},
+		{"nobody", "changeit", http.StatusUnauthorized},
+		{"admin", "changeit", http.StatusOK},
+		{"ops", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+		if tt.username != "" {
+			req.SetBasicAuth(tt.username, tt.password)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		c.Assert(rec.Code, Equals, tt.status, Commentf("user %q", tt.username))
+		if tt.status == http.StatusUnauthorized {
+			c.Assert(rec.Header().Get("WWW-Authenticate"), Equals, `Basic realm="ES"`)
+		}
+	}
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithBasicAuth("", "changeit"))
+	c.Assert(err, ErrorMatches, "basic auth requires a username")
+}
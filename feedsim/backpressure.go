@@ -0,0 +1,67 @@
+package feedsim
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// backpressure defers the events appended to a stream while the simulator is
+// under load, serving them once the stream has been read a number of times.
+type backpressure struct {
+	load  int
+	polls int
+
+	mu sync.Mutex
+	// shown holds the number of events of each stream served so far.
+	shown map[string]int
+	// deferred holds the number of reads of each stream that have omitted
+	// events.
+	deferred map[string]int
+}
+
+// WithBackpressure simulates a server under load that is slow to serve new
+// events. While at least load requests are in flight, counting the read
+// itself, events appended after the stream was last read are omitted from
+// its pages until the stream has been read polls more times. The head page
+// and the page at the end of the stream keep their links until the events
+// appear, so consumers must keep polling for the previous page rather than
+// assume it is there as soon as the events are written. A load of 1 defers
+// events on every read.
+//
+// Backpressure does not apply to virtual streams.
+func WithBackpressure(load, polls int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if load < 1 || polls < 1 {
+			return errors.New("backpressure must have load and polls of at least 1")
+		}
+		h.backpressure = &backpressure{load: load, polls: polls, shown: map[string]int{}, deferred: map[string]int{}}
+		return nil
+	}
+}
+
+// apply returns the events es of the stream read by r as served while
+// inFlight requests are in flight, without the events that are deferred.
+func (b *backpressure) apply(r *StreamURL, es []*eventdata.Event, inFlight int) []*eventdata.Event {
+	if b == nil {
+		return es
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	shown, ok := b.shown[r.Stream]
+	if !ok || len(es) <= shown || inFlight < b.load || b.deferred[r.Stream] >= b.polls {
+		b.shown[r.Stream] = len(es)
+		b.deferred[r.Stream] = 0
+		return es
+	}
+	b.deferred[r.Stream]++
+	return es[:shown]
+}
+
+// requestsInFlight returns the number of requests being served.
+func (h *AtomFeedSimulator) requestsInFlight() int {
+	h.RLock()
+	defer h.RUnlock()
+	return h.active
+}
@@ -0,0 +1,45 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestBackpressureDefersAppendedEvents(c *C) {
+	stream := "backpressure-stream"
+	es := eventdata.CreateTestEvents(12, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es[:10]...), WithBaseURL(u), WithBackpressure(1, 2))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	head := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	end := fmt.Sprintf("%s/streams/%s/10/forward/20", server.URL, stream)
+	c.Assert(getFeed(c, head, nil).Entry[0].Title, Equals, "9@"+stream)
+
+	h.Append(es[10:]...)
+	c.Assert(getFeed(c, head, nil).Entry[0].Title, Equals, "9@"+stream)
+	c.Assert(getFeed(c, end, nil).Entry, HasLen, 0)
+	c.Assert(getFeed(c, head, nil).Entry[0].Title, Equals, "11@"+stream)
+	c.Assert(getFeed(c, end, nil).Entry, HasLen, 2)
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithBackpressure(1, 0))
+	c.Assert(err, ErrorMatches, "backpressure must have load and polls of at least 1")
+}
+
+func (s *MockSuite) TestBackpressureOnlyUnderLoad(c *C) {
+	stream := "backpressure-idle"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es[:2]...), WithBaseURL(u), WithBackpressure(2, 5))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	head := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	c.Assert(getFeed(c, head, nil).Entry, HasLen, 2)
+	h.Append(es[2])
+	c.Assert(getFeed(c, head, nil).Entry, HasLen, 3)
+}
@@ -0,0 +1,72 @@
+package feedsim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// WithBandwidth makes the simulator stream response bodies at roughly
+// bytesPerSecond, flushing the data written so far as it goes, so read
+// timeouts and context deadlines during slow transfers can be reproduced.
+func WithBandwidth(bytesPerSecond int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if bytesPerSecond < 1 {
+			return errors.New("bandwidth must be at least 1 byte per second")
+		}
+		h.bandwidth = bytesPerSecond
+		return nil
+	}
+}
+
+// throttledWriter is an http.ResponseWriter that writes the body in small
+// chunks at a limited rate.
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx            context.Context
+	done           <-chan struct{}
+	bytesPerSecond int
+}
+
+// Write writes b in chunks of around a tenth of the bandwidth, flushing each
+// chunk. Writing stops if the request is cancelled or the simulator is shut
+// down.
+func (t *throttledWriter) Write(b []byte) (int, error) {
+	chunk := t.bytesPerSecond / 10
+	if chunk < 1 {
+		chunk = 1
+	}
+	interval := time.Duration(chunk) * time.Second / time.Duration(t.bytesPerSecond)
+
+	written := 0
+	for written < len(b) {
+		end := written + chunk
+		if end > len(b) {
+			end = len(b)
+		}
+		n, err := t.ResponseWriter.Write(b[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if fl, ok := t.ResponseWriter.(http.Flusher); ok {
+			fl.Flush()
+		}
+		if written == len(b) {
+			break
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			timer.Stop()
+			return written, t.ctx.Err()
+		case <-t.done:
+			timer.Stop()
+			return written, ErrShutdown
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,48 @@
+package feedsim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestBandwidthLimitsResponseRate(c *C) {
+	stream := "slow-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithBandwidth(10000))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	started := time.Now()
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+
+	minimum := time.Duration(len(b)-1000) * time.Second / 10000
+	c.Assert(time.Since(started) >= minimum, Equals, true, Commentf("read %d bytes in %s", len(b), time.Since(started)))
+}
+
+func (s *MockSuite) TestBandwidthTriggersClientTimeout(c *C) {
+	stream := "slow-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithBandwidth(10))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+	resp, err := client.Get(fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	if err == nil {
+		_, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,55 @@
+package feedsim
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// requestURL returns the absolute url of the request r.
+//
+// The simulator can be mounted under a base path, e.g. to simulate a server
+// behind a reverse proxy at http://localhost:2113/eventstore. Requests that
+// include the base path are served as they are, and links are generated with
+// the base path of the request. If the base url of the simulator has a path
+// and a request does not include it, as when the simulator is mounted with
+// http.StripPrefix, the base path of the base url is added so that links
+// are generated as the client would see them.
+//
+// Without a base url the scheme and host are taken from the request.
+func (h *AtomFeedSimulator) requestURL(r *http.Request) *url.URL {
+	u := r.URL
+	if !u.IsAbs() && h.BaseURL != nil {
+		u = h.BaseURL.ResolveReference(u)
+	}
+	if h.requestHostLinks || !u.IsAbs() {
+		c := *u
+		c.Scheme, c.Host = requestHost(r)
+		u = &c
+	}
+
+	if h.BaseURL == nil {
+		return u
+	}
+	prefix := strings.TrimRight(h.BaseURL.EscapedPath(), "/")
+	if prefix == "" || strings.HasPrefix(u.EscapedPath(), prefix+"/") {
+		return u
+	}
+
+	p := *u
+	if err := setEscapedPath(&p, prefix+u.EscapedPath()); err != nil {
+		return u
+	}
+	return &p
+}
+
+// setEscapedPath sets the path of u from its escaped form.
+func setEscapedPath(u *url.URL, escaped string) error {
+	p, err := url.PathUnescape(escaped)
+	if err != nil {
+		return err
+	}
+	u.Path = p
+	u.RawPath = escaped
+	return nil
+}
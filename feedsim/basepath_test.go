@@ -0,0 +1,58 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestRequestsUnderBasePath(c *C) {
+	stream := "prefixed-stream"
+	base := server.URL + "/eventstore"
+	es := eventdata.CreateTestEvents(5, stream, base, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/2", base, stream), nil)
+	c.Assert(f.Entry, HasLen, 2)
+	for _, l := range f.Link {
+		c.Assert(strings.HasPrefix(l.Href, base+"/streams/"+stream), Equals, true, Commentf(l.Href))
+	}
+	c.Assert(h.ReadPosition(stream), Equals, 1)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/3", base, stream)), Equals, http.StatusOK)
+}
+
+func (s *MockSuite) TestMountedWithStripPrefix(c *C) {
+	stream := "prefixed-stream"
+	base := server.URL + "/eventstore"
+	es := eventdata.CreateTestEvents(5, stream, base, "EventTypeX")
+	u, _ := url.Parse(base)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithStream(stream))
+	c.Assert(err, IsNil)
+	mux.Handle("/eventstore/", http.StripPrefix("/eventstore", h))
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", base, stream), nil)
+	c.Assert(f.Entry, HasLen, 5)
+	c.Assert(f.Link[0].Href, Equals, base+"/streams/"+stream)
+	c.Assert(f.Link[1].Href, Equals, base+"/streams/"+stream+"/head/backward/20")
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/2", base, stream), nil)
+	c.Assert(f.Entry, HasLen, 2)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/other-stream", base)), Equals, http.StatusNotFound)
+}
+
+func (s *MockSuite) TestCreateTestFeedWithBasePath(c *C) {
+	stream := "streams"
+	es := eventdata.CreateTestEvents(3, stream, "http://localhost:2113/a/b", "EventTypeX")
+
+	f, err := CreateTestFeed(es, "http://localhost:2113/a/b/streams/streams/0/forward/20")
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.Link[0].Href, Equals, "http://localhost:2113/a/b/streams/streams")
+}
@@ -0,0 +1,68 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// The benchmarks below cover the path from a request to a rendered feed page.
+// Run them with
+//
+//	go test -run XXX -bench . -benchmem
+//
+// and compare allocations with the targets, measured for a page of 20 events
+// of a stream of 1000 events:
+//
+//	BenchmarkCreateTestFeed     under 250 allocs/op
+//	BenchmarkHandlerServeHTTP   under 500 allocs/op
+//	BenchmarkResolveEvent       0 allocs/op
+//
+// A change that exceeds a target should explain why in its description.
+
+const benchServer = "http://localhost:2113"
+
+func BenchmarkCreateTestFeed(b *testing.B) {
+	es := eventdata.CreateTestEvents(1000, "bench-stream", benchServer, "EventTypeX")
+	u := fmt.Sprintf("%s/streams/bench-stream/500/forward/20", benchServer)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CreateTestFeed(es, u); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHandlerServeHTTP(b *testing.B) {
+	es := eventdata.CreateTestEvents(1000, "bench-stream", benchServer, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...))
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/bench-stream/500/forward/20", benchServer), nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatal(rec.Code)
+		}
+	}
+}
+
+func BenchmarkResolveEvent(b *testing.B) {
+	es := eventdata.CreateTestEvents(1000, "bench-stream", benchServer, "EventTypeX")
+	u := fmt.Sprintf("%s/streams/bench-stream/999/", benchServer)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := resolveEvent(es, u); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
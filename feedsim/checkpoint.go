@@ -0,0 +1,90 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// newSystemStream creates a simulator serving the events es of a stream the
+// server writes itself, such as $settings, with the links, clock and error
+// format of h.
+func (h *AtomFeedSimulator) newSystemStream(stream string, es ...*eventdata.Event) (*AtomFeedSimulator, error) {
+	sim, err := newAtomFeedSimulator(WithEvents(es...), WithStream(stream))
+	if err != nil {
+		return nil, err
+	}
+	sim.BaseURL = h.BaseURL
+	sim.relativeLinks = h.relativeLinks
+	sim.requestHostLinks = h.requestHostLinks
+	sim.clock = h.clock
+	sim.errorFormat = h.errorFormat
+	return sim, nil
+}
+
+// server returns the server url of the links of events created by h.
+func (h *AtomFeedSimulator) server() string {
+	if h.BaseURL == nil {
+		return ""
+	}
+	return strings.TrimRight(h.BaseURL.String(), "/")
+}
+
+// checkpoint writes a $SubscriptionCheckpoint event to the checkpoint stream
+// of the group g if the events it has settled have moved its checkpoint on.
+// The checkpoint is the number of the last event before the first that is in
+// flight or waiting to be retried, so every event up to it has been acked,
+// skipped or parked.
+func (h *AtomFeedSimulator) checkpoint(g *persistentGroup) {
+	g.Lock()
+	defer g.Unlock()
+	pos := g.next - 1
+	for _, m := range g.inFlight {
+		if m.event.EventNumber-1 < pos {
+			pos = m.event.EventNumber - 1
+		}
+	}
+	for _, m := range g.retries {
+		if m.event.EventNumber-1 < pos {
+			pos = m.event.EventNumber - 1
+		}
+	}
+	if pos <= g.checkpoint {
+		return
+	}
+	g.checkpoint = pos
+
+	name := eventdata.CheckpointStreamName(g.stream, g.name)
+	data := json.RawMessage(strconv.Itoa(pos))
+	if g.checkpoints == nil {
+		e := eventdata.CreateTestEvent(name, h.server(), eventdata.CheckpointEventType, 0, &data, nil)
+		g.checkpoints, _ = h.newSystemStream(name, e)
+		return
+	}
+	g.checkpoints.Lock()
+	defer g.checkpoints.Unlock()
+	e := eventdata.CreateTestEvent(name, h.server(), eventdata.CheckpointEventType, len(g.checkpoints.Events), &data, nil)
+	g.checkpoints.appendEvents([]*eventdata.Event{e})
+}
+
+// checkpointStream reports whether stream is the checkpoint stream of one of
+// the persistent subscription groups of h and returns the simulator serving
+// it, which is nil until the group has checkpointed.
+func (h *AtomFeedSimulator) checkpointStream(stream string) (*AtomFeedSimulator, bool) {
+	if !strings.HasPrefix(stream, "$persistentsubscription-") {
+		return nil, false
+	}
+	h.RLock()
+	defer h.RUnlock()
+	for _, g := range h.persistent {
+		if eventdata.CheckpointStreamName(g.stream, g.name) != stream {
+			continue
+		}
+		g.Lock()
+		defer g.Unlock()
+		return g.checkpoints, true
+	}
+	return nil, false
+}
@@ -0,0 +1,34 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestCheckpointStream(c *C) {
+	name := eventdata.CheckpointStreamName("orders", "billing")
+	c.Assert(name, Equals, "$persistentsubscription-orders::billing-checkpoint")
+
+	es := eventdata.CreateCheckpointEvents("orders", "billing", server.URL, 10, 25, 40)
+	c.Assert(es, HasLen, 3)
+	for i, e := range es {
+		c.Assert(e.EventStreamID, Equals, name)
+		c.Assert(e.EventNumber, Equals, i)
+		c.Assert(e.EventType, Equals, eventdata.CheckpointEventType)
+	}
+	c.Assert(string(*es[2].Data.(*json.RawMessage)), Equals, "40")
+
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithStream(name), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, url.PathEscape(name)), nil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.Entry[0].Title, Equals, "2@"+name)
+	c.Assert(f.Entry[0].Summary.Body, Equals, eventdata.CheckpointEventType)
+}
@@ -0,0 +1,70 @@
+package feedsim
+
+import (
+	"errors"
+	"net/http"
+)
+
+// WithChunkedTransfer makes the simulator send response bodies with chunked
+// transfer encoding and no Content-Length, flushing after every chunkSize
+// bytes, as happens when the server is deployed behind many proxies. Clients
+// that assume a Content-Length is present can then be caught in tests.
+func WithChunkedTransfer(chunkSize int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if chunkSize < 1 {
+			return errors.New("chunk size must be at least 1 byte")
+		}
+		h.chunkSize = chunkSize
+		return nil
+	}
+}
+
+// chunkedWriter is an http.ResponseWriter that flushes the body every
+// chunkSize bytes, so that it is sent with chunked transfer encoding.
+type chunkedWriter struct {
+	http.ResponseWriter
+	chunkSize   int
+	total       int
+	wroteHeader bool
+}
+
+// WriteHeader removes any Content-Length and writes the header.
+func (c *chunkedWriter) WriteHeader(code int) {
+	c.wroteHeader = true
+	c.Header().Del("Content-Length")
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes b, flushing the body each time a chunk is complete and after
+// the first write so that no Content-Length can be computed.
+func (c *chunkedWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	first := c.total == 0
+	written := 0
+	for written < len(b) {
+		end := written + c.chunkSize - c.total%c.chunkSize
+		if end > len(b) {
+			end = len(b)
+		}
+		n, err := c.ResponseWriter.Write(b[written:end])
+		written += n
+		c.total += n
+		if err != nil {
+			return written, err
+		}
+		if first || c.total%c.chunkSize == 0 {
+			c.Flush()
+			first = false
+		}
+	}
+	return written, nil
+}
+
+// Flush flushes the data written so far to the client.
+func (c *chunkedWriter) Flush() {
+	if fl, ok := c.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
@@ -0,0 +1,59 @@
+package feedsim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// flushRecorder records the size of the body at each flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes []int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes = append(f.flushes, f.Body.Len())
+	f.ResponseRecorder.Flush()
+}
+
+func (s *MockSuite) TestChunkedTransfer(c *C) {
+	stream := "chunked-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithChunkedTransfer(512))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	for _, p := range []string{"", "/1", "/metadata"} {
+		resp, err := http.Get(fmt.Sprintf("%s/streams/%s%s", server.URL, stream, p))
+		c.Assert(err, IsNil)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		c.Assert(resp.ContentLength, Equals, int64(-1))
+		c.Assert(resp.TransferEncoding, DeepEquals, []string{"chunked"})
+		c.Assert(len(b) > 0, Equals, true)
+	}
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithChunkedTransfer(0))
+	c.Assert(err, NotNil)
+}
+
+func (s *MockSuite) TestChunkedWriterFlushesEveryChunk(c *C) {
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w := &chunkedWriter{ResponseWriter: rec, chunkSize: 10}
+
+	fmt.Fprint(w, "abc")
+	fmt.Fprint(w, strings.Repeat("x", 25))
+
+	c.Assert(rec.Body.Len(), Equals, 28)
+	c.Assert(rec.flushes, DeepEquals, []int{3, 10, 20})
+}
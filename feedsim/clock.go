@@ -0,0 +1,100 @@
+package feedsim
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// Clock provides the current time to the simulator.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts an ordinary function to a Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// realClock is the default Clock, which returns the current local time.
+var realClock = ClockFunc(time.Now)
+
+// SteppingClock returns a Clock that returns start the first time it is read
+// and moves on by step every time it is read after that. It gives events and
+// feeds deterministic, evenly spaced timestamps. A step of a day spreads the
+// events of a stream over days, for example to test $maxAge.
+//
+// The Clock is safe for concurrent use.
+func SteppingClock(start time.Time, step time.Duration) Clock {
+	var mu sync.Mutex
+	next := start
+	return ClockFunc(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		t := next
+		next = next.Add(step)
+		return t
+	})
+}
+
+// WithClock sets the clock used for the updated times of feeds and events.
+//
+// Events without a Created time are stamped with the time of the clock when
+// the simulator is constructed or when they are appended, in the order of the
+// events, and the updated time of an event in feeds and event responses is its
+// Created time. The updated time of a feed is read from the clock for every
+// request.
+//
+// By default the current time is used.
+func WithClock(c Clock) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.clock = c
+		return nil
+	}
+}
+
+// WithClockSkew offsets the times generated by the simulator by skew, as if
+// the clock of the server had drifted from that of the client. The updated
+// times of feeds and events, the Created times stamped on events and the Date
+// header of responses are all moved by skew, so staleness checks that compare
+// them with the local time can be tested. A negative skew puts the server
+// behind the client.
+//
+// The skew applies to the clock set with WithClock, whichever option is given
+// first. The Date header is always the current time moved by skew.
+func WithClockSkew(skew time.Duration) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.skew = skew
+		return nil
+	}
+}
+
+// skewedClock returns a Clock that reads c and adds skew.
+func skewedClock(c Clock, skew time.Duration) Clock {
+	return ClockFunc(func() time.Time {
+		return c.Now().Add(skew)
+	})
+}
+
+// writeDate sets the Date header of a simulator with a skewed clock. Without
+// skew the header is left to the server.
+func (h *AtomFeedSimulator) writeDate(w http.ResponseWriter) {
+	if h.skew == 0 {
+		return
+	}
+	w.Header().Set("Date", time.Now().Add(h.skew).UTC().Format(http.TimeFormat))
+}
+
+// stamp sets the Created time of the events that do not have one.
+func stamp(c Clock, es []*eventdata.Event) {
+	for _, e := range es {
+		if e.Created.IsZero() {
+			e.Created = c.Now()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestSteppingClock(c *C) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := SteppingClock(start, time.Hour)
+	c.Assert(clock.Now(), Equals, start)
+	c.Assert(clock.Now(), Equals, start.Add(time.Hour))
+	c.Assert(clock.Now(), Equals, start.Add(2*time.Hour))
+}
+
+func (s *MockSuite) TestWithClockStampsEvents(c *C) {
+	stream := "clock-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	more := eventdata.CreateTestEvents(4, stream, server.URL, "EventTypeX")[3:]
+	more[0].EventNumber = 3
+	u, _ := url.Parse(server.URL)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithClock(SteppingClock(start, 24*time.Hour)))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	h.Append(more...)
+
+	for i, e := range h.StreamEvents(stream) {
+		c.Assert(e.Created, Equals, start.Add(time.Duration(i)*24*time.Hour))
+	}
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream), nil)
+	c.Assert(f.Updated, Equals, atom.Time(start.Add(4*24*time.Hour)))
+	c.Assert(f.Entry, HasLen, 4)
+	for _, e := range f.Entry {
+		var n int
+		fmt.Sscanf(e.Title, "%d@", &n)
+		c.Assert(e.Updated, Equals, atom.Time(start.Add(time.Duration(n)*24*time.Hour)))
+	}
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s/2", server.URL, stream), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Body.String(), Matches, `(?s).*"updated": "2016-01-03T00:00:00\+00:00".*`)
+}
+
+func (s *MockSuite) TestWithClockSkew(c *C) {
+	stream := "skewed-stream"
+	es := eventdata.CreateTestEvents(2, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	skew := -90 * time.Minute
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithClockSkew(skew), WithClock(SteppingClock(start, time.Hour)))
+	c.Assert(err, IsNil)
+
+	c.Assert(h.StreamEvents(stream)[0].Created, Equals, start.Add(skew))
+	c.Assert(h.StreamEvents(stream)[1].Created, Equals, start.Add(time.Hour+skew))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil))
+	f, err := DecodeFeed(rec.Body)
+	c.Assert(err, IsNil)
+	c.Assert(f.Updated, Equals, atom.Time(start.Add(2*time.Hour+skew)))
+	c.Assert(f.Entry[0].Updated, Equals, atom.Time(start.Add(time.Hour+skew)))
+
+	date, err := http.ParseTime(rec.Header().Get("Date"))
+	c.Assert(err, IsNil)
+	c.Assert(time.Since(date) > -skew-time.Minute, Equals, true)
+	c.Assert(time.Since(date) < -skew+time.Minute, Equals, true)
+
+	h, err = NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil))
+	c.Assert(rec.Header().Get("Date"), Equals, "")
+}
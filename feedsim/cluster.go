@@ -0,0 +1,297 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// Cluster is a cluster of simulators, each served by its own test server, so
+// cluster aware clients can be tested for leader discovery, failover and
+// reads from followers that lag behind the leader.
+//
+// One node is the leader. Events appended to the leader, with Append or by
+// clients writing to it, are replicated to the followers after the
+// replication lag, so reads from a follower are stale until then. Writes to a
+// follower are redirected to the leader with 307 Temporary Redirect. Each
+// node describes the cluster at /gossip in the format of the gossip endpoint
+// of the server.
+type Cluster struct {
+	// Nodes are the servers of the nodes of the cluster.
+	Nodes []*SimulatorServer
+
+	lag time.Duration
+
+	mu        sync.Mutex
+	leader    int
+	term      int
+	alive     []bool
+	scheduled []int
+	timers    [][]*time.Timer
+}
+
+// NewCluster starts a cluster of nodes simulators, each configured by opts,
+// whose first node is the leader and whose followers receive the events
+// appended to the leader lag after they are appended.
+//
+// As with StartServer, the base url of each simulator is the url of its
+// server and links are derived from the request, so events can be created
+// with any server url.
+func NewCluster(nodes int, lag time.Duration, opts ...Option) (*Cluster, error) {
+	if nodes < 1 || lag < 0 {
+		return nil, errors.New("a cluster must have at least one node and a non negative lag")
+	}
+	c := &Cluster{
+		lag:       lag,
+		alive:     make([]bool, nodes),
+		scheduled: make([]int, nodes),
+		timers:    make([][]*time.Timer, nodes),
+	}
+	for i := 0; i < nodes; i++ {
+		srv := httptest.NewUnstartedServer(nil)
+		u := &url.URL{Scheme: "http", Host: srv.Listener.Addr().String()}
+		sim, err := NewAtomFeedSimulator(append([]Option{WithBaseURL(u), WithRequestHostLinks()}, opts...)...)
+		if err != nil {
+			srv.Listener.Close()
+			c.Close()
+			return nil, err
+		}
+		// The nodes are created with the same events, which each must hold a
+		// copy of to append to without affecting the others.
+		sim.Events = append([]*eventdata.Event(nil), sim.Events...)
+		srv.Config.Handler = &clusterNode{cluster: c, node: i, sim: sim}
+		srv.Start()
+		c.Nodes = append(c.Nodes, &SimulatorServer{Server: srv, Simulator: sim})
+		c.alive[i] = true
+		c.scheduled[i] = len(sim.Events)
+	}
+	return c, nil
+}
+
+// Leader returns the index of the leader in Nodes.
+func (c *Cluster) Leader() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.leader
+}
+
+// SetLeader makes the node at index i of Nodes the leader, as an election
+// following the failure of the leader would. The new leader holds only the
+// events replicated to it so far. Replication from the old leader that is
+// still pending is abandoned, events the other live nodes hold beyond the
+// head of the new leader are discarded, as a node rejoining a cluster
+// truncates its log, and the new leader replicates the events the followers
+// are missing.
+func (c *Cluster) SetLeader(i int) {
+	c.mu.Lock()
+	c.term++
+	for j := range c.Nodes {
+		c.stopReplication(j)
+	}
+	c.leader = i
+	leader := c.Nodes[i].Simulator
+	leader.RLock()
+	head := len(leader.Events)
+	leader.RUnlock()
+	for j, n := range c.Nodes {
+		if j != i && c.alive[j] {
+			truncateEvents(n.Simulator, head)
+		}
+		n.Simulator.RLock()
+		c.scheduled[j] = len(n.Simulator.Events)
+		n.Simulator.RUnlock()
+	}
+	c.mu.Unlock()
+	c.replicate()
+}
+
+// StopNode closes the server of the node at index i of Nodes, so requests to
+// it are refused and the gossip of the other nodes reports it as dead. The
+// state of its simulator is kept and no more events are replicated to it.
+func (c *Cluster) StopNode(i int) {
+	c.mu.Lock()
+	c.alive[i] = false
+	c.stopReplication(i)
+	c.mu.Unlock()
+	c.Nodes[i].Server.CloseClientConnections()
+	c.Nodes[i].Server.Close()
+}
+
+// Append appends events to the leader and replicates them to the followers.
+func (c *Cluster) Append(events ...*eventdata.Event) {
+	c.Nodes[c.Leader()].Simulator.Append(events...)
+	c.replicate()
+}
+
+// Close stops replication and closes every node.
+func (c *Cluster) Close() {
+	c.mu.Lock()
+	c.term++
+	for i := range c.Nodes {
+		c.stopReplication(i)
+	}
+	c.mu.Unlock()
+	for _, n := range c.Nodes {
+		n.Close()
+	}
+}
+
+// replicate schedules the events of the leader that have yet to be sent to
+// each live follower to be appended to the follower after the replication
+// lag.
+func (c *Cluster) replicate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	leader := c.Nodes[c.leader].Simulator
+	leader.RLock()
+	es := append([]*eventdata.Event{}, leader.Events...)
+	leader.RUnlock()
+	c.scheduled[c.leader] = len(es)
+
+	for i, n := range c.Nodes {
+		if i == c.leader || !c.alive[i] || c.scheduled[i] >= len(es) {
+			continue
+		}
+		batch := es[c.scheduled[i]:]
+		c.scheduled[i] = len(es)
+		sim := n.Simulator
+		if c.lag == 0 {
+			appendPastHead(sim, batch)
+			continue
+		}
+		i, term := i, c.term
+		c.timers[i] = append(c.timers[i], time.AfterFunc(c.lag, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.term == term && c.alive[i] {
+				appendPastHead(sim, batch)
+			}
+		}))
+	}
+}
+
+// stopReplication stops the replication to the node at index i that is still
+// pending. The caller must hold the lock.
+func (c *Cluster) stopReplication(i int) {
+	for _, t := range c.timers[i] {
+		t.Stop()
+	}
+	c.timers[i] = nil
+}
+
+// appendPastHead appends the events of es numbered past the head of the
+// stream of sim, so events the node already holds are not appended twice.
+func appendPastHead(sim *AtomFeedSimulator, es []*eventdata.Event) {
+	sim.Lock()
+	defer sim.Unlock()
+	next := 0
+	if n := len(sim.Events); n > 0 {
+		next = sim.Events[n-1].EventNumber + 1
+	}
+	var past []*eventdata.Event
+	for _, e := range es {
+		if e.EventNumber >= next {
+			past = append(past, e)
+		}
+	}
+	if len(past) > 0 {
+		sim.appendEvents(past)
+	}
+}
+
+// truncateEvents discards the events of sim beyond the first n.
+func truncateEvents(sim *AtomFeedSimulator, n int) {
+	sim.Lock()
+	defer sim.Unlock()
+	if len(sim.Events) <= n {
+		return
+	}
+	sim.Events = append([]*eventdata.Event(nil), sim.Events[:n]...)
+	if sim.TrickleAfter > n {
+		sim.TrickleAfter = n
+	}
+	sim.pages.clear()
+}
+
+// gossipMember describes a node in the gossip of the cluster.
+type gossipMember struct {
+	InstanceID       string `json:"instanceId"`
+	State            string `json:"state"`
+	IsAlive          bool   `json:"isAlive"`
+	HTTPEndPointIP   string `json:"httpEndPointIp"`
+	HTTPEndPointPort int    `json:"httpEndPointPort"`
+	LastCommitPos    int    `json:"lastCommitPosition"`
+}
+
+// gossip returns the gossip of the cluster.
+func (c *Cluster) gossip() []gossipMember {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	members := make([]gossipMember, len(c.Nodes))
+	for i, n := range c.Nodes {
+		host, port, _ := net.SplitHostPort(n.Listener.Addr().String())
+		p, _ := strconv.Atoi(port)
+		state := "Follower"
+		if i == c.leader {
+			state = "Leader"
+		}
+		if !c.alive[i] {
+			state = "Shutdown"
+		}
+		n.Simulator.RLock()
+		last := len(n.Simulator.Events) - 1
+		n.Simulator.RUnlock()
+		members[i] = gossipMember{
+			InstanceID:       fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+			State:            state,
+			IsAlive:          c.alive[i],
+			HTTPEndPointIP:   host,
+			HTTPEndPointPort: p,
+			LastCommitPos:    last,
+		}
+	}
+	return members
+}
+
+// clusterNode serves a simulator as a node of a cluster.
+type clusterNode struct {
+	cluster *Cluster
+	node    int
+	sim     *AtomFeedSimulator
+}
+
+func (n *clusterNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimRight(r.URL.Path, "/") == "/gossip" {
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		json.NewEncoder(w).Encode(struct {
+			Members []gossipMember `json:"members"`
+		}{n.cluster.gossip()})
+		return
+	}
+
+	leader := n.cluster.Leader()
+	if r.Method != http.MethodPost {
+		n.sim.ServeHTTP(w, r)
+		return
+	}
+	if leader != n.node {
+		u := *r.URL
+		u.Scheme = "http"
+		u.Host = n.cluster.Nodes[leader].Listener.Addr().String()
+		http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
+		return
+	}
+	n.sim.ServeHTTP(w, r)
+	n.cluster.replicate()
+}
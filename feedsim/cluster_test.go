@@ -0,0 +1,114 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func clusterGossip(c *C, n *SimulatorServer) []gossipMember {
+	resp, err := http.Get(n.URL + "/gossip")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	var g struct {
+		Members []gossipMember `json:"members"`
+	}
+	c.Assert(json.NewDecoder(resp.Body).Decode(&g), IsNil)
+	return g.Members
+}
+
+func (s *MockSuite) TestClusterReplicatesWithLag(c *C) {
+	stream := "cluster-stream"
+	cl, err := NewCluster(3, 200*time.Millisecond, WithEvents(eventdata.CreateTestEvents(3, stream, "http://localhost:2113", "EventTypeX")...))
+	c.Assert(err, IsNil)
+	defer cl.Close()
+	c.Assert(cl.Nodes, HasLen, 3)
+
+	members := clusterGossip(c, cl.Nodes[2])
+	c.Assert(members, HasLen, 3)
+	c.Assert(members[0].State, Equals, "Leader")
+	c.Assert(members[1].State, Equals, "Follower")
+	c.Assert(members[0].HTTPEndPointPort, Not(Equals), 0)
+
+	body := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Post(fmt.Sprintf("%s/streams/%s", cl.Nodes[1].URL, stream), "application/vnd.eventstore.events+json", strings.NewReader(body))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusTemporaryRedirect)
+	c.Assert(resp.Header.Get("Location"), Equals, fmt.Sprintf("%s/streams/%s", cl.Nodes[0].URL, stream))
+
+	resp, err = http.Post(resp.Header.Get("Location"), "application/vnd.eventstore.events+json", strings.NewReader(body))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusCreated)
+
+	c.Assert(getFeed(c, fmt.Sprintf("%s/streams/%s", cl.Nodes[0].URL, stream), nil).Entry, HasLen, 4)
+	c.Assert(getFeed(c, fmt.Sprintf("%s/streams/%s", cl.Nodes[1].URL, stream), nil).Entry, HasLen, 3)
+	time.Sleep(300 * time.Millisecond)
+	c.Assert(getFeed(c, fmt.Sprintf("%s/streams/%s", cl.Nodes[1].URL, stream), nil).Entry, HasLen, 4)
+	c.Assert(getFeed(c, fmt.Sprintf("%s/streams/%s", cl.Nodes[2].URL, stream), nil).Entry, HasLen, 4)
+}
+
+func (s *MockSuite) TestClusterFailover(c *C) {
+	stream := "failover-stream"
+	cl, err := NewCluster(3, 0, WithEvents(eventdata.CreateTestEvents(3, stream, "http://localhost:2113", "EventTypeX")...))
+	c.Assert(err, IsNil)
+	defer cl.Close()
+
+	cl.StopNode(0)
+	cl.SetLeader(1)
+	c.Assert(cl.Leader(), Equals, 1)
+	_, err = http.Get(cl.Nodes[0].URL + "/gossip")
+	c.Assert(err, NotNil)
+
+	members := clusterGossip(c, cl.Nodes[2])
+	c.Assert(members[0].IsAlive, Equals, false)
+	c.Assert(members[0].State, Equals, "Shutdown")
+	c.Assert(members[1].State, Equals, "Leader")
+
+	cl.Append(offsetEvents(stream, 3, 2)...)
+	c.Assert(cl.Nodes[1].Simulator.StreamEvents(stream), HasLen, 5)
+	c.Assert(cl.Nodes[2].Simulator.StreamEvents(stream), HasLen, 5)
+
+	_, err = NewCluster(0, 0)
+	c.Assert(err, ErrorMatches, "a cluster must have at least one node and a non negative lag")
+}
+
+// eventNumbers returns the event numbers of stream held by sim.
+func eventNumbers(sim *AtomFeedSimulator, stream string) []int {
+	ns := []int{}
+	for _, e := range sim.StreamEvents(stream) {
+		ns = append(ns, e.EventNumber)
+	}
+	return ns
+}
+
+func (s *MockSuite) TestClusterFailoverAbandonsPendingReplication(c *C) {
+	stream := "failover-stream"
+	cl, err := NewCluster(3, 100*time.Millisecond, WithEvents(eventdata.CreateTestEvents(3, stream, "http://localhost:2113", "EventTypeX")...))
+	c.Assert(err, IsNil)
+	defer cl.Close()
+
+	cl.Append(offsetEvents(stream, 3, 3)...)
+	cl.SetLeader(1)
+	c.Assert(eventNumbers(cl.Nodes[0].Simulator, stream), DeepEquals, []int{0, 1, 2})
+
+	cl.Append(offsetEvents(stream, 3, 1)...)
+	time.Sleep(200 * time.Millisecond)
+	for i, n := range cl.Nodes {
+		c.Assert(eventNumbers(n.Simulator, stream), DeepEquals, []int{0, 1, 2, 3}, Commentf("node %d", i))
+	}
+
+	cl.StopNode(2)
+	cl.Append(offsetEvents(stream, 4, 1)...)
+	time.Sleep(200 * time.Millisecond)
+	c.Assert(eventNumbers(cl.Nodes[0].Simulator, stream), DeepEquals, []int{0, 1, 2, 3, 4})
+	c.Assert(eventNumbers(cl.Nodes[1].Simulator, stream), DeepEquals, []int{0, 1, 2, 3, 4})
+	c.Assert(eventNumbers(cl.Nodes[2].Simulator, stream), DeepEquals, []int{0, 1, 2, 3})
+}
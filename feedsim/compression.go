@@ -0,0 +1,153 @@
+package feedsim
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content codings supported by WithCompression and WithForcedCompression.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// WithCompression makes the simulator compress the bodies of feeds, events and
+// metadata when the request's Accept-Encoding header accepts gzip or deflate,
+// preferring gzip, so that clients' transparent decompression is exercised.
+func WithCompression() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.compression = true
+		return nil
+	}
+}
+
+// WithForcedCompression makes the simulator compress the bodies of feeds,
+// events and metadata with encoding, which is EncodingGzip or EncodingDeflate,
+// whatever the request's Accept-Encoding header. It can be used to test
+// clients that do not request compression but must cope with a proxy that
+// compresses anyway.
+func WithForcedCompression(encoding string) Option {
+	return func(h *AtomFeedSimulator) error {
+		switch encoding {
+		case EncodingGzip, EncodingDeflate:
+		default:
+			return fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+		h.compression = true
+		h.forcedEncoding = encoding
+		return nil
+	}
+}
+
+// contentEncoding returns the encoding with which the response to r is
+// compressed, or "" if the response is not compressed.
+func (h *AtomFeedSimulator) contentEncoding(r *http.Request) string {
+	if !h.compression {
+		return ""
+	}
+	if h.forcedEncoding != "" {
+		return h.forcedEncoding
+	}
+	return negotiateEncoding(r.Header.Get("Accept-Encoding"))
+}
+
+// negotiateEncoding returns the supported encoding accepted by the value of an
+// Accept-Encoding header, preferring gzip, or "" if neither is accepted.
+func negotiateEncoding(accept string) string {
+	accepted := map[string]bool{}
+	for _, v := range strings.Split(accept, ",") {
+		parts := strings.Split(v, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		accepted[coding] = q > 0
+	}
+	for _, enc := range []string{EncodingGzip, EncodingDeflate} {
+		if ok, found := accepted[enc]; found {
+			if ok {
+				return enc
+			}
+			continue
+		}
+		if accepted["*"] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressWriter is an http.ResponseWriter that compresses the body of the
+// response. The Content-Encoding header is only added to responses that may
+// have a body, so 304 Not Modified responses are left untouched.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	wroteHeader bool
+	compress    bool
+	w           io.WriteCloser
+}
+
+// WriteHeader sets the Content-Encoding header of responses with a body and
+// writes the header.
+func (c *compressWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.compress = code != http.StatusNoContent && code != http.StatusNotModified && code >= 200
+	if c.compress {
+		hdr := c.Header()
+		hdr.Del("Content-Length")
+		hdr.Set("Content-Encoding", c.encoding)
+		hdr.Add("Vary", "Accept-Encoding")
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write compresses b into the body of the response.
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.compress {
+		return c.ResponseWriter.Write(b)
+	}
+	if c.w == nil {
+		if c.encoding == EncodingDeflate {
+			c.w = zlib.NewWriter(c.ResponseWriter)
+		} else {
+			c.w = gzip.NewWriter(c.ResponseWriter)
+		}
+	}
+	return c.w.Write(b)
+}
+
+// Flush flushes the data compressed so far to the client.
+func (c *compressWriter) Flush() {
+	if fl, ok := c.w.(interface{ Flush() error }); ok {
+		fl.Flush()
+	}
+	if fl, ok := c.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+// Close completes the compressed body.
+func (c *compressWriter) Close() error {
+	if c.w == nil {
+		return nil
+	}
+	return c.w.Close()
+}
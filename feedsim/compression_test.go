@@ -0,0 +1,122 @@
+package feedsim
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestCompressionNegotiatesEncoding(c *C) {
+	stream := "compressed-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithCompression())
+	c.Assert(err, IsNil)
+
+	tests := []struct {
+		accept   string
+		encoding string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"*", "gzip"},
+		{"br", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Encoding", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		c.Assert(rec.Code, Equals, http.StatusOK)
+		c.Assert(rec.Header().Get("Content-Encoding"), Equals, tt.encoding, Commentf("accept %q", tt.accept))
+
+		var body io.Reader = rec.Body
+		switch tt.encoding {
+		case "gzip":
+			body, err = gzip.NewReader(rec.Body)
+			c.Assert(err, IsNil)
+		case "deflate":
+			body, err = zlib.NewReader(rec.Body)
+			c.Assert(err, IsNil)
+		}
+		b, err := ioutil.ReadAll(body)
+		c.Assert(err, IsNil)
+		f := &atom.Feed{}
+		c.Assert(xml.Unmarshal(b, f), IsNil)
+		c.Assert(f.Entry, HasLen, 5)
+	}
+}
+
+func (s *MockSuite) TestForcedCompression(c *C) {
+	stream := "compressed-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithForcedCompression(EncodingDeflate))
+	c.Assert(err, IsNil)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s/1", server.URL, stream), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Header().Get("Content-Encoding"), Equals, "deflate")
+	zr, err := zlib.NewReader(rec.Body)
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(zr)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Matches, "(?s).*"+es[1].EventID+".*")
+
+	head := httptest.NewRequest("HEAD", fmt.Sprintf("%s/streams/%s/1", server.URL, stream), nil)
+	hrec := httptest.NewRecorder()
+	h.ServeHTTP(hrec, head)
+	c.Assert(hrec.Code, Equals, http.StatusOK)
+	c.Assert(hrec.Body.Len(), Equals, 0)
+	for _, k := range []string{"Content-Type", "Content-Encoding", "Content-Length", "Vary"} {
+		c.Assert(hrec.Header()[k], DeepEquals, rec.Header()[k], Commentf(k))
+	}
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithForcedCompression("br"))
+	c.Assert(err, ErrorMatches, "unsupported content encoding \"br\"")
+}
+
+func (s *MockSuite) TestCompressionTransparentToClient(c *C) {
+	stream := "compressed-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithCompression(), WithServerVersion("5.x"))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(resp.Uncompressed, Equals, true)
+	f := &atom.Feed{}
+	c.Assert(xml.Unmarshal(b, f), IsNil)
+	c.Assert(f.Entry, HasLen, 5)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotModified)
+	c.Assert(resp.Header.Get("Content-Encoding"), Equals, "")
+}
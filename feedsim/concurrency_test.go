@@ -0,0 +1,97 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// These tests are intended to be run with the race detector enabled.
+
+func (s *MockSuite) TestConcurrentReadersAndWriters(c *C) {
+	stream := "concurrent-stream"
+	es := eventdata.CreateTestEvents(200, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es[:10]...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, e := range es[10:] {
+			h.Append(e)
+		}
+		h.DeleteStream("other-stream", true)
+	}()
+
+	urls := []string{
+		fmt.Sprintf("%s/streams/%s", server.URL, stream),
+		fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream),
+		fmt.Sprintf("%s/streams/%s/5", server.URL, stream),
+		fmt.Sprintf("%s/streams/%s/head", server.URL, stream),
+		fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream),
+		fmt.Sprintf("%s/streams/%s", server.URL, "other-stream"),
+	}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				resp, err := http.Get(urls[(i+j)%len(urls)])
+				if err == nil {
+					resp.Body.Close()
+				}
+				h.Requests()
+				h.ReadPosition(stream)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/head/backward/1", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 1)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("199@%s", stream))
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s", server.URL, "other-stream")), Equals, http.StatusGone)
+	c.Assert(h.Requests(), HasLen, 8*20+2)
+}
+
+func (s *MockSuite) TestAppendQueuesBehindTrickledEvents(c *C) {
+	stream := "appended-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es[:5]...), WithTrickle(3))
+	c.Assert(err, IsNil)
+
+	h.Append(es[5:]...)
+
+	c.Assert(h.visibleEvents(), HasLen, 3)
+	c.Assert(h.Events, HasLen, 10)
+
+	h, err = NewAtomFeedSimulator(WithEvents(es[:5]...))
+	c.Assert(err, IsNil)
+
+	h.Append(es[5:]...)
+
+	c.Assert(h.visibleEvents(), HasLen, 10)
+}
+
+func (s *MockSuite) TestDeleteStream(c *C) {
+	stream := "deleted-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	h.DeleteStream("soft-deleted", false)
+	h.DeleteStream("hard-deleted", true)
+
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s", server.URL, "soft-deleted")), Equals, http.StatusNotFound)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s", server.URL, "hard-deleted")), Equals, http.StatusGone)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s", server.URL, stream)), Equals, http.StatusOK)
+}
@@ -0,0 +1,65 @@
+package feedsim
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// connectionLimit closes connections once they have carried a number of
+// requests.
+type connectionLimit struct {
+	sync.Mutex
+	max      int
+	requests map[string]int
+}
+
+// WithCloseAfter makes the simulator close each connection after it has
+// carried n requests, by responding to the nth request with a
+// Connection: close header, so that clients' handling of connections closed
+// by the server can be tested.
+func WithCloseAfter(n int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if n < 1 {
+			return errors.New("connections must carry at least 1 request")
+		}
+		h.connLimit = &connectionLimit{max: n, requests: map[string]int{}}
+		return nil
+	}
+}
+
+// WithoutKeepAlives makes the simulator close the connection after every
+// response, as a server with keep-alives disabled does.
+func WithoutKeepAlives() Option {
+	return WithCloseAfter(1)
+}
+
+// WithHTTP2 makes servers started by NewTLSSimulatorServer negotiate HTTP/2
+// with clients that support it.
+func WithHTTP2() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.http2 = true
+		return nil
+	}
+}
+
+// limitConnection adds a Connection: close header to the response to r if the
+// connection carrying r has reached its limit. Connections are identified by
+// the remote address of the request.
+func (h *AtomFeedSimulator) limitConnection(w http.ResponseWriter, r *http.Request) {
+	l := h.connLimit
+	if l == nil {
+		return
+	}
+	l.Lock()
+	l.requests[r.RemoteAddr]++
+	n := l.requests[r.RemoteAddr]
+	if n >= l.max {
+		delete(l.requests, r.RemoteAddr)
+	}
+	l.Unlock()
+
+	if n >= l.max {
+		w.Header().Set("Connection", "close")
+	}
+}
@@ -0,0 +1,83 @@
+package feedsim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// getReused makes a GET request to u with client and reports whether the
+// request reused a connection.
+func getReused(c *C, client *http.Client, u string) bool {
+	reused := false
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused },
+	}
+	req, _ := http.NewRequest("GET", u, nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := client.Do(req)
+	c.Assert(err, IsNil)
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	return reused
+}
+
+func (s *MockSuite) TestCloseAfter(c *C) {
+	stream := "closing-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithCloseAfter(2))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	client := &http.Client{Transport: &http.Transport{}}
+	feedURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	c.Assert(getReused(c, client, feedURL), Equals, false)
+	c.Assert(getReused(c, client, feedURL), Equals, true)
+	c.Assert(getReused(c, client, feedURL), Equals, false)
+	c.Assert(getReused(c, client, feedURL), Equals, true)
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithCloseAfter(0))
+	c.Assert(err, NotNil)
+}
+
+func (s *MockSuite) TestWithoutKeepAlives(c *C) {
+	stream := "closing-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithoutKeepAlives())
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	client := &http.Client{Transport: &http.Transport{}}
+	for i := 0; i < 3; i++ {
+		c.Assert(getReused(c, client, fmt.Sprintf("%s/streams/%s/%d", server.URL, stream, i)), Equals, false)
+	}
+}
+
+func (s *MockSuite) TestHTTP2(c *C) {
+	stream := "http2-stream"
+	es := eventdata.CreateTestEvents(5, stream, "http://localhost:2113", "EventTypeX")
+
+	for _, enabled := range []bool{false, true} {
+		opts := []Option{WithEvents(es...)}
+		if enabled {
+			opts = append(opts, WithHTTP2())
+		}
+		srv, err := NewTLSSimulatorServer(opts...)
+		c.Assert(err, IsNil)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: srv.ClientTLSConfig, ForceAttemptHTTP2: true}}
+		resp, err := client.Get(fmt.Sprintf("%s/streams/%s", srv.URL, stream))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.ProtoMajor == 2, Equals, enabled)
+		srv.Close()
+	}
+}
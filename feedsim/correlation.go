@@ -0,0 +1,37 @@
+package feedsim
+
+import (
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// ByCorrelationID returns a Projection that mirrors the $by_correlation_id
+// projection of the server. Each event whose metadata holds a string
+// correlation id under key is emitted to the stream $bc-{correlationId}, so
+// that sagas and process managers reading the events of a correlation can be
+// tested. key defaults to CorrelationIDKey if it is empty, as the
+// correlationIdProperty of the projection does.
+//
+//	router.Project(feedsim.ByCorrelationID(""))
+//
+// The events of system streams, whose names begin with $, are not projected,
+// and the events of the $bc streams appear as they do when their links are
+// resolved: with the id, type, data and metadata of the event emitted.
+func ByCorrelationID(key string) Projection {
+	if key == "" {
+		key = eventdata.CorrelationIDKey
+	}
+	return func(e *eventdata.Event, emit func(string, *eventdata.Event)) {
+		if strings.HasPrefix(e.EventStreamID, "$") || e.MetaData == nil {
+			return
+		}
+		var meta map[string]interface{}
+		if unmarshalData(e.MetaData, &meta) != nil {
+			return
+		}
+		if id, ok := meta[key].(string); ok && id != "" {
+			emit("$bc-"+id, e)
+		}
+	}
+}
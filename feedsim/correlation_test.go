@@ -0,0 +1,48 @@
+package feedsim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// withMetaData sets the metadata of e to the json encoding of m.
+func withMetaData(e *eventdata.Event, m map[string]string) *eventdata.Event {
+	b, _ := json.Marshal(m)
+	raw := json.RawMessage(b)
+	e.MetaData = &raw
+	return e
+}
+
+func (s *MockSuite) TestByCorrelationIDEmitsToCorrelationStreams(c *C) {
+	u, _ := url.Parse(server.URL)
+	es := eventdata.CreateTestEvents(4, "payments", server.URL, "EventTypeX")
+	withMetaData(es[0], map[string]string{"$correlationId": "saga-1"})
+	withMetaData(es[1], map[string]string{"$correlationId": "saga-2"})
+	withMetaData(es[3], map[string]string{"$correlationId": "saga-1", "tenant": "t-1"})
+	payments, err := NewAtomFeedSimulator(WithEvents(es...), WithStream("payments"), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	router, err := NewStreamRouter(payments)
+	c.Assert(err, IsNil)
+	mux.Handle("/", router)
+
+	router.Project(ByCorrelationID(""))
+	router.Project(ByCorrelationID("tenant"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.Assert(router.WaitForProjections(ctx), IsNil)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/$bc-saga-1/0/forward/20", server.URL), nil)
+	c.Assert(f.Entry, HasLen, 2)
+	saga := router.Simulator("$bc-saga-1").StreamEvents("$bc-saga-1")
+	c.Assert(saga[0].EventID, Equals, es[0].EventID)
+	c.Assert(saga[1].EventID, Equals, es[3].EventID)
+	c.Assert(router.Simulator("$bc-saga-2").StreamEvents("$bc-saga-2"), HasLen, 1)
+	c.Assert(router.Simulator("$bc-t-1").StreamEvents("$bc-t-1"), HasLen, 1)
+	c.Assert(router.Simulator("$bc-$bc-saga-1"), IsNil)
+}
@@ -0,0 +1,43 @@
+package feedsim
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// The CORS headers sent by EventStore with every response.
+const (
+	corsAllowHeaders  = "Content-Type, X-Requested-With, X-Forwarded-Host, X-Forwarded-Prefix, X-PINGOTHER, Authorization, ES-LongPoll, ES-ExpectedVersion, ES-EventId, ES-EventType, ES-RequiresMaster, ES-HardDelete, ES-ResolveLinkTo"
+	corsExposeHeaders = "Location, ES-Position, ES-CurrentVersion"
+)
+
+// allowedMethods returns the methods EventStore allows on the resource
+// addressed by u: streams can be written to and deleted, metadata can be
+// written and feed pages and events can only be read.
+func (h *AtomFeedSimulator) allowedMethods(u *url.URL) string {
+	split, err := pathSegments(u)
+	if err == nil && streamsSegment(split, 2) >= 0 {
+		return "POST, DELETE, GET, OPTIONS"
+	}
+	if h.route(u.String()) == RouteMetadata {
+		return "GET, POST, OPTIONS"
+	}
+	return "GET, OPTIONS"
+}
+
+// writeCORS adds the CORS headers EventStore sends to the response for the
+// resource addressed by u, so that browser based clients can be tested.
+func (h *AtomFeedSimulator) writeCORS(w http.ResponseWriter, u *url.URL) {
+	hdr := w.Header()
+	hdr.Set("Access-Control-Allow-Methods", h.allowedMethods(u))
+	hdr.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+	hdr.Set("Access-Control-Allow-Origin", "*")
+	hdr.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+}
+
+// serveOptions answers an OPTIONS request, such as a CORS preflight, with the
+// methods allowed on the resource addressed by u.
+func (h *AtomFeedSimulator) serveOptions(w http.ResponseWriter, u *url.URL) {
+	w.Header().Set("Allow", h.allowedMethods(u))
+	w.WriteHeader(http.StatusOK)
+}
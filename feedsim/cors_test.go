@@ -0,0 +1,62 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestCORSPreflight(c *C) {
+	stream := "cors-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	tests := []struct {
+		path    string
+		methods string
+	}{
+		{"", "POST, DELETE, GET, OPTIONS"},
+		{"/head/backward/20", "GET, OPTIONS"},
+		{"/1", "GET, OPTIONS"},
+		{"/metadata", "GET, POST, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("OPTIONS", fmt.Sprintf("%s/streams/%s%s", server.URL, stream, tt.path), nil)
+		req.Header.Set("Origin", "http://browser.test")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		c.Assert(resp.Header.Get("Allow"), Equals, tt.methods, Commentf(tt.path))
+		c.Assert(resp.Header.Get("Access-Control-Allow-Methods"), Equals, tt.methods, Commentf(tt.path))
+		c.Assert(resp.Header.Get("Access-Control-Allow-Origin"), Equals, "*")
+		c.Assert(resp.Header.Get("Access-Control-Allow-Headers"), Matches, ".*ES-LongPoll.*")
+	}
+	c.Assert(h.Requests(), HasLen, 4)
+}
+
+func (s *MockSuite) TestCORSHeadersOnResponses(c *C) {
+	stream := "cors-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	for _, p := range []string{"", "/1", "/100"} {
+		resp, err := http.Get(fmt.Sprintf("%s/streams/%s%s", server.URL, stream, p))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.Header.Get("Access-Control-Allow-Origin"), Equals, "*")
+		c.Assert(resp.Header.Get("Access-Control-Expose-Headers"), Equals, "Location, ES-Position, ES-CurrentVersion")
+	}
+}
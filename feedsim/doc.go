@@ -0,0 +1,35 @@
+// Package feedsim simulates the Atom feeds of a GetEventStore server.
+//
+// AtomFeedSimulator is the http.Handler serving a simulated stream. It is
+// created with NewAtomFeedSimulator and configured with options such as
+// WithEvents, WithBaseURL, WithTrickle, WithAppendSchedule, WithServerVersion,
+// WithPageSizeLimits and WithPagingMode. Its state can be inspected with
+// StreamEvents, ReadPositions and Requests, and changed at runtime with
+// Append, Override, DeleteStream and Restore or by clients POSTing events to
+// the stream. Hooks such as OnRequest and OnError observe the requests it
+// serves, and AssertLongPollUsed, AssertPollIntervalAtLeast and
+// AssertNoBusyLoop check that clients poll politely. A simulator serves a
+// single stream; StreamRouter serves several streams, each by its own
+// simulator, and runs projections emitting events from one stream to others.
+//
+// Streams are described by the events of package eventdata, by
+// GenerateStreamSpec for property based tests and by LoadStreamFixture and
+// LoadRouterFixture from JSON files. WithSchema checks the data of events
+// against JSON Schemas. PageBoundaryCases lists the reads of a stream most
+// likely to expose paging errors, with the events expected on each page.
+//
+// Server side behaviour is simulated by options such as WithLatency,
+// WithRateLimit, WithBandwidth, WithMaxInFlight and WithBackpressure, and
+// requests can be failed in front of a simulator with the faults package.
+// LoadScenario loads streams, appends, faults and users from a scenario file.
+// ReaderHarness drives a catch-up reader through scripted appends, restarts
+// and faults and checks its delivery guarantees and checkpoints.
+//
+// StartServer, NewTLSSimulatorServer and NewTransport serve a simulator to a
+// client under test. NewCluster serves a cluster of simulators with a leader
+// and lagging followers. WithGRPC serves the streams of a simulator to gRPC
+// clients as well, including reads of $all with server side filters, and
+// StartTCPServer serves them to clients of the legacy TCP protocol.
+// WithServerSentEvents pushes appended events to clients as server-sent
+// events.
+package feedsim
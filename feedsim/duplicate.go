@@ -0,0 +1,51 @@
+package feedsim
+
+import (
+	"errors"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// WithDuplicateDelivery makes each feed page repeat the overlap events of the
+// page read before it, so events are delivered twice across page boundaries
+// as readers can see during failover. Pages read forward repeat the newest
+// events of the older page before them and pages read backward repeat the
+// oldest events of the newer page before them. The links of the pages are
+// unchanged, so consumers that deduplicate by event number or id can be tested
+// while paging normally.
+//
+// Duplicate delivery does not apply to virtual streams.
+func WithDuplicateDelivery(overlap int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if overlap < 0 {
+			return errors.New("overlap must not be negative")
+		}
+		h.overlap = overlap
+		return nil
+	}
+}
+
+// overlapPage returns the events of page, in feed order, widened by overlap
+// events of es towards the page read before it in direction.
+func overlapPage(es, page []*eventdata.Event, direction string, overlap int) []*eventdata.Event {
+	if len(page) == 0 {
+		return page
+	}
+	lo := positionOf(es, page[len(page)-1].EventNumber)
+	hi := positionOf(es, page[0].EventNumber)
+	if lo < 0 || hi < 0 {
+		return page
+	}
+	if direction == "forward" {
+		lo -= overlap
+		if lo < 0 {
+			lo = 0
+		}
+	} else {
+		hi += overlap
+		if hi > len(es)-1 {
+			hi = len(es) - 1
+		}
+	}
+	return reverseEventSlice(es[lo : hi+1])
+}
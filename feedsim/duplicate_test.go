@@ -0,0 +1,64 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestDuplicateDeliveryForward(c *C) {
+	stream := "duplicate-stream"
+	es := eventdata.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithDuplicateDelivery(2))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	seen := map[string]int{}
+	next := fmt.Sprintf("%s/streams/%s/0/forward/10", server.URL, stream)
+	pages := 0
+	for next != "" && pages < 5 {
+		f := getFeed(c, next, nil)
+		for _, e := range f.Entry {
+			seen[e.Title]++
+		}
+		next = ""
+		if l := f.GetLink("previous"); l != nil && len(f.Entry) > 0 {
+			next = l.Href
+		}
+		pages++
+	}
+
+	c.Assert(seen, HasLen, 30)
+	twice := 0
+	for _, v := range seen {
+		if v == 2 {
+			twice++
+		}
+	}
+	c.Assert(twice, Equals, 4)
+	c.Assert(seen[fmt.Sprintf("8@%s", stream)], Equals, 2)
+	c.Assert(seen[fmt.Sprintf("19@%s", stream)], Equals, 2)
+	c.Assert(seen[fmt.Sprintf("10@%s", stream)], Equals, 1)
+}
+
+func (s *MockSuite) TestDuplicateDeliveryBackward(c *C) {
+	stream := "duplicate-stream"
+	es := eventdata.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithDuplicateDelivery(3))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/19/backward/10", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 13)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("22@%s", stream))
+	c.Assert(f.Entry[12].Title, Equals, fmt.Sprintf("10@%s", stream))
+	c.Assert(f.GetLink("next").Href, Equals, fmt.Sprintf("%s/streams/%s/9/backward/10", server.URL, stream))
+	c.Assert(f.GetLink("previous").Href, Equals, fmt.Sprintf("%s/streams/%s/20/forward/10", server.URL, stream))
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 20)
+}
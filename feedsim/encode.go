@@ -0,0 +1,72 @@
+package feedsim
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// Buffers are pooled so that serving large pages does not allocate a new
+// buffer for every response.
+var (
+	writerPool = sync.Pool{New: func() interface{} { return bufio.NewWriterSize(nil, 32<<10) }}
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+)
+
+// writeFeed encodes the feed f to w as indented xml, identical to
+// f.PrettyPrint, without building the whole document in memory first.
+func writeFeed(w io.Writer, f *atom.Feed) error {
+	bw := writerPool.Get().(*bufio.Writer)
+	bw.Reset(w)
+	defer func() {
+		bw.Reset(nil)
+		writerPool.Put(bw)
+	}()
+
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "\t")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeJSON returns a pooled buffer holding v encoded as indented json,
+// identical to the PrettyPrint methods. The buffer must be returned with
+// releaseBuffer once written.
+func encodeJSON(v interface{}) (*bytes.Buffer, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		releaseBuffer(buf)
+		return nil, err
+	}
+	// Encode terminates the value with a newline which MarshalIndent does not.
+	buf.Truncate(buf.Len() - 1)
+	return buf, nil
+}
+
+// releaseBuffer returns buf to the pool.
+func releaseBuffer(buf *bytes.Buffer) {
+	// Very large buffers are dropped rather than held by the pool.
+	if buf.Cap() > 1<<20 {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+const mediaTypeOctetStream = "application/octet-stream"
+
+// isBinaryData reports whether the data v of an event is binary rather than
+// json, as the data of events written with isJson false are.
+func isBinaryData(v interface{}) bool {
+	_, ok := v.([]byte)
+	return ok
+}
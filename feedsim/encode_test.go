@@ -0,0 +1,79 @@
+package feedsim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+// largeFeed returns a forward feed page of n events.
+func largeFeed(tb interface{ Fatal(...interface{}) }, n int) *atom.Feed {
+	es := eventdata.CreateTestEvents(n, "large-stream", "http://localhost:2113", "EventTypeX")
+	r := &StreamURL{Host: "http://localhost:2113", Stream: "large-stream", Direction: "forward", PageSize: n}
+	f, _, err := createFeed(es, r, time.Now(), LegacyPaging)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return f
+}
+
+func (s *MockSuite) TestWriteFeedMatchesPrettyPrint(c *C) {
+	f := largeFeed(c, 100)
+	rec := httptest.NewRecorder()
+	c.Assert(writeFeed(rec, f), IsNil)
+	c.Assert(rec.Body.String(), Equals, f.PrettyPrint())
+}
+
+func (s *MockSuite) TestEncodeJSONMatchesPrettyPrint(c *C) {
+	e := eventdata.CreateTestEvents(1, "astream", "http://localhost:2113", "EventTypeX")[0]
+	buf, err := encodeJSON(e)
+	c.Assert(err, IsNil)
+	defer releaseBuffer(buf)
+	c.Assert(buf.String(), Equals, e.PrettyPrint())
+}
+
+// BenchmarkWriteFeed4096 and BenchmarkPrettyPrintFeed4096 compare encoding a
+// page of 4096 events directly to the response with building the whole
+// document first.
+func BenchmarkWriteFeed4096(b *testing.B) {
+	f := largeFeed(b, 4096)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writeFeed(ioutil.Discard, f)
+	}
+}
+
+func BenchmarkPrettyPrintFeed4096(b *testing.B) {
+	f := largeFeed(b, 4096)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fmt.Fprint(ioutil.Discard, f.PrettyPrint())
+	}
+}
+
+func BenchmarkServeFeed4096(b *testing.B) {
+	es := eventdata.CreateTestEvents(4096, "large-stream", "http://localhost:2113", "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...))
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "http://localhost:2113/streams/large-stream/0/forward/4096", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatal(rec.Code)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorFormat renders the body of an error response with the status code
+// status and the message describing the error. It returns the content type
+// and the body of the response.
+type ErrorFormat func(status int, message string) (contentType string, body []byte)
+
+// WithErrorFormat renders the bodies of the error responses of the simulator
+// with f, so clients that parse error bodies for diagnostics can be tested
+// against structured payloads. JSONErrors and ProblemDetails are provided.
+//
+// By default error responses carry the message as plain text, as the server
+// does. Responses to requests failed by a faults.FaultInjector or
+// faults.ScenarioRunner are not rendered with f.
+func WithErrorFormat(f ErrorFormat) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.errorFormat = f
+		return nil
+	}
+}
+
+// JSONErrors renders errors as a json object holding the status code and the
+// message of the error, for example
+//
+//	{"code": 404, "message": "event 7 not found"}
+func JSONErrors(status int, message string) (string, []byte) {
+	b, _ := json.Marshal(struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}{status, message})
+	return mediaTypeJSON, b
+}
+
+// ProblemDetails renders errors as RFC 7807 problem details, for example
+//
+//	{"type": "about:blank", "title": "Not Found", "status": 404, "detail": "event 7 not found"}
+func ProblemDetails(status int, message string) (string, []byte) {
+	b, _ := json.Marshal(struct {
+		Type   string `json:"type"`
+		Title  string `json:"title"`
+		Status int    `json:"status"`
+		Detail string `json:"detail,omitempty"`
+	}{"about:blank", http.StatusText(status), status, message})
+	return "application/problem+json", b
+}
+
+// writeError replies to the request with the error message and status code,
+// rendering the body with the error format of the simulator.
+func (h *AtomFeedSimulator) writeError(w http.ResponseWriter, message string, status int) {
+	if h.errorFormat == nil {
+		http.Error(w, message, status)
+		return
+	}
+	ct, body := h.errorFormat(status, message)
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,50 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestErrorFormats(c *C) {
+	stream := "error-format-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	get := func(h http.Handler, u string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", u, nil))
+		return rec
+	}
+
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithErrorFormat(ProblemDetails))
+	c.Assert(err, IsNil)
+	rec := get(h, streamURL+"/7")
+	c.Assert(rec.Code, Equals, http.StatusNotFound)
+	c.Assert(rec.Header().Get("Content-Type"), Equals, "application/problem+json")
+	var problem map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &problem), IsNil)
+	c.Assert(problem, DeepEquals, map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Not Found",
+		"status": 404.0,
+		"detail": "event 7 not found",
+	})
+
+	h, err = NewAtomFeedSimulator(WithEvents(es...), WithErrorFormat(JSONErrors))
+	c.Assert(err, IsNil)
+	rec = get(h, streamURL+"/0/sideways/20")
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	c.Assert(rec.Header().Get("Content-Type"), Equals, "application/json")
+	c.Assert(rec.Body.String(), Equals, `{"code":400,"message":"\"sideways\" is not a valid direction, expected forward or backward"}`)
+
+	h, err = NewAtomFeedSimulator(WithEvents(es...))
+	c.Assert(err, IsNil)
+	rec = get(h, streamURL+"/7")
+	c.Assert(rec.Code, Equals, http.StatusNotFound)
+	c.Assert(rec.Header().Get("Content-Type"), Equals, "text/plain; charset=utf-8")
+	c.Assert(rec.Body.String(), Equals, "event 7 not found\n")
+}
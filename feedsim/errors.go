@@ -0,0 +1,105 @@
+package feedsim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoEvents is returned by NewAtomFeedSimulator when it is not provided
+// with one or more events.
+var ErrNoEvents = errors.New("Must provide one or more events.")
+
+// ErrEmptyStreamName is returned by options that are given an empty stream
+// name.
+var ErrEmptyStreamName = errors.New("stream name must not be empty")
+
+// ErrShutdown is returned by methods that wait on a simulator when the
+// simulator is shut down.
+var ErrShutdown = errors.New("simulator shut down")
+
+// InvalidVersionError is returned when a request addresses a stream version
+// that is not a valid event number.
+type InvalidVersionError int
+
+func (i InvalidVersionError) Error() string {
+	return fmt.Sprintf("%d is not a valid event number", int(i))
+}
+
+// InvalidPageSizeError is returned when a request asks for a page size that
+// is outside the limits configured with WithPageSizeLimits.
+type InvalidPageSizeError int
+
+func (i InvalidPageSizeError) Error() string {
+	return fmt.Sprintf("%d is not a valid page size", int(i))
+}
+
+// MalformedVersionError is returned when a request addresses a feed page by a
+// version that is neither head nor a number.
+type MalformedVersionError string
+
+func (v MalformedVersionError) Error() string {
+	return fmt.Sprintf("%q is not a valid event number", string(v))
+}
+
+// MalformedPageSizeError is returned when a request asks for a page size that
+// is not a number.
+type MalformedPageSizeError string
+
+func (p MalformedPageSizeError) Error() string {
+	return fmt.Sprintf("%q is not a valid page size", string(p))
+}
+
+// InvalidDirectionError is returned when a request addresses a feed page read
+// in a direction other than forward or backward.
+type InvalidDirectionError string
+
+func (d InvalidDirectionError) Error() string {
+	return fmt.Sprintf("%q is not a valid direction, expected forward or backward", string(d))
+}
+
+// InvalidURLError is returned when the url of a request does not address a
+// feed page of a stream.
+type InvalidURLError string
+
+func (u InvalidURLError) Error() string {
+	return fmt.Sprintf("%s is not a valid stream url", string(u))
+}
+
+// EventNotFoundError is returned when a request addresses an event that does
+// not exist in the stream.
+type EventNotFoundError int
+
+func (i EventNotFoundError) Error() string {
+	return fmt.Sprintf("event %d not found", int(i))
+}
+
+// EventIDNotFoundError is returned when a request addresses an event by an
+// event id that is not the id of an event in the stream.
+type EventIDNotFoundError string
+
+func (id EventIDNotFoundError) Error() string {
+	return fmt.Sprintf("event %s not found", string(id))
+}
+
+// StreamNotFoundError is returned for a stream that does not exist or has
+// been soft deleted.
+type StreamNotFoundError string
+
+func (s StreamNotFoundError) Error() string {
+	return fmt.Sprintf("stream %s not found", string(s))
+}
+
+// StreamDeletedError is returned for a stream that has been hard deleted.
+type StreamDeletedError string
+
+func (s StreamDeletedError) Error() string {
+	return fmt.Sprintf("stream %s deleted", string(s))
+}
+
+// UnsupportedServerVersionError is returned by WithServerVersion for a
+// version that the simulator cannot emulate.
+type UnsupportedServerVersionError string
+
+func (v UnsupportedServerVersionError) Error() string {
+	return fmt.Sprintf("unsupported server version %q", string(v))
+}
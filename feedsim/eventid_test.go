@@ -0,0 +1,64 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestFeedEntryIDsAddressEvents(c *C) {
+	stream := "entry-id-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 3)
+	for i, e := range f.Entry {
+		c.Assert(e.ID, Equals, fmt.Sprintf("%s/streams/%s/%d/", server.URL, stream, 2-i))
+
+		resp, err := http.Get(e.ID)
+		c.Assert(err, IsNil)
+		var er eventdata.EventAtomResponse
+		c.Assert(json.NewDecoder(resp.Body).Decode(&er), IsNil)
+		resp.Body.Close()
+		c.Assert(er.Title, Equals, e.Title)
+	}
+}
+
+func (s *MockSuite) TestGetEventByEventID(c *C) {
+	stream := "event-id-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithTrickle(2))
+	c.Assert(err, IsNil)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s/%s", server.URL, stream, id), nil)
+		req.Header.Set("Accept", "application/vnd.eventstore.event+json")
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(strings.ToUpper(es[1].EventID))
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	var e eventdata.Event
+	c.Assert(json.NewDecoder(rec.Body).Decode(&e), IsNil)
+	c.Assert(e.EventNumber, Equals, 1)
+	c.Assert(e.EventID, Equals, es[1].EventID)
+
+	rec = get(es[2].EventID)
+	c.Assert(rec.Code, Equals, http.StatusNotFound)
+	c.Assert(strings.TrimSpace(rec.Body.String()), Equals, fmt.Sprintf("event %s not found", es[2].EventID))
+
+	rec = get("00000000-0000-0000-0000-000000000000")
+	c.Assert(rec.Code, Equals, http.StatusNotFound)
+}
@@ -0,0 +1,321 @@
+package feedsim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// fixture is the on disk description of the streams served by a simulator.
+//
+//	{
+//	  "streams": [
+//	    {
+//	      "name": "orders-1",
+//	      "metadata": {"$maxCount": 50},
+//	      "events": [
+//	        {"eventType": "OrderPlaced", "data": {"total": 10}, "metadata": {"user": "bob"}}
+//	      ]
+//	    }
+//	  ]
+//	}
+type fixture struct {
+	Streams []fixtureStream `json:"streams"`
+}
+
+type fixtureStream struct {
+	Name     string          `json:"name"`
+	MetaData json.RawMessage `json:"metadata,omitempty"`
+	Events   []fixtureEvent  `json:"events"`
+}
+
+type fixtureEvent struct {
+	EventType string          `json:"eventType"`
+	EventID   string          `json:"eventId,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	MetaData  json.RawMessage `json:"metadata,omitempty"`
+}
+
+// LoadStreamFixture creates a simulator serving the stream described by the
+// JSON fixture file at path.
+//
+// A fixture lists streams, each with a name, optional stream metadata and its
+// events. Each event has an event type, data and optional metadata and event
+// id. Event numbers are assigned in the order the events are listed. A
+// simulator serves one stream, so the fixture must describe exactly one
+// stream; use LoadRouterFixture to serve a fixture describing several.
+//
+// Only JSON fixtures are supported. Files with a .yaml or .yml extension are
+// rejected with an error rather than read as JSON.
+//
+// opts are applied before the fixture, so the base url provided with
+// WithBaseURL is used for the links of the events and the events and metadata
+// of the fixture replace any provided with WithEvents and WithMetaData.
+func LoadStreamFixture(path string, opts ...Option) (*AtomFeedSimulator, error) {
+	fx, err := readFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(fx.Streams) != 1 {
+		return nil, fmt.Errorf("%s: fixture must describe exactly one stream, load fixtures of several streams with LoadRouterFixture", path)
+	}
+	return NewAtomFeedSimulator(append(opts, withFixture(fx.Streams[0]))...)
+}
+
+// LoadRouterFixture creates a StreamRouter serving each of the streams
+// described by the JSON fixture file at path with its own simulator. The
+// fixture is in the format read by LoadStreamFixture and may describe any
+// number of streams, each with a distinct name.
+//
+// opts are applied to the simulator of every stream before its part of the
+// fixture, as with LoadStreamFixture.
+func LoadRouterFixture(path string, opts ...Option) (*StreamRouter, error) {
+	fx, err := readFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	sims := make([]*AtomFeedSimulator, 0, len(fx.Streams))
+	for _, s := range fx.Streams {
+		sim, err := NewAtomFeedSimulator(append(opts[:len(opts):len(opts)], withFixture(s))...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: stream %q: %v", path, s.Name, err)
+		}
+		sims = append(sims, sim)
+	}
+	return NewStreamRouter(sims...)
+}
+
+// readFixture reads and validates the JSON fixture file at path.
+func readFixture(path string) (*fixture, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return nil, fmt.Errorf("unsupported fixture format %q, fixtures must be JSON", ext)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fx, err := decodeFixture(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return fx, nil
+}
+
+// decodeFixture reads and validates a fixture.
+func decodeFixture(r io.Reader) (*fixture, error) {
+	var fx fixture
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fx); err != nil {
+		return nil, err
+	}
+	if len(fx.Streams) == 0 {
+		return nil, errors.New("fixture must describe at least one stream")
+	}
+	if err := fx.validate(); err != nil {
+		return nil, err
+	}
+	return &fx, nil
+}
+
+// validate checks the streams of a fixture.
+func (fx *fixture) validate() error {
+	names := make(map[string]bool, len(fx.Streams))
+	for _, s := range fx.Streams {
+		if s.Name == "" {
+			return ErrEmptyStreamName
+		}
+		if names[s.Name] {
+			return fmt.Errorf("stream %q is described more than once", s.Name)
+		}
+		names[s.Name] = true
+		for i, e := range s.Events {
+			if e.EventType == "" {
+				return fmt.Errorf("stream %q: event %d has no event type", s.Name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// withFixture serves the stream s of a fixture.
+func withFixture(s fixtureStream) Option {
+	return func(h *AtomFeedSimulator) error {
+		server := ""
+		if h.BaseURL != nil {
+			server = strings.TrimRight(h.BaseURL.String(), "/")
+		}
+
+		es := make([]*eventdata.Event, 0, len(s.Events))
+		for i, v := range s.Events {
+			e := eventdata.CreateTestEvent(s.Name, server, v.EventType, i, rawMessage(v.Data), rawMessage(v.MetaData))
+			if v.EventID != "" {
+				e.EventID = v.EventID
+			}
+			es = append(es, e)
+		}
+		h.Events = es
+		h.stream = s.Name
+
+		h.MetaData = nil
+		if len(s.MetaData) > 0 {
+			h.MetaData = eventdata.CreateTestEvent("$$"+s.Name, server, "$metadata", 0, rawMessage(s.MetaData), nil)
+		}
+		return nil
+	}
+}
+
+// rawMessage returns a pointer to a copy of m, or nil if m is empty.
+func rawMessage(m json.RawMessage) *json.RawMessage {
+	if len(m) == 0 {
+		return nil
+	}
+	c := append(json.RawMessage{}, m...)
+	return &c
+}
+
+// ExportStreamFixture writes the stream currently served by the simulator to
+// w in the fixture format read by LoadStreamFixture, including events that
+// have been appended at runtime but not yet made visible to readers.
+//
+// The fixture is indented so that it can be checked into a repository and
+// compared with DiffStreamFixture.
+func (h *AtomFeedSimulator) ExportStreamFixture(w io.Writer) error {
+	h.RLock()
+	fx, err := h.fixture()
+	h.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// DiffStreamFixture compares the stream currently served by the simulator with
+// the golden fixture file at path. It returns an empty string if they are the
+// same, otherwise the lines of the golden file that are missing from the
+// export prefixed with "-" and the lines of the export that are missing from
+// the golden file prefixed with "+".
+//
+// Event ids are part of the comparison, so golden fixtures should be produced
+// from fixtures that specify the id of every event.
+func (h *AtomFeedSimulator) DiffStreamFixture(path string) (string, error) {
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var got bytes.Buffer
+	if err := h.ExportStreamFixture(&got); err != nil {
+		return "", err
+	}
+	return diffLines(string(want), got.String()), nil
+}
+
+// fixture returns the fixture describing the state of the simulator. The
+// caller must hold the lock.
+func (h *AtomFeedSimulator) fixture() (*fixture, error) {
+	s := fixtureStream{Name: h.stream, Events: []fixtureEvent{}}
+	if s.Name == "" && len(h.Events) > 0 {
+		s.Name = h.Events[0].EventStreamID
+	}
+
+	for _, e := range h.Events {
+		data, err := marshalFixtureValue(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		meta, err := marshalFixtureValue(e.MetaData)
+		if err != nil {
+			return nil, err
+		}
+		s.Events = append(s.Events, fixtureEvent{
+			EventType: e.EventType,
+			EventID:   e.EventID,
+			Data:      data,
+			MetaData:  meta,
+		})
+	}
+
+	if h.MetaData != nil {
+		meta, err := marshalFixtureValue(h.MetaData.Data)
+		if err != nil {
+			return nil, err
+		}
+		s.MetaData = meta
+	}
+
+	return &fixture{Streams: []fixtureStream{s}}, nil
+}
+
+// marshalFixtureValue returns the json encoding of the data or metadata of an
+// event. Empty metadata, which eventdata.CreateTestEvent represents as an empty
+// string, is omitted.
+func marshalFixtureValue(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == `""` || string(b) == "null" {
+		return nil, nil
+	}
+	return json.RawMessage(b), nil
+}
+
+// diffLines returns the lines removed from a and added in b, based on their
+// longest common subsequence.
+func diffLines(a, b string) string {
+	x := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	y := strings.Split(strings.TrimRight(b, "\n"), "\n")
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var d strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&d, "-%s\n", x[i])
+			i++
+		default:
+			fmt.Fprintf(&d, "+%s\n", y[j])
+			j++
+		}
+	}
+	return d.String()
+}
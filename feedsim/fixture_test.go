@@ -0,0 +1,143 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestLoadStreamFixture(c *C) {
+	u, _ := url.Parse(server.URL)
+	h, err := LoadStreamFixture(filepath.Join("testdata", "orders.json"), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	es := h.StreamEvents("orders-1")
+	c.Assert(es, HasLen, 3)
+	c.Assert(es[0].EventType, Equals, "OrderPlaced")
+	c.Assert(es[0].EventID, Equals, "2f5f8f2e-9d4b-4a4e-8c43-6e7e1a9f0c11")
+	c.Assert(es[2].EventNumber, Equals, 2)
+	c.Assert(es[1].Links[0].URI, Equals, fmt.Sprintf("%s/streams/orders-1/1/", server.URL))
+	c.Assert(h.StreamExists("other-stream"), Equals, false)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/orders-1/0", server.URL), nil)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	var data map[string]interface{}
+	c.Assert(json.NewDecoder(resp.Body).Decode(&data), IsNil)
+	c.Assert(data, DeepEquals, map[string]interface{}{"orderId": "1", "total": float64(10)})
+
+	c.Assert(h.StreamMetaData("orders-1"), NotNil)
+	c.Assert(string(*h.StreamMetaData("orders-1").Data.(*json.RawMessage)), Equals, `{"$maxCount": 50}`)
+}
+
+func (s *MockSuite) TestLoadStreamFixtureErrors(c *C) {
+	dir := c.MkDir()
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"fixture.yaml", `streams: []`, `unsupported fixture format ".yaml".*`},
+		{"empty.json", `{"streams": []}`, `.*fixture must describe at least one stream`},
+		{"two.json", `{"streams": [{"name": "a", "events": [{"eventType": "x"}]}, {"name": "b", "events": [{"eventType": "x"}]}]}`, `.*fixture must describe exactly one stream.*LoadRouterFixture`},
+		{"twice.json", `{"streams": [{"name": "a", "events": []}, {"name": "a", "events": []}]}`, `.*stream "a" is described more than once`},
+		{"unnamed.json", `{"streams": [{"events": []}]}`, `.*stream name must not be empty`},
+		{"untyped.json", `{"streams": [{"name": "a", "events": [{"data": {}}]}]}`, `.*event 0 has no event type`},
+		{"unknown.json", `{"streams": [{"name": "a", "evnts": []}]}`, `.*unknown field "evnts"`},
+		{"noevents.json", `{"streams": [{"name": "a", "events": []}]}`, `Must provide one or more events.`},
+	}
+
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name)
+		c.Assert(ioutil.WriteFile(p, []byte(tt.content), 0644), IsNil)
+		_, err := LoadStreamFixture(p)
+		c.Assert(err, ErrorMatches, tt.err, Commentf(tt.name))
+	}
+
+	_, err := LoadStreamFixture(filepath.Join(dir, "missing.json"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *MockSuite) TestLoadRouterFixture(c *C) {
+	u, _ := url.Parse(server.URL)
+	sr, err := LoadRouterFixture(filepath.Join("testdata", "shop.json"), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", sr)
+
+	orders := sr.Simulator("orders-1")
+	c.Assert(orders, NotNil)
+	c.Assert(orders.StreamEvents("orders-1"), HasLen, 2)
+	c.Assert(orders.StreamMetaData("orders-1"), IsNil)
+
+	customers := sr.Simulator("customers-1")
+	c.Assert(customers, NotNil)
+	es := customers.StreamEvents("customers-1")
+	c.Assert(es, HasLen, 1)
+	c.Assert(es[0].EventType, Equals, "CustomerRegistered")
+	c.Assert(customers.StreamMetaData("customers-1"), NotNil)
+
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/orders-1/1", server.URL)), Equals, http.StatusOK)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/customers-1/0", server.URL)), Equals, http.StatusOK)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/customers-1/1", server.URL)), Equals, http.StatusNotFound)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/refunds-1", server.URL)), Equals, http.StatusNotFound)
+}
+
+func (s *MockSuite) TestLoadRouterFixtureErrors(c *C) {
+	dir := c.MkDir()
+	p := filepath.Join(dir, "fixture.yml")
+	c.Assert(ioutil.WriteFile(p, []byte(`streams: []`), 0644), IsNil)
+	_, err := LoadRouterFixture(p)
+	c.Assert(err, ErrorMatches, `unsupported fixture format ".yml".*`)
+
+	p = filepath.Join(dir, "noevents.json")
+	c.Assert(ioutil.WriteFile(p, []byte(`{"streams": [{"name": "a", "events": [{"eventType": "x"}]}, {"name": "b", "events": []}]}`), 0644), IsNil)
+	_, err = LoadRouterFixture(p)
+	c.Assert(err, ErrorMatches, `.*stream "b": Must provide one or more events.`)
+}
+
+func (s *MockSuite) TestExportStreamFixtureRoundTrips(c *C) {
+	h, err := LoadStreamFixture(filepath.Join("testdata", "orders.json"))
+	c.Assert(err, IsNil)
+
+	diff, err := h.DiffStreamFixture(filepath.Join("testdata", "orders.golden.json"))
+	c.Assert(err, IsNil)
+	c.Assert(diff, Equals, "")
+
+	p := filepath.Join(c.MkDir(), "exported.json")
+	f, err := os.Create(p)
+	c.Assert(err, IsNil)
+	c.Assert(h.ExportStreamFixture(f), IsNil)
+	f.Close()
+
+	r, err := LoadStreamFixture(p)
+	c.Assert(err, IsNil)
+	c.Assert(r.StreamEvents("orders-1"), HasLen, 3)
+	c.Assert(r.StreamEvents("orders-1")[1].EventID, Equals, "8c1d0f5a-3b7e-4f3e-9a61-0d2c4b5e6f70")
+}
+
+func (s *MockSuite) TestDiffStreamFixtureReportsChanges(c *C) {
+	h, err := LoadStreamFixture(filepath.Join("testdata", "orders.json"))
+	c.Assert(err, IsNil)
+	e := eventdata.CreateTestEvent("orders-1", "", "OrderDelivered", 3, nil, nil)
+	e.EventID = "0a1b2c3d-0000-4000-8000-000000000000"
+	h.Append(e)
+
+	diff, err := h.DiffStreamFixture(filepath.Join("testdata", "orders.golden.json"))
+	c.Assert(err, IsNil)
+	c.Assert(diff, Equals, `+        },
++        {
++          "eventType": "OrderDelivered",
++          "eventId": "0a1b2c3d-0000-4000-8000-000000000000",
++          "data": null
+`)
+}
@@ -0,0 +1,68 @@
+package feedsim
+
+import (
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// FeedFormat configures the Atom fields of the feeds served by the simulator,
+// so that they can match those of a particular deployment. Fields left unset
+// keep the values EventStore uses by default.
+type FeedFormat struct {
+	// Author is the name of the author of feeds and their entries, by
+	// default "EventStore".
+	Author string
+
+	// Title, if set, returns the title of the feed of stream. By default
+	// the title is "Event stream 'stream'".
+	Title func(stream string) string
+
+	// ID, if set, returns the id of the feed of stream whose self link is
+	// self. By default feeds have no id.
+	ID func(stream, self string) string
+
+	// Summary, if set, returns the summary of the entry for the event e in
+	// feeds and in the atom representation of the event. By default the
+	// summary is the event type.
+	Summary func(e *eventdata.Event) string
+}
+
+// WithFeedFormat makes the simulator format the Atom fields of feeds as
+// configured by ff.
+//
+//	feedsim.WithFeedFormat(feedsim.FeedFormat{
+//		Author: "eventstore-prod",
+//		ID:     func(stream, self string) string { return self },
+//	})
+func WithFeedFormat(ff FeedFormat) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.format = ff
+		return nil
+	}
+}
+
+// apply sets the fields of the feed f of stream, whose entries are for the
+// events page, as configured.
+func (ff FeedFormat) apply(f *atom.Feed, stream string, page []*eventdata.Event) {
+	if ff.Author != "" {
+		f.Author = &atom.Person{Name: ff.Author}
+		for _, e := range f.Entry {
+			e.Author = &atom.Person{Name: ff.Author}
+		}
+	}
+	if ff.Title != nil {
+		f.Title = ff.Title(stream)
+	}
+	if ff.ID != nil {
+		self := ""
+		if l := f.GetLink("self"); l != nil {
+			self = l.Href
+		}
+		f.ID = ff.ID(stream, self)
+	}
+	if ff.Summary != nil {
+		for i, e := range f.Entry {
+			e.Summary = &atom.Text{Body: ff.Summary(page[i])}
+		}
+	}
+}
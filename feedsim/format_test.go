@@ -0,0 +1,57 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestFeedFormat(c *C) {
+	stream := "formatted-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithFeedFormat(FeedFormat{
+		Author:  "eventstore-prod",
+		Title:   func(stream string) string { return "Stream " + stream },
+		ID:      func(stream, self string) string { return self },
+		Summary: func(e *eventdata.Event) string { return fmt.Sprintf("%s #%d", e.EventType, e.EventNumber) },
+	}))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Title, Equals, "Stream "+stream)
+	c.Assert(f.ID, Equals, fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	c.Assert(f.Author.Name, Equals, "eventstore-prod")
+	c.Assert(f.Entry, HasLen, 3)
+	for i, e := range f.Entry {
+		c.Assert(e.Author.Name, Equals, "eventstore-prod")
+		c.Assert(e.Summary.Body, Equals, fmt.Sprintf("EventTypeX #%d", 2-i))
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/1", server.URL, stream))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	var e eventdata.EventAtomResponse
+	c.Assert(json.NewDecoder(resp.Body).Decode(&e), IsNil)
+	c.Assert(e.Summary, Equals, "EventTypeX #1")
+}
+
+func (s *MockSuite) TestFeedFormatDefaults(c *C) {
+	stream := "formatted-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithFeedFormat(FeedFormat{}))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Title, Equals, fmt.Sprintf("Event stream '%s'", stream))
+	c.Assert(f.ID, Equals, "")
+	c.Assert(f.Author.Name, Equals, "EventStore")
+	c.Assert(f.Entry[0].Summary.Body, Equals, "EventTypeX")
+}
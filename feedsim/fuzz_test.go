@@ -0,0 +1,89 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+func FuzzParseURL(f *testing.F) {
+	for _, s := range []string{
+		"http://localhost:2113/streams/astream",
+		"http://localhost:2113/streams/astream/head/backward/20",
+		"http://localhost:2113/streams/astream/0/forward/20",
+		"http://localhost:2113/streams/astream/-1/forward/20",
+		"http://localhost:2113/streams/astream/0/forward",
+		"http://localhost:2113/streams",
+		"http://localhost:2113/",
+		"%",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, u string) {
+		r, err := ParseStreamURL(u)
+		if err != nil {
+			return
+		}
+		if r.Stream == "" || r.PageSize < 1 || r.Version < 0 {
+			t.Errorf("ParseStreamURL(%q) = %+v", u, r)
+		}
+	})
+}
+
+func FuzzCreateTestFeed(f *testing.F) {
+	f.Add("astream", "0", "forward", "20", 10)
+	f.Add("astream", "head", "backward", "20", 10)
+	f.Add("astream", "9", "backward", "3", 10)
+	f.Add("astream", "100", "backward", "3", 10)
+	f.Add("astream", "9223372036854775807", "backward", "9223372036854775807", 0)
+
+	f.Fuzz(func(t *testing.T, stream, version, direction, pageSize string, n int) {
+		if n < 0 || n > 100 {
+			return
+		}
+		es := eventdata.CreateTestEvents(n, stream, "http://localhost:2113", "EventTypeX")
+		u := fmt.Sprintf("http://localhost:2113/streams/%s/%s/%s/%s", url.PathEscape(stream), url.PathEscape(version), url.PathEscape(direction), url.PathEscape(pageSize))
+		feed, err := CreateTestFeed(es, u)
+		if err != nil {
+			return
+		}
+		r, _ := ParseStreamURL(u)
+		if len(feed.Entry) > r.PageSize {
+			t.Errorf("%s returned %d entries", u, len(feed.Entry))
+		}
+	})
+}
+
+// FuzzServeHTTP checks that no request path makes the simulator fail with an
+// internal server error.
+func FuzzServeHTTP(f *testing.F) {
+	f.Add("/streams/astream")
+	f.Add("/streams/astream/head/backward/20")
+	f.Add("/streams/astream/3")
+	f.Add("/streams/astream/metadata")
+	f.Add("/streams/astream/99999999999999999999/forward/20")
+
+	u, _ := url.Parse("http://localhost:2113")
+	es := eventdata.CreateTestEvents(10, "astream", u.String(), "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		req, err := http.NewRequest("GET", "http://localhost:2113"+path, nil)
+		if err != nil {
+			return
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code == http.StatusInternalServerError {
+			t.Errorf("GET %q: %d %s", path, rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,683 @@
+package feedsim
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+)
+
+// grpcStreamsService is the path prefix of the methods of the Streams service
+// of EventStoreDB.
+const grpcStreamsService = "/event_store.client.streams.Streams/"
+
+// gRPC status codes returned by the simulator.
+const (
+	grpcOK                 = 0
+	grpcInvalidArgument    = 3
+	grpcNotFound           = 5
+	grpcAlreadyExists      = 6
+	grpcFailedPrecondition = 9
+	grpcUnimplemented      = 12
+	grpcInternal           = 13
+	grpcUnavailable        = 14
+)
+
+// maxGRPCMessage is the largest gRPC message the simulator reads, the default
+// limit of gRPC servers.
+const maxGRPCMessage = 4 << 20
+
+// grpcStatus is the status a gRPC call ends with. Exception names the
+// EventStoreDB exception reported to clients in the exception trailer, with
+// the details of the exception in meta.
+type grpcStatus struct {
+	code      int
+	message   string
+	exception string
+	meta      map[string]string
+}
+
+// grpcError returns a status with the code and message.
+func grpcError(code int, format string, args ...interface{}) *grpcStatus {
+	return &grpcStatus{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// grpcStreamDeleted returns the status of a call to the deleted stream.
+func grpcStreamDeleted(stream string) *grpcStatus {
+	return &grpcStatus{
+		code:      grpcFailedPrecondition,
+		message:   fmt.Sprintf("Event stream '%s' is deleted.", stream),
+		exception: "stream-deleted",
+		meta:      map[string]string{"stream-name": stream},
+	}
+}
+
+// WithGRPC makes the simulator serve the Streams service of the gRPC protocol
+// of EventStoreDB 20 and later alongside the atom feeds, so that teams
+// migrating from AtomPub to gRPC clients can reuse the same fixtures and
+// scenarios for both protocols.
+//
+// Streams.Read, Streams.Append, Streams.Delete and Streams.Tombstone are served
+// from and change the events served as atom feeds. Reads may subscribe to the
+// stream, receiving events as they are appended. $all holds the events of the
+// simulator and may be read and subscribed to with the server side filters of
+// newer servers, matching events by the prefixes or a regular expression of
+// their stream or event type; filtered subscriptions receive checkpoints. The
+// commit and prepare positions of events are their event numbers.
+//
+// The PersistentSubscriptions service is served too, see
+// PersistentSubscriptionSettings.
+//
+// gRPC requires HTTP/2, so WithGRPC implies WithHTTP2 and the simulator must be
+// served with TLS, for example by NewTLSSimulatorServer. Credentials, faults
+// such as WithLatency and WithRateLimit and shutdown apply to gRPC calls as to
+// other requests, but access control lists and stream permissions apply to
+// atom feeds only.
+func WithGRPC() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.grpc = true
+		h.http2 = true
+		return nil
+	}
+}
+
+// isGRPC reports whether r is a gRPC call.
+func isGRPC(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// serveGRPC serves the gRPC call r, writing the messages it returns and
+// ending the call with its status in the trailers of the response.
+func (h *AtomFeedSimulator) serveGRPC(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		h.writeError(w, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.WriteHeader(http.StatusOK)
+
+	var st *grpcStatus
+	switch {
+	case strings.HasPrefix(r.URL.Path, grpcStreamsService):
+		st = h.serveStreams(w, r, strings.TrimPrefix(r.URL.Path, grpcStreamsService))
+	case strings.HasPrefix(r.URL.Path, grpcPersistentService):
+		st = h.servePersistentSubscriptions(w, r, strings.TrimPrefix(r.URL.Path, grpcPersistentService))
+	default:
+		st = grpcError(grpcUnimplemented, "unknown service %s", r.URL.Path)
+	}
+	if st == nil {
+		st = &grpcStatus{code: grpcOK}
+	}
+	writeGRPCStatus(w, st)
+}
+
+// serveStreams serves the method of the Streams service.
+func (h *AtomFeedSimulator) serveStreams(w http.ResponseWriter, r *http.Request, method string) *grpcStatus {
+	switch method {
+	case "Read":
+		req, err := readGRPCMessage(r.Body)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		return h.grpcRead(w, r, req)
+	case "Append":
+		return h.grpcAppend(w, r)
+	case "Delete", "Tombstone":
+		req, err := readGRPCMessage(r.Body)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		return h.grpcDelete(w, req, method == "Tombstone")
+	}
+	return grpcError(grpcUnimplemented, "method %s is not supported by the simulator", method)
+}
+
+// writeGRPCStatus ends the call with the status st.
+func writeGRPCStatus(w http.ResponseWriter, st *grpcStatus) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(st.code))
+	if st.message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", st.message)
+	}
+	if st.exception != "" {
+		w.Header().Set(http.TrailerPrefix+"Exception", st.exception)
+	}
+	for k, v := range st.meta {
+		w.Header().Set(http.TrailerPrefix+k, v)
+	}
+}
+
+// readGRPCMessage reads the next length prefixed message of a call from r. It
+// returns io.EOF when the client has sent every message.
+func readGRPCMessage(r io.Reader) (protowire.Message, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, protowire.ErrTruncated
+		}
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed messages are not supported by the simulator")
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if n > maxGRPCMessage {
+		return nil, fmt.Errorf("message of %d bytes is larger than %d bytes", n, maxGRPCMessage)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, protowire.ErrTruncated
+	}
+	return protowire.Parse(b)
+}
+
+// grpcMessageError returns the status of a call whose request could not be
+// read.
+func grpcMessageError(err error) *grpcStatus {
+	if err == io.EOF {
+		return grpcError(grpcInvalidArgument, "missing request message")
+	}
+	return grpcError(grpcInvalidArgument, "invalid request message: %v", err)
+}
+
+// writeGRPCMessage writes the message b to the client and flushes it, so that
+// the messages of streaming calls are received as they are written.
+func writeGRPCMessage(w http.ResponseWriter, b []byte) {
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(b)))
+	w.Write(prefix[:])
+	w.Write(b)
+	if fl, ok := w.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+// grpcStreamName returns the name of the stream of a StreamIdentifier.
+func grpcStreamName(m protowire.Message) string {
+	return m.String(3)
+}
+
+// appendStreamIdentifier appends the StreamIdentifier of stream as the field
+// num of b.
+func appendStreamIdentifier(b []byte, num int, stream string) []byte {
+	return protowire.AppendBytes(b, num, protowire.AppendString(nil, 3, stream))
+}
+
+// grpcUUID returns the uuid held by a UUID message, which is either
+// structured, as its most and least significant bits, or a string.
+func grpcUUID(m protowire.Message) (string, error) {
+	if !m.Has(1) {
+		return m.String(2), nil
+	}
+	s, err := m.Message(1)
+	if err != nil {
+		return "", err
+	}
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], s.Uint(1))
+	binary.BigEndian.PutUint64(b[8:], s.Uint(2))
+	return formatUUID(b[:]), nil
+}
+
+// appendUUID appends the UUID message of the uuid id as the field num of b,
+// structured or as a string.
+func appendUUID(b []byte, num int, id string, structured bool) []byte {
+	var u []byte
+	raw, err := hex.DecodeString(strings.Replace(id, "-", "", -1))
+	if structured && err == nil && len(raw) == 16 {
+		var s []byte
+		s = protowire.AppendUint(s, 1, binary.BigEndian.Uint64(raw[:8]))
+		s = protowire.AppendUint(s, 2, binary.BigEndian.Uint64(raw[8:]))
+		u = protowire.AppendBytes(u, 1, s)
+	} else {
+		u = protowire.AppendString(u, 2, id)
+	}
+	return protowire.AppendBytes(b, num, u)
+}
+
+// formatUUID formats the 16 bytes of a uuid in its canonical form.
+func formatUUID(b []byte) string {
+	s := hex.EncodeToString(b)
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// grpcExpectedVersion returns the expected version of the
+// expected_stream_revision oneof of the options of a write, which defaults to
+// any version.
+func grpcExpectedVersion(opts protowire.Message) int {
+	switch {
+	case opts.Has(2):
+		return int(opts.Uint(2))
+	case opts.Has(3):
+		return ExpectedVersionNoStream
+	case opts.Has(5):
+		return ExpectedVersionStreamExists
+	}
+	return ExpectedVersionAny
+}
+
+// appendPosition appends the Position message of the event number n as the
+// field num of b. Positions of events in the log are simulated by their event
+// numbers.
+func appendPosition(b []byte, num int, n int) []byte {
+	var p []byte
+	p = protowire.AppendUint(p, 1, uint64(n))
+	p = protowire.AppendUint(p, 2, uint64(n))
+	return protowire.AppendBytes(b, num, p)
+}
+
+// empty is the encoding of the Empty message.
+var empty = []byte{}
+
+// grpcRead serves Streams.Read. It reads count events of the stream from a
+// revision forwards or backwards, or subscribes to the stream, writing a
+// ReadResp for each event.
+func (h *AtomFeedSimulator) grpcRead(w http.ResponseWriter, r *http.Request, req protowire.Message) *grpcStatus {
+	opts, err := req.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	uuids, err := opts.Message(9)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	structured := !uuids.Has(2)
+	if opts.Has(2) {
+		return h.grpcReadAll(w, r, opts, structured)
+	}
+
+	so, err := opts.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	id, err := so.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	stream := grpcStreamName(id)
+	if stream == "" {
+		return grpcError(grpcInvalidArgument, "stream name must not be empty")
+	}
+
+	if opts.Has(6) {
+		return h.grpcSubscribe(w, r, stream, so, structured)
+	}
+
+	es, err := h.grpcEvents(stream)
+	switch err.(type) {
+	case StreamNotFoundError:
+		var nf []byte
+		nf = appendStreamIdentifier(nf, 1, stream)
+		writeGRPCMessage(w, protowire.AppendBytes(nil, 4, nf))
+		return nil
+	case StreamDeletedError:
+		return grpcStreamDeleted(stream)
+	}
+
+	backwards := opts.Uint(3) == 1
+	from := 0
+	switch {
+	case so.Has(2):
+		from = int(so.Uint(2))
+	case so.Has(4):
+		from = int(^uint(0) >> 1)
+		if !backwards && len(es) > 0 {
+			from = es[len(es)-1].EventNumber + 1
+		}
+	}
+	count := opts.Uint(5)
+
+	var page []*eventdata.Event
+	if backwards {
+		for i := len(es) - 1; i >= 0 && uint64(len(page)) < count; i-- {
+			if es[i].EventNumber <= from {
+				page = append(page, es[i])
+			}
+		}
+	} else {
+		for i := 0; i < len(es) && uint64(len(page)) < count; i++ {
+			if es[i].EventNumber >= from {
+				page = append(page, es[i])
+			}
+		}
+	}
+
+	for _, e := range page {
+		b, err := readResp(e, stream, structured)
+		if err != nil {
+			return grpcError(grpcInternal, "%v", err)
+		}
+		writeGRPCMessage(w, b)
+		h.served(stream, e.EventNumber)
+	}
+	return nil
+}
+
+// grpcSubscribe serves a Streams.Read subscribing to the stream. It confirms
+// the subscription and then writes the events of the stream after the
+// revision of the subscription, followed by events as they are appended,
+// until the client cancels the call or the simulator is shut down.
+func (h *AtomFeedSimulator) grpcSubscribe(w http.ResponseWriter, r *http.Request, stream string, so protowire.Message, structured bool) *grpcStatus {
+	if _, ok := h.streamErr(stream).(StreamDeletedError); ok {
+		return grpcStreamDeleted(stream)
+	}
+
+	next := 0
+	switch {
+	case so.Has(2):
+		next = int(so.Uint(2)) + 1
+	case so.Has(4):
+		if es, _ := h.grpcEvents(stream); len(es) > 0 {
+			next = es[len(es)-1].EventNumber + 1
+		}
+	}
+
+	var confirmation []byte
+	confirmation = protowire.AppendString(confirmation, 1, uuid.NewUUID())
+	writeGRPCMessage(w, protowire.AppendBytes(nil, 2, confirmation))
+
+	for {
+		appended := h.appendNotification()
+
+		es, err := h.grpcEvents(stream)
+		if _, ok := err.(StreamDeletedError); ok {
+			return grpcStreamDeleted(stream)
+		}
+		for _, e := range es {
+			if e.EventNumber < next {
+				continue
+			}
+			b, err := readResp(e, stream, structured)
+			if err != nil {
+				return grpcError(grpcInternal, "%v", err)
+			}
+			writeGRPCMessage(w, b)
+			h.served(stream, e.EventNumber)
+			next = e.EventNumber + 1
+		}
+
+		var wake <-chan time.Time
+		var t *time.Timer
+		if at, ok := h.nextScheduledAppend(); ok {
+			t = time.NewTimer(time.Until(at))
+			wake = t.C
+		}
+		select {
+		case <-appended:
+		case <-wake:
+		case <-r.Context().Done():
+			return nil
+		case <-h.done:
+			return grpcError(grpcUnavailable, "Server is shutting down.")
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// grpcEvents returns the events of stream that can be read, or a
+// StreamNotFoundError or StreamDeletedError if the stream cannot be read.
+func (h *AtomFeedSimulator) grpcEvents(stream string) ([]*eventdata.Event, error) {
+	if err := h.streamErr(stream); err != nil {
+		return nil, err
+	}
+	return h.streamEvents(stream), nil
+}
+
+// readResp returns the ReadResp carrying the event e of stream.
+func readResp(e *eventdata.Event, stream string, structured bool) ([]byte, error) {
+	re, err := recordedEvent(e, stream, structured)
+	if err != nil {
+		return nil, err
+	}
+	var ev []byte
+	ev = protowire.AppendBytes(ev, 1, re)
+	ev = protowire.AppendBytes(ev, 4, empty)
+	return protowire.AppendBytes(nil, 1, ev), nil
+}
+
+// recordedEvent returns the RecordedEvent message of the event e of stream.
+func recordedEvent(e *eventdata.Event, stream string, structured bool) ([]byte, error) {
+	data, err := marshalEventData(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := marshalEventData(e.MetaData)
+	if err != nil {
+		return nil, err
+	}
+
+	var re []byte
+	re = appendUUID(re, 1, e.EventID, structured)
+	re = appendStreamIdentifier(re, 2, stream)
+	re = protowire.AppendUint(re, 3, uint64(e.EventNumber))
+	re = protowire.AppendUint(re, 4, uint64(e.EventNumber))
+	re = protowire.AppendUint(re, 5, uint64(e.EventNumber))
+	re = protowire.AppendMapEntry(re, 6, "type", e.EventType)
+	contentType := mediaTypeJSON
+	if isBinaryData(e.Data) {
+		contentType = mediaTypeOctetStream
+	}
+	re = protowire.AppendMapEntry(re, 6, "content-type", contentType)
+	re = protowire.AppendMapEntry(re, 6, "created", strconv.FormatInt(e.Created.UnixNano()/100, 10))
+	re = protowire.AppendBytes(re, 7, meta)
+	re = protowire.AppendBytes(re, 8, data)
+	return re, nil
+}
+
+// marshalEventData returns the json encoding of the data or metadata v of an
+// event, or nothing if the event has none. Binary data is returned as is.
+func marshalEventData(v interface{}) ([]byte, error) {
+	switch d := v.(type) {
+	case nil:
+		return nil, nil
+	case *json.RawMessage:
+		if d == nil {
+			return nil, nil
+		}
+		return *d, nil
+	case json.RawMessage:
+		return d, nil
+	case []byte:
+		return d, nil
+	}
+	return json.Marshal(v)
+}
+
+// grpcAppend serves Streams.Append. The first message of the call holds the
+// stream and expected version of the write and the messages after it the
+// events to write, which are written as though they were POSTed to the
+// stream.
+func (h *AtomFeedSimulator) grpcAppend(w http.ResponseWriter, r *http.Request) *grpcStatus {
+	req, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	opts, err := req.Message(1)
+	if err != nil || !req.Has(1) {
+		return grpcError(grpcInvalidArgument, "the first message of an append must hold its options")
+	}
+	id, err := opts.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	stream := grpcStreamName(id)
+	if stream == "" {
+		return grpcError(grpcInvalidArgument, "stream name must not be empty")
+	}
+	expected := grpcExpectedVersion(opts)
+
+	var body []writeEvent
+	size := 0
+	for {
+		req, err := readGRPCMessage(r.Body)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		m, err := req.Message(2)
+		if err != nil || !req.Has(2) {
+			return grpcError(grpcInvalidArgument, "expected a proposed message")
+		}
+		e, err := proposedEvent(m)
+		if err != nil {
+			return grpcError(grpcInvalidArgument, "event %d: %v", len(body), err)
+		}
+		size += len(e.Data) + len(e.MetaData)
+		body = append(body, e)
+	}
+	if len(body) == 0 {
+		return grpcError(grpcInvalidArgument, "an append must hold one or more events")
+	}
+	l := h.writeLimits
+	if (l.maxBytes > 0 && int64(size) > l.maxBytes) || (l.maxEvents > 0 && len(body) > l.maxEvents) {
+		return &grpcStatus{
+			code:      grpcInvalidArgument,
+			message:   "Maximum Append Size Exceeded.",
+			exception: "maximum-append-size-exceeded",
+			meta:      map[string]string{"maximum-append-size": strconv.FormatInt(l.maxBytes, 10)},
+		}
+	}
+
+	if !h.servesStream(stream) {
+		return grpcError(grpcNotFound, "Not Found")
+	}
+	if _, ok := h.streamErr(stream).(StreamDeletedError); ok {
+		return grpcStreamDeleted(stream)
+	}
+	if err := h.checkWrite(stream, body); err != nil {
+		return grpcError(grpcInvalidArgument, "%v", err)
+	}
+
+	scheme, host := requestHost(r)
+	fr := &StreamURL{Host: scheme + "://" + host, Stream: stream}
+	if h.BaseURL != nil {
+		fr.Host = strings.TrimRight(h.BaseURL.String(), "/")
+	}
+	_, current, ok := h.write(fr, expected, body)
+
+	var result []byte
+	if ok {
+		var s []byte
+		s = protowire.AppendUint(s, 1, uint64(current))
+		s = appendPosition(s, 3, current)
+		result = protowire.AppendBytes(result, 1, s)
+	} else {
+		var wv []byte
+		if current < 0 {
+			wv = protowire.AppendBytes(wv, 7, empty)
+		} else {
+			wv = protowire.AppendUint(wv, 6, uint64(current))
+		}
+		switch expected {
+		case ExpectedVersionAny:
+			wv = protowire.AppendBytes(wv, 9, empty)
+		case ExpectedVersionStreamExists:
+			wv = protowire.AppendBytes(wv, 10, empty)
+		case ExpectedVersionNoStream:
+			wv = protowire.AppendBytes(wv, 11, empty)
+		default:
+			wv = protowire.AppendUint(wv, 8, uint64(expected))
+		}
+		result = protowire.AppendBytes(result, 2, wv)
+	}
+	writeGRPCMessage(w, result)
+	return nil
+}
+
+// proposedEvent returns the event of a write described by the ProposedMessage
+// m, whose metadata holds its type and content type.
+func proposedEvent(m protowire.Message) (writeEvent, error) {
+	var e writeEvent
+	uid, err := m.Message(1)
+	if err != nil {
+		return e, err
+	}
+	if e.EventID, err = grpcUUID(uid); err != nil {
+		return e, err
+	}
+	md, err := m.Map(2)
+	if err != nil {
+		return e, err
+	}
+	e.EventType = md["type"]
+	if e.EventID == "" || e.EventType == "" {
+		return e, errors.New("events must have an id and a type")
+	}
+	if ct := md["content-type"]; ct != mediaTypeJSON {
+		return e, fmt.Errorf("content type %q is not supported by the simulator, event data must be %s", ct, mediaTypeJSON)
+	}
+	if data := m.Bytes(4); len(data) > 0 {
+		if !json.Valid(data) {
+			return e, errors.New("data is not valid json")
+		}
+		e.Data = append(json.RawMessage{}, data...)
+	}
+	if meta := m.Bytes(3); len(meta) > 0 {
+		if !json.Valid(meta) {
+			return e, errors.New("metadata is not valid json")
+		}
+		e.MetaData = append(json.RawMessage{}, meta...)
+	}
+	return e, nil
+}
+
+// grpcDelete serves Streams.Delete and Streams.Tombstone, soft or hard
+// deleting the stream if its version is the expected version. Deletes with
+// the wrong expected version fail with the wrong-expected-version exception.
+func (h *AtomFeedSimulator) grpcDelete(w http.ResponseWriter, req protowire.Message, hard bool) *grpcStatus {
+	opts, err := req.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	id, err := opts.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	stream := grpcStreamName(id)
+	if stream == "" {
+		return grpcError(grpcInvalidArgument, "stream name must not be empty")
+	}
+	expected := grpcExpectedVersion(opts)
+
+	es, err := h.grpcEvents(stream)
+	if _, ok := err.(StreamDeletedError); ok {
+		return grpcStreamDeleted(stream)
+	}
+	current := -1
+	if err == nil && len(es) > 0 {
+		current = es[len(es)-1].EventNumber
+	}
+	switch {
+	case expected == ExpectedVersionAny:
+	case expected == ExpectedVersionStreamExists && current >= 0:
+	case expected == current:
+	default:
+		return &grpcStatus{
+			code:      grpcFailedPrecondition,
+			message:   "Append failed due to WrongExpectedVersion.",
+			exception: "wrong-expected-version",
+			meta: map[string]string{
+				"stream-name":      stream,
+				"expected-version": strconv.Itoa(expected),
+				"actual-version":   strconv.Itoa(current),
+			},
+		}
+	}
+
+	h.DeleteStream(stream, hard)
+	writeGRPCMessage(w, appendPosition(nil, 1, current))
+	return nil
+}
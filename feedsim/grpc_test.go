@@ -0,0 +1,246 @@
+package feedsim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+	. "gopkg.in/check.v1"
+)
+
+// grpcFrames returns the body of a gRPC call sending the messages msgs.
+func grpcFrames(msgs ...[]byte) io.Reader {
+	var b bytes.Buffer
+	for _, m := range msgs {
+		var prefix [5]byte
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(m)))
+		b.Write(prefix[:])
+		b.Write(m)
+	}
+	return &b
+}
+
+// http2Client returns a client of the TLS server s speaking HTTP/2.
+func http2Client(s *SimulatorServer) *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: s.ClientTLSConfig, ForceAttemptHTTP2: true}}
+}
+
+// startGRPCCall starts the call of the Streams method sending msgs to the
+// server s.
+func startGRPCCall(c *C, s *SimulatorServer, method string, msgs ...[]byte) *http.Response {
+	return startGRPC(c, s, grpcStreamsService+method, grpcFrames(msgs...))
+}
+
+// startGRPC starts the call of the method at path sending body to the server
+// s.
+func startGRPC(c *C, s *SimulatorServer, path string, body io.Reader) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, s.URL+path, body)
+	c.Assert(err, IsNil)
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := http2Client(s).Do(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.ProtoMajor, Equals, 2)
+	return resp
+}
+
+// grpcCall makes the call of the Streams method sending msgs to the server s
+// and returns the messages and trailers of the response.
+func grpcCall(c *C, s *SimulatorServer, method string, msgs ...[]byte) ([]protowire.Message, http.Header) {
+	resp := startGRPCCall(c, s, method, msgs...)
+	defer resp.Body.Close()
+	var resps []protowire.Message
+	for {
+		m, err := readGRPCMessage(resp.Body)
+		if err == io.EOF {
+			return resps, resp.Trailer
+		}
+		c.Assert(err, IsNil)
+		resps = append(resps, m)
+	}
+}
+
+// streamOptions returns the options of a read or write of stream with the
+// revision field rev, such as 2 for a revision or 4 for the end, set to v.
+func streamOptions(stream string, rev int, v uint64) []byte {
+	b := appendStreamIdentifier(nil, 1, stream)
+	if rev == 2 {
+		return protowire.AppendUint(b, rev, v)
+	}
+	return protowire.AppendBytes(b, rev, empty)
+}
+
+// grpcReadReq returns a ReadReq reading count events of stream from the
+// revision option rev with the value v.
+func grpcReadReq(stream string, rev int, v uint64, backwards bool, count uint64) []byte {
+	var o []byte
+	o = protowire.AppendBytes(o, 1, streamOptions(stream, rev, v))
+	if backwards {
+		o = protowire.AppendUint(o, 3, 1)
+	}
+	o = protowire.AppendUint(o, 5, count)
+	return protowire.AppendBytes(nil, 1, o)
+}
+
+// readEventsOf returns the recorded events of the ReadResp messages resps.
+func readEventsOf(c *C, resps []protowire.Message) []protowire.Message {
+	var es []protowire.Message
+	for _, r := range resps {
+		if !r.Has(1) {
+			continue
+		}
+		ev, err := r.Message(1)
+		c.Assert(err, IsNil)
+		re, err := ev.Message(1)
+		c.Assert(err, IsNil)
+		es = append(es, re)
+	}
+	return es
+}
+
+func (s *MockSuite) TestGRPCReadsStream(c *C) {
+	stream := "grpc-read"
+	es := eventdata.CreateTestEvents(10, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...), WithMissingStream("no-such-stream"))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	resps, trailer := grpcCall(c, srv, "Read", grpcReadReq(stream, 2, 3, false, 4))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	got := readEventsOf(c, resps)
+	c.Assert(got, HasLen, 4)
+	for i, re := range got {
+		e := es[3+i]
+		c.Assert(re.Uint(3), Equals, uint64(e.EventNumber))
+		uid, err := re.Message(1)
+		c.Assert(err, IsNil)
+		id, err := grpcUUID(uid)
+		c.Assert(err, IsNil)
+		c.Assert(id, Equals, e.EventID)
+		md, err := re.Map(6)
+		c.Assert(err, IsNil)
+		c.Assert(md["type"], Equals, "EventTypeX")
+		c.Assert(md["content-type"], Equals, "application/json")
+		data, err := marshalEventData(e.Data)
+		c.Assert(err, IsNil)
+		c.Assert(string(re.Bytes(8)), Equals, string(data))
+	}
+
+	resps, _ = grpcCall(c, srv, "Read", grpcReadReq(stream, 4, 0, true, 2))
+	got = readEventsOf(c, resps)
+	c.Assert(got, HasLen, 2)
+	c.Assert(got[0].Uint(3), Equals, uint64(9))
+	c.Assert(got[1].Uint(3), Equals, uint64(8))
+
+	resps, trailer = grpcCall(c, srv, "Read", grpcReadReq("no-such-stream", 3, 0, false, 10))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	c.Assert(resps, HasLen, 1)
+	c.Assert(resps[0].Has(4), Equals, true)
+}
+
+// grpcAppendReqs returns the messages of an append of events of the type
+// eventType with the data data to stream at the expected revision option rev
+// with the value v.
+func grpcAppendReqs(stream string, rev int, v uint64, eventType string, data ...string) [][]byte {
+	reqs := [][]byte{protowire.AppendBytes(nil, 1, streamOptions(stream, rev, v))}
+	for _, d := range data {
+		var m []byte
+		m = appendUUID(m, 1, uuid.NewUUID(), true)
+		m = protowire.AppendMapEntry(m, 2, "type", eventType)
+		m = protowire.AppendMapEntry(m, 2, "content-type", "application/json")
+		m = protowire.AppendBytes(m, 4, []byte(d))
+		reqs = append(reqs, protowire.AppendBytes(nil, 2, m))
+	}
+	return reqs
+}
+
+func (s *MockSuite) TestGRPCAppendIsServedAsFeed(c *C) {
+	stream := "grpc-append"
+	es := eventdata.CreateTestEvents(3, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	resps, trailer := grpcCall(c, srv, "Append", grpcAppendReqs(stream, 2, 2, "Appended", `{"n":1}`, `{"n":2}`)...)
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	c.Assert(resps, HasLen, 1)
+	success, err := resps[0].Message(1)
+	c.Assert(err, IsNil)
+	c.Assert(success.Uint(1), Equals, uint64(4))
+
+	got := srv.Simulator.StreamEvents(stream)
+	c.Assert(got, HasLen, 5)
+	c.Assert(got[4].EventType, Equals, "Appended")
+
+	resp, err := http2Client(srv).Get(fmt.Sprintf("%s/streams/%s/4", srv.URL, stream))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	resps, _ = grpcCall(c, srv, "Append", grpcAppendReqs(stream, 2, 2, "Appended", `{"n":3}`)...)
+	c.Assert(resps, HasLen, 1)
+	wrong, err := resps[0].Message(2)
+	c.Assert(err, IsNil)
+	c.Assert(resps[0].Has(2), Equals, true)
+	c.Assert(wrong.Uint(6), Equals, uint64(4))
+	c.Assert(wrong.Uint(8), Equals, uint64(2))
+
+	_, trailer = grpcCall(c, srv, "Append", grpcAppendReqs(stream, 4, 0, "Appended", "not json")...)
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "3")
+}
+
+func (s *MockSuite) TestGRPCDelete(c *C) {
+	stream := "grpc-delete"
+	es := eventdata.CreateTestEvents(3, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	del := protowire.AppendBytes(nil, 1, streamOptions(stream, 2, 1))
+	_, trailer := grpcCall(c, srv, "Delete", del)
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "9")
+	c.Assert(trailer.Get("Exception"), Equals, "wrong-expected-version")
+	c.Assert(srv.Simulator.StreamDeleted(stream), Equals, false)
+
+	tomb := protowire.AppendBytes(nil, 1, streamOptions(stream, 2, 2))
+	_, trailer = grpcCall(c, srv, "Tombstone", tomb)
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	c.Assert(srv.Simulator.StreamDeleted(stream), Equals, true)
+
+	_, trailer = grpcCall(c, srv, "Read", grpcReadReq(stream, 3, 0, false, 10))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "9")
+	c.Assert(trailer.Get("Exception"), Equals, "stream-deleted")
+}
+
+func (s *MockSuite) TestGRPCSubscription(c *C) {
+	stream := "grpc-subscription"
+	es := eventdata.CreateTestEvents(4, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	var o []byte
+	o = protowire.AppendBytes(o, 1, streamOptions(stream, 2, 1))
+	o = protowire.AppendBytes(o, 6, empty)
+	resp := startGRPCCall(c, srv, "Read", protowire.AppendBytes(nil, 1, o))
+	defer resp.Body.Close()
+
+	m, err := readGRPCMessage(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(m.Has(2), Equals, true)
+
+	for _, want := range []int{2, 3, 4} {
+		if want == 4 {
+			srv.Simulator.Append(eventdata.CreateTestEvent(stream, "https://localhost:2113", "EventTypeX", 4, nil, nil))
+		}
+		m, err := readGRPCMessage(resp.Body)
+		c.Assert(err, IsNil)
+		got := readEventsOf(c, []protowire.Message{m})
+		c.Assert(got, HasLen, 1)
+		c.Assert(got[0].Uint(3), Equals, uint64(want))
+	}
+}
@@ -0,0 +1,1041 @@
+package feedsim
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/jsonschema"
+)
+
+// AtomFeedSimulator is the type that stores configuration and state for
+// the feed simulator.
+//
+// The simulator is safe for concurrent use. Its state is guarded by the
+// embedded RWMutex, which must be held when reading or modifying Events,
+// MetaData or TrickleAfter directly once the simulator is serving requests.
+// Prefer Append and DeleteStream to modify the stream at runtime.
+type AtomFeedSimulator struct {
+	sync.RWMutex
+	Events       []*eventdata.Event
+	BaseURL      *url.URL
+	MetaData     *eventdata.Event
+	TrickleAfter int
+
+	// Configuration, set by the constructor and options and not modified
+	// afterwards.
+	feedRegex        *regexp.Regexp
+	eventRegex       *regexp.Regexp
+	metaRegex        *regexp.Regexp
+	live             bool
+	stream           string
+	pageSize         pageSizeLimits
+	writeLimits      writeLimits
+	commitDelay      *commitDelay
+	version          serverVersion
+	latencies        []routeLatency
+	rateLimit        *tokenBucket
+	bandwidth        int
+	clock            Clock
+	skew             time.Duration
+	relativeLinks    bool
+	requestHostLinks bool
+	compression      bool
+	forcedEncoding   string
+	chunkSize        int
+	connLimit        *connectionLimit
+	http2            bool
+	grpc             bool
+	sse              bool
+	sseKeepAlive     time.Duration
+	strictHead       bool
+	strictAccept     bool
+	overlap          int
+	replicaLag       *replicaLag
+	backpressure     *backpressure
+	paging           PagingMode
+	format           FeedFormat
+	errorFormat      ErrorFormat
+	virtual          *virtualStream
+	pages            *pageCache
+	inFlight         *inFlightLimit
+	metrics          *metrics
+	logger           Logger
+	users            map[string]string
+	tokenTTL         time.Duration
+	settings         *settingsStream
+	permissions      []StreamPermission
+	schemas          map[string]*jsonschema.Schema
+	strictSchemas    bool
+	hooks            hooks
+	done             chan struct{}
+
+	// State guarded by the lock.
+	schedule      []AppendStep
+	nextStep      int
+	nextAppend    time.Time
+	appended      chan struct{}
+	streamStates  map[string]streamState
+	requests      []RecordedRequest
+	readPositions map[string]int
+	observed      chan struct{}
+	overrides     []override
+	active        int
+	idle          chan struct{}
+	initial       Snapshot
+	store         eventStore
+	tokens        map[string]time.Time
+	persistent    map[string]*persistentGroup
+	violations    []SchemaViolation
+}
+
+// NewAtomFeedSimulator consructs a new AtomFeedSimulator configured by the
+// options provided.
+//
+// The events to be served must be provided using WithEvents and there must be
+// one or more of them, unless a virtual stream is served using
+// WithVirtualStream. The base url of the test server should be provided
+// using WithBaseURL so that requests with relative urls can be resolved.
+//
+//	sim, err := feedsim.NewAtomFeedSimulator(
+//		feedsim.WithEvents(es...),
+//		feedsim.WithBaseURL(u),
+//		feedsim.WithTrickle(5))
+func NewAtomFeedSimulator(opts ...Option) (*AtomFeedSimulator, error) {
+	fs, err := newAtomFeedSimulator(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(fs.Events) <= 0 && fs.virtual == nil {
+		return nil, ErrNoEvents
+	}
+	return fs, nil
+}
+
+// NewAtomFeedSimulatorFromEvents consructs a new AtomFeedSimulator.
+//
+// It is equivalent to calling NewAtomFeedSimulator with the options WithEvents,
+// WithBaseURL, WithMetaData and WithTrickle followed by opts.
+//
+// Deprecated: Use NewAtomFeedSimulator with functional options instead.
+func NewAtomFeedSimulatorFromEvents(events []*eventdata.Event, baseURL *url.URL, streamMeta *eventdata.Event, trickleAfter int, opts ...Option) (*AtomFeedSimulator, error) {
+	o := []Option{
+		WithEvents(events...),
+		WithBaseURL(baseURL),
+		WithMetaData(streamMeta),
+		WithTrickle(trickleAfter),
+	}
+	return NewAtomFeedSimulator(append(o, opts...)...)
+}
+
+func newAtomFeedSimulator(opts ...Option) (*AtomFeedSimulator, error) {
+	fs := &AtomFeedSimulator{
+		Events:       []*eventdata.Event{},
+		TrickleAfter: -1,
+		pageSize:     defaultPageSizeLimits,
+		version:      defaultServerVersion,
+		done:         make(chan struct{}),
+		clock:        realClock,
+	}
+
+	fr, err := regexp.Compile("(?:streams\\/[^\\/]+\\/[^\\/]+\\/[^\\/]+\\/[^\\/]+)|(?:streams\\/[^\\/]+$)")
+	if err != nil {
+		return nil, err
+	}
+	fs.feedRegex = fr
+
+	er, err := regexp.Compile("streams\\/[^\\/]+\\/(?:head|\\d+|" + eventIDPattern + ")\\/?$")
+	if err != nil {
+		return nil, err
+	}
+	fs.eventRegex = er
+
+	mr, err := regexp.Compile("streams\\/[^\\/]+\\/metadata")
+	if err != nil {
+		return nil, err
+	}
+	fs.metaRegex = mr
+
+	for _, opt := range opts {
+		if err := opt(fs); err != nil {
+			return nil, err
+		}
+	}
+
+	if fs.TrickleAfter < 0 || fs.TrickleAfter > len(fs.Events) {
+		fs.TrickleAfter = len(fs.Events)
+	}
+	if fs.skew != 0 {
+		fs.clock = skewedClock(fs.clock, fs.skew)
+	}
+	if fs.settings != nil {
+		if err := fs.settings.start(fs); err != nil {
+			return nil, err
+		}
+	}
+	if err := fs.checkSchemas(); err != nil {
+		return nil, err
+	}
+	fs.startPersistentGroups()
+	stamp(fs.clock, fs.Events)
+	fs.startSchedule()
+	fs.initial = fs.snapshot()
+
+	return fs, nil
+}
+
+// ServeHTTP serves atom feed responses
+func (h *AtomFeedSimulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.logger != nil {
+		lw := &loggingWriter{ResponseWriter: w}
+		defer h.logRequest(r, lw, time.Now())
+		w = lw
+	}
+
+	reqURL := h.requestURL(r)
+
+	if !h.begin() {
+		h.writeShutdown(w)
+		return
+	}
+	defer h.end()
+
+	if h.serveMetrics(w, reqURL) {
+		return
+	}
+
+	h.record(r, reqURL.String())
+	h.metrics.request(h.route(reqURL.String()))
+	h.limitConnection(w, r)
+	h.writeCORS(w, reqURL)
+	h.writeDate(w)
+
+	if r.Method == http.MethodOptions {
+		h.serveOptions(w, reqURL)
+		return
+	}
+
+	if h.serveToken(w, r, reqURL) {
+		return
+	}
+
+	if !h.authenticate(w, r) || !h.authorize(w, r, reqURL) || !h.permit(w, r, reqURL) {
+		return
+	}
+
+	ok, done := h.admit(w)
+	if !ok {
+		return
+	}
+	defer done()
+
+	if h.throttle(w) {
+		return
+	}
+
+	if !h.delay(r, reqURL.String()) {
+		h.writeShutdown(w)
+		return
+	}
+
+	if h.grpc && isGRPC(r) {
+		h.serveGRPC(w, r)
+		return
+	}
+
+	if h.bandwidth > 0 {
+		w = &throttledWriter{ResponseWriter: w, ctx: r.Context(), done: h.done, bytesPerSecond: h.bandwidth}
+	}
+
+	if h.chunkSize > 0 {
+		w = &chunkedWriter{ResponseWriter: w, chunkSize: h.chunkSize}
+	}
+
+	if o := h.overrideFor(reqURL.String()); o != nil {
+		o.ServeHTTP(w, r)
+		return
+	}
+
+	if h.settings != nil && streamFromURL(reqURL) == SettingsStream {
+		h.settings.sim.ServeHTTP(w, r)
+		return
+	}
+
+	if cs, ok := h.checkpointStream(streamFromURL(reqURL)); ok {
+		if cs == nil {
+			h.writeError(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		cs.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost && h.writeStreamState(w, streamFromURL(reqURL)) {
+		return
+	}
+
+	if enc := h.contentEncoding(r); enc != "" {
+		cw := &compressWriter{ResponseWriter: w, encoding: enc}
+		defer cw.Close()
+		w = cw
+	}
+
+	d := h.requestDetails(r, reqURL)
+	h.requestReceived(d)
+
+	if h.sse && d.Route == RouteFeed && isServerSentEvents(r) {
+		h.serveServerSentEvents(w, r, d)
+		return
+	}
+
+	if !h.checkAccept(w, r, d) {
+		return
+	}
+
+	switch d.Route {
+	case RouteFeed:
+		if r.Method == http.MethodPost {
+			h.serveWrite(w, r, d)
+			return
+		}
+		h.serveFeed(w, r, d)
+
+	case RouteEvent:
+		h.serveEvent(w, r, d)
+
+	case RouteMetadata:
+		h.RLock()
+		meta := h.MetaData
+		h.RUnlock()
+		if meta == nil {
+			fmt.Fprint(w, "{}")
+			return
+		}
+		m, err := eventdata.CreateTestEventAtomResponse(meta, nil)
+		if err != nil {
+			h.serverError(w, d, err)
+			return
+		}
+		fmt.Fprint(w, m.PrettyPrint())
+	}
+}
+
+// serveFeed writes the feed page addressed by the request.
+//
+// If the page contains no entries and the request carries an ES-LongPoll
+// header, the request is held until new events become available or the long
+// poll expires. When an append schedule has been configured or the simulator
+// is fed from a channel the events arrive as they are appended, otherwise the
+// next event is released after a random interval.
+func (h *AtomFeedSimulator) serveFeed(w http.ResponseWriter, r *http.Request, d RequestDetails) {
+	fr, err := ParseStreamURL(d.URL)
+	if err != nil {
+		h.writeFeedError(w, d, err)
+		return
+	}
+
+	if err := h.applyPageSizeLimits(fr); err != nil {
+		h.writeFeedError(w, d, err)
+		return
+	}
+
+	if p, ok := h.pages.get(*fr); ok {
+		h.writeFeedPage(w, r, d, fr, p.feed, p.page)
+		return
+	}
+
+	es := h.backpressure.apply(fr, h.streamEvents(fr.Stream), h.requestsInFlight())
+	f, page, err := h.createFeed(h.replicaLag.apply(fr, es), fr)
+	if err != nil {
+		h.writeFeedError(w, d, err)
+		return
+	}
+
+	if len(f.Entry) <= 0 && r.Header.Get("ES-LongPoll") != "" {
+		longPoll, err := strconv.Atoi(r.Header.Get("ES-LongPoll"))
+		if err != nil {
+			h.writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		started := time.Now()
+		defer func() { h.metrics.longPoll(time.Since(started)) }()
+
+		if h.virtual != nil {
+			h.sleep(r.Context(), time.Duration(longPoll)*time.Second)
+		} else if h.appendsEvents() || h.stateOf(fr.Stream) == streamEmpty {
+			f, page, err = h.waitForEvents(r.Context(), fr, time.Duration(longPoll)*time.Second)
+			if err != nil {
+				h.writeFeedError(w, d, err)
+				return
+			}
+		} else {
+			h.Lock()
+			h.TrickleAfter++
+			if h.TrickleAfter > len(h.Events) {
+				h.TrickleAfter--
+			}
+			h.invalidateAppended()
+			index := h.TrickleAfter
+			if index < 0 {
+				index = 0
+			}
+
+			f, page, err = h.createFeed(h.Events[:index], fr)
+			h.Unlock()
+			if err != nil {
+				h.writeFeedError(w, d, err)
+				return
+			}
+
+			waitDuration := longPoll
+			if len(f.Entry) > 0 {
+				waitDuration = rand.Intn(longPoll)
+			}
+			h.sleep(r.Context(), time.Duration(waitDuration)*time.Second)
+		}
+	}
+
+	h.writeFeedPage(w, r, d, fr, f, page)
+}
+
+// writeFeedPage writes the feed f requested by fr, holding the events page.
+func (h *AtomFeedSimulator) writeFeedPage(w http.ResponseWriter, r *http.Request, d RequestDetails, fr *StreamURL, f *atom.Feed, page []*eventdata.Event) {
+	h.writeCacheControl(w, f)
+	if writeETag(w, r, f) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if body := h.pages.put(*fr, f, page); body != nil {
+		w.Write(body)
+	} else {
+		writeFeed(w, f)
+	}
+
+	for _, e := range page {
+		h.served(fr.Stream, e.EventNumber)
+	}
+	h.feedServed(d, page)
+}
+
+// createFeed creates the feed page of the events es requested by r in the
+// shape of the server version being simulated. The events of the page are
+// returned in the order of the entries of the feed.
+func (h *AtomFeedSimulator) createFeed(es []*eventdata.Event, r *StreamURL) (*atom.Feed, []*eventdata.Event, error) {
+	var f *atom.Feed
+	var page []*eventdata.Event
+	var err error
+	if h.virtual != nil {
+		f, page, err = h.virtual.createFeed(r, h.clock.Now(), h.paging)
+		es = h.virtual.head()
+	} else {
+		now := h.clock.Now()
+		f, page, err = createFeed(es, r, now, h.paging)
+		if err == nil && h.overlap > 0 {
+			page = overlapPage(es, page, r.Direction, h.overlap)
+			f.Entry = feedEntries(page, r.Stream, now)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	h.version.apply(f, es)
+	h.format.apply(f, r.Stream, page)
+	h.applyStrictHead(f, es, r)
+	if base, err := url.Parse(r.Host); err == nil {
+		h.rewriteFeedLinks(f, base)
+	}
+	return f, page, nil
+}
+
+// waitForEvents blocks until the feed page requested by r contains entries,
+// the timeout expires, ctx is done or the simulator is shut down and then
+// returns the feed and the events of the page.
+func (h *AtomFeedSimulator) waitForEvents(ctx context.Context, r *StreamURL, timeout time.Duration) (*atom.Feed, []*eventdata.Event, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		appended := h.appendNotification()
+
+		f, page, err := h.createFeed(h.streamEvents(r.Stream), r)
+		if err != nil || len(f.Entry) > 0 || !time.Now().Before(deadline) {
+			return f, page, err
+		}
+
+		wake := deadline
+		if next, ok := h.nextScheduledAppend(); ok && next.Before(deadline) {
+			wake = next
+		}
+		t := time.NewTimer(wake.Sub(time.Now()))
+		select {
+		case <-appended:
+		case <-t.C:
+		case <-ctx.Done():
+			deadline = time.Now()
+		case <-h.done:
+			deadline = time.Now()
+		}
+		t.Stop()
+	}
+}
+
+// appendsEvents reports whether events are appended to the stream by an
+// append schedule or a channel rather than by the default trickle behaviour.
+func (h *AtomFeedSimulator) appendsEvents() bool {
+	h.RLock()
+	defer h.RUnlock()
+	return h.schedule != nil || h.live
+}
+
+// appendNotification returns a channel that is closed the next time events
+// are appended to the stream.
+func (h *AtomFeedSimulator) appendNotification() <-chan struct{} {
+	h.Lock()
+	defer h.Unlock()
+	if h.appended == nil {
+		h.appended = make(chan struct{})
+	}
+	return h.appended
+}
+
+// notifyAppend wakes any requests waiting for events to be appended.
+// The caller must hold the lock.
+func (h *AtomFeedSimulator) notifyAppend() {
+	h.invalidateAppended()
+	if h.appended != nil {
+		close(h.appended)
+		h.appended = nil
+	}
+}
+
+// writeFeedError writes the http error response appropriate to an error
+// returned while creating a feed.
+func (h *AtomFeedSimulator) writeFeedError(w http.ResponseWriter, d RequestDetails, err error) {
+	switch err.(type) {
+	case InvalidVersionError, InvalidPageSizeError, InvalidURLError,
+		MalformedVersionError, MalformedPageSizeError, InvalidDirectionError:
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.serverError(w, d, err)
+	}
+}
+
+// serveEvent writes the event addressed by the request in the representation
+// requested by the Accept header of the request. Events are addressed by the
+// uri in the id of their feed entry, /streams/{stream}/{number}, or by their
+// event id as /streams/{stream}/{eventId}.
+//
+// application/vnd.eventstore.atom+json (the default) returns the atom entry
+// for the event, application/vnd.eventstore.event+json returns the event
+// itself and application/json returns only the event data.
+// HEAD requests receive the headers of the equivalent GET, compressed or not,
+// without a body.
+func (h *AtomFeedSimulator) serveEvent(w http.ResponseWriter, r *http.Request, d RequestDetails) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.writeError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	e, err := h.resolveEvent(d.Stream, d.URL)
+	if err != nil {
+		h.writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	mediaType := negotiateEventMediaType(r.Header.Get("Accept"))
+	base, _ := url.Parse(d.URL)
+	out := h.rewriteEventLinks(e, base)
+
+	var v interface{}
+	switch mediaType {
+	case mediaTypeEventJSON:
+		v = out
+	case mediaTypeJSON:
+		v = out.Data
+	default:
+		updated := eventdata.Time(h.clock.Now())
+		if !e.Created.IsZero() {
+			updated = eventdata.Time(e.Created)
+		}
+		er, err := eventdata.CreateTestEventAtomResponse(out, &updated)
+		if err != nil {
+			h.serverError(w, d, err)
+			return
+		}
+		if h.format.Summary != nil {
+			er.Summary = h.format.Summary(e)
+		}
+		v = er
+	}
+
+	body, err := encodeJSON(v)
+	if err != nil {
+		h.serverError(w, d, err)
+		return
+	}
+	defer releaseBuffer(body)
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	if r.Method == http.MethodHead {
+		// Writing the header explicitly lets a compressWriter replace the
+		// Content-Length with the Content-Encoding, as it does for a GET.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Write(body.Bytes())
+	h.served(d.Stream, e.EventNumber)
+	h.eventServed(d, e)
+}
+
+// visibleEvents returns the events that have been made available to readers
+// so far, taking the trickle position into account.
+func (h *AtomFeedSimulator) visibleEvents() []*eventdata.Event {
+	h.Lock()
+	defer h.Unlock()
+	if now, ok := h.pendingStep(); ok {
+		h.advanceSchedule(now)
+	}
+	index := h.TrickleAfter
+	if index < 0 {
+		index = 0
+	}
+	if index > len(h.Events) {
+		index = len(h.Events)
+	}
+	return h.Events[:index]
+}
+
+// Append appends events to the end of the stream.
+//
+// If every event in the stream is visible to readers the appended events
+// become visible immediately and waiting long polls are released. Otherwise
+// they are queued behind the events that have yet to trickle in.
+func (h *AtomFeedSimulator) Append(events ...*eventdata.Event) {
+	h.Lock()
+	defer h.Unlock()
+	h.appendEvents(events)
+}
+
+// appendEvents appends events to the end of the stream. The caller must hold
+// the lock.
+func (h *AtomFeedSimulator) appendEvents(events []*eventdata.Event) {
+	stamp(h.clock, events)
+	h.checkAppended(events)
+	visible := h.TrickleAfter >= len(h.Events)
+	h.Events = append(h.Events, events...)
+	if visible {
+		h.TrickleAfter = len(h.Events)
+	}
+	h.notifyAppend()
+}
+
+// CreateTestFeed creates an atom feed object from the events passed in and the
+// url provided.
+//
+// The function will take the events that correspond to the version number and
+// page size in the url and return a feed object that contains those events.
+// If the url defines a set larger than the events passed in the returned events
+// will only contain the events available.
+func CreateTestFeed(es []*eventdata.Event, feedURL string) (*atom.Feed, error) {
+
+	r, err := ParseStreamURL(feedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	f, _, err := createFeed(es, r, time.Now(), LegacyPaging)
+	return f, err
+}
+
+// createFeed creates an atom feed object containing the page of the events
+// described by the request r. The events of the page are returned in the order
+// of the entries of the feed.
+//
+// The feed is updated at now and each entry at the time its event was created,
+// or now if the event has no created time.
+func createFeed(es []*eventdata.Event, r *StreamURL, now time.Time, mode PagingMode) (*atom.Feed, []*eventdata.Event, error) {
+	first, head := -1, -1
+	if len(es) > 0 {
+		first, head = es[0].EventNumber, es[len(es)-1].EventNumber
+	}
+
+	version := r.Version
+	if r.Head && len(es) > 0 {
+		version = head
+	}
+
+	return createFeedPage(getSliceSection(es, version, r.PageSize, r.Direction, mode), first, r, now)
+}
+
+// createFeedPage creates the feed page showing the window w of a stream whose
+// oldest event is numbered first.
+func createFeedPage(w FeedWindow, first int, r *StreamURL, now time.Time) (*atom.Feed, []*eventdata.Event, error) {
+
+	sr := reverseEventSlice(w.Events)
+
+	f := &atom.Feed{}
+
+	f.Title = fmt.Sprintf("Event stream '%s'", r.Stream)
+	f.Updated = atom.Time(now)
+	f.Author = &atom.Person{Name: "EventStore"}
+
+	f.Link = NewLinkBuilder(r).links(first, w)
+
+	if w.IsHead {
+		f.HeadOfStream = true
+	}
+
+	f.StreamID = r.Stream
+
+	f.Entry = feedEntries(sr, r.Stream, now)
+
+	return f, sr, nil
+}
+
+// feedEntries returns the feed entries of the events sr of stream, in the
+// order given.
+func feedEntries(sr []*eventdata.Event, stream string, now time.Time) []*atom.Entry {
+	var entries []*atom.Entry
+	for _, v := range sr {
+		e := &atom.Entry{}
+		e.Title = fmt.Sprintf("%d@%s", v.EventNumber, stream)
+		e.ID = v.Links[0].URI
+		e.Updated = atom.Time(now)
+		if !v.Created.IsZero() {
+			e.Updated = atom.Time(v.Created)
+		}
+		e.Author = &atom.Person{Name: "EventStore"}
+		e.Summary = &atom.Text{Body: v.EventType}
+		e.Link = append(e.Link, atom.Link{Rel: "edit", Href: v.Links[0].URI})
+		e.Link = append(e.Link, atom.Link{Rel: "alternate", Href: v.Links[0].URI})
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// FeedWindow is the part of a stream shown by a page of its feed and the
+// position of the page among the pages of the stream. Pages are ordered from
+// the first page, holding the newest events, to the last page, holding the
+// oldest; the next page of a page holds older events and its previous page
+// newer events.
+type FeedWindow struct {
+	// Events holds the events on the page, oldest first.
+	Events []*eventdata.Event
+
+	// IsFirstPage is true if the page holds the newest event of the stream.
+	IsFirstPage bool
+	// IsLastPage is true if the page holds the oldest event of the stream,
+	// or if there are no older events to page to. The last page has no last
+	// or next links.
+	IsLastPage bool
+	// IsHead is true if the page reaches the head of the stream.
+	IsHead bool
+
+	// NextVersion is the version of the next page, read backward, or -1 if
+	// the page is the last page.
+	NextVersion int
+	// PrevVersion is the version of the previous page, read forward, or -1
+	// if the page has no previous link. Pages at the head of the stream have
+	// a previous link so that readers can poll for new events.
+	PrevVersion int
+}
+
+// getSliceSection returns the window of the events es shown by the page
+// requested by ver, pageSize and direction under the paging rules of mode.
+func getSliceSection(es []*eventdata.Event, ver int, pageSize int, direction string, mode PagingMode) FeedWindow {
+	first, last := -1, -1
+	if len(es) > 0 {
+		first, last = es[0].EventNumber, es[len(es)-1].EventNumber
+	}
+	start, end, w := getSliceBounds(len(es), first, last, ver, pageSize, direction, mode)
+	w.Events = es[start:end]
+	return w
+}
+
+// getSliceBounds returns the indexes [start, end) of the events on the page
+// of a stream of n events, numbered from first to last, requested by ver,
+// pageSize and direction under the paging rules of mode, and the window of
+// the page without its events.
+func getSliceBounds(n, first, last, ver, pageSize int, direction string, mode PagingMode) (start, end int, w FeedWindow) {
+
+	if n < 1 {
+		// The stream has no events yet so there is nothing to page back
+		// through but readers can poll for the first event.
+		return 0, 0, FeedWindow{IsFirstPage: true, IsLastPage: true, IsHead: true, NextVersion: -1, PrevVersion: 0}
+	}
+
+	if ver < 0 {
+		return 0, 0, FeedWindow{NextVersion: last, PrevVersion: -1}
+	}
+
+	// Event numbers are converted to indexes by their offset from the first
+	// event, so streams that do not start at event 0 are paged correctly.
+	switch direction {
+	case "forward":
+		if ver == 0 || (mode == CleanPaging && ver < first) {
+			start = 0
+		} else {
+			start = ver - first
+			if ver > last {
+				// Out of range over
+				return 0, 0, FeedWindow{IsFirstPage: true, IsHead: true, NextVersion: last, PrevVersion: -1}
+			} else if ver < first {
+				// Out of range under
+				return 0, 0, FeedWindow{IsLastPage: true, NextVersion: -1, PrevVersion: -1}
+			}
+		}
+		//if start + pageSize exceeds the last item, set end to be last item
+		end = n
+		if pageSize < end-start {
+			end = start + pageSize
+		}
+
+	case "backward", "":
+		if (ver == 0 && mode == LegacyPaging) || ver > last {
+			end = n
+		} else if ver < first {
+			end = 0
+		} else {
+			end = ver - first + 1
+		}
+		//if end - pagesize is less than first item return first item
+		start = 0
+		if pageSize < end {
+			start = end - pageSize
+		}
+	}
+
+	w = FeedWindow{
+		IsFirstPage: end >= n,
+		IsLastPage:  start <= 0,
+		IsHead:      end > n-1,
+		NextVersion: last,
+		PrevVersion: -1,
+	}
+	if end > start {
+		w.NextVersion = first + start - 1
+		w.PrevVersion = first + end
+	}
+	if w.IsLastPage {
+		w.NextVersion = -1
+	}
+	return start, end, w
+}
+
+// ParseStreamURL parses the url u of a stream or of a page of a stream, such as
+// http://localhost:2113/streams/orders/10/forward/20, as the simulator does.
+// The url of a stream addresses its head, read backward 20 events at a time.
+//
+// An InvalidURLError is returned for urls that do not address a stream. The
+// component of a page url that cannot be parsed is described by a
+// MalformedVersionError, InvalidDirectionError or MalformedPageSizeError, and
+// negative versions and page sizes less than 1 by an InvalidVersionError or
+// InvalidPageSizeError.
+func ParseStreamURL(u string) (*StreamURL, error) {
+
+	r := StreamURL{}
+
+	ru, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	r.Host = ru.Scheme + "://" + ru.Host
+	r.Query = ru.RawQuery
+
+	split, err := pathSegments(ru)
+	if err != nil {
+		return nil, InvalidURLError(u)
+	}
+	i := streamsSegment(split, 2, 5)
+	if i < 0 || split[i+1] == "" {
+		return nil, InvalidURLError(u)
+	}
+	for _, v := range split[:i] {
+		r.Host += "/" + url.PathEscape(v)
+	}
+	split = split[i:]
+	r.Stream = split[1]
+
+	if len(split) > 2 {
+		r.Head = split[2] == "head"
+		if !r.Head {
+			i, err := strconv.ParseInt(split[2], 10, 0)
+			if err != nil {
+				return nil, MalformedVersionError(split[2])
+			}
+			if i < 0 {
+				return nil, InvalidVersionError(i)
+			}
+			r.Version = int(i)
+		}
+		r.Direction = split[3]
+		if r.Direction != "forward" && r.Direction != "backward" {
+			return nil, InvalidDirectionError(r.Direction)
+		}
+		p, err := strconv.ParseInt(split[4], 10, 0)
+		if err != nil {
+			return nil, MalformedPageSizeError(split[4])
+		}
+		if p < 1 {
+			return nil, InvalidPageSizeError(p)
+		}
+		r.PageSize = int(p)
+	} else {
+		r.Head = true
+		r.Direction = "backward"
+		r.PageSize = 20
+	}
+
+	return &r, nil
+}
+
+// pathSegments returns the unescaped segments of the path of u. Escaped
+// slashes, as in a stream name containing %2F, do not separate segments.
+func pathSegments(u *url.URL) ([]string, error) {
+	split := strings.Split(strings.TrimLeft(u.EscapedPath(), "/"), "/")
+	for i, v := range split {
+		s, err := url.PathUnescape(v)
+		if err != nil {
+			return nil, err
+		}
+		split[i] = s
+	}
+	return split, nil
+}
+
+// streamsSegment returns the index of the first "streams" segment of a path
+// that is followed by one of the numbers of segments given, or -1 if there is
+// none. The segments before it are the base path under which the simulator is
+// mounted.
+func streamsSegment(split []string, lengths ...int) int {
+	for i, v := range split {
+		if v != "streams" {
+			continue
+		}
+		for _, l := range lengths {
+			if len(split)-i == l {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func reverseEventSlice(s []*eventdata.Event) []*eventdata.Event {
+	r := []*eventdata.Event{}
+	for i := len(s) - 1; i >= 0; i-- {
+		r = append(r, s[i])
+	}
+	return r
+}
+
+func resolveEvent(events []*eventdata.Event, url string) (*eventdata.Event, error) {
+	n, head, err := parseEventURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if head {
+		if len(events) == 0 {
+			return nil, EventNotFoundError(0)
+		}
+		return events[len(events)-1], nil
+	}
+	if i := positionOf(events, n); i >= 0 {
+		return events[i], nil
+	}
+	return nil, EventNotFoundError(n)
+}
+
+// positionOf returns the index of the event number n in events, which are
+// numbered consecutively, or -1 if there is no such event.
+func positionOf(events []*eventdata.Event, n int) int {
+	if len(events) == 0 {
+		return -1
+	}
+	i := n - events[0].EventNumber
+	if i < 0 || i >= len(events) || events[i].EventNumber != n {
+		return -1
+	}
+	return i
+}
+
+// parseEventURL returns the event number addressed by url, or head if it
+// addresses the head of the stream.
+func parseEventURL(url string) (n int, head bool, err error) {
+	trimmed := strings.TrimRight(url, "/")
+	if strings.HasSuffix(trimmed, "/head") {
+		return 0, true, nil
+	}
+
+	// The event number is the run of digits at the end of the url.
+	j := len(trimmed)
+	for j > 0 && trimmed[j-1] >= '0' && trimmed[j-1] <= '9' {
+		j--
+	}
+	i, err := strconv.ParseInt(trimmed[j:], 10, 0)
+	if err != nil {
+		return 0, false, err
+	}
+	return int(i), false, nil
+}
+
+// eventIndex returns the index of the event addressed by url in a stream of n
+// events numbered from 0.
+func eventIndex(n int, url string) (int, error) {
+	i, head, err := parseEventURL(url)
+	if err != nil {
+		return 0, err
+	}
+	if head {
+		i = n - 1
+	}
+	if i < 0 || i >= n {
+		if head {
+			i = 0
+		}
+		return 0, EventNotFoundError(i)
+	}
+	return i, nil
+}
+
+const (
+	mediaTypeAtomJSON  = "application/vnd.eventstore.atom+json"
+	mediaTypeEventJSON = "application/vnd.eventstore.event+json"
+	mediaTypeJSON      = "application/json"
+)
+
+// negotiateEventMediaType returns the media type that should be used to
+// represent a single event given the value of the Accept header.
+// The atom representation is used when no supported type is requested.
+func negotiateEventMediaType(accept string) string {
+	for _, v := range strings.Split(accept, ",") {
+		mt := strings.TrimSpace(strings.Split(v, ";")[0])
+		switch mt {
+		case mediaTypeAtomJSON, mediaTypeEventJSON, mediaTypeJSON:
+			return mt
+		}
+	}
+	return mediaTypeAtomJSON
+}
+
+// StreamURL describes a request for a feed page. Host is the base url of the
+// server, including any base path under which the simulator is mounted, and
+// Query is the encoded query string of the request, such as embed=body.
+type StreamURL struct {
+	Host      string
+	Stream    string
+	Direction string
+	Version   int
+	PageSize  int
+	Head      bool
+	Query     string
+}
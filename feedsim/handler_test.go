@@ -0,0 +1,830 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+type MockSuite struct{}
+
+var (
+
+	// mux is the HTTP request multiplexer used with the test server
+	mux *http.ServeMux
+
+	// server is a test HTTP server used to provide mack API responses
+	server *httptest.Server
+
+	_ = Suite(&MockSuite{})
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+func (s *MockSuite) SetUpTest(c *C) {
+	mux = http.NewServeMux()
+	server = httptest.NewServer(mux)
+}
+
+func (s *MockSuite) TearDownTest(c *C) {
+	server.Close()
+}
+
+func getStatus(c *C, u string) int {
+	resp, err := http.Get(u)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// Test that an attempt to construct a simulator with no events returns an error
+func (s *MockSuite) TestCreateSimulatorWithNoEventsReturnsError(c *C) {
+	stream := "noevents-stream"
+	es := eventdata.CreateTestEvents(0, stream, server.URL, "EventTypeY")
+
+	handler, err := NewAtomFeedSimulator(WithEvents(es...), WithTrickle(0))
+
+	c.Assert(err, NotNil)
+	c.Assert(errors.Is(err, ErrNoEvents), Equals, true)
+	c.Assert(handler, IsNil)
+
+	handler, err = NewAtomFeedSimulatorFromEvents(es, nil, nil, 0)
+
+	c.Assert(errors.Is(err, ErrNoEvents), Equals, true)
+	c.Assert(handler, IsNil)
+}
+
+// Test that the deprecated positional constructor is equivalent to the options
+func (s *MockSuite) TestCreateSimulatorFromEvents(c *C) {
+	stream := "fromevents-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	m := eventdata.CreateTestEvents(1, stream, server.URL, "metadata")[0]
+	u, _ := url.Parse(server.URL)
+
+	handler, err := NewAtomFeedSimulatorFromEvents(es, u, m, 4)
+
+	c.Assert(err, IsNil)
+	c.Assert(handler.Events, DeepEquals, es)
+	c.Assert(handler.BaseURL, Equals, u)
+	c.Assert(handler.MetaData, Equals, m)
+	c.Assert(handler.TrickleAfter, Equals, 4)
+
+	handler, err = NewAtomFeedSimulatorFromEvents(es, u, nil, -1)
+
+	c.Assert(err, IsNil)
+	c.Assert(handler.TrickleAfter, Equals, len(es))
+}
+
+// Test that a simulator restricted to a stream does not serve other streams
+func (s *MockSuite) TestWithStream(c *C) {
+	stream := "named-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithStream(stream))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	c.Assert(getStatus(c, server.URL+"/streams/"+stream), Equals, http.StatusOK)
+	c.Assert(getStatus(c, server.URL+"/streams/"+stream+"/0"), Equals, http.StatusOK)
+	c.Assert(getStatus(c, server.URL+"/streams/other-stream"), Equals, http.StatusNotFound)
+	c.Assert(getStatus(c, server.URL+"/streams/other-stream/0"), Equals, http.StatusNotFound)
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithStream(""))
+	c.Assert(err, NotNil)
+}
+
+func (s *MockSuite) TestGetEventResponse(c *C) {
+	stream := "astream-54"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeA")
+	e := es[0]
+
+	b, err := json.Marshal(e)
+	raw := json.RawMessage(b)
+
+	timeStr := eventdata.Time(time.Now())
+
+	want := &eventdata.EventAtomResponse{
+		Title:   fmt.Sprintf("%d@%s", e.EventNumber, stream),
+		ID:      e.Links[0].URI,
+		Updated: timeStr,
+		Summary: e.EventType,
+		Content: &raw,
+	}
+
+	got, err := eventdata.CreateTestEventAtomResponse(e, &timeStr)
+	c.Assert(err, IsNil)
+	c.Assert(got, DeepEquals, want)
+}
+
+func (s *MockSuite) TestResolveEvent(c *C) {
+	stream := "astream5"
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	eu := fmt.Sprintf("%s/streams/%s/%d/", server.URL, stream, 9)
+
+	got, err := resolveEvent(es, eu)
+
+	c.Assert(err, IsNil)
+	c.Assert(got, DeepEquals, es[9])
+}
+
+func (s *MockSuite) TestGetSliceSectionForwardFromZero(c *C) {
+	es := eventdata.CreateTestEvents(15, "x", "x", "x")
+
+	w := getSliceSection(es, 0, 10, "forward", LegacyPaging)
+	sl := w.Events
+
+	c.Assert(sl, HasLen, 10)
+	c.Assert(w.IsFirstPage, Equals, false)
+	c.Assert(w.IsLastPage, Equals, true)
+	c.Assert(w.IsHead, Equals, false)
+	c.Assert(sl[0].EventNumber, Equals, 0)
+	c.Assert(sl[len(sl)-1].EventNumber, Equals, 9)
+}
+
+// Testing a slice from the middle of the strem not exceeding any bounds.
+func (s *MockSuite) TestGetSliceSectionForward(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 25, 50, "forward", LegacyPaging)
+	se := w.Events
+
+	c.Assert(se, HasLen, 50)
+	c.Assert(w.IsFirstPage, Equals, false)
+	c.Assert(w.IsLastPage, Equals, false)
+	c.Assert(w.IsHead, Equals, false)
+	c.Assert(w.NextVersion, Equals, 24)
+	c.Assert(w.PrevVersion, Equals, 75)
+
+	c.Assert(se[0].EventNumber, Equals, 25)
+	c.Assert(se[len(se)-1].EventNumber, Equals, 74)
+}
+
+// Testing a slice from the middle of the stream not exceeding any bounds
+func (s *MockSuite) TestGetSliceSectionBackward(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 75, 50, "backward", LegacyPaging)
+	se := w.Events
+
+	c.Assert(se, HasLen, 50)
+	c.Assert(w.IsFirstPage, Equals, false)
+	c.Assert(w.IsLastPage, Equals, false)
+	c.Assert(w.IsHead, Equals, false)
+	c.Assert(se[0].EventNumber, Equals, 26)
+	c.Assert(se[len(se)-1].EventNumber, Equals, 75)
+}
+
+// Version number is in range, but page number means the set will exceed
+// the number of events in the stream.
+func (s *MockSuite) TestGetSliceSectionBackwardUnder(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 25, 50, "backward", LegacyPaging)
+	se := w.Events
+
+	c.Assert(se, HasLen, 26)
+	c.Assert(w.IsFirstPage, Equals, false)
+	c.Assert(w.IsLastPage, Equals, true)
+	c.Assert(w.IsHead, Equals, false)
+	c.Assert(se[0].EventNumber, Equals, 0)
+	c.Assert(se[len(se)-1].EventNumber, Equals, 25)
+}
+
+// Testing the case where the version may be over the
+// size of the highest version. This will happen when
+// polling the head of the stream waiting for changes
+func (s *MockSuite) TestGetSliceSectionForwardOut(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 101, 50, "forward", LegacyPaging)
+	se := w.Events
+
+	c.Assert(se, HasLen, 0)
+	c.Assert(w.IsFirstPage, Equals, true)
+	c.Assert(w.IsLastPage, Equals, false)
+	c.Assert(w.IsHead, Equals, true)
+	c.Assert(w.NextVersion, Equals, 99)
+	c.Assert(w.PrevVersion, Equals, -1)
+}
+
+// Version number is in range but version plus pagesize is greter the the highest
+// event number and so the query exeeds the number of results that can be returned
+func (s *MockSuite) TestGetSliceSectionForwardOver(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 75, 50, "forward", LegacyPaging)
+	se := w.Events
+	c.Assert(se, HasLen, 25)
+	c.Assert(w.IsFirstPage, Equals, true)
+	c.Assert(w.IsLastPage, Equals, false)
+	c.Assert(w.IsHead, Equals, true)
+	c.Assert(se[0].EventNumber, Equals, 75)
+	c.Assert(se[len(se)-1].EventNumber, Equals, 99)
+}
+
+// This test covers the case where the version is higher than the highest version
+func (s *MockSuite) TestGetSliceSectionTail(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 100, 20, "forward", LegacyPaging)
+	se := w.Events
+
+	c.Assert(se, HasLen, 0)
+	c.Assert(w.IsFirstPage, Equals, true)
+	c.Assert(w.IsLastPage, Equals, false)
+	c.Assert(w.IsHead, Equals, true)
+}
+
+func (s *MockSuite) TestGetSliceSectionAllForward(c *C) {
+	es := eventdata.CreateTestEvents(100, "x", "x", "x")
+
+	w := getSliceSection(es, 0, 100, "forward", LegacyPaging)
+	se := w.Events
+
+	c.Assert(se, HasLen, 100)
+	c.Assert(w.IsFirstPage, Equals, true)
+	c.Assert(w.IsLastPage, Equals, true)
+	c.Assert(w.IsHead, Equals, true)
+	c.Assert(se[0].EventNumber, Equals, 0)
+	c.Assert(se[len(se)-1].EventNumber, Equals, 99)
+}
+
+func (s *MockSuite) TestParseURLVersioned(c *C) {
+	srv := "http://localhost:2113"
+	stream := "An-Qw3334rd-St333"
+	ver := 50
+	direction := "backward"
+	pageSize := 10
+
+	url := fmt.Sprintf("%s/streams/%s/%d/%s/%d", srv, stream, ver, direction, pageSize)
+
+	er, err := ParseStreamURL(url)
+
+	c.Assert(err, IsNil)
+	c.Assert(er.Host, Equals, srv)
+	c.Assert(er.Stream, Equals, stream)
+	c.Assert(er.Version, Equals, ver)
+	c.Assert(er.Direction, Equals, direction)
+	c.Assert(er.PageSize, Equals, pageSize)
+}
+
+func (s *MockSuite) TestParseURLInvalidVersion(c *C) {
+	srv := "http://localhost:2113"
+	stream := "An-Qw3334rd-St333"
+	pageSize := 20
+	direction := "backward"
+	version := -1
+	url := fmt.Sprintf("%s/streams/%s/%d/%s/%d", srv, stream, version, direction, pageSize)
+
+	_, err := ParseStreamURL(url)
+
+	c.Assert(err, FitsTypeOf, InvalidVersionError(version))
+
+	var verr InvalidVersionError
+	c.Assert(errors.As(err, &verr), Equals, true)
+	c.Assert(int(verr), Equals, version)
+}
+
+func (s *MockSuite) TestParseURLBase(c *C) {
+	srv := "http://localhost:2113"
+	stream := "An-Qw3334rd-St333"
+	pageSize := 20
+	direction := "backward"
+
+	url := fmt.Sprintf("%s/streams/%s", srv, stream)
+
+	er, err := ParseStreamURL(url)
+
+	c.Assert(err, IsNil)
+	c.Assert(er.Host, Equals, srv)
+	c.Assert(er.Stream, Equals, stream)
+	c.Assert(er.Version, Equals, 0)
+	c.Assert(er.Direction, Equals, direction)
+	c.Assert(er.PageSize, Equals, pageSize)
+	c.Assert(er.Head, Equals, true)
+}
+
+func (s *MockSuite) TestParseURLHead(c *C) {
+	srv := "http://localhost:2113"
+	stream := "An-Qw3334rd-St333"
+	direction := "backward"
+	pageSize := 100
+
+	url := fmt.Sprintf("%s/streams/%s/%s/%s/%d", srv, stream, "head", direction, pageSize)
+
+	er, err := ParseStreamURL(url)
+
+	c.Assert(err, IsNil)
+	c.Assert(er.Host, Equals, srv)
+	c.Assert(er.Stream, Equals, stream)
+	c.Assert(er.Version, Equals, 0)
+	c.Assert(er.Direction, Equals, direction)
+	c.Assert(er.PageSize, Equals, pageSize)
+	c.Assert(er.Head, Equals, true)
+}
+
+func (s *MockSuite) TestCreateFeedLinksBackward(c *C) {
+	stream := "astream"
+	ver := 50
+	url := fmt.Sprintf("%s/streams/%s/%d/backward/20", server.URL, stream, ver)
+
+	selfWant := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	firstWant := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+	lastWant := fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream)
+	nextWant := fmt.Sprintf("%s/streams/%s/30/backward/20", server.URL, stream)
+	prevWant := fmt.Sprintf("%s/streams/%s/51/forward/20", server.URL, stream)
+	metaWant := fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)
+
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	var self, first, next, last, prev, meta bool
+	for _, v := range m.Link {
+
+		switch v.Rel {
+		case "self":
+			self = true
+			c.Assert(v.Href, Equals, selfWant)
+		case "first":
+			first = true
+			c.Assert(v.Href, Equals, firstWant)
+		case "next":
+			next = true
+			c.Assert(v.Href, Equals, nextWant)
+		case "last":
+			last = true
+			c.Assert(v.Href, Equals, lastWant)
+		case "previous":
+			prev = true
+			c.Assert(v.Href, Equals, prevWant)
+		case "metadata":
+			meta = true
+			c.Assert(v.Href, Equals, metaWant)
+		}
+	}
+
+	c.Assert(self, Equals, true)
+	c.Assert(first, Equals, true)
+	c.Assert(next, Equals, true)
+	c.Assert(last, Equals, true)
+	c.Assert(prev, Equals, true)
+	c.Assert(meta, Equals, true)
+}
+
+func (s *MockSuite) TestCreateFeedLinksLast(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream)
+
+	selfWant := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	firstWant := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+	prevWant := fmt.Sprintf("%s/streams/%s/20/forward/20", server.URL, stream)
+	metaWant := fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)
+
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	var self, first, next, last, prev, meta bool
+	for _, v := range m.Link {
+
+		switch v.Rel {
+		case "self":
+			self = true
+			c.Assert(v.Href, Equals, selfWant)
+		case "first":
+			first = true
+			c.Assert(v.Href, Equals, firstWant)
+		case "next":
+			next = true
+		case "last":
+			last = true
+		case "previous":
+			prev = true
+			c.Assert(v.Href, Equals, prevWant)
+		case "metadata":
+			meta = true
+			c.Assert(v.Href, Equals, metaWant)
+		}
+	}
+
+	c.Assert(self, Equals, true)
+	c.Assert(first, Equals, true)
+	c.Assert(next, Equals, false)
+	c.Assert(last, Equals, false)
+	c.Assert(prev, Equals, true)
+	c.Assert(meta, Equals, true)
+}
+
+func (s *MockSuite) TestCreateFeedLinksTail(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/100/forward/20", server.URL, stream)
+
+	selfWant := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	firstWant := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+	lastWant := fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream)
+	nextWant := fmt.Sprintf("%s/streams/%s/99/backward/20", server.URL, stream)
+	metaWant := fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)
+
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	var self, first, next, last, prev, meta bool
+	for _, v := range m.Link {
+
+		switch v.Rel {
+		case "self":
+			self = true
+			c.Assert(v.Href, Equals, selfWant)
+		case "first":
+			first = true
+			c.Assert(v.Href, Equals, firstWant)
+		case "next":
+			next = true
+			c.Assert(v.Href, Equals, nextWant)
+		case "last":
+			last = true
+			c.Assert(v.Href, Equals, lastWant)
+		case "previous":
+			prev = true
+		case "metadata":
+			meta = true
+			c.Assert(v.Href, Equals, metaWant)
+		}
+	}
+
+	c.Assert(self, Equals, true)
+	c.Assert(first, Equals, true)
+	c.Assert(next, Equals, true)
+	c.Assert(last, Equals, true)
+	c.Assert(prev, Equals, false)
+	c.Assert(meta, Equals, true)
+}
+
+func (s *MockSuite) TestCreateFeedLinksHead(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+
+	selfWant := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	firstWant := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+	lastWant := fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream)
+	nextWant := fmt.Sprintf("%s/streams/%s/79/backward/20", server.URL, stream)
+	prevWant := fmt.Sprintf("%s/streams/%s/100/forward/20", server.URL, stream)
+	metaWant := fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)
+
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	var self, first, next, last, prev, meta bool
+	for _, v := range m.Link {
+		switch v.Rel {
+		case "self":
+			self = true
+			c.Assert(v.Href, Equals, selfWant)
+		case "first":
+			first = true
+			c.Assert(v.Href, Equals, firstWant)
+		case "next":
+			next = true
+			c.Assert(v.Href, Equals, nextWant)
+		case "last":
+			last = true
+			c.Assert(v.Href, Equals, lastWant)
+		case "previous":
+			prev = true
+			c.Assert(v.Href, Equals, prevWant)
+		case "metadata":
+			meta = true
+			c.Assert(v.Href, Equals, metaWant)
+		}
+	}
+
+	c.Assert(self, Equals, true)
+	c.Assert(first, Equals, true)
+	c.Assert(next, Equals, true)
+	c.Assert(last, Equals, true)
+	c.Assert(prev, Equals, true)
+	c.Assert(meta, Equals, true)
+
+}
+
+func (s *MockSuite) TestCreateFeedEntriesLast(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	c.Assert(m.Entry, HasLen, 20)
+	for k, v := range m.Entry {
+		num := (20 - 1) - k
+		ti := fmt.Sprintf("%d@%s", num, stream)
+		c.Assert(v.Title, Equals, ti)
+	}
+}
+
+func (s *MockSuite) TestCreateFeedEntries(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/20/forward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	c.Assert(m.Entry, HasLen, 20)
+	for k, v := range m.Entry {
+		num := (40 - 1) - k
+		ti := fmt.Sprintf("%d@%s", num, stream)
+		c.Assert(v.Title, Equals, ti)
+	}
+}
+
+func (s *MockSuite) TestCreateFeedEntriesTail(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/100/forward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+	c.Assert(m.Entry, HasLen, 0)
+}
+
+func (s *MockSuite) TestCreateFeedEntriesHead(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	c.Assert(m.Entry, HasLen, 20)
+	for k, v := range m.Entry {
+		num := (len(es) - 1) - k
+		ti := fmt.Sprintf("%d@%s", num, stream)
+		c.Assert(v.Title, Equals, ti)
+	}
+}
+
+func (s *MockSuite) TestCreateFeedWithNoEvents(c *C) {
+	stream := "empty-stream"
+	f, err := CreateTestFeed([]*eventdata.Event{}, fmt.Sprintf("%s/streams/%s", server.URL, stream))
+
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 0)
+	c.Assert(f.HeadOfStream, Equals, true)
+	c.Assert(f.GetLink("next"), IsNil)
+	c.Assert(f.GetLink("last"), IsNil)
+	c.Assert(f.GetLink("previous").Href, Equals, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream))
+}
+
+func (s *MockSuite) TestReverseSlice(c *C) {
+	es := eventdata.CreateTestEvents(100, "astream", server.URL, "EventTypeX")
+	rs := reverseEventSlice(es)
+
+	c.Assert(rs, HasLen, 100)
+	top := len(es) - 1
+	for i := 0; i <= top; i++ {
+		c.Assert(rs[i].EventNumber, Equals, top-i)
+	}
+}
+
+func (s *MockSuite) TestHeadOfStreamSetTrueWhenAtHeadOfStream(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/90/forward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	c.Assert(m.HeadOfStream, Equals, true)
+}
+
+// If the result is the first page but has not exceeded the number of events
+// the stream is not at the head of the stream. Only when the query exceeds the
+// number of results is the reader at the head of the stream
+func (s *MockSuite) TestHeadOfStreamSetFalseWhenNotAtHeadOfStream(c *C) {
+	stream := "astream"
+	url := fmt.Sprintf("%s/streams/%s/79/forward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	c.Assert(m.HeadOfStream, Equals, false)
+}
+
+func (s *MockSuite) TestSetStreamID(c *C) {
+	stream := "some-stream"
+	url := fmt.Sprintf("%s/streams/%s/90/forward/20", server.URL, stream)
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+	m, _ := CreateTestFeed(es, url)
+
+	c.Assert(m.StreamID, Equals, stream)
+}
+
+func (s *MockSuite) TestResolveEventOutOfRange(c *C) {
+	stream := "astream5"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	eu := fmt.Sprintf("%s/streams/%s/%d", server.URL, stream, 10)
+
+	got, err := resolveEvent(es, eu)
+
+	c.Assert(got, IsNil)
+	c.Assert(err, FitsTypeOf, EventNotFoundError(10))
+}
+
+func (s *MockSuite) TestGetEventContentNegotiation(c *C) {
+	stream := "negotiation-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	handler, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", handler)
+
+	for _, eu := range []string{
+		fmt.Sprintf("%s/streams/%s/%d", server.URL, stream, 3),
+		fmt.Sprintf("%s/streams/%s/%d/", server.URL, stream, 3),
+	} {
+		req, _ := http.NewRequest("GET", eu, nil)
+		req.Header.Set("Accept", "application/vnd.eventstore.event+json")
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		c.Assert(resp.Header.Get("Content-Type"), Equals, "application/vnd.eventstore.event+json; charset=utf-8")
+		got := &eventdata.Event{}
+		err = json.NewDecoder(resp.Body).Decode(got)
+		resp.Body.Close()
+		c.Assert(err, IsNil)
+		c.Assert(got.EventID, Equals, es[3].EventID)
+
+		req.Header.Set("Accept", "application/json")
+		resp, err = http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		c.Assert(resp.Header.Get("Content-Type"), Equals, "application/json; charset=utf-8")
+		data := map[string]string{}
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		resp.Body.Close()
+		c.Assert(err, IsNil)
+		want := map[string]string{}
+		json.Unmarshal(*es[3].Data.(*json.RawMessage), &want)
+		c.Assert(data, DeepEquals, want)
+	}
+}
+
+func (s *MockSuite) TestHeadEventRequestHasNoBody(c *C) {
+	stream := "head-request-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	handler, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", handler)
+
+	resp, err := http.Head(fmt.Sprintf("%s/streams/%s/%d", server.URL, stream, 1))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(b, HasLen, 0)
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "application/vnd.eventstore.atom+json; charset=utf-8")
+}
+
+func (s *MockSuite) TestGetEventOutOfRangeReturnsNotFound(c *C) {
+	stream := "missing-event-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	handler, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", handler)
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/%d", server.URL, stream, 5))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *MockSuite) TestResolveEventHead(c *C) {
+	stream := "astream5"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+
+	for _, eu := range []string{
+		fmt.Sprintf("%s/streams/%s/head", server.URL, stream),
+		fmt.Sprintf("%s/streams/%s/head/", server.URL, stream),
+	} {
+		got, err := resolveEvent(es, eu)
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, es[9])
+	}
+}
+
+func (s *MockSuite) TestGetHeadEventReturnsLatestAvailableEvent(c *C) {
+	stream := "head-event-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	handler, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithTrickle(6))
+	c.Assert(err, IsNil)
+	mux.Handle("/", handler)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s/head", server.URL, stream), nil)
+	req.Header.Set("Accept", "application/vnd.eventstore.event+json")
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	got := &eventdata.Event{}
+	err = json.NewDecoder(resp.Body).Decode(got)
+	c.Assert(err, IsNil)
+	c.Assert(got.EventNumber, Equals, 5)
+	c.Assert(got.EventID, Equals, es[5].EventID)
+}
+
+func (s *MockSuite) TestCreateFeedHeadBackwardMatchesStreamURL(c *C) {
+	stream := "astream"
+	es := eventdata.CreateTestEvents(100, stream, server.URL, "EventTypeX")
+
+	head, err := CreateTestFeed(es, fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream))
+	c.Assert(err, IsNil)
+	base, err := CreateTestFeed(es, fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	c.Assert(err, IsNil)
+
+	c.Assert(head.Entry, HasLen, len(base.Entry))
+	for k := range head.Entry {
+		c.Assert(head.Entry[k].Title, Equals, base.Entry[k].Title)
+	}
+	c.Assert(head.Link, DeepEquals, base.Link)
+	c.Assert(head.HeadOfStream, Equals, true)
+}
+
+// Test that a page that ends just before the head of the stream does not
+// include the last event
+func (s *MockSuite) TestPageEndingBeforeHeadHasPageSizeEntries(c *C) {
+	stream := "astream-112"
+	es := eventdata.CreateTestEvents(6, stream, server.URL, "EventTypeX")
+
+	f, err := CreateTestFeed(es, fmt.Sprintf("%s/streams/%s/2/forward/3", server.URL, stream))
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("4@%s", stream))
+
+	f, err = CreateTestFeed(es, fmt.Sprintf("%s/streams/%s/4/backward/3", server.URL, stream))
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("4@%s", stream))
+}
+
+func (s *MockSuite) TestParseURLRejectsMalformedURLs(c *C) {
+	srv := "http://localhost:2113"
+	for _, u := range []string{
+		srv + "/",
+		srv + "/streams",
+		srv + "/streams/",
+		srv + "/other/astream",
+		srv + "/streams/astream/0/forward",
+	} {
+		_, err := ParseStreamURL(u)
+		c.Assert(err, FitsTypeOf, InvalidURLError(""), Commentf(u))
+	}
+
+	for u, want := range map[string]error{
+		srv + "/streams/astream/abc/forward/20":                  MalformedVersionError("abc"),
+		srv + "/streams/astream/0x10/forward/20":                 MalformedVersionError("0x10"),
+		srv + "/streams/astream/99999999999999999999/forward/20": MalformedVersionError("99999999999999999999"),
+		srv + "/streams/astream/0/sideways/20":                   InvalidDirectionError("sideways"),
+		srv + "/streams/astream/0/Forward/20":                    InvalidDirectionError("Forward"),
+		srv + "/streams/astream/0/forward/abc":                   MalformedPageSizeError("abc"),
+		srv + "/streams/astream/0/forward/99999999999999999999":  MalformedPageSizeError("99999999999999999999"),
+		srv + "/streams/astream/0/forward/0":                     InvalidPageSizeError(0),
+	} {
+		_, err := ParseStreamURL(u)
+		c.Assert(err, Equals, want, Commentf(u))
+	}
+}
+
+func (s *MockSuite) TestMalformedFeedURLsReceiveBadRequest(c *C) {
+	stream := "malformed-stream"
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")...))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	for path, want := range map[string]string{
+		"/abc/forward/20":     `"abc" is not a valid event number`,
+		"/0/sideways/20":      `"sideways" is not a valid direction, expected forward or backward`,
+		"/0/forward/lots":     `"lots" is not a valid page size`,
+		"/0/forward/10000":    "10000 is not a valid page size",
+		"/-1/forward/20":      "-1 is not a valid event number",
+		"/head/backward/20/x": streamURL + "/head/backward/20/x is not a valid stream url",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", streamURL+path, nil))
+		c.Assert(rec.Code, Equals, http.StatusBadRequest, Commentf(path))
+		c.Assert(strings.TrimSpace(rec.Body.String()), Equals, want, Commentf(path))
+	}
+}
+
+func (s *MockSuite) TestBackwardPageBeyondHead(c *C) {
+	stream := "astream-113"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+
+	f, err := CreateTestFeed(es, fmt.Sprintf("%s/streams/%s/100/backward/3", server.URL, stream))
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("4@%s", stream))
+}
@@ -0,0 +1,321 @@
+package feedsim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+)
+
+// CheckpointedReader is a catch-up reader under test. Read reads the stream at
+// streamURL, starting with the event after checkpoint, or with the first event
+// if checkpoint is -1, and carries on reading events as they are appended
+// until ctx is done. It reports the events it processes and the checkpoints
+// it saves to d, and should retry failed requests rather than return.
+type CheckpointedReader interface {
+	Read(ctx context.Context, streamURL string, checkpoint int, d *Delivery) error
+}
+
+// ReaderFunc adapts a function to a CheckpointedReader.
+type ReaderFunc func(ctx context.Context, streamURL string, checkpoint int, d *Delivery) error
+
+// Read calls f.
+func (f ReaderFunc) Read(ctx context.Context, streamURL string, checkpoint int, d *Delivery) error {
+	return f(ctx, streamURL, checkpoint, d)
+}
+
+// DeliveryGuarantee is the guarantee a reader makes about how often each
+// event is processed.
+type DeliveryGuarantee int
+
+const (
+	// AtLeastOnce readers process every event, and process again only the
+	// events after their last checkpoint when they are restarted.
+	AtLeastOnce DeliveryGuarantee = iota
+	// ExactlyOnce readers process every event once, saving their checkpoint
+	// with the processing of each event.
+	ExactlyOnce
+)
+
+// ReaderStep is a step of the script run by a ReaderHarness. The actions of a
+// step are taken in the order of its fields, after which the harness waits
+// for the reader to process every event appended so far.
+type ReaderStep struct {
+	// FailRequests is the number of requests received next that fail with
+	// FailStatus, or 503 Service Unavailable if it is zero.
+	FailRequests int
+	FailStatus   int
+
+	// RestartServer restarts the server, refusing connections for the
+	// duration, if it is not zero.
+	RestartServer time.Duration
+
+	// RestartReader stops the reader and starts it again from its last
+	// checkpoint.
+	RestartReader bool
+
+	// Append is the number of events appended to the stream.
+	Append int
+}
+
+// ReaderHarness drives a CheckpointedReader through a script of appends,
+// restarts and faults, checking that it processes every event as its delivery
+// guarantee requires and that its checkpoints only move forwards and never
+// ahead of the events processed. It is an executable specification of a
+// catch-up reader:
+//
+//	rh := &feedsim.ReaderHarness{Reader: reader, Guarantee: feedsim.AtLeastOnce}
+//	err := rh.Run(
+//		feedsim.ReaderStep{Append: 30},
+//		feedsim.ReaderStep{FailRequests: 3, Append: 5},
+//		feedsim.ReaderStep{RestartReader: true, Append: 5},
+//		feedsim.ReaderStep{RestartServer: 100 * time.Millisecond, Append: 5},
+//	)
+type ReaderHarness struct {
+	Reader    CheckpointedReader
+	Guarantee DeliveryGuarantee
+
+	// Stream is the name of the stream read, "reader-harness" if it is
+	// empty.
+	Stream string
+
+	// Options configure the simulator serving the stream, for example with
+	// WithLongPoll or WithPageSize.
+	Options []Option
+
+	// Timeout is how long the reader is given to process the events of each
+	// step and to stop, 10 seconds if it is zero.
+	Timeout time.Duration
+}
+
+// ReaderHarnessError reports the ways in which a reader failed to meet its
+// guarantees.
+type ReaderHarnessError struct {
+	Violations []string
+}
+
+func (e *ReaderHarnessError) Error() string {
+	return "reader harness: " + strings.Join(e.Violations, "; ")
+}
+
+// Delivery records the events processed and the checkpoints saved by a
+// reader run by a ReaderHarness. Its methods may be called from any
+// goroutine.
+type Delivery struct {
+	mu         sync.Mutex
+	guarantee  DeliveryGuarantee
+	delivered  map[int]int
+	last       int
+	checkpoint int
+	violations []string
+	progressed chan struct{}
+}
+
+// Deliver records that the reader has processed the event eventNumber.
+func (d *Delivery) Deliver(eventNumber int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case eventNumber > d.last+1:
+		d.violate("events %d to %d were skipped", d.last+1, eventNumber-1)
+	case d.delivered[eventNumber] > 0 && d.guarantee == ExactlyOnce:
+		d.violate("event %d was delivered more than once", eventNumber)
+	case eventNumber <= d.checkpoint:
+		d.violate("event %d was delivered again after checkpoint %d", eventNumber, d.checkpoint)
+	}
+	d.delivered[eventNumber]++
+	if eventNumber > d.last {
+		d.last = eventNumber
+	}
+	d.notify()
+}
+
+// Checkpoint records that the reader has saved a checkpoint after processing
+// the event eventNumber.
+func (d *Delivery) Checkpoint(eventNumber int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case eventNumber < d.checkpoint:
+		d.violate("checkpoint moved backwards from %d to %d", d.checkpoint, eventNumber)
+	case eventNumber > d.last:
+		d.violate("checkpoint %d is ahead of the last event delivered, %d", eventNumber, d.last)
+	default:
+		d.checkpoint = eventNumber
+	}
+	d.notify()
+}
+
+// violate records a violation. The caller must hold the lock.
+func (d *Delivery) violate(format string, args ...interface{}) {
+	d.violations = append(d.violations, fmt.Sprintf(format, args...))
+}
+
+// notify wakes the harness waiting for progress. The caller must hold the
+// lock.
+func (d *Delivery) notify() {
+	if d.progressed != nil {
+		close(d.progressed)
+		d.progressed = nil
+	}
+}
+
+// progress returns whether every event up to last has been delivered and a
+// channel that is closed when the reader next makes progress.
+func (d *Delivery) progress(last int) (bool, <-chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.progressed == nil {
+		d.progressed = make(chan struct{})
+	}
+	return d.last >= last, d.progressed
+}
+
+// record records a violation found by the harness.
+func (d *Delivery) record(format string, args ...interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.violate(format, args...)
+}
+
+// Run serves an empty stream and runs the script of steps against the reader,
+// returning a *ReaderHarnessError listing the violations of the guarantees of
+// the reader, if there are any, once the script has finished. Other errors
+// are returned if the stream cannot be served.
+func (rh *ReaderHarness) Run(steps ...ReaderStep) error {
+	stream := rh.Stream
+	if stream == "" {
+		stream = "reader-harness"
+	}
+	timeout := rh.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	srv, err := newUnstartedServer("")
+	if err != nil {
+		return err
+	}
+	u := &url.URL{Scheme: "http", Host: srv.Listener.Addr().String()}
+	o := append([]Option{WithStream(stream), WithBaseURL(u), WithRequestHostLinks()}, rh.Options...)
+	sim, err := newAtomFeedSimulator(o...)
+	if err != nil {
+		srv.Listener.Close()
+		return err
+	}
+	fi := faults.NewFaultInjector(sim)
+	srv.Config.Handler = fi
+	srv.Start()
+	s := &SimulatorServer{Server: srv, Simulator: sim}
+	defer s.Close()
+
+	d := &Delivery{guarantee: rh.Guarantee, delivered: map[int]int{}, last: -1, checkpoint: -1}
+	streamURL := fmt.Sprintf("%s/streams/%s", u, stream)
+	r := rh.start(d, streamURL, -1)
+	appended := 0
+	for i, step := range steps {
+		if step.FailRequests > 0 {
+			status := step.FailStatus
+			if status == 0 {
+				status = http.StatusServiceUnavailable
+			}
+			responses := make([]faults.CannedResponse, step.FailRequests)
+			for j := range responses {
+				responses[j] = faults.CannedResponse{Status: status}
+			}
+			fi.RespondInSequence(".", responses...)
+		}
+		if step.RestartServer > 0 {
+			if err := s.Restart(step.RestartServer); err != nil {
+				r.stop(d, timeout)
+				return err
+			}
+		}
+		if step.RestartReader {
+			r.stop(d, timeout)
+			d.mu.Lock()
+			checkpoint := d.checkpoint
+			d.mu.Unlock()
+			r = rh.start(d, streamURL, checkpoint)
+		}
+		for j := 0; j < step.Append; j++ {
+			sim.Append(eventdata.CreateTestEvent(stream, u.String(), "HarnessEvent", appended, nil, nil))
+			appended++
+		}
+
+		if !d.await(appended-1, timeout) {
+			d.record("step %d: not every event up to %d was delivered within %v", i+1, appended-1, timeout)
+		}
+	}
+	r.stop(d, timeout)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.violations) > 0 {
+		return &ReaderHarnessError{Violations: d.violations}
+	}
+	return nil
+}
+
+// await waits for every event up to last to be delivered, reporting whether
+// they were before the timeout.
+func (d *Delivery) await(last int, timeout time.Duration) bool {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for {
+		done, progressed := d.progress(last)
+		if done {
+			return true
+		}
+		select {
+		case <-progressed:
+		case <-t.C:
+			return false
+		}
+	}
+}
+
+// harnessRun is a run of the reader, from its start to its stop.
+type harnessRun struct {
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// start runs the reader of rh from checkpoint.
+func (rh *ReaderHarness) start(d *Delivery, streamURL string, checkpoint int) *harnessRun {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &harnessRun{cancel: cancel, done: make(chan error, 1)}
+	go func() { r.done <- rh.Reader.Read(ctx, streamURL, checkpoint, d) }()
+	return r
+}
+
+// stop stops the run r, recording a violation if the reader returned before
+// it was stopped or does not return within the timeout.
+func (r *harnessRun) stop(d *Delivery, timeout time.Duration) {
+	select {
+	case err := <-r.done:
+		d.record("reader returned before it was stopped: %v", err)
+		r.cancel()
+		return
+	default:
+	}
+
+	r.cancel()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case err := <-r.done:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			d.record("reader failed when stopped: %v", err)
+		}
+	case <-t.C:
+		d.record("reader did not stop within %v", timeout)
+	}
+}
@@ -0,0 +1,100 @@
+package feedsim
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+// pollingReader is a catch-up reader that polls forward pages of the stream,
+// checkpointing after every event or, if batch is true, after every page.
+func pollingReader(batch bool) ReaderFunc {
+	return func(ctx context.Context, streamURL string, checkpoint int, d *Delivery) error {
+		next := checkpoint + 1
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Millisecond):
+			}
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%d/forward/20", streamURL, next), nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+			if err != nil {
+				continue
+			}
+			f := &atom.Feed{}
+			err = xml.NewDecoder(resp.Body).Decode(f)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || err != nil {
+				continue
+			}
+			for i := len(f.Entry) - 1; i >= 0; i-- {
+				n, _ := strconv.Atoi(strings.SplitN(f.Entry[i].Title, "@", 2)[0])
+				d.Deliver(n)
+				if !batch {
+					d.Checkpoint(n)
+				}
+				next = n + 1
+			}
+			if batch && len(f.Entry) > 0 {
+				d.Checkpoint(next - 1)
+			}
+		}
+	}
+}
+
+func (s *MockSuite) TestReaderHarnessPassesConformingReader(c *C) {
+	steps := []ReaderStep{
+		{Append: 25},
+		{FailRequests: 3, Append: 5},
+		{RestartReader: true, Append: 5},
+		{RestartServer: 50 * time.Millisecond, FailStatus: http.StatusBadGateway, FailRequests: 1, Append: 5},
+	}
+	rh := &ReaderHarness{Reader: pollingReader(false), Guarantee: ExactlyOnce, Timeout: 5 * time.Second}
+	c.Assert(rh.Run(steps...), IsNil)
+
+	rh = &ReaderHarness{Reader: pollingReader(true), Guarantee: AtLeastOnce, Timeout: 5 * time.Second}
+	c.Assert(rh.Run(steps...), IsNil)
+}
+
+func (s *MockSuite) TestReaderHarnessReportsViolations(c *C) {
+	rh := &ReaderHarness{
+		Reader: ReaderFunc(func(ctx context.Context, streamURL string, checkpoint int, d *Delivery) error {
+			d.Deliver(0)
+			d.Deliver(0)
+			d.Deliver(2)
+			d.Checkpoint(5)
+			d.Checkpoint(2)
+			d.Checkpoint(1)
+			<-ctx.Done()
+			return nil
+		}),
+		Guarantee: ExactlyOnce,
+		Timeout:   50 * time.Millisecond,
+	}
+	err := rh.Run(ReaderStep{Append: 4})
+	c.Assert(err, FitsTypeOf, &ReaderHarnessError{})
+	c.Assert(err.(*ReaderHarnessError).Violations, DeepEquals, []string{
+		"event 0 was delivered more than once",
+		"events 1 to 1 were skipped",
+		"checkpoint 5 is ahead of the last event delivered, 2",
+		"checkpoint moved backwards from 2 to 1",
+		"step 1: not every event up to 3 was delivered within 50ms",
+	})
+
+	rh.Reader = ReaderFunc(func(ctx context.Context, streamURL string, checkpoint int, d *Delivery) error {
+		return fmt.Errorf("gave up")
+	})
+	err = rh.Run(ReaderStep{Append: 1})
+	c.Assert(err, ErrorMatches, "reader harness: step 1: .*; reader returned before it was stopped: gave up")
+}
@@ -0,0 +1,150 @@
+package feedsim
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// Routes recognised by the simulator.
+const (
+	RouteFeed     = "feed"
+	RouteEvent    = "event"
+	RouteMetadata = "metadata"
+)
+
+// RequestDetails holds the details the simulator parsed from a request.
+//
+// Route is one of RouteFeed, RouteEvent and RouteMetadata, or empty if the url
+// is not recognised. For feed requests Version, Direction and PageSize
+// describe the page requested and Head is true if the head of the stream was
+// requested. For event requests Version is the number of the event requested,
+// or Head is true if the latest event was requested.
+type RequestDetails struct {
+	Request   *http.Request
+	URL       string
+	Route     string
+	Stream    string
+	Version   int
+	Direction string
+	PageSize  int
+	Head      bool
+}
+
+type hooks struct {
+	onRequest     []func(d RequestDetails)
+	onFeedServed  []func(d RequestDetails, events []*eventdata.Event)
+	onEventServed []func(d RequestDetails, e *eventdata.Event)
+	onError       []func(d RequestDetails, err error)
+}
+
+// OnRequest registers fn to be called with the details of each request before
+// the simulator handles it. The request is not handled until fn returns, so fn
+// can be used to change the behaviour of the simulator or to advance a fake
+// clock at a precise point.
+func OnRequest(fn func(d RequestDetails)) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.hooks.onRequest = append(h.hooks.onRequest, fn)
+		return nil
+	}
+}
+
+// OnFeedServed registers fn to be called after a feed page has been served.
+// events holds the entries of the page in the order they appear in the feed.
+func OnFeedServed(fn func(d RequestDetails, events []*eventdata.Event)) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.hooks.onFeedServed = append(h.hooks.onFeedServed, fn)
+		return nil
+	}
+}
+
+// OnEventServed registers fn to be called after a single event has been served.
+func OnEventServed(fn func(d RequestDetails, e *eventdata.Event)) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.hooks.onEventServed = append(h.hooks.onEventServed, fn)
+		return nil
+	}
+}
+
+// OnError registers fn to be called when the simulator fails to serve a
+// request because of an internal error, such as event data that cannot be
+// encoded. The request receives 500 Internal Server Error. Deliberate
+// failures, such as injected faults, are not reported.
+func OnError(fn func(d RequestDetails, err error)) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.hooks.onError = append(h.hooks.onError, fn)
+		return nil
+	}
+}
+
+// route returns the route that the url u addresses, ignoring its query.
+func (h *AtomFeedSimulator) route(u string) string {
+	u = stripQuery(u)
+	switch {
+	case h.feedRegex.MatchString(u):
+		return RouteFeed
+	case h.eventRegex.MatchString(u):
+		return RouteEvent
+	case h.metaRegex.MatchString(u):
+		return RouteMetadata
+	}
+	return ""
+}
+
+// requestDetails parses the details of the request r for the url reqURL.
+func (h *AtomFeedSimulator) requestDetails(r *http.Request, reqURL *url.URL) RequestDetails {
+	d := RequestDetails{
+		Request: r,
+		URL:     reqURL.String(),
+		Route:   h.route(reqURL.String()),
+		Stream:  streamFromURL(reqURL),
+	}
+
+	switch d.Route {
+	case RouteFeed:
+		if fr, err := ParseStreamURL(d.URL); err == nil {
+			d.Version = fr.Version
+			d.Direction = fr.Direction
+			d.PageSize = fr.PageSize
+			d.Head = fr.Head
+		}
+	case RouteEvent:
+		last := strings.TrimRight(reqURL.EscapedPath(), "/")
+		last = last[strings.LastIndex(last, "/")+1:]
+		if last == "head" {
+			d.Head = true
+		} else if n, err := strconv.Atoi(last); err == nil {
+			d.Version = n
+		}
+	}
+	return d
+}
+
+func (h *AtomFeedSimulator) requestReceived(d RequestDetails) {
+	for _, fn := range h.hooks.onRequest {
+		fn(d)
+	}
+}
+
+func (h *AtomFeedSimulator) feedServed(d RequestDetails, events []*eventdata.Event) {
+	for _, fn := range h.hooks.onFeedServed {
+		fn(d, events)
+	}
+}
+
+func (h *AtomFeedSimulator) eventServed(d RequestDetails, e *eventdata.Event) {
+	for _, fn := range h.hooks.onEventServed {
+		fn(d, e)
+	}
+}
+
+// serverError reports the internal error err and writes a 500 response.
+func (h *AtomFeedSimulator) serverError(w http.ResponseWriter, d RequestDetails, err error) {
+	for _, fn := range h.hooks.onError {
+		fn(d, err)
+	}
+	h.writeError(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,57 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestHooksReceiveRequestDetails(c *C) {
+	stream := "hooked-stream"
+	es := eventdata.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+
+	var requests []RequestDetails
+	var feeds [][]*eventdata.Event
+	var events []*eventdata.Event
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), OnRequest(func(d RequestDetails) {
+		requests = append(requests, d)
+	}),
+		OnFeedServed(func(d RequestDetails, page []*eventdata.Event) {
+			feeds = append(feeds, page)
+		}),
+		OnEventServed(func(d RequestDetails, e *eventdata.Event) {
+			events = append(events, e)
+		}))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	getFeed(c, fmt.Sprintf("%s/streams/%s/10/forward/5", server.URL, stream), nil)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/12/", server.URL, stream)), Equals, http.StatusOK)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/head", server.URL, stream)), Equals, http.StatusOK)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)), Equals, http.StatusOK)
+
+	c.Assert(requests, HasLen, 4)
+	c.Assert(requests[0].Route, Equals, RouteFeed)
+	c.Assert(requests[0].Stream, Equals, stream)
+	c.Assert(requests[0].Version, Equals, 10)
+	c.Assert(requests[0].Direction, Equals, "forward")
+	c.Assert(requests[0].PageSize, Equals, 5)
+	c.Assert(requests[1].Route, Equals, RouteEvent)
+	c.Assert(requests[1].Version, Equals, 12)
+	c.Assert(requests[2].Route, Equals, RouteEvent)
+	c.Assert(requests[2].Head, Equals, true)
+	c.Assert(requests[3].Route, Equals, RouteMetadata)
+
+	c.Assert(feeds, HasLen, 1)
+	c.Assert(feeds[0], HasLen, 5)
+	c.Assert(feeds[0][0].EventNumber, Equals, 14)
+	c.Assert(feeds[0][4].EventNumber, Equals, 10)
+
+	c.Assert(events, HasLen, 2)
+	c.Assert(events[0], Equals, es[12])
+	c.Assert(events[1], Equals, es[29])
+}
@@ -0,0 +1,51 @@
+package feedsim
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+)
+
+type routeLatency struct {
+	pattern *regexp.Regexp
+	latency faults.Latency
+}
+
+// WithLatency delays the responses to requests whose url matches the regular
+// expression pattern by the duration returned by l. An empty pattern matches
+// every request. When several patterns match a request the latency that was
+// configured first is used.
+//
+// Any func(r *http.Request) time.Duration can be converted to a
+// faults.Latency, so delays can be computed from the request, for example to
+// slow down only long polls.
+//
+// Waiting is abandoned if the client cancels the request or the simulator is
+// shut down.
+func WithLatency(pattern string, l faults.Latency) Option {
+	return func(h *AtomFeedSimulator) error {
+		if l == nil {
+			return errors.New("latency must not be nil")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		h.latencies = append(h.latencies, routeLatency{pattern: re, latency: l})
+		return nil
+	}
+}
+
+// delay waits for the latency configured for the request r. It returns false
+// if the request was cancelled or the simulator shut down while waiting.
+func (h *AtomFeedSimulator) delay(r *http.Request, reqURL string) bool {
+	for _, v := range h.latencies {
+		if !v.pattern.MatchString(reqURL) {
+			continue
+		}
+		return h.sleep(r.Context(), v.latency(r))
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestLatencyAppliesToMatchingRoutes(c *C) {
+	stream := "slow-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithLatency("/metadata$", faults.FixedLatency(100*time.Millisecond)))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	started := time.Now()
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)), Equals, http.StatusOK)
+	c.Assert(time.Since(started) >= 100*time.Millisecond, Equals, true)
+
+	started = time.Now()
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s", server.URL, stream)), Equals, http.StatusOK)
+	c.Assert(time.Since(started) < 100*time.Millisecond, Equals, true)
+}
+
+func (s *MockSuite) TestLatencyFromRequest(c *C) {
+	stream := "slow-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	byHeader := func(r *http.Request) time.Duration {
+		d, _ := time.ParseDuration(r.Header.Get("X-Delay"))
+		return d
+	}
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithLatency("", byHeader))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	req.Header.Set("X-Delay", "50ms")
+	started := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(time.Since(started) >= 50*time.Millisecond, Equals, true)
+}
+
+func (s *MockSuite) TestLatencyRespectsClientTimeout(c *C) {
+	stream := "slow-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithLatency("", faults.FixedLatency(time.Minute)))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err = client.Get(fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	c.Assert(err, NotNil)
+}
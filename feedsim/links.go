@@ -0,0 +1,102 @@
+package feedsim
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// WithRelativeLinks makes the simulator emit the links of feeds and events
+// relative to the server, e.g. /streams/astream/0/forward/20, instead of as
+// absolute urls, as GetEventStore can be configured to do. Clients must then
+// resolve the links against the url of the feed.
+func WithRelativeLinks() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.relativeLinks = true
+		return nil
+	}
+}
+
+// WithRequestHostLinks makes the simulator derive the scheme and host of links
+// from each request rather than from the base url and the urls of the events.
+// The host is taken from the X-Forwarded-Host header or else the Host header,
+// and the scheme from the X-Forwarded-Proto header or else the connection.
+//
+// Events and fixtures created with any server url can then be served from an
+// httptest server on an ephemeral port, or behind a proxy, and clients follow
+// links back to the server they made the request to.
+func WithRequestHostLinks() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.requestHostLinks = true
+		return nil
+	}
+}
+
+// requestHost returns the scheme and host that the client used to make the
+// request r.
+func requestHost(r *http.Request) (scheme, host string) {
+	scheme = "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if v := firstHeaderValue(r, "X-Forwarded-Proto"); v != "" {
+		scheme = v
+	}
+	host = r.Host
+	if v := firstHeaderValue(r, "X-Forwarded-Host"); v != "" {
+		host = v
+	}
+	return scheme, host
+}
+
+// firstHeaderValue returns the first of the comma separated values of the
+// header key, as added by the proxy closest to the client.
+func firstHeaderValue(r *http.Request, key string) string {
+	return strings.TrimSpace(strings.Split(r.Header.Get(key), ",")[0])
+}
+
+// rewriteLink returns uri as the simulator emits it in a response to a
+// request for base.
+func (h *AtomFeedSimulator) rewriteLink(uri string, base *url.URL) string {
+	if !h.relativeLinks && !h.requestHostLinks {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() {
+		return uri
+	}
+	if h.relativeLinks {
+		r := url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery}
+		return r.String()
+	}
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	return u.String()
+}
+
+// rewriteFeedLinks rewrites the links of the feed f and its entries.
+func (h *AtomFeedSimulator) rewriteFeedLinks(f *atom.Feed, base *url.URL) {
+	for i := range f.Link {
+		f.Link[i].Href = h.rewriteLink(f.Link[i].Href, base)
+	}
+	for _, e := range f.Entry {
+		e.ID = h.rewriteLink(e.ID, base)
+		for i := range e.Link {
+			e.Link[i].Href = h.rewriteLink(e.Link[i].Href, base)
+		}
+	}
+}
+
+// rewriteEventLinks returns a copy of the event e with its links rewritten.
+func (h *AtomFeedSimulator) rewriteEventLinks(e *eventdata.Event, base *url.URL) *eventdata.Event {
+	c := *e
+	c.Links = make([]eventdata.Link, len(e.Links))
+	for i, l := range e.Links {
+		l.URI = h.rewriteLink(l.URI, base)
+		c.Links[i] = l
+	}
+	return &c
+}
@@ -0,0 +1,85 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestRelativeLinks(c *C) {
+	stream := "relative-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithRelativeLinks())
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/2", server.URL, stream), nil)
+	c.Assert(f.Link[0].Href, Equals, "/streams/"+stream)
+	for _, l := range f.Link {
+		c.Assert(strings.HasPrefix(l.Href, "/streams/"), Equals, true, Commentf(l.Href))
+	}
+	c.Assert(f.Entry[0].Link[0].Href, Equals, fmt.Sprintf("/streams/%s/1/", stream))
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/1", server.URL, stream))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	var e eventdata.EventAtomResponse
+	c.Assert(json.NewDecoder(resp.Body).Decode(&e), IsNil)
+	c.Assert(e.ID, Equals, fmt.Sprintf("/streams/%s/1/", stream))
+
+	c.Assert(es[1].Links[0].URI, Equals, fmt.Sprintf("%s/streams/%s/1/", server.URL, stream))
+}
+
+func (s *MockSuite) TestRequestHostLinks(c *C) {
+	stream := "host-stream"
+	es := eventdata.CreateTestEvents(5, stream, "http://fixture.example:1234", "EventTypeX")
+	u, _ := url.Parse("http://fixture.example:1234")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithRequestHostLinks())
+	c.Assert(err, IsNil)
+
+	tests := []struct {
+		header http.Header
+		want   string
+	}{
+		{nil, "http://simulator.test:8080"},
+		{http.Header{"X-Forwarded-Host": {"proxy.test"}, "X-Forwarded-Proto": {"https"}}, "https://proxy.test"},
+		{http.Header{"X-Forwarded-Host": {"outer.test, inner.test"}}, "http://outer.test"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/streams/%s/0/forward/2", stream), nil)
+		req.Host = "simulator.test:8080"
+		for k, v := range tt.header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		c.Assert(rec.Code, Equals, http.StatusOK)
+		f := &atom.Feed{}
+		c.Assert(xml.Unmarshal(rec.Body.Bytes(), f), IsNil)
+		for _, l := range f.Link {
+			c.Assert(strings.HasPrefix(l.Href, tt.want+"/streams/"), Equals, true, Commentf(l.Href))
+		}
+		c.Assert(f.Entry[0].Link[0].Href, Equals, fmt.Sprintf("%s/streams/%s/1/", tt.want, stream))
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/streams/%s/1", stream), nil)
+		req.Host = "simulator.test:8080"
+		for k, v := range tt.header {
+			req.Header[k] = v
+		}
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var e eventdata.EventAtomResponse
+		c.Assert(json.Unmarshal(rec.Body.Bytes(), &e), IsNil)
+		c.Assert(e.ID, Equals, fmt.Sprintf("%s/streams/%s/1/", tt.want, stream))
+	}
+}
@@ -0,0 +1,45 @@
+package feedsim
+
+import "github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+
+// NewAtomFeedSimulatorFromChannel constructs an AtomFeedSimulator for a stream
+// whose events are pushed by the test through ch.
+//
+// Unless events are provided using WithEvents the stream starts out empty.
+// Each event received from ch is appended to the stream and becomes visible to
+// readers immediately, so head pages reflect the events sent so far and long
+// poll requests waiting at the head of the stream return as soon as the next
+// event arrives. Events should be numbered sequentially from 0 as they are for
+// NewAtomFeedSimulator.
+//
+// The simulator stops consuming events when ch is closed or the simulator is
+// shut down.
+//
+// opts have the same meaning as for NewAtomFeedSimulator.
+func NewAtomFeedSimulatorFromChannel(ch <-chan *eventdata.Event, opts ...Option) (*AtomFeedSimulator, error) {
+	fs, err := newAtomFeedSimulator(opts...)
+	if err != nil {
+		return nil, err
+	}
+	fs.live = true
+
+	go fs.consume(ch)
+
+	return fs, nil
+}
+
+// consume appends the events received from ch to the stream until ch is closed
+// or the simulator is shut down.
+func (h *AtomFeedSimulator) consume(ch <-chan *eventdata.Event) {
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			h.Append(e)
+		case <-h.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package feedsim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestChannelFeedServesPushedEvents(c *C) {
+	stream := "live-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	ch := make(chan *eventdata.Event)
+	h, err := NewAtomFeedSimulatorFromChannel(ch, WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	defer close(ch)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 0)
+
+	for _, e := range es {
+		ch <- e
+	}
+
+	// Long poll for the last event to ensure all events have been appended.
+	header := http.Header{}
+	header.Set("ES-LongPoll", "5")
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s/2/forward/20", server.URL, stream), header)
+	c.Assert(f.Entry, HasLen, 1)
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("2@%s", stream))
+}
+
+func (s *MockSuite) TestChannelFeedReleasesLongPollOnPush(c *C) {
+	stream := "live-stream"
+	es := eventdata.CreateTestEvents(2, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	ch := make(chan *eventdata.Event, 1)
+	h, err := NewAtomFeedSimulatorFromChannel(ch, WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	defer close(ch)
+
+	ch <- es[0]
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ch <- es[1]
+	}()
+
+	header := http.Header{}
+	header.Set("ES-LongPoll", "10")
+	started := time.Now()
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/1/forward/20", server.URL, stream), header)
+
+	c.Assert(time.Since(started) < 10*time.Second, Equals, true)
+	c.Assert(f.Entry, HasLen, 1)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("1@%s", stream))
+}
+
+// getFeed requests the feed at feedURL with the headers provided and decodes
+// the response.
+func getFeed(c *C, feedURL string, header http.Header) *atom.Feed {
+	req, err := http.NewRequest("GET", feedURL, nil)
+	c.Assert(err, IsNil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	f := &atom.Feed{}
+	err = xml.NewDecoder(resp.Body).Decode(f)
+	c.Assert(err, IsNil)
+	return f
+}
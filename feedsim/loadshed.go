@@ -0,0 +1,54 @@
+package feedsim
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// inFlightLimit sheds the requests received while a number of requests are
+// already being served.
+type inFlightLimit struct {
+	max        int64
+	retryAfter time.Duration
+	inFlight   int64
+}
+
+// WithMaxInFlight limits the number of requests the simulator serves
+// concurrently to max. Requests received while max requests are in flight
+// receive 503 Service Unavailable with a Retry-After header holding
+// retryAfter rounded up to whole seconds, as an overloaded server responds.
+//
+// The limit can be used both to test how clients back off from an overloaded
+// server and to keep the simulator responsive when it is the target of a
+// client load test.
+func WithMaxInFlight(max int, retryAfter time.Duration) Option {
+	return func(h *AtomFeedSimulator) error {
+		if max < 1 {
+			return errors.New("the in flight limit must be at least 1")
+		}
+		h.inFlight = &inFlightLimit{max: int64(max), retryAfter: retryAfter}
+		return nil
+	}
+}
+
+// admit reports whether a request can be served, writing a 503 response if
+// it cannot. If it returns true done must be called once the request has
+// been served.
+func (h *AtomFeedSimulator) admit(w http.ResponseWriter) (ok bool, done func()) {
+	l := h.inFlight
+	if l == nil {
+		return true, func() {}
+	}
+	if atomic.AddInt64(&l.inFlight, 1) > l.max {
+		atomic.AddInt64(&l.inFlight, -1)
+		h.metrics.fault("load_shed")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(l.retryAfter.Seconds()))))
+		h.writeError(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return false, nil
+	}
+	return true, func() { atomic.AddInt64(&l.inFlight, -1) }
+}
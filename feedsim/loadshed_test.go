@@ -0,0 +1,59 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestMaxInFlightShedsLoad(c *C) {
+	stream := "busy-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u),
+		WithLatency("", faults.FixedLatency(300*time.Millisecond)),
+		WithMaxInFlight(2, 1500*time.Millisecond))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	feedURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	var mu sync.Mutex
+	var statuses []int
+	var retryAfter []string
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(feedURL)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, resp.StatusCode)
+			if resp.StatusCode == http.StatusServiceUnavailable {
+				retryAfter = append(retryAfter, resp.Header.Get("Retry-After"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Ints(statuses)
+	c.Assert(statuses, DeepEquals, []int{http.StatusOK, http.StatusOK, http.StatusServiceUnavailable, http.StatusServiceUnavailable})
+	c.Assert(retryAfter, DeepEquals, []string{"2", "2"})
+	c.Assert(h.Requests(), HasLen, 4)
+
+	c.Assert(getStatus(c, feedURL), Equals, http.StatusOK)
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithMaxInFlight(0, time.Second))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,117 @@
+package feedsim
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// LogEntry describes a request served by the simulator.
+type LogEntry struct {
+	Method    string
+	URL       string
+	Route     string
+	Stream    string
+	Version   int
+	Direction string
+	Head      bool
+	Status    int
+	Bytes     int
+	Latency   time.Duration
+}
+
+// Logger receives an entry for every request served by the simulator.
+type Logger interface {
+	LogRequest(e LogEntry)
+}
+
+// LoggerFunc is a function that implements Logger.
+type LoggerFunc func(e LogEntry)
+
+// LogRequest calls fn(e).
+func (fn LoggerFunc) LogRequest(e LogEntry) {
+	fn(e)
+}
+
+// SlogLogger returns a Logger that writes each entry to l as a structured
+// record at the info level.
+func SlogLogger(l *slog.Logger) Logger {
+	return LoggerFunc(func(e LogEntry) {
+		l.LogAttrs(context.Background(), slog.LevelInfo, "request",
+			slog.String("method", e.Method),
+			slog.String("url", e.URL),
+			slog.String("route", e.Route),
+			slog.String("stream", e.Stream),
+			slog.Int("version", e.Version),
+			slog.String("direction", e.Direction),
+			slog.Bool("head", e.Head),
+			slog.Int("status", e.Status),
+			slog.Int("bytes", e.Bytes),
+			slog.Duration("latency", e.Latency))
+	})
+}
+
+// WithLogger makes the simulator log every request it serves to l. By default
+// the simulator logs nothing.
+//
+//	feedsim.WithLogger(feedsim.SlogLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))))
+func WithLogger(l Logger) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.logger = l
+		return nil
+	}
+}
+
+// loggingWriter is an http.ResponseWriter that records the status and size
+// of the response.
+type loggingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (l *loggingWriter) WriteHeader(code int) {
+	if l.status == 0 {
+		l.status = code
+	}
+	l.ResponseWriter.WriteHeader(code)
+}
+
+func (l *loggingWriter) Write(b []byte) (int, error) {
+	if l.status == 0 {
+		l.status = http.StatusOK
+	}
+	n, err := l.ResponseWriter.Write(b)
+	l.bytes += n
+	return n, err
+}
+
+// Flush flushes the data written so far to the client.
+func (l *loggingWriter) Flush() {
+	if fl, ok := l.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+// logRequest logs the request r whose response was written to lw, having
+// started at start.
+func (h *AtomFeedSimulator) logRequest(r *http.Request, lw *loggingWriter, start time.Time) {
+	d := h.requestDetails(r, h.requestURL(r))
+	status := lw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	h.logger.LogRequest(LogEntry{
+		Method:    r.Method,
+		URL:       d.URL,
+		Route:     d.Route,
+		Stream:    d.Stream,
+		Version:   d.Version,
+		Direction: d.Direction,
+		Head:      d.Head,
+		Status:    status,
+		Bytes:     lw.bytes,
+		Latency:   time.Since(start),
+	})
+}
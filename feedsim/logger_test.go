@@ -0,0 +1,69 @@
+package feedsim
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestLogger(c *C) {
+	stream := "logged-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	var mu sync.Mutex
+	var entries []LogEntry
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithLogger(LoggerFunc(func(e LogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, e)
+	})))
+	c.Assert(err, IsNil)
+
+	// The handler is called directly as entries are logged once the handler
+	// returns, which may be after the client has read the response.
+	for _, p := range []string{"/2/forward/2", "/head", "/9"} {
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s%s", server.URL, stream, p), nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(entries, HasLen, 3)
+	c.Assert(entries[0].Method, Equals, "GET")
+	c.Assert(entries[0].Route, Equals, RouteFeed)
+	c.Assert(entries[0].Stream, Equals, stream)
+	c.Assert(entries[0].Version, Equals, 2)
+	c.Assert(entries[0].Direction, Equals, "forward")
+	c.Assert(entries[0].Status, Equals, http.StatusOK)
+	c.Assert(entries[0].Bytes > 0, Equals, true)
+	c.Assert(entries[1].Route, Equals, RouteEvent)
+	c.Assert(entries[1].Head, Equals, true)
+	c.Assert(entries[2].Version, Equals, 9)
+	c.Assert(entries[2].Status, Equals, http.StatusNotFound)
+}
+
+func (s *MockSuite) TestSlogLogger(c *C) {
+	stream := "logged-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, nil))
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithLogger(SlogLogger(l)))
+	c.Assert(err, IsNil)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	for _, attr := range []string{"msg=request", "route=feed", "stream=" + stream, "status=200", "head=true"} {
+		c.Assert(strings.Contains(line, attr), Equals, true, Commentf("%s not in %s", attr, line))
+	}
+}
@@ -0,0 +1,140 @@
+package feedsim
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metrics counts what the simulator has done, for exposition in the Prometheus
+// text format.
+type metrics struct {
+	sync.Mutex
+	requests      map[string]int64
+	eventsServed  int64
+	faults        map[string]int64
+	longPollCount int64
+	longPollSum   time.Duration
+}
+
+// WithMetrics makes the simulator serve metrics in the Prometheus text format
+// at /metrics, beneath any base path, so that a simulator used in a long
+// running integration environment can be monitored like a real dependency.
+//
+// The metrics are
+//
+//	testfeed_requests_total{route="feed|event|metadata|other"}
+//	testfeed_events_served_total
+//	testfeed_faults_total{kind="rate_limit|load_shed|injected"}
+//	testfeed_long_poll_wait_seconds (a summary of the time long polls waited)
+//
+// Faults injected by a faults.FaultInjector are counted when the injector wraps
+// the simulator directly.
+func WithMetrics() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.metrics = &metrics{requests: map[string]int64{}, faults: map[string]int64{}}
+		return nil
+	}
+}
+
+// request counts a request for route.
+func (m *metrics) request(route string) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = "other"
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.requests[route]++
+}
+
+// served counts n served events.
+func (m *metrics) served(n int) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.eventsServed += int64(n)
+}
+
+// fault counts a fault of kind.
+func (m *metrics) fault(kind string) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.faults[kind]++
+}
+
+// RecordFault counts a fault of kind injected in front of the simulator, so
+// that faults.FaultInjector failures appear in the metrics it serves.
+func (h *AtomFeedSimulator) RecordFault(kind string) {
+	h.metrics.fault(kind)
+}
+
+// longPoll records that a long poll waited for d.
+func (m *metrics) longPoll(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.longPollCount++
+	m.longPollSum += d
+}
+
+// write writes the metrics to w in the Prometheus text format.
+func (m *metrics) write(w io.Writer) {
+	m.Lock()
+	defer m.Unlock()
+
+	fmt.Fprintln(w, "# HELP testfeed_requests_total Requests received by route.")
+	fmt.Fprintln(w, "# TYPE testfeed_requests_total counter")
+	for _, k := range sortedKeys(m.requests) {
+		fmt.Fprintf(w, "testfeed_requests_total{route=%q} %d\n", k, m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP testfeed_events_served_total Events served in feeds and as single events.")
+	fmt.Fprintln(w, "# TYPE testfeed_events_served_total counter")
+	fmt.Fprintf(w, "testfeed_events_served_total %d\n", m.eventsServed)
+
+	fmt.Fprintln(w, "# HELP testfeed_faults_total Requests failed by kind of fault.")
+	fmt.Fprintln(w, "# TYPE testfeed_faults_total counter")
+	for _, k := range sortedKeys(m.faults) {
+		fmt.Fprintf(w, "testfeed_faults_total{kind=%q} %d\n", k, m.faults[k])
+	}
+
+	fmt.Fprintln(w, "# HELP testfeed_long_poll_wait_seconds Time long polls waited for events.")
+	fmt.Fprintln(w, "# TYPE testfeed_long_poll_wait_seconds summary")
+	fmt.Fprintf(w, "testfeed_long_poll_wait_seconds_sum %g\n", m.longPollSum.Seconds())
+	fmt.Fprintf(w, "testfeed_long_poll_wait_seconds_count %d\n", m.longPollCount)
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// serveMetrics writes the metrics if u is the url of the metrics of the
+// simulator and reports whether it did so.
+func (h *AtomFeedSimulator) serveMetrics(w http.ResponseWriter, u *url.URL) bool {
+	if h.metrics == nil || !strings.HasSuffix(strings.TrimRight(u.Path, "/"), "/metrics") || h.route(u.String()) != "" {
+		return false
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	h.metrics.write(w)
+	return true
+}
@@ -0,0 +1,71 @@
+package feedsim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	. "gopkg.in/check.v1"
+)
+
+func getMetrics(c *C, u string) string {
+	resp, err := http.Get(u + "/metrics")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), Matches, "text/plain; version=0.0.4.*")
+	b, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	return string(b)
+}
+
+func (s *MockSuite) TestMetrics(c *C) {
+	stream := "metered-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithMetrics())
+	c.Assert(err, IsNil)
+	fi := faults.NewFaultInjector(h)
+	fi.FailNth(4, http.StatusServiceUnavailable)
+	mux.Handle("/", fi)
+
+	getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/1", server.URL, stream)), Equals, http.StatusOK)
+	getFeed(c, fmt.Sprintf("%s/streams/%s/5/forward/20", server.URL, stream), http.Header{"Es-Longpoll": {"1"}})
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/1", server.URL, stream)), Equals, http.StatusServiceUnavailable)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)), Equals, http.StatusOK)
+
+	m := getMetrics(c, server.URL)
+	for _, line := range []string{
+		`testfeed_requests_total{route="event"} 1`,
+		`testfeed_requests_total{route="feed"} 2`,
+		`testfeed_requests_total{route="metadata"} 1`,
+		`testfeed_events_served_total 6`,
+		`testfeed_faults_total{kind="injected"} 1`,
+		`testfeed_long_poll_wait_seconds_count 1`,
+	} {
+		c.Assert(strings.Contains(m, line+"\n"), Equals, true, Commentf("%s not in\n%s", line, m))
+	}
+	c.Assert(h.Requests(), HasLen, 4)
+}
+
+func (s *MockSuite) TestMetricsDisabledByDefault(c *C) {
+	es := eventdata.CreateTestEvents(5, "metrics", server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	resp, err := http.Get(server.URL + "/metrics")
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Not(Matches), "(?s).*testfeed_.*")
+	f := getFeed(c, server.URL+"/streams/metrics", nil)
+	c.Assert(f.Entry, HasLen, 5)
+}
@@ -0,0 +1,42 @@
+package feedsim
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// Feed is a page of an atom feed as served by the simulator and returned by
+// CreateTestFeed. Feeds can be decoded from responses with DecodeFeed and
+// compared with those built by CreateTestFeed, or built directly as the
+// expected value of an assertion.
+type Feed = atom.Feed
+
+// FeedEntry is an entry of a Feed, describing one event.
+type FeedEntry = atom.Entry
+
+// FeedLink is a link of a Feed or FeedEntry. Rel is the relation of the link,
+// such as "self", "first", "last", "next", "previous", "metadata", "edit" or
+// "alternate".
+type FeedLink = atom.Link
+
+// FeedPerson is the author of a Feed or FeedEntry.
+type FeedPerson = atom.Person
+
+// FeedText is the summary or content of a FeedEntry.
+type FeedText = atom.Text
+
+// FeedTime is a time formatted as in feeds. A eventdata.TimeStr can be
+// converted to a FeedTime.
+type FeedTime = atom.TimeStr
+
+// DecodeFeed decodes an atom feed page from r, such as the body of a response
+// from the simulator to a request for application/atom+xml.
+func DecodeFeed(r io.Reader) (*Feed, error) {
+	f := &Feed{}
+	if err := xml.NewDecoder(r).Decode(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,68 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestDecodeFeedMatchesCreateTestFeed(c *C) {
+	stream := "model-stream"
+	es := eventdata.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+
+	feedURL := fmt.Sprintf("%s/streams/%s/10/forward/10", server.URL, stream)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", feedURL, nil))
+	got, err := DecodeFeed(rec.Body)
+	c.Assert(err, IsNil)
+
+	want, err := CreateTestFeed(es, feedURL)
+	c.Assert(err, IsNil)
+	c.Assert(got.Entry, HasLen, len(want.Entry))
+	for i, e := range want.Entry {
+		c.Assert(got.Entry[i].ID, Equals, e.ID)
+		c.Assert(got.Entry[i].Link, DeepEquals, e.Link)
+	}
+	c.Assert(got.GetLink("self"), DeepEquals, &FeedLink{Rel: "self", Href: fmt.Sprintf("%s/streams/%s", server.URL, stream)})
+	c.Assert(got.Author, DeepEquals, &FeedPerson{Name: "EventStore"})
+
+	_, err = DecodeFeed(rec.Body)
+	c.Assert(err, NotNil)
+}
+
+func (s *MockSuite) TestEventAtomResponseEvent(c *C) {
+	stream := "model-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+
+	er, err := eventdata.CreateTestEventAtomResponse(es[1], nil)
+	c.Assert(err, IsNil)
+	e, err := er.Event()
+	c.Assert(err, IsNil)
+	c.Assert(e.EventID, Equals, es[1].EventID)
+	c.Assert(e.Links, DeepEquals, es[1].Links)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("%s/streams/%s/2", server.URL, stream), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	decoded := &eventdata.EventAtomResponse{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), decoded), IsNil)
+	e, err = decoded.Event()
+	c.Assert(err, IsNil)
+	c.Assert(e.EventNumber, Equals, 2)
+	c.Assert(e.EventType, Equals, "EventTypeX")
+
+	_, err = (&eventdata.EventAtomResponse{}).Event()
+	c.Assert(err, ErrorMatches, "event atom response has no content")
+}
@@ -0,0 +1,78 @@
+package feedsim
+
+import (
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// Option configures optional behaviour of an AtomFeedSimulator.
+//
+// Options are passed to NewAtomFeedSimulator and are applied in order once
+// the simulator has been constructed.
+type Option func(*AtomFeedSimulator) error
+
+// WithEvents sets the events of the simulated stream.
+//
+// The events are equivalent to the total number of events in a stream and
+// these can be read and paged as you would read and page a stream in
+// GetEventStore. Events should be numbered sequentially from 0.
+func WithEvents(events ...*eventdata.Event) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.Events = events
+		return nil
+	}
+}
+
+// WithBaseURL sets the base url of the test server, which is used to resolve
+// the relative urls of incoming requests.
+func WithBaseURL(u *url.URL) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.BaseURL = u
+		return nil
+	}
+}
+
+// WithMetaData sets the stream metadata that is returned if a request for
+// metadata is made to the server.
+func WithMetaData(m *eventdata.Event) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.MetaData = m
+		return nil
+	}
+}
+
+// WithStream sets the name of the simulated stream. Requests for any other
+// stream receive 404 Not Found unless another option, such as
+// WithEmptyStream, describes the stream.
+//
+// By default the events are served for whichever stream is requested.
+func WithStream(stream string) Option {
+	return func(h *AtomFeedSimulator) error {
+		if stream == "" {
+			return ErrEmptyStreamName
+		}
+		h.stream = stream
+		return nil
+	}
+}
+
+// WithTrickle is used to simulate polling and the arrival of new events while
+// polling.
+//
+// The simulator will return any events after the version specified by the
+// after argument. For example, if ten events are provided and after is set to
+// 5, the first five events will be returned in a feed page as if they existed
+// before the request and then a subsequent poll to the head of the stream will
+// return no events. Set the LongPoll header and the simulator will return the
+// next five events at some random interval between 0 seconds and the number
+// of seconds specified by the value of the LongPoll header.
+//
+// By default all events are returned as existing, which can also be requested
+// by setting after to -1.
+func WithTrickle(after int) Option {
+	return func(h *AtomFeedSimulator) error {
+		h.TrickleAfter = after
+		return nil
+	}
+}
@@ -0,0 +1,38 @@
+package feedsim
+
+import (
+	"net/http"
+	"regexp"
+)
+
+type override struct {
+	pattern *regexp.Regexp
+	handler http.Handler
+}
+
+// Override replaces the behaviour of the simulator for requests whose url
+// matches the regular expression pattern with the handler h, leaving every
+// other route intact. For example the metadata endpoint can be replaced with
+//
+//	sim.Override("/metadata$", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ... }))
+//
+// When several overrides match a request the one registered last is used.
+// Override panics if the pattern cannot be compiled.
+func (h *AtomFeedSimulator) Override(pattern string, handler http.Handler) {
+	re := regexp.MustCompile(pattern)
+	h.Lock()
+	defer h.Unlock()
+	h.overrides = append(h.overrides, override{pattern: re, handler: handler})
+}
+
+// overrideFor returns the handler overriding the url u or nil if there is none.
+func (h *AtomFeedSimulator) overrideFor(u string) http.Handler {
+	h.RLock()
+	defer h.RUnlock()
+	for i := len(h.overrides) - 1; i >= 0; i-- {
+		if h.overrides[i].pattern.MatchString(u) {
+			return h.overrides[i].handler
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package feedsim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestOverrideReplacesSingleRoute(c *C) {
+	stream := "overridden-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	h.Override("/metadata$", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"$maxCount": 10}`)
+	}))
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream))
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"$maxCount": 10}`)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 5)
+
+	h.Override("/metadata$", http.NotFoundHandler())
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)), Equals, http.StatusNotFound)
+}
@@ -0,0 +1,87 @@
+package feedsim
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// pageCache holds the rendered feed pages that can no longer change.
+type pageCache struct {
+	sync.Mutex
+	pages map[StreamURL]*cachedPage
+}
+
+// cachedPage is a rendered feed page and the events on it.
+type cachedPage struct {
+	feed *atom.Feed
+	page []*eventdata.Event
+	body []byte
+}
+
+// WithPageCache makes the simulator cache the rendered feed pages that no
+// longer change, which is every page except the pages at the head of the
+// stream, so that load tests with many clients reading the same pages are not
+// limited by rendering them again.
+//
+// Pages are dropped from the cache when the stream is deleted, restored or
+// reset and, when feeds carry an eTag that changes as events are appended,
+// when events are appended. Cached pages keep the updated time of the feed
+// they were rendered at. The events of a simulator with a page cache must
+// only be modified through its methods.
+func WithPageCache() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.pages = &pageCache{pages: map[StreamURL]*cachedPage{}}
+		return nil
+	}
+}
+
+// get returns the cached page requested by r.
+func (c *pageCache) get(r StreamURL) (*cachedPage, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.Lock()
+	defer c.Unlock()
+	p, ok := c.pages[r]
+	return p, ok
+}
+
+// put returns the rendered body of the feed f requested by r, caching it if
+// the page can no longer change. It returns nil if the page is not cached.
+func (c *pageCache) put(r StreamURL, f *atom.Feed, page []*eventdata.Event) []byte {
+	if c == nil || len(page) == 0 || f.HeadOfStream {
+		return nil
+	}
+	c.Lock()
+	defer c.Unlock()
+	if p, ok := c.pages[r]; ok {
+		return p.body
+	}
+	var buf bytes.Buffer
+	if err := writeFeed(&buf, f); err != nil {
+		return nil
+	}
+	c.pages[r] = &cachedPage{feed: f, page: page, body: buf.Bytes()}
+	return buf.Bytes()
+}
+
+// clear drops every cached page.
+func (c *pageCache) clear() {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.pages = map[StreamURL]*cachedPage{}
+}
+
+// invalidateAppended drops the cached pages that change when events are
+// appended. The caller must hold the lock.
+func (h *AtomFeedSimulator) invalidateAppended() {
+	if h.version.eTag || h.strictHead {
+		h.pages.clear()
+	}
+}
@@ -0,0 +1,80 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) newCachingSimulator(c *C, stream string, rendered *int64, opts ...Option) *AtomFeedSimulator {
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	o := []Option{WithEvents(es...), WithBaseURL(u), WithPageCache(), WithFeedFormat(FeedFormat{
+		Summary: func(e *eventdata.Event) string {
+			atomic.AddInt64(rendered, 1)
+			return e.EventType
+		},
+	})}
+	h, err := NewAtomFeedSimulator(append(o, opts...)...)
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	return h
+}
+
+func (s *MockSuite) TestPageCacheServesImmutablePages(c *C) {
+	stream := "cached-stream"
+	var rendered int64
+	h := s.newCachingSimulator(c, stream, &rendered)
+
+	page := fmt.Sprintf("%s/streams/%s/0/forward/5", server.URL, stream)
+	f := getFeed(c, page, nil)
+	c.Assert(f.Entry, HasLen, 5)
+	c.Assert(atomic.LoadInt64(&rendered), Equals, int64(5))
+
+	f = getFeed(c, page, nil)
+	c.Assert(f.Entry, HasLen, 5)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("4@%s", stream))
+	c.Assert(atomic.LoadInt64(&rendered), Equals, int64(5))
+	c.Assert(h.ReadPosition(stream), Equals, 4)
+
+	head := fmt.Sprintf("%s/streams/%s/5/forward/5", server.URL, stream)
+	getFeed(c, head, nil)
+	getFeed(c, head, nil)
+	c.Assert(atomic.LoadInt64(&rendered), Equals, int64(15))
+
+	h.Append(eventdata.CreateTestEvent(stream, server.URL, "EventTypeX", 10, nil, nil))
+	getFeed(c, page, nil)
+	c.Assert(atomic.LoadInt64(&rendered), Equals, int64(15))
+	f = getFeed(c, head, nil)
+	c.Assert(f.Entry, HasLen, 5)
+	c.Assert(f.HeadOfStream, Equals, false)
+
+	h.DeleteStream(stream, false)
+	c.Assert(getStatus(c, page), Equals, http.StatusNotFound)
+	h.Reset()
+	getFeed(c, page, nil)
+	c.Assert(atomic.LoadInt64(&rendered), Equals, int64(25))
+}
+
+func (s *MockSuite) TestPageCacheInvalidatesETagsOnAppend(c *C) {
+	stream := "cached-stream"
+	var rendered int64
+	h := s.newCachingSimulator(c, stream, &rendered, WithServerVersion("5.x"))
+
+	page := fmt.Sprintf("%s/streams/%s/0/forward/5", server.URL, stream)
+	resp, err := http.Get(page)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.Header.Get("ETag"), Matches, "\"9;.*")
+
+	h.Append(eventdata.CreateTestEvent(stream, server.URL, "EventTypeX", 10, nil, nil))
+	resp, err = http.Get(page)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.Header.Get("ETag"), Matches, "\"10;.*")
+	c.Assert(atomic.LoadInt64(&rendered), Equals, int64(10))
+}
@@ -0,0 +1,101 @@
+package feedsim
+
+import "fmt"
+
+// PageCase is a read of a page of a stream and the events expected on the
+// page under the default paging rules of the simulator, LegacyPaging.
+type PageCase struct {
+	Name      string
+	Version   int
+	Direction string
+	PageSize  int
+
+	// Head is true for the read of the head of the stream, which has no
+	// version.
+	Head bool
+
+	// Events holds the numbers of the events expected on the page, oldest
+	// first. Feeds list their entries newest first.
+	Events []int
+}
+
+// Path returns the path of the page relative to the url of the stream, such
+// as /20/forward/20.
+func (pc PageCase) Path() string {
+	if pc.Head {
+		return fmt.Sprintf("/head/backward/%d", pc.PageSize)
+	}
+	return fmt.Sprintf("/%d/%s/%d", pc.Version, pc.Direction, pc.PageSize)
+}
+
+// URL returns the url of the page of the stream at streamURL, such as
+// http://localhost:2113/streams/orders.
+func (pc PageCase) URL(streamURL string) string {
+	return streamURL + pc.Path()
+}
+
+// PageBoundaryCases returns the reads of a stream of length events, numbered
+// from 0, paged pageSize events at a time that are most likely to expose
+// off by one errors in client paging: the head, the tail, the first and last
+// events in both directions, each page boundary and the versions either side
+// of it, and the empty page past the head. Each case holds the events the
+// simulator serves, so paging code can be table tested against it:
+//
+//	for _, pc := range feedsim.PageBoundaryCases(45, 20) {
+//		got := readPage(pc.URL(streamURL))
+//		if !reflect.DeepEqual(got, pc.Events) {
+//			t.Errorf("%s: got %v, want %v", pc.Name, got, pc.Events)
+//		}
+//	}
+func PageBoundaryCases(length, pageSize int) []PageCase {
+	var cases []PageCase
+	seen := map[string]bool{}
+	add := func(name string, version int, direction string) {
+		pc := PageCase{Name: name, Version: version, Direction: direction, PageSize: pageSize}
+		if version < 0 {
+			pc = PageCase{Name: name, Direction: "backward", PageSize: pageSize, Head: true}
+		}
+		if seen[pc.Path()] {
+			return
+		}
+		seen[pc.Path()] = true
+		first, last := -1, -1
+		if length > 0 {
+			first, last = 0, length-1
+		}
+		v := pc.Version
+		if pc.Head {
+			v = last + 1
+		}
+		start, end, _ := getSliceBounds(length, first, last, v, pageSize, pc.Direction, LegacyPaging)
+		pc.Events = []int{}
+		for n := start; n < end; n++ {
+			pc.Events = append(pc.Events, n)
+		}
+		cases = append(cases, pc)
+	}
+
+	add("head", -1, "")
+	add("tail forward", 0, "forward")
+	if length > 0 {
+		add("last event forward", length-1, "forward")
+		add("last event backward", length-1, "backward")
+		tail := pageSize
+		if length < tail {
+			tail = length
+		}
+		add("tail backward", tail-1, "backward")
+	}
+	for b := pageSize; b <= length; b += pageSize {
+		add(fmt.Sprintf("forward from boundary %d", b), b, "forward")
+		add(fmt.Sprintf("backward from one under boundary %d", b), b-1, "backward")
+		if b < length {
+			add(fmt.Sprintf("backward from boundary %d", b), b, "backward")
+		}
+		if b+1 < length {
+			add(fmt.Sprintf("forward from one over boundary %d", b), b+1, "forward")
+		}
+	}
+	add("empty page past head", length, "forward")
+	return cases
+}
@@ -0,0 +1,47 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestPageBoundaryCases(c *C) {
+	cases := PageBoundaryCases(5, 2)
+	got := map[string][]int{}
+	for _, pc := range cases {
+		got[pc.Path()] = pc.Events
+	}
+	c.Assert(got, DeepEquals, map[string][]int{
+		"/head/backward/2": {3, 4},
+		"/0/forward/2":     {0, 1},
+		"/4/forward/2":     {4},
+		"/4/backward/2":    {3, 4},
+		"/1/backward/2":    {0, 1},
+		"/2/forward/2":     {2, 3},
+		"/2/backward/2":    {1, 2},
+		"/3/forward/2":     {3, 4},
+		"/3/backward/2":    {2, 3},
+		"/5/forward/2":     {},
+	})
+	c.Assert(cases[0].Name, Equals, "head")
+	c.Assert(cases[len(cases)-1].Name, Equals, "empty page past head")
+}
+
+func (s *MockSuite) TestPageBoundaryCasesMatchSimulator(c *C) {
+	u, _ := url.Parse(server.URL)
+	for _, size := range [][2]int{{1, 1}, {20, 20}, {21, 20}, {45, 20}, {7, 3}} {
+		stream := fmt.Sprintf("boundary-%d-%d", size[0], size[1])
+		es := eventdata.CreateTestEvents(size[0], stream, server.URL, "EventTypeX")
+		h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+		c.Assert(err, IsNil)
+		mux.Handle("/streams/"+stream+"/", h)
+
+		for _, pc := range PageBoundaryCases(size[0], size[1]) {
+			got := pageEvents(c, pc.URL(server.URL+"/streams/"+stream))
+			c.Assert(got, DeepEquals, pc.Events, Commentf("%s: %s", stream, pc.Name))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package feedsim
+
+import "errors"
+
+// defaultPageSizeLimits reflects the limits enforced by the EventStore server.
+var defaultPageSizeLimits = pageSizeLimits{min: 1, max: 4096}
+
+type pageSizeLimits struct {
+	min   int
+	max   int
+	clamp bool
+}
+
+// WithPageSizeLimits sets the smallest and largest page sizes the simulator
+// will serve.
+//
+// By default the simulator behaves like the EventStore server and accepts page
+// sizes between 1 and 4096. Requests for a page size outside the limits are
+// rejected with 400 Bad Request unless clamp is true, in which case the page
+// size is clamped to the nearest limit and the page is served.
+func WithPageSizeLimits(min, max int, clamp bool) Option {
+	return func(h *AtomFeedSimulator) error {
+		if min < 1 || max < min {
+			return errors.New("page size limits must satisfy 1 <= min <= max")
+		}
+		h.pageSize = pageSizeLimits{min: min, max: max, clamp: clamp}
+		return nil
+	}
+}
+
+// applyPageSizeLimits checks the page size of the request r against the limits
+// configured for the simulator, clamping it if required.
+func (h *AtomFeedSimulator) applyPageSizeLimits(r *StreamURL) error {
+	l := h.pageSize
+	if r.PageSize >= l.min && r.PageSize <= l.max {
+		return nil
+	}
+	if !l.clamp {
+		return InvalidPageSizeError(r.PageSize)
+	}
+	if r.PageSize < l.min {
+		r.PageSize = l.min
+	} else {
+		r.PageSize = l.max
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestPageSizeAboveServerLimitReturnsBadRequest(c *C) {
+	stream := "paged-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	for _, size := range []int{0, 4097} {
+		resp, err := http.Get(fmt.Sprintf("%s/streams/%s/0/forward/%d", server.URL, stream, size))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/0/forward/%d", server.URL, stream, 4096))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MockSuite) TestPageSizeIsClampedToConfiguredLimits(c *C) {
+	stream := "paged-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithPageSizeLimits(2, 3, true))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/%d", server.URL, stream, 20), nil)
+	c.Assert(f.Entry, HasLen, 3)
+	c.Assert(f.GetLink("previous").Href, Equals, fmt.Sprintf("%s/streams/%s/3/forward/3", server.URL, stream))
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/%d", server.URL, stream, 1), nil)
+	c.Assert(f.Entry, HasLen, 2)
+}
+
+func (s *MockSuite) TestPageSizeLimitsMustBeValid(c *C) {
+	es := eventdata.CreateTestEvents(1, "astream", server.URL, "EventTypeX")
+
+	for _, opt := range []Option{WithPageSizeLimits(0, 10, false), WithPageSizeLimits(10, 5, false)} {
+		h, err := NewAtomFeedSimulator(WithEvents(es...), opt)
+		c.Assert(h, IsNil)
+		c.Assert(err, NotNil)
+	}
+}
@@ -0,0 +1,115 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// LinkBuilder builds the urls of the pages of a stream with the paging rules
+// of the simulator, so clients can assert on links without repeating them.
+//
+// Host is the base url of the server, including any base path, and PageSize
+// is the number of events on each page. Embed, if set, is the embed query
+// parameter carried by the links of the pages, as the server carries the
+// embed parameter of a request across the links of the page it returns.
+// Paging is the paging mode of the simulator, LegacyPaging by default.
+//
+//	b := feedsim.LinkBuilder{Host: "http://localhost:2113", Stream: "orders", PageSize: 20}
+//	b.Next(9) // http://localhost:2113/streams/orders/9/backward/20
+type LinkBuilder struct {
+	Host     string
+	Stream   string
+	PageSize int
+	Embed    string
+	Paging   PagingMode
+}
+
+// NewLinkBuilder returns a LinkBuilder for the stream, page size and embed
+// query parameter of the page addressed by u.
+func NewLinkBuilder(u *StreamURL) LinkBuilder {
+	b := LinkBuilder{Host: u.Host, Stream: u.Stream, PageSize: u.PageSize}
+	if q, err := url.ParseQuery(u.Query); err == nil {
+		b.Embed = q.Get("embed")
+	}
+	return b
+}
+
+// Self returns the url of the stream.
+func (b LinkBuilder) Self() string {
+	return fmt.Sprintf("%s/streams/%s", b.Host, url.PathEscape(b.Stream))
+}
+
+// Page returns the url of the page of the stream starting at version and read
+// in direction, "forward" or "backward".
+func (b LinkBuilder) Page(version int, direction string) string {
+	return fmt.Sprintf("%s/%d/%s/%d%s", b.Self(), version, direction, b.PageSize, b.query())
+}
+
+// First returns the url of the first page, holding the latest events.
+func (b LinkBuilder) First() string {
+	return fmt.Sprintf("%s/head/backward/%d%s", b.Self(), b.PageSize, b.query())
+}
+
+// query returns the query string of the links of the pages.
+func (b LinkBuilder) query() string {
+	if b.Embed == "" {
+		return ""
+	}
+	return "?embed=" + url.QueryEscape(b.Embed)
+}
+
+// Last returns the url of the last page, holding the events from first, the
+// number of the earliest event of the stream.
+func (b LinkBuilder) Last(first int) string {
+	return b.Page(first, "forward")
+}
+
+// Next returns the url of the next page, holding older events, of a page
+// whose earliest event is numbered version+1.
+func (b LinkBuilder) Next(version int) string {
+	return b.Page(version, "backward")
+}
+
+// Previous returns the url of the previous page, holding newer events, of a
+// page whose latest event is numbered version-1.
+func (b LinkBuilder) Previous(version int) string {
+	return b.Page(version, "forward")
+}
+
+// Metadata returns the url of the metadata of the stream.
+func (b LinkBuilder) Metadata() string {
+	return b.Self() + "/metadata"
+}
+
+// Links returns the links of the page starting at version and read in
+// direction of a stream whose events are numbered from first to head, as
+// served by the simulator. head is -1 for a stream with no events. The links
+// of the head of the stream are those of the page at head read backward.
+func (b LinkBuilder) Links(first, head, version int, direction string) []FeedLink {
+	n := 0
+	if head >= 0 {
+		n = head - first + 1
+	}
+	_, _, w := getSliceBounds(n, first, head, version, b.PageSize, direction, b.Paging)
+	return b.links(first, w)
+}
+
+// links returns the links of the page showing the window w of a stream whose
+// oldest event is numbered first. The last and next links are omitted from
+// the last page and the previous link when the window has no previous page.
+func (b LinkBuilder) links(first int, w FeedWindow) []atom.Link {
+	l := []atom.Link{
+		{Href: b.Self(), Rel: "self"},
+		{Href: b.First(), Rel: "first"},
+	}
+	if !w.IsLastPage {
+		l = append(l, atom.Link{Href: b.Last(first), Rel: "last"})
+		l = append(l, atom.Link{Href: b.Next(w.NextVersion), Rel: "next"})
+	}
+	if w.PrevVersion >= 0 {
+		l = append(l, atom.Link{Href: b.Previous(w.PrevVersion), Rel: "previous"})
+	}
+	return append(l, atom.Link{Href: b.Metadata(), Rel: "metadata"})
+}
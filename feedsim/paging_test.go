@@ -0,0 +1,107 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestParseStreamURL(c *C) {
+	u, err := ParseStreamURL("http://localhost:2113/eventstore/streams/orders%2F1/10/forward/5")
+	c.Assert(err, IsNil)
+	c.Assert(u, DeepEquals, &StreamURL{
+		Host:      "http://localhost:2113/eventstore",
+		Stream:    "orders/1",
+		Direction: "forward",
+		Version:   10,
+		PageSize:  5,
+	})
+
+	u, err = ParseStreamURL("http://localhost:2113/streams/orders")
+	c.Assert(err, IsNil)
+	c.Assert(u.Head, Equals, true)
+	c.Assert(u.Direction, Equals, "backward")
+	c.Assert(u.PageSize, Equals, 20)
+
+	_, err = ParseStreamURL("http://localhost:2113/streams/orders/10/sideways/5")
+	c.Assert(err, FitsTypeOf, InvalidDirectionError(""))
+}
+
+func (s *MockSuite) TestLinkBuilderURLs(c *C) {
+	b := LinkBuilder{Host: "http://localhost:2113", Stream: "orders 1", PageSize: 20}
+	c.Assert(b.Self(), Equals, "http://localhost:2113/streams/orders%201")
+	c.Assert(b.First(), Equals, "http://localhost:2113/streams/orders%201/head/backward/20")
+	c.Assert(b.Last(0), Equals, "http://localhost:2113/streams/orders%201/0/forward/20")
+	c.Assert(b.Next(9), Equals, "http://localhost:2113/streams/orders%201/9/backward/20")
+	c.Assert(b.Previous(30), Equals, "http://localhost:2113/streams/orders%201/30/forward/20")
+	c.Assert(b.Metadata(), Equals, "http://localhost:2113/streams/orders%201/metadata")
+}
+
+func (s *MockSuite) TestLinkBuilderMatchesFeeds(c *C) {
+	stream := "paged-stream"
+	host := "http://localhost:2113"
+	es := eventdata.CreateTestEvents(45, stream, host, "EventTypeX")
+	b := LinkBuilder{Host: host, Stream: stream, PageSize: 10}
+
+	for _, direction := range []string{"forward", "backward"} {
+		for version := 0; version < 60; version++ {
+			u := b.Page(version, direction)
+			r, err := ParseStreamURL(u)
+			c.Assert(err, IsNil)
+			f, _, err := createFeed(es, r, time.Now(), LegacyPaging)
+			c.Assert(err, IsNil)
+			c.Assert(b.Links(0, 44, version, direction), DeepEquals, f.Link, Commentf(u))
+		}
+	}
+
+	f, _, err := createFeed(es[:0], &StreamURL{Host: host, Stream: stream, PageSize: 10, Head: true, Direction: "backward"}, time.Now(), LegacyPaging)
+	c.Assert(err, IsNil)
+	c.Assert(b.Links(0, -1, 0, "backward"), DeepEquals, f.Link)
+
+	offset := offsetEvents(stream, 10, 35)
+	for _, version := range []int{15, 20, 30, 44} {
+		r, _ := ParseStreamURL(fmt.Sprintf("%s/streams/%s/%d/backward/10", host, stream, version))
+		f, _, err = createFeed(offset, r, time.Now(), LegacyPaging)
+		c.Assert(err, IsNil)
+		c.Assert(b.Links(10, 44, version, "backward"), DeepEquals, f.Link)
+	}
+}
+
+func (s *MockSuite) TestQueryParametersPreservedAndEmbedPropagated(c *C) {
+	u, err := ParseStreamURL("http://localhost:2113/streams/orders/10/backward/5?embed=body&format=json")
+	c.Assert(err, IsNil)
+	c.Assert(u.Query, Equals, "embed=body&format=json")
+	c.Assert(u.Version, Equals, 10)
+
+	b := NewLinkBuilder(u)
+	c.Assert(b.Embed, Equals, "body")
+	c.Assert(b.Next(4), Equals, "http://localhost:2113/streams/orders/4/backward/5?embed=body")
+	c.Assert(b.Previous(11), Equals, "http://localhost:2113/streams/orders/11/forward/5?embed=body")
+	c.Assert(b.First(), Equals, "http://localhost:2113/streams/orders/head/backward/5?embed=body")
+	c.Assert(b.Self(), Equals, "http://localhost:2113/streams/orders")
+
+	stream := "embedded-stream"
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(30, stream, server.URL, "EventTypeX")...))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s?embed=rich&format=json", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 20)
+	links := map[string]string{}
+	for _, l := range f.Link {
+		links[l.Rel] = l.Href
+	}
+	c.Assert(links["next"], Equals, fmt.Sprintf("%s/streams/%s/9/backward/20?embed=rich", server.URL, stream))
+	c.Assert(links["previous"], Equals, fmt.Sprintf("%s/streams/%s/30/forward/20?embed=rich", server.URL, stream))
+
+	f = getFeed(c, links["next"], nil)
+	c.Assert(f.Entry, HasLen, 10)
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/3?embed=body", server.URL, stream))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+}
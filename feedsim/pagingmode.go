@@ -0,0 +1,45 @@
+package feedsim
+
+import "errors"
+
+// PagingMode selects the rules by which the simulator pages streams near
+// version 0 and the start of a stream.
+type PagingMode int
+
+const (
+	// LegacyPaging reproduces the simulator's historical paging, which is
+	// kept as the default so that existing tests continue to pass. A page
+	// read backward from version 0 holds the newest events of the stream, a
+	// full page of 20 events by default, rather than event 0 alone. A page
+	// read forward from version 0 starts at the first event of the stream
+	// even if the stream has been truncated, while a page read forward from
+	// any other version before the first event is empty.
+	LegacyPaging PagingMode = iota
+	// CleanPaging pages streams by the plain rule that a page read backward
+	// from a version holds the events numbered up to the version, and a
+	// page read forward holds the events numbered from the version, so a
+	// page read backward from version 0 holds event 0 alone and pages read
+	// forward from any version before the first event start at the first
+	// event.
+	CleanPaging
+)
+
+// ServerPaging is the former name of LegacyPaging.
+//
+// Deprecated: Use LegacyPaging, which makes no claim to match the paging of
+// the server.
+const ServerPaging = LegacyPaging
+
+// WithPagingMode sets the paging rules of the simulator. By default the
+// simulator keeps its historical paging, LegacyPaging. CleanPaging is
+// recommended for new tests, as it pages by a plain rule that a client can
+// rely on without depending on the quirks of the simulator.
+func WithPagingMode(m PagingMode) Option {
+	return func(h *AtomFeedSimulator) error {
+		if m != LegacyPaging && m != CleanPaging {
+			return errors.New("unknown paging mode")
+		}
+		h.paging = m
+		return nil
+	}
+}
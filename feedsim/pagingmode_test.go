@@ -0,0 +1,95 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// pageEvents returns the numbers of the events on the page at pageURL, oldest
+// first.
+func pageEvents(c *C, pageURL string) []int {
+	f := getFeed(c, pageURL, nil)
+	got := []int{}
+	for i := len(f.Entry) - 1; i >= 0; i-- {
+		n, err := strconv.Atoi(strings.SplitN(f.Entry[i].Title, "@", 2)[0])
+		c.Assert(err, IsNil)
+		got = append(got, n)
+	}
+	return got
+}
+
+// numbers returns the numbers from first to last.
+func numbers(first, last int) []int {
+	ns := []int{}
+	for n := first; n <= last; n++ {
+		ns = append(ns, n)
+	}
+	return ns
+}
+
+// The historical paging reads a full page of the newest events backward from
+// version 0, starts pages read forward from version 0 at the first event of a
+// truncated stream and serves an empty page forward from other versions before
+// it.
+func (s *MockSuite) TestLegacyPagingNearVersionZero(c *C) {
+	u, _ := url.Parse(server.URL)
+	es := eventdata.CreateTestEvents(50, "server-paging", server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/streams/server-paging/", h)
+	truncated, err := NewAtomFeedSimulator(WithEvents(offsetEvents("server-truncated", 10, 50)...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/streams/server-truncated/", truncated)
+
+	stream := server.URL + "/streams/server-paging"
+	c.Assert(pageEvents(c, stream+"/0/backward/20"), DeepEquals, numbers(30, 49))
+	c.Assert(pageEvents(c, stream+"/1/backward/20"), DeepEquals, numbers(0, 1))
+	c.Assert(pageEvents(c, stream+"/0/forward/20"), DeepEquals, numbers(0, 19))
+
+	stream = server.URL + "/streams/server-truncated"
+	c.Assert(pageEvents(c, stream+"/0/forward/20"), DeepEquals, numbers(10, 29))
+	c.Assert(pageEvents(c, stream+"/5/forward/20"), DeepEquals, []int{})
+	c.Assert(pageEvents(c, stream+"/0/backward/20"), DeepEquals, numbers(40, 59))
+	c.Assert(pageEvents(c, stream+"/5/backward/20"), DeepEquals, []int{})
+}
+
+// Clean paging reads the events numbered up to a version backward and from a
+// version forward, whatever the version.
+func (s *MockSuite) TestCleanPagingNearVersionZero(c *C) {
+	u, _ := url.Parse(server.URL)
+	es := eventdata.CreateTestEvents(50, "clean-paging", server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithPagingMode(CleanPaging))
+	c.Assert(err, IsNil)
+	mux.Handle("/streams/clean-paging/", h)
+	truncated, err := NewAtomFeedSimulator(WithEvents(offsetEvents("clean-truncated", 10, 50)...), WithBaseURL(u), WithPagingMode(CleanPaging))
+	c.Assert(err, IsNil)
+	mux.Handle("/streams/clean-truncated/", truncated)
+
+	stream := server.URL + "/streams/clean-paging"
+	c.Assert(pageEvents(c, stream+"/0/backward/20"), DeepEquals, []int{0})
+	c.Assert(pageEvents(c, stream+"/1/backward/20"), DeepEquals, numbers(0, 1))
+	c.Assert(pageEvents(c, stream+"/0/forward/20"), DeepEquals, numbers(0, 19))
+	c.Assert(pageEvents(c, stream+"/head/backward/20"), DeepEquals, numbers(30, 49))
+
+	stream = server.URL + "/streams/clean-truncated"
+	c.Assert(pageEvents(c, stream+"/0/forward/20"), DeepEquals, numbers(10, 29))
+	c.Assert(pageEvents(c, stream+"/5/forward/20"), DeepEquals, numbers(10, 29))
+	c.Assert(pageEvents(c, stream+"/0/backward/20"), DeepEquals, []int{})
+	c.Assert(pageEvents(c, stream+"/5/backward/20"), DeepEquals, []int{})
+
+	b := LinkBuilder{Host: server.URL, Stream: "clean-paging", PageSize: 20, Paging: CleanPaging}
+	links := map[string]string{}
+	for _, l := range b.Links(0, 49, 0, "backward") {
+		links[l.Rel] = l.Href
+	}
+	c.Assert(links["previous"], Equals, fmt.Sprintf("%s/streams/clean-paging/1/forward/20", server.URL))
+	c.Assert(links["next"], Equals, "")
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithPagingMode(PagingMode(7)))
+	c.Assert(err, ErrorMatches, "unknown paging mode")
+}
@@ -0,0 +1,47 @@
+package feedsim
+
+import (
+	"math/rand"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	. "gopkg.in/check.v1"
+)
+
+var orderPlacedMessage = eventdata.ProtoMessage{Name: "OrderPlaced", Fields: []eventdata.ProtoField{
+	{Name: "order_id", Number: 1, Type: "string"},
+	{Name: "total", Number: 2, Type: "double"},
+	{Name: "delta", Number: 3, Type: "sint32"},
+	{Name: "paid", Number: 4, Type: "bool"},
+}}
+
+func (s *MockSuite) TestBinaryEventsAreServedOverGRPC(c *C) {
+	stream := "binary-stream"
+	schema, err := eventdata.ParseAvroSchema([]byte(`{"type": "record", "name": "Shipped", "fields": [{"name": "id", "type": "int"}]}`))
+	c.Assert(err, IsNil)
+	g := eventdata.NewEventGenerator(rand.NewSource(7))
+	es, err := g.CreateProtobufEvents(3, stream, "https://localhost:2113", orderPlacedMessage)
+	c.Assert(err, IsNil)
+	avro, err := g.CreateAvroEvents(4, stream, "https://localhost:2113", schema)
+	c.Assert(err, IsNil)
+	es = append(es, avro[3])
+	for _, e := range es[:3] {
+		c.Assert(e.EventType, Equals, "OrderPlaced")
+		m, err := protowire.Parse(e.Data.([]byte))
+		c.Assert(err, IsNil)
+		c.Assert(m.String(1), Not(Equals), "")
+	}
+	c.Assert(es[3].EventType, Equals, "Shipped")
+
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+	resps, trailer := grpcCall(c, srv, "Read", grpcReadReq(stream, 2, 0, false, 10))
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "0")
+	got := readEventsOf(c, resps)
+	c.Assert(got, HasLen, 4)
+	md, err := got[3].Map(6)
+	c.Assert(err, IsNil)
+	c.Assert(md["content-type"], Equals, "application/octet-stream")
+	c.Assert(got[3].Bytes(8), DeepEquals, es[3].Data)
+}
@@ -0,0 +1,76 @@
+package feedsim
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// StreamPermission grants users access to the streams whose names match
+// Stream, a pattern in the syntax of path.Match such as "tenant-a-*". Read
+// lists the users allowed to read the streams and Write the users allowed to
+// write them. The user "*" stands for any authenticated user.
+type StreamPermission struct {
+	Stream string
+	Read   []string
+	Write  []string
+}
+
+// WithStreamPermissions restricts access to streams by the user of each
+// request, given by its basic auth credentials, so gateway code that confines
+// each tenant to its own streams can be tested. Requests for a stream matched
+// by a permission are allowed if any permission matching the stream grants
+// the user access, and otherwise receive 403 Forbidden. Streams matched by no
+// permission are not restricted.
+//
+// Permissions are checked after authentication and, with WithSettings, after
+// access control lists, so a request must pass both.
+func WithStreamPermissions(perms ...StreamPermission) Option {
+	return func(h *AtomFeedSimulator) error {
+		for _, p := range perms {
+			if _, err := path.Match(p.Stream, ""); err != nil || p.Stream == "" {
+				return errors.New("stream permissions must have a valid stream pattern")
+			}
+		}
+		h.permissions = append(h.permissions, perms...)
+		return nil
+	}
+}
+
+// permit writes a 403 response if the stream permissions of the simulator
+// deny the request r for the url u. It reports whether the request may
+// proceed.
+func (h *AtomFeedSimulator) permit(w http.ResponseWriter, r *http.Request, u *url.URL) bool {
+	if len(h.permissions) == 0 {
+		return true
+	}
+	stream := streamFromURL(u)
+	if stream == "" {
+		return true
+	}
+
+	username, _, authenticated := r.BasicAuth()
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	matched := false
+	for _, p := range h.permissions {
+		if ok, _ := path.Match(p.Stream, stream); !ok {
+			continue
+		}
+		matched = true
+		users := p.Read
+		if write {
+			users = p.Write
+		}
+		for _, v := range users {
+			if authenticated && (v == "*" || v == username) {
+				return true
+			}
+		}
+	}
+	if !matched {
+		return true
+	}
+	h.writeError(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	return false
+}
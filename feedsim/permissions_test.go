@@ -0,0 +1,46 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestStreamPermissions(c *C) {
+	h, err := NewAtomFeedSimulator(
+		WithEvents(eventdata.CreateTestEvents(3, "tenant-a-orders", server.URL, "EventTypeX")...),
+		WithBasicAuth("alice", "alice-password"),
+		WithBasicAuth("bob", "bob-password"),
+		WithBasicAuth("auditor", "auditor-password"),
+		WithStreamPermissions(
+			StreamPermission{Stream: "tenant-a-*", Read: []string{"alice"}, Write: []string{"alice"}},
+			StreamPermission{Stream: "tenant-*", Read: []string{"auditor"}},
+		))
+	c.Assert(err, IsNil)
+
+	do := func(method, stream, username string) int {
+		body := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+		req := httptest.NewRequest(method, fmt.Sprintf("%s/streams/%s", server.URL, stream), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/vnd.eventstore.events+json")
+		req.SetBasicAuth(username, username+"-password")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	c.Assert(do("GET", "tenant-a-orders", "alice"), Equals, http.StatusOK)
+	c.Assert(do("GET", "tenant-a-orders", "auditor"), Equals, http.StatusOK)
+	c.Assert(do("GET", "tenant-a-orders", "bob"), Equals, http.StatusForbidden)
+	c.Assert(do("POST", "tenant-a-orders", "auditor"), Equals, http.StatusForbidden)
+	c.Assert(do("POST", "tenant-a-orders", "bob"), Equals, http.StatusForbidden)
+	c.Assert(do("POST", "tenant-a-orders", "alice"), Equals, http.StatusCreated)
+	c.Assert(do("GET", "shared-orders", "bob"), Equals, http.StatusOK)
+
+	_, err = NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(1, "a", server.URL, "EventTypeX")...),
+		WithStreamPermissions(StreamPermission{Stream: "[", Read: []string{"*"}}))
+	c.Assert(err, ErrorMatches, "stream permissions must have a valid stream pattern")
+}
@@ -0,0 +1,671 @@
+package feedsim
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+)
+
+// grpcPersistentService is the path prefix of the methods of the
+// PersistentSubscriptions service of EventStoreDB.
+const grpcPersistentService = "/event_store.client.persistent_subscriptions.PersistentSubscriptions/"
+
+// StartFromEnd is the StartFrom of a persistent subscription that receives
+// only the events written after it is created.
+const StartFromEnd = -1
+
+// Defaults of persistent subscription settings, as in EventStoreDB.
+const (
+	defaultMaxRetryCount  = 10
+	defaultMessageTimeout = 30 * time.Second
+	defaultBufferSize     = 10
+)
+
+// PersistentSubscriptionSettings configures a persistent subscription group.
+//
+// Persistent subscriptions are served by the gRPC PersistentSubscriptions
+// service enabled by WithGRPC. Groups are created by clients with Create, or
+// in advance with WithPersistentSubscription, and deleted with Delete. Each
+// Read of a group connects a competing consumer, to which events are
+// dispatched while it has fewer unacknowledged events than its buffer size.
+//
+// An event that is nacked with the retry action, that is not acknowledged
+// within the message timeout or that was in flight to a consumer that
+// disconnected is retried, ahead of events that have yet to be dispatched,
+// and its retry count is incremented. An event that is retried more than the
+// max retry count, or nacked with the park action, is parked and can be
+// inspected with ParkedEvents. An event nacked with the skip action is
+// dropped, and a nack with the stop action ends the Read of the consumer.
+//
+// As consumers settle events the group writes its checkpoint, the number of
+// the last event before the first that is still in flight or to be retried,
+// to the stream named by eventdata.CheckpointStreamName as a
+// $SubscriptionCheckpoint event whose data is the event number. The
+// checkpoint stream is served by the simulator and does not exist until the
+// group first checkpoints.
+type PersistentSubscriptionSettings struct {
+	// StartFrom is the event number of the first event dispatched to the
+	// group, or StartFromEnd.
+	StartFrom int
+
+	// MaxRetryCount is the number of times an event is retried before it is
+	// parked. It defaults to 10.
+	MaxRetryCount int
+
+	// MessageTimeout is the time consumers have to acknowledge an event
+	// before it is retried. It defaults to 30 seconds.
+	MessageTimeout time.Duration
+
+	// MaxSubscriberCount is the largest number of consumers that can connect
+	// to the group at once, or zero for no limit.
+	MaxSubscriberCount int
+}
+
+// persistentGroup is the state of a persistent subscription group. The
+// simulator holds its groups by key, the stream and name of the group.
+type persistentGroup struct {
+	sync.Mutex
+	stream   string
+	name     string
+	settings PersistentSubscriptionSettings
+
+	next      int
+	retries   []*persistentMessage
+	inFlight  map[string]*persistentMessage
+	parked    []*eventdata.Event
+	consumers int
+	nextID    int
+	changed   chan struct{}
+	deleted   chan struct{}
+
+	// checkpoint is the number of the last event checkpointed and
+	// checkpoints serves the checkpoint stream of the group once it has
+	// checkpointed.
+	checkpoint  int
+	checkpoints *AtomFeedSimulator
+}
+
+// persistentMessage is an event dispatched, or to be retried, to the
+// consumers of a group.
+type persistentMessage struct {
+	event      *eventdata.Event
+	retryCount int
+	consumer   int
+	deadline   time.Time
+}
+
+// persistentKey returns the key of the group of stream.
+func persistentKey(stream, group string) string {
+	return stream + "::" + group
+}
+
+// WithPersistentSubscription creates the persistent subscription group to
+// stream with the settings s, as though it had been created by a client, so
+// that consumers can be tested against an existing group. Settings left zero
+// take their defaults.
+func WithPersistentSubscription(stream, group string, s PersistentSubscriptionSettings) Option {
+	return func(h *AtomFeedSimulator) error {
+		if stream == "" {
+			return ErrEmptyStreamName
+		}
+		if group == "" {
+			return errors.New("persistent subscription group name must not be empty")
+		}
+		if _, ok := h.createPersistentGroup(stream, group, s); !ok {
+			return errors.New("persistent subscription group " + persistentKey(stream, group) + " already exists")
+		}
+		return nil
+	}
+}
+
+// startPersistentGroups starts the groups created by options that start from
+// the end of the stream after the events the simulator was created with.
+func (h *AtomFeedSimulator) startPersistentGroups() {
+	for _, g := range h.persistent {
+		if g.next != StartFromEnd {
+			continue
+		}
+		g.next = 0
+		if len(h.Events) > 0 {
+			g.next = h.Events[len(h.Events)-1].EventNumber + 1
+		}
+		g.checkpoint = g.next - 1
+	}
+}
+
+// createPersistentGroup creates the group of stream with the settings s. It
+// reports whether the group was created, which it is not if it already exists.
+func (h *AtomFeedSimulator) createPersistentGroup(stream, group string, s PersistentSubscriptionSettings) (*persistentGroup, bool) {
+	if s.MaxRetryCount <= 0 {
+		s.MaxRetryCount = defaultMaxRetryCount
+	}
+	if s.MessageTimeout <= 0 {
+		s.MessageTimeout = defaultMessageTimeout
+	}
+	h.Lock()
+	defer h.Unlock()
+	key := persistentKey(stream, group)
+	if g, ok := h.persistent[key]; ok {
+		return g, false
+	}
+	if h.persistent == nil {
+		h.persistent = map[string]*persistentGroup{}
+	}
+	g := &persistentGroup{
+		stream:     stream,
+		name:       group,
+		settings:   s,
+		next:       s.StartFrom,
+		inFlight:   map[string]*persistentMessage{},
+		changed:    make(chan struct{}),
+		deleted:    make(chan struct{}),
+		checkpoint: s.StartFrom - 1,
+	}
+	h.persistent[key] = g
+	return g, true
+}
+
+// persistentGroupState is a copy of the state of a persistent subscription
+// group, as held by a snapshot.
+type persistentGroupState struct {
+	stream   string
+	name     string
+	settings PersistentSubscriptionSettings
+	next     int
+	retries  []persistentMessage
+	parked   []*eventdata.Event
+
+	checkpoint  int
+	checkpoints []*eventdata.Event
+}
+
+// state returns a copy of the state of the group. Consumers are not part of
+// the state, so the events in flight to them are copied as events to be
+// retried, oldest first.
+func (g *persistentGroup) state() persistentGroupState {
+	g.Lock()
+	defer g.Unlock()
+	s := persistentGroupState{
+		stream:   g.stream,
+		name:     g.name,
+		settings: g.settings,
+		next:     g.next,
+		parked:   append([]*eventdata.Event(nil), g.parked...),
+
+		checkpoint: g.checkpoint,
+	}
+	if g.checkpoints != nil {
+		g.checkpoints.RLock()
+		s.checkpoints = append(s.checkpoints, g.checkpoints.Events...)
+		g.checkpoints.RUnlock()
+	}
+	for _, m := range g.retries {
+		s.retries = append(s.retries, *m)
+	}
+	var inFlight []persistentMessage
+	for _, m := range g.inFlight {
+		inFlight = append(inFlight, *m)
+	}
+	sort.Slice(inFlight, func(i, j int) bool {
+		return inFlight[i].event.EventNumber < inFlight[j].event.EventNumber
+	})
+	s.retries = append(s.retries, inFlight...)
+	return s
+}
+
+// group returns a group of h without consumers in the state s.
+func (s persistentGroupState) group(h *AtomFeedSimulator) *persistentGroup {
+	g := &persistentGroup{
+		stream:   s.stream,
+		name:     s.name,
+		settings: s.settings,
+		next:     s.next,
+		parked:   append([]*eventdata.Event(nil), s.parked...),
+		inFlight: map[string]*persistentMessage{},
+		changed:  make(chan struct{}),
+		deleted:  make(chan struct{}),
+
+		checkpoint: s.checkpoint,
+	}
+	if len(s.checkpoints) > 0 {
+		name := eventdata.CheckpointStreamName(s.stream, s.name)
+		g.checkpoints, _ = h.newSystemStream(name, append([]*eventdata.Event(nil), s.checkpoints...)...)
+	}
+	for _, m := range s.retries {
+		m := m
+		m.consumer = 0
+		m.deadline = time.Time{}
+		g.retries = append(g.retries, &m)
+	}
+	return g
+}
+
+// persistentGroup returns the group of stream, or nil if there is none.
+func (h *AtomFeedSimulator) persistentGroup(stream, group string) *persistentGroup {
+	h.RLock()
+	defer h.RUnlock()
+	return h.persistent[persistentKey(stream, group)]
+}
+
+// ParkedEvents returns the events parked by the persistent subscription group
+// to stream, in the order they were parked, or nil if there is no such group.
+func (h *AtomFeedSimulator) ParkedEvents(stream, group string) []*eventdata.Event {
+	g := h.persistentGroup(stream, group)
+	if g == nil {
+		return nil
+	}
+	g.Lock()
+	defer g.Unlock()
+	return append([]*eventdata.Event(nil), g.parked...)
+}
+
+// notify wakes the consumers of the group. The caller must hold the lock of
+// the group.
+func (g *persistentGroup) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// notification returns a channel that is closed the next time the state of
+// the group changes.
+func (g *persistentGroup) notification() <-chan struct{} {
+	g.Lock()
+	defer g.Unlock()
+	return g.changed
+}
+
+// connect connects a consumer to the group, returning its id, and reports
+// whether the group had room for it.
+func (g *persistentGroup) connect() (int, bool) {
+	g.Lock()
+	defer g.Unlock()
+	if max := g.settings.MaxSubscriberCount; max > 0 && g.consumers >= max {
+		return 0, false
+	}
+	g.consumers++
+	g.nextID++
+	return g.nextID, true
+}
+
+// disconnect disconnects the consumer, retrying the events in flight to it.
+func (g *persistentGroup) disconnect(consumer int) {
+	g.Lock()
+	defer g.Unlock()
+	g.consumers--
+	for id, m := range g.inFlight {
+		if m.consumer == consumer {
+			delete(g.inFlight, id)
+			g.retry(m)
+		}
+	}
+	g.notify()
+}
+
+// retry queues the message m to be dispatched again, or parks it if it has
+// been retried too many times. The caller must hold the lock of the group.
+func (g *persistentGroup) retry(m *persistentMessage) {
+	m.retryCount++
+	if m.retryCount > g.settings.MaxRetryCount {
+		g.parked = append(g.parked, m.event)
+		return
+	}
+	g.retries = append(g.retries, m)
+}
+
+// take dispatches events to the consumer with the buffer size buffer at now,
+// retries first and then the events es of the stream that have yet to be
+// dispatched, and returns them. Events whose message timeout has expired are
+// retried first. It also returns when the next message timeout expires.
+func (g *persistentGroup) take(consumer, buffer int, es []*eventdata.Event, now time.Time) ([]*persistentMessage, time.Time) {
+	g.Lock()
+	defer g.Unlock()
+
+	n := 0
+	for id, m := range g.inFlight {
+		if !now.Before(m.deadline) {
+			delete(g.inFlight, id)
+			g.retry(m)
+		} else if m.consumer == consumer {
+			n++
+		}
+	}
+
+	var out []*persistentMessage
+	i := 0
+	for n < buffer {
+		var m *persistentMessage
+		if len(g.retries) > 0 {
+			m, g.retries = g.retries[0], g.retries[1:]
+		} else {
+			for i < len(es) && es[i].EventNumber < g.next {
+				i++
+			}
+			if i == len(es) {
+				break
+			}
+			m = &persistentMessage{event: es[i]}
+			g.next = es[i].EventNumber + 1
+		}
+		m.consumer = consumer
+		m.deadline = now.Add(g.settings.MessageTimeout)
+		g.inFlight[strings.ToLower(m.event.EventID)] = m
+		out = append(out, m)
+		n++
+	}
+
+	var wake time.Time
+	for _, m := range g.inFlight {
+		if wake.IsZero() || m.deadline.Before(wake) {
+			wake = m.deadline
+		}
+	}
+	return out, wake
+}
+
+// Actions of a nack.
+const (
+	nackUnknown = 0
+	nackPark    = 1
+	nackRetry   = 2
+	nackSkip    = 3
+	nackStop    = 4
+)
+
+// settle acknowledges, if ack is true, or nacks with the action the events in
+// flight with the ids.
+func (g *persistentGroup) settle(ids []string, ack bool, action int) {
+	g.Lock()
+	defer g.Unlock()
+	for _, id := range ids {
+		m, ok := g.inFlight[strings.ToLower(id)]
+		if !ok {
+			continue
+		}
+		delete(g.inFlight, strings.ToLower(id))
+		switch {
+		case ack, action == nackSkip:
+		case action == nackPark:
+			g.parked = append(g.parked, m.event)
+		default:
+			g.retry(m)
+		}
+	}
+	g.notify()
+}
+
+// servePersistentSubscriptions serves the method of the
+// PersistentSubscriptions service.
+func (h *AtomFeedSimulator) servePersistentSubscriptions(w http.ResponseWriter, r *http.Request, method string) *grpcStatus {
+	switch method {
+	case "Read":
+		return h.persistentRead(w, r)
+	case "Create", "Delete":
+		req, err := readGRPCMessage(r.Body)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		opts, err := req.Message(1)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		if method == "Create" {
+			return h.persistentCreate(w, opts)
+		}
+		return h.persistentDelete(w, opts)
+	}
+	return grpcError(grpcUnimplemented, "method %s is not supported by the simulator", method)
+}
+
+// persistentNotFound returns the status of a call to a group that does not
+// exist.
+func persistentNotFound(stream, group string) *grpcStatus {
+	return &grpcStatus{
+		code:      grpcNotFound,
+		message:   "Subscription group '" + group + "' on stream '" + stream + "' does not exist.",
+		exception: "persistent-subscription-does-not-exist",
+		meta:      map[string]string{"stream-name": stream, "group-name": group},
+	}
+}
+
+// persistentCreate serves PersistentSubscriptions.Create, creating a group
+// with the settings of the request.
+func (h *AtomFeedSimulator) persistentCreate(w http.ResponseWriter, opts protowire.Message) *grpcStatus {
+	if opts.Has(5) {
+		return grpcError(grpcUnimplemented, "persistent subscriptions to $all are not supported by the simulator")
+	}
+	settings, err := opts.Message(3)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	var s PersistentSubscriptionSettings
+	var stream string
+	if opts.Has(4) {
+		so, err := opts.Message(4)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		id, err := so.Message(1)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		stream = grpcStreamName(id)
+		switch {
+		case so.Has(2):
+			s.StartFrom = int(so.Uint(2))
+		case so.Has(4):
+			s.StartFrom = StartFromEnd
+		}
+	} else {
+		id, err := opts.Message(1)
+		if err != nil {
+			return grpcMessageError(err)
+		}
+		stream = grpcStreamName(id)
+		if rev := settings.Uint(2); rev == math.MaxUint64 {
+			s.StartFrom = StartFromEnd
+		} else {
+			s.StartFrom = int(rev)
+		}
+	}
+	group := opts.String(2)
+	if stream == "" || group == "" {
+		return grpcError(grpcInvalidArgument, "persistent subscriptions must have a stream and a group name")
+	}
+
+	s.MaxRetryCount = int(int32(settings.Uint(5)))
+	s.MaxSubscriberCount = int(int32(settings.Uint(9)))
+	switch {
+	case settings.Has(14):
+		s.MessageTimeout = time.Duration(int32(settings.Uint(14))) * time.Millisecond
+	case settings.Has(4):
+		s.MessageTimeout = time.Duration(int64(settings.Uint(4))) * 100
+	}
+	if s.StartFrom == StartFromEnd {
+		s.StartFrom = 0
+		if es, _ := h.grpcEvents(stream); len(es) > 0 {
+			s.StartFrom = es[len(es)-1].EventNumber + 1
+		}
+	}
+
+	if _, ok := h.createPersistentGroup(stream, group, s); !ok {
+		return &grpcStatus{
+			code:      grpcAlreadyExists,
+			message:   "Subscription group '" + group + "' on stream '" + stream + "' exists.",
+			exception: "persistent-subscription-exists",
+			meta:      map[string]string{"stream-name": stream, "group-name": group},
+		}
+	}
+	writeGRPCMessage(w, empty)
+	return nil
+}
+
+// persistentDelete serves PersistentSubscriptions.Delete, deleting the group
+// and ending the Reads of its consumers.
+func (h *AtomFeedSimulator) persistentDelete(w http.ResponseWriter, opts protowire.Message) *grpcStatus {
+	if opts.Has(3) {
+		return grpcError(grpcUnimplemented, "persistent subscriptions to $all are not supported by the simulator")
+	}
+	id, err := opts.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	stream, group := grpcStreamName(id), opts.String(2)
+
+	h.Lock()
+	g, ok := h.persistent[persistentKey(stream, group)]
+	delete(h.persistent, persistentKey(stream, group))
+	h.Unlock()
+	if !ok {
+		return persistentNotFound(stream, group)
+	}
+	close(g.deleted)
+	writeGRPCMessage(w, empty)
+	return nil
+}
+
+// persistentRead serves PersistentSubscriptions.Read. The first message of
+// the call connects a consumer to a group and the messages after it ack or
+// nack the events dispatched to the consumer, which are written to it until
+// the client ends the call, the consumer nacks with the stop action, the group
+// is deleted or the simulator is shut down.
+func (h *AtomFeedSimulator) persistentRead(w http.ResponseWriter, r *http.Request) *grpcStatus {
+	req, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	opts, err := req.Message(1)
+	if err != nil || !req.Has(1) {
+		return grpcError(grpcInvalidArgument, "the first message of a read must hold its options")
+	}
+	if opts.Has(5) {
+		return grpcError(grpcUnimplemented, "persistent subscriptions to $all are not supported by the simulator")
+	}
+	id, err := opts.Message(1)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	stream, group := grpcStreamName(id), opts.String(2)
+	buffer := int(int32(opts.Uint(3)))
+	if buffer <= 0 {
+		buffer = defaultBufferSize
+	}
+	uuids, err := opts.Message(4)
+	if err != nil {
+		return grpcMessageError(err)
+	}
+	structured := !uuids.Has(2)
+
+	g := h.persistentGroup(stream, group)
+	if g == nil {
+		return persistentNotFound(stream, group)
+	}
+	consumer, ok := g.connect()
+	if !ok {
+		return &grpcStatus{
+			code:      grpcFailedPrecondition,
+			message:   "Maximum subscriptions reached.",
+			exception: "maximum-subscribers-reached",
+			meta:      map[string]string{"stream-name": stream, "group-name": group},
+		}
+	}
+	defer g.disconnect(consumer)
+
+	var confirmation []byte
+	confirmation = protowire.AppendString(confirmation, 1, persistentKey(stream, group))
+	writeGRPCMessage(w, protowire.AppendBytes(nil, 2, confirmation))
+
+	stop := make(chan struct{})
+	go h.readSettlements(g, r.Body, stop)
+
+	for {
+		appended := h.appendNotification()
+		changed := g.notification()
+
+		es, err := h.grpcEvents(stream)
+		if _, ok := err.(StreamDeletedError); ok {
+			return grpcStreamDeleted(stream)
+		}
+		ms, wake := g.take(consumer, buffer, es, time.Now())
+		for _, m := range ms {
+			re, err := recordedEvent(m.event, stream, structured)
+			if err != nil {
+				return grpcError(grpcInternal, "%v", err)
+			}
+			var ev []byte
+			ev = protowire.AppendBytes(ev, 1, re)
+			ev = protowire.AppendBytes(ev, 4, empty)
+			ev = protowire.AppendUint(ev, 5, uint64(m.retryCount))
+			writeGRPCMessage(w, protowire.AppendBytes(nil, 1, ev))
+			h.served(stream, m.event.EventNumber)
+		}
+
+		var timeout <-chan time.Time
+		var t *time.Timer
+		if !wake.IsZero() {
+			t = time.NewTimer(time.Until(wake))
+			timeout = t.C
+		}
+		select {
+		case <-appended:
+		case <-changed:
+		case <-timeout:
+		case <-stop:
+			return nil
+		case <-g.deleted:
+			return grpcError(grpcUnavailable, "Persistent subscription group '%s' on stream '%s' was deleted.", group, stream)
+		case <-r.Context().Done():
+			return nil
+		case <-h.done:
+			return grpcError(grpcUnavailable, "Server is shutting down.")
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// readSettlements reads the acks and nacks of a consumer from body until the
+// client stops sending, closing stop if the consumer nacks with the stop
+// action. The checkpoint of the group is written as events are settled.
+func (h *AtomFeedSimulator) readSettlements(g *persistentGroup, body io.Reader, stop chan struct{}) {
+	for {
+		req, err := readGRPCMessage(body)
+		if err != nil {
+			return
+		}
+		ack := req.Has(2)
+		num := 3
+		if ack {
+			num = 2
+		}
+		m, err := req.Message(num)
+		if err != nil {
+			return
+		}
+		uids, err := m.Messages(2)
+		if err != nil {
+			return
+		}
+		ids := make([]string, 0, len(uids))
+		for _, u := range uids {
+			if id, err := grpcUUID(u); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		action := int(m.Uint(3))
+		g.settle(ids, ack, action)
+		h.checkpoint(g)
+		if !ack && action == nackStop {
+			close(stop)
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package feedsim
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	. "gopkg.in/check.v1"
+)
+
+// persistentCall makes the unary call of the PersistentSubscriptions method
+// sending req and returns its trailers.
+func persistentCall(c *C, s *SimulatorServer, method string, req []byte) http.Header {
+	resp := startGRPC(c, s, grpcPersistentService+method, grpcFrames(req))
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.Trailer
+}
+
+// persistentConsumer is a consumer connected to a group by a Read.
+type persistentConsumer struct {
+	c    *C
+	send *io.PipeWriter
+	resp *http.Response
+}
+
+// startPersistentRead connects a consumer with the buffer size buffer to the
+// group of stream.
+func startPersistentRead(c *C, s *SimulatorServer, stream, group string, buffer int) *persistentConsumer {
+	var o []byte
+	o = appendStreamIdentifier(o, 1, stream)
+	o = protowire.AppendString(o, 2, group)
+	o = protowire.AppendUint(o, 3, uint64(buffer))
+
+	pr, pw := io.Pipe()
+	go io.Copy(pw, grpcFrames(protowire.AppendBytes(nil, 1, o)))
+	return &persistentConsumer{c: c, send: pw, resp: startGRPC(c, s, grpcPersistentService+"Read", pr)}
+}
+
+// next returns the number, id and retry count of the next event received by
+// the consumer.
+func (p *persistentConsumer) next() (int, string, int) {
+	m, err := readGRPCMessage(p.resp.Body)
+	p.c.Assert(err, IsNil)
+	ev, err := m.Message(1)
+	p.c.Assert(err, IsNil)
+	re, err := ev.Message(1)
+	p.c.Assert(err, IsNil)
+	uid, err := re.Message(1)
+	p.c.Assert(err, IsNil)
+	id, err := grpcUUID(uid)
+	p.c.Assert(err, IsNil)
+	return int(re.Uint(3)), id, int(ev.Uint(5))
+}
+
+// settle acks the event id, or nacks it with the action if action is not
+// zero.
+func (p *persistentConsumer) settle(id string, action int) {
+	var m []byte
+	m = appendUUID(m, 2, id, true)
+	num := 2
+	if action != 0 {
+		m = protowire.AppendUint(m, 3, uint64(action))
+		num = 3
+	}
+	_, err := io.Copy(p.send, grpcFrames(protowire.AppendBytes(nil, num, m)))
+	p.c.Assert(err, IsNil)
+}
+
+func (p *persistentConsumer) close() {
+	p.send.Close()
+	p.resp.Body.Close()
+}
+
+func (s *MockSuite) TestPersistentSubscriptionRetriesAndParks(c *C) {
+	stream := "persistent-retries"
+	es := eventdata.CreateTestEvents(5, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	var settings []byte
+	settings = protowire.AppendUint(settings, 5, 1)
+	var so []byte
+	so = appendStreamIdentifier(so, 1, stream)
+	so = protowire.AppendBytes(so, 3, empty)
+	var o []byte
+	o = protowire.AppendString(o, 2, "group")
+	o = protowire.AppendBytes(o, 3, settings)
+	o = protowire.AppendBytes(o, 4, so)
+	create := protowire.AppendBytes(nil, 1, o)
+	c.Assert(persistentCall(c, srv, "Create", create).Get("Grpc-Status"), Equals, "0")
+	c.Assert(persistentCall(c, srv, "Create", create).Get("Exception"), Equals, "persistent-subscription-exists")
+
+	p := startPersistentRead(c, srv, stream, "group", 2)
+	defer p.close()
+	confirmation, err := readGRPCMessage(p.resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(confirmation.Has(2), Equals, true)
+
+	n0, id0, _ := p.next()
+	n1, id1, _ := p.next()
+	c.Assert([]int{n0, n1}, DeepEquals, []int{0, 1})
+
+	p.settle(id0, 0)
+	n, _, retries := p.next()
+	c.Assert(n, Equals, 2)
+	c.Assert(retries, Equals, 0)
+
+	p.settle(id1, nackRetry)
+	n, _, retries = p.next()
+	c.Assert(n, Equals, 1)
+	c.Assert(retries, Equals, 1)
+
+	p.settle(id1, nackRetry)
+	n, _, _ = p.next()
+	c.Assert(n, Equals, 3)
+	parked := srv.Simulator.ParkedEvents(stream, "group")
+	c.Assert(parked, HasLen, 1)
+	c.Assert(parked[0].EventNumber, Equals, 1)
+}
+
+func (s *MockSuite) TestPersistentSubscriptionMessageTimeout(c *C) {
+	stream := "persistent-timeout"
+	es := eventdata.CreateTestEvents(3, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...),
+		WithPersistentSubscription(stream, "group", PersistentSubscriptionSettings{
+			StartFrom:          1,
+			MessageTimeout:     50 * time.Millisecond,
+			MaxSubscriberCount: 1,
+		}))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	_, trailer := grpcCall(c, srv, "Read", nil)
+	c.Assert(trailer.Get("Grpc-Status"), Equals, "3")
+
+	p := startPersistentRead(c, srv, stream, "group", 1)
+	defer p.close()
+	_, err = readGRPCMessage(p.resp.Body)
+	c.Assert(err, IsNil)
+
+	second := startPersistentRead(c, srv, stream, "group", 1)
+	_, err = io.Copy(io.Discard, second.resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(second.resp.Trailer.Get("Exception"), Equals, "maximum-subscribers-reached")
+	second.close()
+
+	n, _, retries := p.next()
+	c.Assert(n, Equals, 1)
+	c.Assert(retries, Equals, 0)
+	n, _, retries = p.next()
+	c.Assert(n, Equals, 1)
+	c.Assert(retries, Equals, 1)
+
+	missing := startPersistentRead(c, srv, stream, "no-such-group", 1)
+	_, err = io.Copy(io.Discard, missing.resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(missing.resp.Trailer.Get("Exception"), Equals, "persistent-subscription-does-not-exist")
+	missing.close()
+}
+
+// checkpoints returns the positions in the checkpoint stream of the group of
+// stream served by sim, oldest first, once it holds n of them.
+func checkpoints(c *C, sim *AtomFeedSimulator, stream, group string, n int) []string {
+	u := "https://localhost:2113/streams/" + url.PathEscape(eventdata.CheckpointStreamName(stream, group))
+	get := func(u, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", u, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		sim.ServeHTTP(rec, req)
+		return rec
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		var got []string
+		if rec := get(u, "application/atom+xml"); rec.Code == http.StatusOK {
+			f, err := DecodeFeed(rec.Body)
+			c.Assert(err, IsNil)
+			for i := range f.Entry {
+				c.Assert(f.Entry[i].Summary.Body, Equals, eventdata.CheckpointEventType)
+				got = append(got, get(fmt.Sprintf("%s/%d", u, i), "application/json").Body.String())
+			}
+		}
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *MockSuite) TestPersistentSubscriptionWritesCheckpoints(c *C) {
+	stream := "persistent-checkpoints"
+	es := eventdata.CreateTestEvents(3, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...),
+		WithPersistentSubscription(stream, "group", PersistentSubscriptionSettings{}))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	p := startPersistentRead(c, srv, stream, "group", 3)
+	defer p.close()
+	_, err = readGRPCMessage(p.resp.Body)
+	c.Assert(err, IsNil)
+	_, id0, _ := p.next()
+	_, id1, _ := p.next()
+	_, id2, _ := p.next()
+
+	p.settle(id1, 0)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(checkpoints(c, srv.Simulator, stream, "group", 0), HasLen, 0)
+
+	p.settle(id0, 0)
+	c.Assert(checkpoints(c, srv.Simulator, stream, "group", 1), DeepEquals, []string{"1"})
+
+	p.settle(id2, nackSkip)
+	c.Assert(checkpoints(c, srv.Simulator, stream, "group", 2), DeepEquals, []string{"1", "2"})
+}
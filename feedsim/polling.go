@@ -0,0 +1,101 @@
+package feedsim
+
+import (
+	"net/http"
+	"time"
+)
+
+// TestReporter is the part of testing.TB used by the polling assertions, so
+// they can be used with other test frameworks too.
+type TestReporter interface {
+	Errorf(format string, args ...interface{})
+}
+
+// helper marks the calling function as a test helper if t supports it.
+func helper(t TestReporter) {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+}
+
+// polls returns the GET requests received by the simulator that are matched
+// by all of the matchers provided.
+func (h *AtomFeedSimulator) polls(matchers []RequestMatcher) []RecordedRequest {
+	return h.RequestsMatching(append([]RequestMatcher{MatchMethod(http.MethodGet)}, matchers...)...)
+}
+
+// AssertLongPollUsed checks that the client long polls the stream rather than
+// polling it repeatedly: at least one GET request matched by the matchers
+// must carry the ES-LongPoll header, as must every request for a url that had
+// already been requested. It reports a failure to t and returns false if it
+// does not.
+//
+//	defer sim.AssertLongPollUsed(t, feedsim.MatchURL("/streams/orders"))
+func (h *AtomFeedSimulator) AssertLongPollUsed(t TestReporter, matchers ...RequestMatcher) bool {
+	helper(t)
+	ok, longPolls := true, 0
+	seen := map[string]bool{}
+	for _, r := range h.polls(matchers) {
+		long := MatchLongPoll()(r)
+		if long {
+			longPolls++
+		} else if seen[r.URL] {
+			t.Errorf("%s was polled again without ES-LongPoll at %s", r.URL, r.Time.Format(time.RFC3339Nano))
+			ok = false
+		}
+		seen[r.URL] = true
+	}
+	if longPolls == 0 {
+		t.Errorf("no request used ES-LongPoll")
+		ok = false
+	}
+	return ok
+}
+
+// AssertPollIntervalAtLeast checks that the client waits at least d between
+// successive GET requests for the same url, reporting the shortest interval
+// to t and returning false if it does not. Requests carrying ES-LongPoll are
+// exempt, as the server holds them until there are events to return.
+func (h *AtomFeedSimulator) AssertPollIntervalAtLeast(t TestReporter, d time.Duration, matchers ...RequestMatcher) bool {
+	helper(t)
+	last := map[string]time.Time{}
+	var shortest time.Duration
+	var url string
+	for _, r := range h.polls(matchers) {
+		prev, ok := last[r.URL]
+		last[r.URL] = r.Time
+		if !ok || MatchLongPoll()(r) {
+			continue
+		}
+		if i := r.Time.Sub(prev); i < d && (url == "" || i < shortest) {
+			shortest, url = i, r.URL
+		}
+	}
+	if url != "" {
+		t.Errorf("%s was polled %v after the previous request, want at least %v", url, shortest, d)
+		return false
+	}
+	return true
+}
+
+// AssertNoBusyLoop checks that the client never made more than
+// maxRequestsPerSecond requests matched by the matchers within any second,
+// reporting the busiest second to t and returning false if it did.
+func (h *AtomFeedSimulator) AssertNoBusyLoop(t TestReporter, maxRequestsPerSecond int, matchers ...RequestMatcher) bool {
+	helper(t)
+	rs := h.RequestsMatching(matchers...)
+	busiest, start := 0, 0
+	for i, j := 0, 0; j < len(rs); j++ {
+		for rs[j].Time.Sub(rs[i].Time) >= time.Second {
+			i++
+		}
+		if n := j - i + 1; n > busiest {
+			busiest, start = n, i
+		}
+	}
+	if busiest > maxRequestsPerSecond {
+		t.Errorf("%d requests were made in the second from %s, want at most %d", busiest, rs[start].Time.Format(time.RFC3339Nano), maxRequestsPerSecond)
+		return false
+	}
+	return true
+}
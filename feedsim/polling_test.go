@@ -0,0 +1,83 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// failures records the failures reported by assertions.
+type failures []string
+
+func (f *failures) Errorf(format string, args ...interface{}) {
+	*f = append(*f, fmt.Sprintf(format, args...))
+}
+
+// recordPoll records a GET request for url received at the offset at from
+// the start of the test, carrying ES-LongPoll if longPoll is true.
+func recordPoll(h *AtomFeedSimulator, start time.Time, url string, at time.Duration, longPoll bool) {
+	r := httptest.NewRequest("GET", url, nil)
+	if longPoll {
+		r.Header.Set("ES-LongPoll", "10")
+	}
+	h.record(r, url)
+	h.Lock()
+	h.requests[len(h.requests)-1].Time = start.Add(at)
+	h.Unlock()
+}
+
+func (s *MockSuite) TestAssertLongPollUsed(c *C) {
+	es := eventdata.CreateTestEvents(1, "polling-stream", server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...))
+	c.Assert(err, IsNil)
+	start := time.Now()
+	head := server.URL + "/streams/polling-stream"
+
+	var f failures
+	c.Assert(h.AssertLongPollUsed(&f), Equals, false)
+	c.Assert(f, DeepEquals, failures{"no request used ES-LongPoll"})
+
+	recordPoll(h, start, head+"/0/forward/20", 0, false)
+	recordPoll(h, start, head+"/1/forward/20", time.Second, true)
+	recordPoll(h, start, head+"/1/forward/20", 2*time.Second, true)
+	f = nil
+	c.Assert(h.AssertLongPollUsed(&f), Equals, true)
+	c.Assert(f, HasLen, 0)
+
+	recordPoll(h, start, head+"/1/forward/20", 3*time.Second, false)
+	c.Assert(h.AssertLongPollUsed(&f), Equals, false)
+	c.Assert(f, HasLen, 1)
+	c.Assert(f[0], Matches, ".*/1/forward/20 was polled again without ES-LongPoll at .*")
+	f = nil
+	c.Assert(h.AssertLongPollUsed(&f, MatchURL("/0/forward")), Equals, false)
+	c.Assert(f, DeepEquals, failures{"no request used ES-LongPoll"})
+}
+
+func (s *MockSuite) TestAssertPollIntervalAndBusyLoop(c *C) {
+	es := eventdata.CreateTestEvents(1, "polling-stream", server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...))
+	c.Assert(err, IsNil)
+	start := time.Now()
+	head := server.URL + "/streams/polling-stream"
+
+	for i := 0; i < 5; i++ {
+		recordPoll(h, start, head, time.Duration(i)*300*time.Millisecond, false)
+	}
+	recordPoll(h, start, head, 1250*time.Millisecond, true)
+	recordPoll(h, start, head+"/0", 1260*time.Millisecond, false)
+
+	var f failures
+	c.Assert(h.AssertPollIntervalAtLeast(&f, 300*time.Millisecond), Equals, true)
+	c.Assert(h.AssertPollIntervalAtLeast(&f, 500*time.Millisecond), Equals, false)
+	c.Assert(f, DeepEquals, failures{head + " was polled 300ms after the previous request, want at least 500ms"})
+
+	f = nil
+	c.Assert(h.AssertNoBusyLoop(&f, 6), Equals, true)
+	c.Assert(h.AssertNoBusyLoop(&f, 5, MatchURL("/streams/polling-stream$")), Equals, true)
+	c.Assert(h.AssertNoBusyLoop(&f, 4, MatchURL("/streams/polling-stream$")), Equals, false)
+	c.Assert(f, HasLen, 1)
+	c.Assert(f[0], Matches, "5 requests were made in the second from .*, want at most 4")
+}
@@ -0,0 +1,275 @@
+package feedsim
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+)
+
+// Projection is run by a StreamRouter for each event of the streams it serves,
+// as a projection is run by GetEventStore for each event written, so that a
+// pipeline of producer, projection and consumer can be tested in process.
+//
+// The projection emits events to other streams by calling emit with the name
+// of the stream and the event. The emitted event is copied and appended to the
+// end of the stream, numbered, linked and timestamped as an event of that
+// stream; its id is kept if it has one. Streams that the router does not serve
+// are created when an event is first emitted to them. For example
+//
+//	router.Project(func(e *eventdata.Event, emit func(string, *eventdata.Event)) {
+//		if e.EventType == "OrderPlaced" {
+//			emit("orders-to-ship", e)
+//		}
+//	})
+//
+// forwards placed orders to a stream read by the shipping consumer under
+// test.
+type Projection func(e *eventdata.Event, emit func(stream string, e *eventdata.Event))
+
+type projection struct {
+	fn      Projection
+	streams map[string]bool
+
+	// positions holds the number of the next event of each stream to be
+	// projected. It is guarded by the lock of the router.
+	positions map[string]int
+}
+
+// projects reports whether pr is run for the events of stream.
+func (pr *projection) projects(stream string) bool {
+	return len(pr.streams) == 0 || pr.streams[stream]
+}
+
+// Project runs p for every event of streams, or of every stream served by sr
+// if no streams are given, starting with the events already in each stream and
+// continuing with events as they become visible to readers. A projection of
+// every stream projects the streams that projections emit to as well, so it
+// should not emit to a stream without a condition that ends the chain.
+//
+// Projections are run in the background and their events appear eventually,
+// as they do on the server; use WaitForProjections to wait for them. The
+// events of a stream are projected in order until its simulator is shut down.
+//
+// An event emitted to a stream that cannot be created, such as a stream
+// without a name, or a nil event is dropped and the error recorded. The errors
+// are returned by ProjectionErrors and WaitForProjections.
+func (sr *StreamRouter) Project(p Projection, streams ...string) {
+	pr := &projection{fn: p, streams: map[string]bool{}, positions: map[string]int{}}
+	for _, stream := range streams {
+		pr.streams[stream] = true
+	}
+	var sims []*AtomFeedSimulator
+	sr.mu.Lock()
+	sr.projections = append(sr.projections, pr)
+	for stream, h := range sr.streams {
+		if pr.projects(stream) {
+			sims = append(sims, h)
+		}
+	}
+	sr.mu.Unlock()
+
+	for _, h := range sims {
+		go sr.project(pr, h)
+	}
+}
+
+// WaitForProjections blocks until every projection has been run for every
+// event visible in the streams of sr. It then returns the first error recorded
+// by a projection, if any.
+//
+// It returns the error of the context if the context is done first.
+func (sr *StreamRouter) WaitForProjections(ctx context.Context) error {
+	for {
+		progressed := sr.progress()
+		if sr.projected() {
+			if errs := sr.ProjectionErrors(); len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		}
+		select {
+		case <-progressed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ProjectionErrors returns the errors of events that projections failed to
+// emit, in the order they occurred.
+func (sr *StreamRouter) ProjectionErrors() []error {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	return append([]error{}, sr.projectionErrs...)
+}
+
+// projected reports whether every projection has been run for every event
+// visible in the streams of sr.
+func (sr *StreamRouter) projected() bool {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	for stream, h := range sr.streams {
+		es := h.streamEvents(stream)
+		if len(es) == 0 {
+			continue
+		}
+		next := es[len(es)-1].EventNumber + 1
+		for _, pr := range sr.projections {
+			if pr.projects(stream) && pr.positions[stream] < next {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// progress returns a channel that is closed the next time a projection is run
+// or a stream is created.
+func (sr *StreamRouter) progress() <-chan struct{} {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.progressed == nil {
+		sr.progressed = make(chan struct{})
+	}
+	return sr.progressed
+}
+
+// notifyProgress wakes any goroutines waiting for progress. The caller must
+// hold the lock.
+func (sr *StreamRouter) notifyProgress() {
+	if sr.progressed != nil {
+		close(sr.progressed)
+		sr.progressed = nil
+	}
+}
+
+// project runs the projection pr for the events of the stream of h as they
+// become visible, until h is shut down.
+func (sr *StreamRouter) project(pr *projection, h *AtomFeedSimulator) {
+	emit := func(stream string, e *eventdata.Event) { sr.emit(h, stream, e) }
+	next := 0
+	for {
+		appended := h.appendNotification()
+
+		for _, e := range h.streamEvents(h.stream) {
+			if e.EventNumber < next {
+				continue
+			}
+			pr.fn(e, emit)
+			next = e.EventNumber + 1
+			sr.mu.Lock()
+			pr.positions[h.stream] = next
+			sr.notifyProgress()
+			sr.mu.Unlock()
+		}
+
+		var wake <-chan time.Time
+		var t *time.Timer
+		if at, ok := h.nextScheduledAppend(); ok {
+			t = time.NewTimer(time.Until(at))
+			wake = t.C
+		}
+		select {
+		case <-appended:
+		case <-wake:
+		case <-h.done:
+			return
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// emit appends a copy of the event e, emitted by a projection of the stream of
+// from, to the end of stream, creating the stream if sr does not serve it.
+func (sr *StreamRouter) emit(from *AtomFeedSimulator, stream string, e *eventdata.Event) {
+	if e == nil {
+		sr.projectionFailed(fmt.Errorf("projection of stream %q emitted a nil event to stream %q", from.stream, stream))
+		return
+	}
+	h, prs, err := sr.emitted(from, stream)
+	if err != nil {
+		sr.projectionFailed(fmt.Errorf("projection of stream %q emitted to stream %q: %v", from.stream, stream, err))
+		return
+	}
+	for _, pr := range prs {
+		go sr.project(pr, h)
+	}
+
+	server := ""
+	if h.BaseURL != nil {
+		server = strings.TrimRight(h.BaseURL.String(), "/")
+	} else {
+		server = eventServer(e)
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	n := 0
+	if len(h.Events) > 0 {
+		n = h.Events[len(h.Events)-1].EventNumber + 1
+	}
+	c := *e
+	c.EventStreamID = stream
+	c.EventNumber = n
+	c.Links = eventdata.EventLinks(stream, server, n)
+	c.Created = time.Time{}
+	if c.EventID == "" {
+		c.EventID = uuid.NewUUID()
+	}
+	h.appendEvents([]*eventdata.Event{&c})
+}
+
+// projectionFailed records the error err of a projection.
+func (sr *StreamRouter) projectionFailed(err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.projectionErrs = append(sr.projectionErrs, err)
+	sr.notifyProgress()
+}
+
+// emitted returns the simulator serving stream. If sr does not serve the
+// stream it is created with the configuration of from, and the projections to
+// be run for it are returned too. An error is returned if the stream cannot be
+// created.
+func (sr *StreamRouter) emitted(from *AtomFeedSimulator, stream string) (*AtomFeedSimulator, []*projection, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if h, ok := sr.streams[stream]; ok {
+		return h, nil, nil
+	}
+	h, err := newAtomFeedSimulator(WithStream(stream))
+	if err != nil {
+		return nil, nil, err
+	}
+	h.BaseURL = from.BaseURL
+	h.relativeLinks = from.relativeLinks
+	h.requestHostLinks = from.requestHostLinks
+	h.clock = from.clock
+	h.errorFormat = from.errorFormat
+	sr.streams[stream] = h
+	sr.notifyProgress()
+	var prs []*projection
+	for _, pr := range sr.projections {
+		if pr.projects(stream) {
+			prs = append(prs, pr)
+		}
+	}
+	return h, prs, nil
+}
+
+// eventServer returns the url of the server in the links of the event e, or
+// "" if it has none.
+func eventServer(e *eventdata.Event) string {
+	for _, l := range e.Links {
+		if i := strings.Index(l.URI, "/streams/"); i >= 0 {
+			return l.URI[:i]
+		}
+	}
+	return ""
+}
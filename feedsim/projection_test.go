@@ -0,0 +1,76 @@
+package feedsim
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestProjectionEmitsToNewStreams(c *C) {
+	u, _ := url.Parse(server.URL)
+	es := eventdata.CreateInterleavedEvents(2, "orders", server.URL,
+		eventdata.TypeCount{EventType: "OrderPlaced", Count: 1},
+		eventdata.TypeCount{EventType: "ItemAdded", Count: 2})
+	orders, err := NewAtomFeedSimulator(WithEvents(es...), WithStream("orders"), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	router, err := NewStreamRouter(orders)
+	c.Assert(err, IsNil)
+	mux.Handle("/", router)
+
+	router.Project(func(e *eventdata.Event, emit func(string, *eventdata.Event)) {
+		if e.EventType == "OrderPlaced" {
+			emit("orders-to-ship", e)
+		}
+	}, "orders")
+	router.Project(func(e *eventdata.Event, emit func(string, *eventdata.Event)) {
+		emit("shipping-audit", &eventdata.Event{EventType: "Shipped", Data: e.Data})
+	}, "orders-to-ship")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.Assert(router.WaitForProjections(ctx), IsNil)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/orders-to-ship/0/forward/20", server.URL), nil)
+	c.Assert(f.Entry, HasLen, 2)
+	c.Assert(f.Entry[0].Title, Equals, "1@orders-to-ship")
+	shipped := router.Simulator("orders-to-ship").StreamEvents("orders-to-ship")
+	c.Assert(shipped[1].EventID, Equals, es[3].EventID)
+	c.Assert(router.Simulator("shipping-audit").StreamEvents("shipping-audit"), HasLen, 2)
+
+	more := eventdata.CreateTestEvents(7, "orders", server.URL, "OrderPlaced")[6:]
+	orders.Append(more...)
+	c.Assert(router.WaitForProjections(ctx), IsNil)
+	audit := router.Simulator("shipping-audit").StreamEvents("shipping-audit")
+	c.Assert(audit, HasLen, 3)
+	c.Assert(audit[2].EventNumber, Equals, 2)
+	c.Assert(audit[2].EventType, Equals, "Shipped")
+	c.Assert(audit[2].EventID, Not(Equals), "")
+}
+
+func (s *MockSuite) TestProjectionErrorsAreRecorded(c *C) {
+	es := eventdata.CreateTestEvents(2, "orders", server.URL, "OrderPlaced")
+	orders, err := NewAtomFeedSimulator(WithEvents(es...), WithStream("orders"))
+	c.Assert(err, IsNil)
+	router, err := NewStreamRouter(orders)
+	c.Assert(err, IsNil)
+
+	router.Project(func(e *eventdata.Event, emit func(string, *eventdata.Event)) {
+		if e.EventNumber == 0 {
+			emit("", e)
+		} else {
+			emit("orders-to-ship", nil)
+		}
+	}, "orders")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.Assert(router.WaitForProjections(ctx), ErrorMatches, `projection of stream "orders" emitted to stream "": .*`)
+	errs := router.ProjectionErrors()
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[1], ErrorMatches, `projection of stream "orders" emitted a nil event to stream "orders-to-ship"`)
+	c.Assert(router.Simulator("orders-to-ship"), IsNil)
+}
@@ -0,0 +1,109 @@
+package feedsim
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// StreamSpec is a description of a stream that can be generated at random by
+// property based testing tools. It implements testing/quick.Generator, so
+// properties can take a StreamSpec argument and be checked with quick.Check:
+//
+//	f := func(s feedsim.StreamSpec) bool {
+//		sim, err := s.Simulator(feedsim.WithBaseURL(u))
+//		...
+//	}
+//	if err := quick.Check(f, nil); err != nil {
+//		t.Error(err)
+//	}
+//
+// Other tools can use GenerateStreamSpec to create values. The events of a
+// spec are generated from its Seed, so the same spec always describes the
+// same events and failing cases can be reproduced.
+type StreamSpec struct {
+	Stream     string
+	Count      int
+	EventTypes []string
+	PageSize   int
+	Seed       int64
+}
+
+// Generate returns a random StreamSpec whose number of events grows with
+// size. It implements testing/quick.Generator.
+func (StreamSpec) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(GenerateStreamSpec(r, size))
+}
+
+// GenerateStreamSpec returns a random StreamSpec with up to size*10 events of
+// up to five event types, and a page size of up to size+1.
+func GenerateStreamSpec(r *rand.Rand, size int) StreamSpec {
+	if size < 1 {
+		size = 1
+	}
+	s := StreamSpec{
+		Stream:   fmt.Sprintf("stream-%d", r.Intn(1000000)),
+		Count:    1 + r.Intn(size*10),
+		PageSize: 1 + r.Intn(size+1),
+		Seed:     r.Int63(),
+	}
+	for i := 0; i <= r.Intn(5); i++ {
+		s.EventTypes = append(s.EventTypes, fmt.Sprintf("EventType%c", 'A'+i))
+	}
+	return s
+}
+
+// Events returns the events described by the spec. The data of each event is
+// a json object of random shape.
+func (s StreamSpec) Events(server string) []*eventdata.Event {
+	g := eventdata.NewEventGenerator(rand.NewSource(s.Seed))
+	types := s.EventTypes
+	if len(types) == 0 {
+		types = []string{"EventType"}
+	}
+	return g.CreateTestEventsWith(s.Count, s.Stream, server, func(i int) (string, interface{}, interface{}) {
+		return types[g.Intn(len(types))], randomObject(g.Intn, 2), nil
+	})
+}
+
+// Simulator returns a simulator serving the events of the spec. The base url
+// provided with WithBaseURL is used for the links of the events.
+func (s StreamSpec) Simulator(opts ...Option) (*AtomFeedSimulator, error) {
+	h, err := newAtomFeedSimulator(opts...)
+	if err != nil {
+		return nil, err
+	}
+	server := ""
+	if h.BaseURL != nil {
+		server = h.BaseURL.String()
+	}
+	return NewAtomFeedSimulator(append([]Option{WithEvents(s.Events(server)...)}, opts...)...)
+}
+
+// randomObject returns a json object with random keys and values, nested up
+// to depth levels.
+func randomObject(intn func(n int) int, depth int) map[string]interface{} {
+	o := make(map[string]interface{})
+	for i := 0; i < 1+intn(5); i++ {
+		k := fmt.Sprintf("field%d", intn(100))
+		switch n := intn(5); {
+		case n == 0:
+			o[k] = intn(1000000)
+		case n == 1:
+			o[k] = intn(2) == 1
+		case n == 2 && depth > 0:
+			o[k] = randomObject(intn, depth-1)
+		case n == 3:
+			a := []interface{}{}
+			for j := 0; j < intn(4); j++ {
+				a = append(a, fmt.Sprintf("value%d", intn(1000)))
+			}
+			o[k] = a
+		default:
+			o[k] = fmt.Sprintf("value%d", intn(1000))
+		}
+	}
+	return o
+}
@@ -0,0 +1,52 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+	"testing/quick"
+
+	. "gopkg.in/check.v1"
+)
+
+// Test the paging invariant that reading a stream forward page by page
+// returns every event exactly once and in order.
+func (s *MockSuite) TestPagingForwardReadsEveryEvent(c *C) {
+	u, _ := url.Parse("http://localhost:2113")
+	f := func(spec StreamSpec) bool {
+		sim, err := spec.Simulator(WithBaseURL(u))
+		if err != nil {
+			return false
+		}
+		es := sim.StreamEvents(spec.Stream)
+
+		read := []int{}
+		for v := 0; v < len(es); v += spec.PageSize {
+			f, err := CreateTestFeed(es, fmt.Sprintf("%s/streams/%s/%d/forward/%d", u, spec.Stream, v, spec.PageSize))
+			if err != nil {
+				return false
+			}
+			for i := len(f.Entry) - 1; i >= 0; i-- {
+				var n int
+				fmt.Sscanf(f.Entry[i].Title, "%d@", &n)
+				read = append(read, n)
+			}
+		}
+
+		if len(read) != spec.Count {
+			return false
+		}
+		for i, n := range read {
+			if n != i {
+				return false
+			}
+		}
+		return true
+	}
+
+	c.Assert(quick.Check(f, nil), IsNil)
+}
+
+func (s *MockSuite) TestStreamSpecIsReproducible(c *C) {
+	spec := StreamSpec{Stream: "spec-stream", Count: 5, EventTypes: []string{"A", "B"}, Seed: 11}
+	c.Assert(spec.Events("http://localhost:2113"), DeepEquals, spec.Events("http://localhost:2113"))
+}
@@ -0,0 +1,72 @@
+package feedsim
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token bucket rate limiter.
+type tokenBucket struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token from the bucket if one is available. If no token is
+// available it returns the time until the next token will be.
+func (b *tokenBucket) take(now time.Time) (ok bool, wait time.Duration) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// WithRateLimit limits the rate at which the simulator serves requests to
+// rate requests per second, allowing bursts of up to burst requests.
+//
+// Requests exceeding the limit receive 429 Too Many Requests with a
+// Retry-After header holding the number of seconds until the next request
+// will be accepted.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if rate <= 0 || burst < 1 {
+			return errors.New("rate limit must have a positive rate and a burst of at least 1")
+		}
+		h.rateLimit = &tokenBucket{
+			rate:   rate,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+		return nil
+	}
+}
+
+// throttle writes a 429 response if the request exceeds the rate limit and
+// reports whether it did so.
+func (h *AtomFeedSimulator) throttle(w http.ResponseWriter) bool {
+	if h.rateLimit == nil {
+		return false
+	}
+	ok, wait := h.rateLimit.take(time.Now())
+	if ok {
+		return false
+	}
+	h.metrics.fault("rate_limit")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	h.writeError(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	return true
+}
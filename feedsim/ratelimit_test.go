@@ -0,0 +1,54 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestRateLimitReturnsTooManyRequests(c *C) {
+	stream := "limited-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithRateLimit(0.5, 2))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	feedURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	c.Assert(getStatus(c, feedURL), Equals, http.StatusOK)
+	c.Assert(getStatus(c, feedURL), Equals, http.StatusOK)
+
+	resp, err := http.Get(feedURL)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusTooManyRequests)
+	c.Assert(resp.Header.Get("Retry-After"), Equals, "2")
+}
+
+func (s *MockSuite) TestTokenBucketRefills(c *C) {
+	now := time.Now()
+	b := &tokenBucket{rate: 10, burst: 1, tokens: 1, last: now}
+
+	ok, _ := b.take(now)
+	c.Assert(ok, Equals, true)
+
+	ok, wait := b.take(now)
+	c.Assert(ok, Equals, false)
+	c.Assert(wait, Equals, 100*time.Millisecond)
+
+	ok, _ = b.take(now.Add(100 * time.Millisecond))
+	c.Assert(ok, Equals, true)
+}
+
+func (s *MockSuite) TestRateLimitMustBeValid(c *C) {
+	es := eventdata.CreateTestEvents(1, "astream", server.URL, "EventTypeX")
+
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithRateLimit(0, 1))
+
+	c.Assert(h, IsNil)
+	c.Assert(err, NotNil)
+}
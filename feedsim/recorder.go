@@ -0,0 +1,141 @@
+package feedsim
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// RecordedRequest is a request received by the simulator.
+//
+// URL is the absolute url of the request resolved against the base url of the
+// simulator.
+type RecordedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+}
+
+// RequestMatcher reports whether a recorded request is of interest.
+type RequestMatcher func(r RecordedRequest) bool
+
+// MatchURL matches requests whose url matches the regular expression pattern.
+// It panics if the pattern cannot be compiled.
+func MatchURL(pattern string) RequestMatcher {
+	re := regexp.MustCompile(pattern)
+	return func(r RecordedRequest) bool {
+		return re.MatchString(r.URL)
+	}
+}
+
+// MatchMethod matches requests made with the http method provided.
+func MatchMethod(method string) RequestMatcher {
+	return func(r RecordedRequest) bool {
+		return r.Method == method
+	}
+}
+
+// MatchHeader matches requests carrying the header name with the value
+// provided. If value is empty any request carrying the header matches.
+func MatchHeader(name, value string) RequestMatcher {
+	return func(r RecordedRequest) bool {
+		v, ok := r.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+		for _, hv := range v {
+			if hv == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchLongPoll matches requests using the ES-LongPoll header.
+func MatchLongPoll() RequestMatcher {
+	return MatchHeader("ES-LongPoll", "")
+}
+
+// record stores the request r received for the url reqURL. The bodies of gRPC
+// calls are not recorded, as streaming calls send messages for as long as the
+// call lasts.
+//
+// When WithWriteLimits sets a maximum size, no more than that many bytes of
+// the body are read and recorded. The rest of the body is left unread, so the
+// handler still sees the whole body and can reject it as too large.
+func (h *AtomFeedSimulator) record(r *http.Request, reqURL string) {
+	var body []byte
+	if r.Body != nil && !isGRPC(r) {
+		if l := h.writeLimits.maxBytes; l > 0 {
+			body, _ = ioutil.ReadAll(io.LimitReader(r.Body, l))
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		} else {
+			body, _ = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+	}
+
+	rr := RecordedRequest{
+		Method: r.Method,
+		URL:    reqURL,
+		Header: r.Header.Clone(),
+		Body:   body,
+		Time:   time.Now(),
+	}
+
+	h.Lock()
+	h.requests = append(h.requests, rr)
+	h.notifyObservers()
+	h.Unlock()
+}
+
+// readCloser reads from a Reader and closes a Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Requests returns every request received by the simulator in the order they
+// were received.
+func (h *AtomFeedSimulator) Requests() []RecordedRequest {
+	h.RLock()
+	defer h.RUnlock()
+	rs := make([]RecordedRequest, len(h.requests))
+	copy(rs, h.requests)
+	return rs
+}
+
+// RequestsMatching returns the requests received by the simulator that are
+// matched by all of the matchers provided.
+func (h *AtomFeedSimulator) RequestsMatching(matchers ...RequestMatcher) []RecordedRequest {
+	rs := []RecordedRequest{}
+	for _, r := range h.Requests() {
+		matched := true
+		for _, m := range matchers {
+			if !m(r) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// RequestCount returns the number of requests received by the simulator whose
+// url matches the regular expression pattern.
+func (h *AtomFeedSimulator) RequestCount(pattern string) int {
+	return len(h.RequestsMatching(MatchURL(pattern)))
+}
@@ -0,0 +1,46 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestRecordsRequests(c *C) {
+	stream := "recorded-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	headURL := fmt.Sprintf("%s/streams/%s/head/backward/20", server.URL, stream)
+	header := http.Header{}
+	header.Set("Accept", "application/atom+xml")
+	getFeed(c, headURL, header)
+	getFeed(c, headURL, nil)
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/2", server.URL, stream)), Equals, http.StatusOK)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("%s/streams/%s", server.URL, stream), strings.NewReader("body"))
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+
+	rs := h.Requests()
+	c.Assert(rs, HasLen, 4)
+	c.Assert(rs[0].Method, Equals, "GET")
+	c.Assert(rs[0].URL, Equals, headURL)
+	c.Assert(rs[0].Header.Get("Accept"), Equals, "application/atom+xml")
+	c.Assert(rs[3].Method, Equals, "POST")
+	c.Assert(string(rs[3].Body), Equals, "body")
+	c.Assert(rs[0].Time.After(rs[1].Time), Equals, false)
+
+	c.Assert(h.RequestCount("/head/backward/"), Equals, 2)
+	c.Assert(h.RequestsMatching(MatchURL("/head/"), MatchHeader("Accept", "application/atom+xml")), HasLen, 1)
+	c.Assert(h.RequestsMatching(MatchMethod("POST")), HasLen, 1)
+	c.Assert(h.RequestsMatching(MatchLongPoll()), HasLen, 0)
+}
@@ -0,0 +1,48 @@
+package feedsim
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// replicaLag serves some reads of the head of the stream from a replica that
+// has yet to receive the newest events.
+type replicaLag struct {
+	every int64
+	lag   int
+	reads int64
+}
+
+// WithReplicaLag serves every every-th read of the head of the stream without
+// its newest lag events, as a read served by a lagging replica would be. The
+// events reappear on the following reads, so a client sees the head of the
+// stream move backwards and then forwards again, which exercises
+// checkpointing logic that assumes the head only ever advances.
+//
+// Replica lag does not apply to virtual streams.
+func WithReplicaLag(every, lag int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if every < 1 || lag < 1 {
+			return errors.New("replica lag must have every and lag of at least 1")
+		}
+		h.replicaLag = &replicaLag{every: int64(every), lag: lag}
+		return nil
+	}
+}
+
+// apply returns the events es of the stream as seen by the read of the page
+// r, without the newest events if the read is served by a lagging replica.
+func (l *replicaLag) apply(r *StreamURL, es []*eventdata.Event) []*eventdata.Event {
+	if l == nil || !r.Head {
+		return es
+	}
+	if atomic.AddInt64(&l.reads, 1)%l.every != 0 {
+		return es
+	}
+	if l.lag >= len(es) {
+		return es[:0]
+	}
+	return es[:len(es)-l.lag]
+}
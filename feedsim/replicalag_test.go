@@ -0,0 +1,37 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestReplicaLag(c *C) {
+	stream := "lagging-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithReplicaLag(3, 2))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	head := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	newest := []string{}
+	for i := 0; i < 6; i++ {
+		f := getFeed(c, head, nil)
+		newest = append(newest, f.Entry[0].Title)
+	}
+	c.Assert(newest, DeepEquals, []string{
+		"9@" + stream, "9@" + stream, "7@" + stream,
+		"9@" + stream, "9@" + stream, "7@" + stream,
+	})
+
+	for i := 0; i < 3; i++ {
+		f := getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream), nil)
+		c.Assert(f.Entry, HasLen, 10)
+	}
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithReplicaLag(0, 1))
+	c.Assert(err, ErrorMatches, "replica lag must have every and lag of at least 1")
+}
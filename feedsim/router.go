@@ -0,0 +1,68 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// StreamRouter serves several simulated streams, each by its own simulator,
+// so streams can be given independent events, append schedules and trickle
+// rates. For example a busy and a quiet stream can be served together to
+// check that a client polling both reads each fairly.
+//
+//	busy, _ := feedsim.NewAtomFeedSimulator(feedsim.WithEvents(orders...), feedsim.WithStream("orders"),
+//		feedsim.WithTrickle(0), feedsim.WithAppendSchedule(fast))
+//	quiet, _ := feedsim.NewAtomFeedSimulator(feedsim.WithEvents(refunds...), feedsim.WithStream("refunds"),
+//		feedsim.WithTrickle(0), feedsim.WithAppendSchedule(slow))
+//	router, _ := feedsim.NewStreamRouter(busy, quiet)
+//
+// Requests for a stream no simulator serves receive 404 Not Found.
+type StreamRouter struct {
+	mu          sync.RWMutex
+	streams     map[string]*AtomFeedSimulator
+	projections []*projection
+	progressed  chan struct{}
+
+	// projectionErrs holds the errors of events that projections failed to
+	// emit.
+	projectionErrs []error
+}
+
+// NewStreamRouter returns a StreamRouter serving the streams of sims. Each
+// simulator must be given the name of its stream with WithStream or a fixture,
+// and no two simulators may serve the same stream.
+func NewStreamRouter(sims ...*AtomFeedSimulator) (*StreamRouter, error) {
+	sr := &StreamRouter{streams: make(map[string]*AtomFeedSimulator, len(sims))}
+	for i, h := range sims {
+		if h.stream == "" {
+			return nil, fmt.Errorf("simulator %d does not name its stream", i)
+		}
+		if _, ok := sr.streams[h.stream]; ok {
+			return nil, fmt.Errorf("stream %q is served by more than one simulator", h.stream)
+		}
+		sr.streams[h.stream] = h
+	}
+	return sr, nil
+}
+
+// Simulator returns the simulator serving stream, or nil if there is none.
+func (sr *StreamRouter) Simulator(stream string) *AtomFeedSimulator {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	return sr.streams[stream]
+}
+
+// ServeHTTP serves the request with the simulator of the stream it addresses.
+func (sr *StreamRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	split, err := pathSegments(r.URL)
+	if err == nil {
+		if i := streamsSegment(split, 2, 3, 5); i >= 0 {
+			if h := sr.Simulator(split[i+1]); h != nil {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+	}
+	http.Error(w, "Not Found", http.StatusNotFound)
+}
@@ -0,0 +1,57 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestStreamRouterServesIndependentSchedules(c *C) {
+	u, _ := url.Parse(server.URL)
+	busy, err := NewAtomFeedSimulator(
+		WithEvents(eventdata.CreateTestEvents(10, "busy-stream", server.URL, "EventTypeX")...),
+		WithStream("busy-stream"), WithBaseURL(u), WithTrickle(0),
+		WithAppendSchedule([]AppendStep{{Count: 3}, {Count: 3}}))
+	c.Assert(err, IsNil)
+	quiet, err := NewAtomFeedSimulator(
+		WithEvents(eventdata.CreateTestEvents(10, "quiet-stream", server.URL, "EventTypeY")...),
+		WithStream("quiet-stream"), WithBaseURL(u), WithTrickle(2),
+		WithAppendSchedule([]AppendStep{{After: time.Hour, Count: 1}}))
+	c.Assert(err, IsNil)
+	router, err := NewStreamRouter(busy, quiet)
+	c.Assert(err, IsNil)
+	c.Assert(router.Simulator("quiet-stream"), Equals, quiet)
+	mux.Handle("/", router)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/busy-stream", server.URL), nil)
+	c.Assert(f.Entry, HasLen, 6)
+	c.Assert(f.Entry[0].Title, Equals, "5@busy-stream")
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/quiet-stream/0/forward/20", server.URL), nil)
+	c.Assert(f.Entry, HasLen, 2)
+	c.Assert(f.Entry[0].Title, Equals, "1@quiet-stream")
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/quiet-stream/1", server.URL))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	resp, err = http.Get(fmt.Sprintf("%s/streams/other-stream", server.URL))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *MockSuite) TestNewStreamRouterRequiresDistinctNamedStreams(c *C) {
+	unnamed, _ := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(1, "a-stream", server.URL, "EventTypeX")...))
+	_, err := NewStreamRouter(unnamed)
+	c.Assert(err, ErrorMatches, "simulator 0 does not name its stream")
+
+	named, _ := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(1, "a-stream", server.URL, "EventTypeX")...), WithStream("a-stream"))
+	_, err = NewStreamRouter(named, named)
+	c.Assert(err, ErrorMatches, `stream "a-stream" is served by more than one simulator`)
+}
@@ -0,0 +1,210 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+)
+
+// Scenario is a test environment loaded from a scenario file: a simulator
+// serving a stream and the faults scripted in front of it. A Scenario is an
+// http.Handler serving requests through its faults.
+type Scenario struct {
+	// Simulator serves the stream of the scenario.
+	Simulator *AtomFeedSimulator
+
+	// Faults wraps Simulator with the faults of the scenario. Further faults
+	// can be added to it.
+	Faults *faults.FaultInjector
+}
+
+// scenarioFile is the on disk description of a scenario. It extends the
+// fixture format with the arrival of events, a timeline of faults and the
+// users allowed to read the stream.
+//
+//	{
+//	  "streams": [{"name": "orders-1", "events": [...]}],
+//	  "visible": 2,
+//	  "appends": [{"after": "100ms", "count": 5}, {"after": "1s", "count": 1}],
+//	  "faults": [
+//	    {"after": "2s", "for": "500ms", "status": 503},
+//	    {"match": "/metadata$", "nth": 3, "status": 500},
+//	    {"match": "head/backward", "dropAfter": 100}
+//	  ],
+//	  "users": [{"username": "admin", "password": "changeit"}]
+//	}
+type scenarioFile struct {
+	fixture
+	Visible *int             `json:"visible,omitempty"`
+	Appends []scenarioAppend `json:"appends,omitempty"`
+	Faults  []scenarioFault  `json:"faults,omitempty"`
+	Users   []scenarioUser   `json:"users,omitempty"`
+}
+
+type scenarioAppend struct {
+	After duration `json:"after"`
+	Count int      `json:"count"`
+}
+
+type scenarioFault struct {
+	After     duration `json:"after,omitempty"`
+	For       duration `json:"for,omitempty"`
+	Match     string   `json:"match,omitempty"`
+	Nth       int      `json:"nth,omitempty"`
+	Status    int      `json:"status,omitempty"`
+	DropAfter *int     `json:"dropAfter,omitempty"`
+}
+
+type scenarioUser struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// duration is a time.Duration written as a string such as "1.5s" in scenario
+// files.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("durations must be strings such as \"1.5s\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// LoadScenario creates the test environment described by the JSON scenario
+// file at path, so environments can be kept under version control as data
+// rather than built by setup code.
+//
+// A scenario file is a stream fixture, as read by LoadStreamFixture, with the
+// following optional additions.
+//
+// visible is the number of events that exist when the scenario is loaded, as
+// with WithTrickle. It defaults to every event, or to none if appends are
+// given.
+//
+// appends is the schedule on which the remaining events arrive, as with
+// WithAppendSchedule. Durations are written as strings such as "250ms".
+//
+// faults is a timeline of faults. A fault applies to requests received after
+// its after duration has elapsed since the scenario was loaded, for the
+// duration of for if given, whose url matches the regular expression match if
+// given and, if nth is given, to the nth such request only. A fault fails the
+// request with status or, if dropAfter is given, drops the connection after
+// that many bytes of the body. Faults are checked in the order they are
+// listed.
+//
+// users lists the username and password of users allowed to read the stream
+// using basic authentication, as with WithBasicAuth.
+//
+// opts are applied before the scenario, as with LoadStreamFixture.
+func LoadScenario(path string, opts ...Option) (*Scenario, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return nil, fmt.Errorf("unsupported scenario format %q, scenarios must be JSON", ext)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sf scenarioFile
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sf); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := sf.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	o := append(opts, withFixture(sf.Streams[0]))
+	visible := -1
+	if len(sf.Appends) > 0 {
+		visible = 0
+	}
+	if sf.Visible != nil {
+		visible = *sf.Visible
+	}
+	o = append(o, WithTrickle(visible))
+	if len(sf.Appends) > 0 {
+		steps := make([]AppendStep, len(sf.Appends))
+		for i, v := range sf.Appends {
+			steps[i] = AppendStep{After: time.Duration(v.After), Count: v.Count}
+		}
+		o = append(o, WithAppendSchedule(steps))
+	}
+	for _, v := range sf.Users {
+		o = append(o, WithBasicAuth(v.Username, v.Password))
+	}
+
+	sim, err := NewAtomFeedSimulator(o...)
+	if err != nil {
+		return nil, err
+	}
+	fi := faults.NewFaultInjector(sim)
+	start := time.Now()
+	for _, v := range sf.Faults {
+		if err := fi.FailWindow(start, v.window()); err != nil {
+			return nil, err
+		}
+	}
+	return &Scenario{Simulator: sim, Faults: fi}, nil
+}
+
+// validate checks the parts of a scenario that are not checked by the options
+// it is turned into.
+func (sf *scenarioFile) validate() error {
+	if len(sf.Streams) != 1 {
+		return errors.New("scenario must describe exactly one stream")
+	}
+	if err := sf.fixture.validate(); err != nil {
+		return err
+	}
+	for i, v := range sf.Faults {
+		if v.DropAfter == nil && v.Status == 0 {
+			return fmt.Errorf("fault %d has neither a status nor dropAfter", i)
+		}
+		if v.Match != "" {
+			if _, err := regexp.Compile(v.Match); err != nil {
+				return fmt.Errorf("fault %d: %v", i, err)
+			}
+		}
+	}
+	for i, v := range sf.Users {
+		if v.Username == "" {
+			return fmt.Errorf("user %d has no username", i)
+		}
+	}
+	return nil
+}
+
+// window returns the fault described by v.
+func (v scenarioFault) window() faults.Window {
+	return faults.Window{
+		After:     time.Duration(v.After),
+		For:       time.Duration(v.For),
+		Match:     v.Match,
+		Nth:       v.Nth,
+		Status:    v.Status,
+		DropAfter: v.DropAfter,
+	}
+}
+
+// ServeHTTP serves the request through the faults of the scenario.
+func (s *Scenario) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Faults.ServeHTTP(w, r)
+}
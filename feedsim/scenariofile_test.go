@@ -0,0 +1,97 @@
+package feedsim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func serveScenario(h http.Handler, u string, authenticated bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", u, nil)
+	if authenticated {
+		req.SetBasicAuth("admin", "changeit")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *MockSuite) TestLoadScenario(c *C) {
+	u, _ := url.Parse(server.URL)
+	sc, err := LoadScenario(filepath.Join("testdata", "scenario.json"), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	stream := fmt.Sprintf("%s/streams/orders-2", server.URL)
+
+	c.Assert(serveScenario(sc, stream, false).Code, Equals, http.StatusUnauthorized)
+
+	rec := serveScenario(sc, stream, true)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	f := &atom.Feed{}
+	c.Assert(xml.Unmarshal(rec.Body.Bytes(), f), IsNil)
+	c.Assert(f.Entry, HasLen, 1)
+
+	c.Assert(serveScenario(sc, stream+"/metadata", true).Code, Equals, http.StatusServiceUnavailable)
+	c.Assert(serveScenario(sc, stream+"/0", true).Code, Equals, http.StatusOK)
+	c.Assert(serveScenario(sc, stream+"/0", true).Code, Equals, http.StatusInternalServerError)
+	c.Assert(serveScenario(sc, stream+"/0", true).Code, Equals, http.StatusOK)
+
+	time.Sleep(75 * time.Millisecond)
+	rec = serveScenario(sc, stream, true)
+	f = &atom.Feed{}
+	c.Assert(xml.Unmarshal(rec.Body.Bytes(), f), IsNil)
+	c.Assert(f.Entry, HasLen, 4)
+}
+
+func (s *MockSuite) TestLoadScenarioFaultTimeline(c *C) {
+	dir := c.MkDir()
+	p := filepath.Join(dir, "timeline.json")
+	content := `{
+	  "streams": [{"name": "a", "events": [{"eventType": "X", "data": {}}]}],
+	  "faults": [{"after": "50ms", "for": "50ms", "status": 502}]
+	}`
+	c.Assert(ioutil.WriteFile(p, []byte(content), 0644), IsNil)
+	u, _ := url.Parse(server.URL)
+	sc, err := LoadScenario(p, WithBaseURL(u))
+	c.Assert(err, IsNil)
+	stream := fmt.Sprintf("%s/streams/a", server.URL)
+
+	c.Assert(serveScenario(sc, stream, false).Code, Equals, http.StatusOK)
+	time.Sleep(60 * time.Millisecond)
+	c.Assert(serveScenario(sc, stream, false).Code, Equals, http.StatusBadGateway)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(serveScenario(sc, stream, false).Code, Equals, http.StatusOK)
+}
+
+func (s *MockSuite) TestLoadScenarioErrors(c *C) {
+	dir := c.MkDir()
+	stream := `"streams": [{"name": "a", "events": [{"eventType": "X", "data": {}}]}]`
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"scenario.yml", `streams: []`, `unsupported scenario format ".yml".*`},
+		{"empty.json", `{"streams": []}`, `.*scenario must describe exactly one stream`},
+		{"untyped.json", `{"streams": [{"name": "a", "events": [{"data": {}}]}]}`, `.*event 0 has no event type`},
+		{"duration.json", `{` + stream + `, "appends": [{"after": 5, "count": 1}]}`, `.*durations must be strings such as "1.5s"`},
+		{"action.json", `{` + stream + `, "faults": [{"match": "a"}]}`, `.*fault 0 has neither a status nor dropAfter`},
+		{"pattern.json", `{` + stream + `, "faults": [{"match": "(", "status": 500}]}`, `.*fault 0: error parsing regexp.*`},
+		{"user.json", `{` + stream + `, "users": [{"password": "x"}]}`, `.*user 0 has no username`},
+		{"unknown.json", `{` + stream + `, "fault": []}`, `.*unknown field "fault"`},
+	}
+
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name)
+		c.Assert(ioutil.WriteFile(p, []byte(tt.content), 0644), IsNil)
+		_, err := LoadScenario(p)
+		c.Assert(err, ErrorMatches, tt.err, Commentf(tt.name))
+	}
+}
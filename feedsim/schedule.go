@@ -0,0 +1,102 @@
+package feedsim
+
+import (
+	"errors"
+	"time"
+)
+
+// AppendStep describes a batch of events that become available to readers of
+// the simulated stream.
+//
+// After is the time to wait after the previous step, or after the simulator
+// was constructed for the first step, before the events are appended.
+// Count is the number of events appended by the step.
+type AppendStep struct {
+	After time.Duration
+	Count int
+}
+
+// WithAppendSchedule scripts the arrival of events in the simulated stream.
+//
+// The events made available by WithTrickle exist when the simulator is
+// constructed. The remaining events are appended in the order given by the
+// steps, so bursts, quiet periods and late arriving events can be modelled.
+// For example
+//
+//	WithAppendSchedule([]AppendStep{
+//		{After: 100 * time.Millisecond, Count: 5},
+//		{After: time.Second, Count: 1},
+//	})
+//
+// appends five events 100ms after construction and a further event one second
+// after that.
+//
+// Steps fall due by the clock of the simulator, so a schedule can be driven
+// by a fake clock set with WithClock. Long poll requests made while the head
+// of the stream is empty are held until the next step is due or the long poll
+// expires, whichever comes first.
+func WithAppendSchedule(steps []AppendStep) Option {
+	return func(h *AtomFeedSimulator) error {
+		for _, v := range steps {
+			if v.After < 0 || v.Count < 0 {
+				return errors.New("append steps must have a non negative After and Count")
+			}
+		}
+		h.schedule = steps
+		h.nextStep = 0
+		return nil
+	}
+}
+
+// advanceSchedule applies every step of the append schedule that is due at
+// time now. The caller must hold the lock.
+func (h *AtomFeedSimulator) advanceSchedule(now time.Time) {
+	for h.nextStep < len(h.schedule) && !now.Before(h.nextAppend) {
+		h.TrickleAfter += h.schedule[h.nextStep].Count
+		if h.TrickleAfter > len(h.Events) {
+			h.TrickleAfter = len(h.Events)
+		}
+		h.nextStep++
+		h.notifyAppend()
+		if h.nextStep < len(h.schedule) {
+			h.nextAppend = h.nextAppend.Add(h.schedule[h.nextStep].After)
+		}
+	}
+}
+
+// pendingStep reports whether a step of the append schedule is still to be
+// applied and, if so, returns the time of the clock of the simulator. The clock
+// is only read while a step is pending, so that a SteppingClock is not moved on
+// by simulators without a schedule. The caller must hold the lock.
+func (h *AtomFeedSimulator) pendingStep() (now time.Time, ok bool) {
+	if h.nextStep >= len(h.schedule) {
+		return time.Time{}, false
+	}
+	return h.clock.Now(), true
+}
+
+// startSchedule schedules the first step of the append schedule by the clock
+// of the simulator. It is called once the options have set the clock.
+func (h *AtomFeedSimulator) startSchedule() {
+	if len(h.schedule) > 0 {
+		h.nextAppend = h.clock.Now().Add(h.schedule[0].After)
+	}
+}
+
+// nextScheduledAppend returns the time at which the next step of the append
+// schedule is due, converted from the clock of the simulator to real time so
+// that callers can wait for it with a timer. ok is false when the schedule has
+// been exhausted.
+func (h *AtomFeedSimulator) nextScheduledAppend() (next time.Time, ok bool) {
+	h.Lock()
+	defer h.Unlock()
+	now, ok := h.pendingStep()
+	if !ok {
+		return time.Time{}, false
+	}
+	h.advanceSchedule(now)
+	if h.nextStep >= len(h.schedule) {
+		return time.Time{}, false
+	}
+	return time.Now().Add(h.nextAppend.Sub(now)), true
+}
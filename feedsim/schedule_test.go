@@ -0,0 +1,100 @@
+package feedsim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestAppendScheduleAppliesStepsWhenDue(c *C) {
+	es := eventdata.CreateTestEvents(20, "astream", server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	steps := []AppendStep{
+		{After: time.Minute, Count: 5},
+		{After: time.Hour, Count: 3},
+		{After: time.Minute, Count: 100},
+	}
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithTrickle(2), WithAppendSchedule(steps))
+	c.Assert(err, IsNil)
+	start := h.nextAppend.Add(-time.Minute)
+
+	h.advanceSchedule(start.Add(59 * time.Second))
+	c.Assert(h.TrickleAfter, Equals, 2)
+
+	h.advanceSchedule(start.Add(time.Minute))
+	c.Assert(h.TrickleAfter, Equals, 7)
+
+	h.advanceSchedule(start.Add(time.Hour))
+	c.Assert(h.TrickleAfter, Equals, 7)
+
+	h.advanceSchedule(start.Add(2 * time.Hour))
+	c.Assert(h.TrickleAfter, Equals, 20)
+	c.Assert(h.nextStep, Equals, len(steps))
+}
+
+func (s *MockSuite) TestAppendScheduleRejectsNegativeSteps(c *C) {
+	es := eventdata.CreateTestEvents(2, "astream", server.URL, "EventTypeX")
+
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithTrickle(0), WithAppendSchedule([]AppendStep{{After: -time.Second, Count: 1}}))
+
+	c.Assert(h, IsNil)
+	c.Assert(err, NotNil)
+}
+
+func (s *MockSuite) TestAppendScheduleReleasesEventsToLongPoll(c *C) {
+	stream := "scheduled-stream"
+	es := eventdata.CreateTestEvents(10, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithTrickle(5), WithAppendSchedule([]AppendStep{{After: 50 * time.Millisecond, Count: 2}}))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s/5/forward/20", server.URL, stream), nil)
+	req.Header.Set("ES-LongPoll", "5")
+	started := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(time.Since(started) < 5*time.Second, Equals, true)
+
+	f := &atom.Feed{}
+	err = xml.NewDecoder(resp.Body).Decode(f)
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 2)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("6@%s", stream))
+	c.Assert(f.Entry[1].Title, Equals, fmt.Sprintf("5@%s", stream))
+}
+
+func (s *MockSuite) TestAppendScheduleFollowsClock(c *C) {
+	stream := "clocked-schedule"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	var mu sync.Mutex
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	})
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithTrickle(1), WithClock(clock),
+		WithAppendSchedule([]AppendStep{{After: time.Hour, Count: 2}}))
+	c.Assert(err, IsNil)
+	c.Assert(h.streamEvents(stream), HasLen, 1)
+
+	next, ok := h.nextScheduledAppend()
+	c.Assert(ok, Equals, true)
+	c.Assert(time.Until(next) > 59*time.Minute, Equals, true)
+
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	c.Assert(h.streamEvents(stream), HasLen, 3)
+	_, ok = h.nextScheduledAppend()
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,144 @@
+package feedsim
+
+import (
+	"fmt"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/jsonschema"
+)
+
+// SchemaViolation describes an event whose data does not validate against the
+// JSON Schema registered for its event type with WithSchema.
+//
+// Rejected is true if the event was written by a client and the write was
+// rejected by a simulator with strict schemas, in which case EventNumber is
+// -1.
+type SchemaViolation struct {
+	Stream      string
+	EventNumber int
+	EventType   string
+	EventID     string
+	Rejected    bool
+	Err         error
+}
+
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("data of %s event %s of stream %s does not match its schema: %v", v.EventType, v.EventID, v.Stream, v.Err)
+}
+
+// WithSchema registers the JSON Schema schema for the data of events of
+// eventType, so that contracts between the producers and consumers of events
+// are checked by tests. Events without a registered schema and binary events
+// are not checked.
+// The supported keywords of JSON Schema are those of type, enum, const,
+// objects, arrays, strings, numbers and the allOf, anyOf and oneOf
+// combinators; other keywords, such as $ref and format, are ignored.
+//
+// The events of the simulator are checked when it is created and as they are
+// appended or written, and the events that do not validate are returned by
+// SchemaViolations. With WithStrictSchemas events that do not validate are
+// rejected instead.
+func WithSchema(eventType string, schema []byte) Option {
+	return func(h *AtomFeedSimulator) error {
+		s, err := jsonschema.Compile(schema)
+		if err != nil {
+			return fmt.Errorf("schema of event type %q: %v", eventType, err)
+		}
+		if h.schemas == nil {
+			h.schemas = make(map[string]*jsonschema.Schema)
+		}
+		h.schemas[eventType] = s
+		return nil
+	}
+}
+
+// WithStrictSchemas makes the simulator reject events whose data do not
+// validate against the schemas registered with WithSchema. Writes holding such
+// an event receive 400 Bad Request, an InvalidArgument status over gRPC or
+// BadRequest over TCP, and change nothing. NewAtomFeedSimulator returns a
+// SchemaViolation if any of the events it is given do not validate, flagging
+// fixtures and generated events that have drifted from the schema. Events appended by the test with
+// Append or a schedule cannot be rejected and are reported by
+// SchemaViolations.
+func WithStrictSchemas() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.strictSchemas = true
+		return nil
+	}
+}
+
+// SchemaViolations returns the events whose data have not validated against
+// their schema, in the order they were found.
+func (h *AtomFeedSimulator) SchemaViolations() []SchemaViolation {
+	h.RLock()
+	defer h.RUnlock()
+	return append([]SchemaViolation(nil), h.violations...)
+}
+
+// checkSchemas checks the events the simulator was created with against their
+// schemas, returning the first violation in strict mode.
+func (h *AtomFeedSimulator) checkSchemas() error {
+	for _, e := range h.Events {
+		v, ok := h.violation(e.EventStreamID, e.EventType, e.Data)
+		if !ok {
+			continue
+		}
+		v.EventNumber, v.EventID = e.EventNumber, e.EventID
+		if h.strictSchemas {
+			return v
+		}
+		h.violations = append(h.violations, v)
+	}
+	return nil
+}
+
+// checkAppended records the violations of the events appended to the stream.
+// The caller must hold the lock.
+func (h *AtomFeedSimulator) checkAppended(events []*eventdata.Event) {
+	for _, e := range events {
+		if v, ok := h.violation(e.EventStreamID, e.EventType, e.Data); ok {
+			v.EventNumber, v.EventID = e.EventNumber, e.EventID
+			h.violations = append(h.violations, v)
+		}
+	}
+}
+
+// checkWrite checks the events of a write to stream against their schemas. In
+// strict mode it records and returns the first violation.
+func (h *AtomFeedSimulator) checkWrite(stream string, body []writeEvent) error {
+	if !h.strictSchemas {
+		return nil
+	}
+	for _, e := range body {
+		v, ok := h.violation(stream, e.EventType, e.Data)
+		if !ok {
+			continue
+		}
+		v.EventNumber, v.EventID, v.Rejected = -1, e.EventID, true
+		h.Lock()
+		h.violations = append(h.violations, v)
+		h.Unlock()
+		return v
+	}
+	return nil
+}
+
+// violation returns the violation of the data of an event of eventType in
+// stream, if it does not validate against the schema of the event type.
+func (h *AtomFeedSimulator) violation(stream, eventType string, data interface{}) (SchemaViolation, bool) {
+	s, ok := h.schemas[eventType]
+	if !ok || isBinaryData(data) {
+		return SchemaViolation{}, false
+	}
+	b, err := marshalEventData(data)
+	if err == nil {
+		if len(b) == 0 {
+			b = []byte("null")
+		}
+		err = s.Validate(b)
+	}
+	if err == nil {
+		return SchemaViolation{}, false
+	}
+	return SchemaViolation{Stream: stream, EventType: eventType, Err: err}, true
+}
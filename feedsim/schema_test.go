@@ -0,0 +1,88 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+const orderPlacedSchema = `{
+	"type": "object",
+	"required": ["orderId", "total"],
+	"properties": {
+		"orderId": {"type": "string", "pattern": "^o-[0-9]+$"},
+		"total": {"type": "number", "minimum": 0},
+		"lines": {"type": "array", "items": {"type": "integer"}, "maxItems": 2}
+	},
+	"additionalProperties": false
+}`
+
+type OrderPlaced struct {
+	OrderID string  `json:"orderId"`
+	Total   float64 `json:"total"`
+	Lines   []int   `json:"lines,omitempty"`
+}
+
+func (s *MockSuite) TestSchemaViolationsAreReported(c *C) {
+	stream := "schema-stream"
+	es := eventdata.CreateTestEventsFromData(stream, server.URL,
+		&OrderPlaced{OrderID: "o-1", Total: 10, Lines: []int{1, 2}},
+		&OrderPlaced{OrderID: "order-2", Total: 5})
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithSchema("OrderPlaced", []byte(orderPlacedSchema)))
+	c.Assert(err, IsNil)
+
+	v := h.SchemaViolations()
+	c.Assert(v, HasLen, 1)
+	c.Assert(v[0].EventNumber, Equals, 1)
+	c.Assert(v[0].EventID, Equals, es[1].EventID)
+	c.Assert(v[0].Err, ErrorMatches, `/orderId: "order-2" does not match the pattern .*`)
+
+	h.Append(eventdata.CreateTestEventFromData(stream, server.URL, 2, &OrderPlaced{OrderID: "o-3", Total: -1}, nil))
+	extra := eventdata.CreateTestEventFromData(stream, server.URL, 3, map[string]interface{}{"orderId": "o-4", "total": 1, "extra": true}, nil)
+	extra.EventType = "OrderPlaced"
+	h.Append(extra)
+	v = h.SchemaViolations()
+	c.Assert(v, HasLen, 3)
+	c.Assert(v[1].Err, ErrorMatches, "/total: -1 is less than the minimum 0")
+	c.Assert(v[2].Err, ErrorMatches, `/: property "extra" is not allowed`)
+
+	h.Append(eventdata.CreateTestEvent(stream, server.URL, "OrderPlaced", 4, nil, nil))
+	v = h.SchemaViolations()
+	c.Assert(v, HasLen, 4)
+	c.Assert(v[3].Err, ErrorMatches, "/: expected object, got null")
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithSchema("OrderPlaced", []byte(`{"type": "float"}`)))
+	c.Assert(err, ErrorMatches, `schema of event type "OrderPlaced": .*unknown type "float"`)
+}
+
+func (s *MockSuite) TestStrictSchemasRejectWrites(c *C) {
+	stream := "strict-schema-stream"
+	good := eventdata.CreateTestEventsFromData(stream, server.URL, &OrderPlaced{OrderID: "o-1", Total: 1})
+	_, err := NewAtomFeedSimulator(WithStrictSchemas(), WithSchema("OrderPlaced", []byte(orderPlacedSchema)),
+		WithEvents(eventdata.CreateTestEventsFromData(stream, server.URL, &OrderPlaced{OrderID: "o-1", Total: -3})...))
+	c.Assert(err, FitsTypeOf, SchemaViolation{})
+	c.Assert(err, ErrorMatches, ".*/total: -3 is less than the minimum 0")
+
+	h, err := NewAtomFeedSimulator(WithEvents(good...), WithStrictSchemas(),
+		WithSchema("OrderPlaced", []byte(orderPlacedSchema)))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	rec := postEvents(h, streamURL, "", `[
+		{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {"orderId": "o-2", "total": 3}},
+		{"eventId": "0f9fad5b-d9cb-469f-a165-70867728950e", "eventType": "OrderPlaced", "data": {"orderId": "o-3", "lines": [1, 2, 3]}}
+	]`)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	c.Assert(rec.Body.String(), Matches, `.*missing required property "total"\n`)
+	c.Assert(h.StreamEvents(stream), HasLen, 1)
+	v := h.SchemaViolations()
+	c.Assert(v, HasLen, 1)
+	c.Assert(v[0].Rejected, Equals, true)
+	c.Assert(v[0].EventID, Equals, "0f9fad5b-d9cb-469f-a165-70867728950e")
+
+	rec = postEvents(h, streamURL, "", `[{"eventId": "8e0bb0c9-5d8d-4ad4-9c1c-3c43a4f0ec1d", "eventType": "OrderPlaced", "data": {"orderId": "o-2", "total": 3, "lines": [1]}}]`)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(h.StreamEvents(stream), HasLen, 2)
+}
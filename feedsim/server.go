@@ -0,0 +1,222 @@
+package feedsim
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+)
+
+// SimulatorServer is an httptest server serving an AtomFeedSimulator.
+type SimulatorServer struct {
+	*httptest.Server
+
+	// Simulator is the simulator serving the requests to the server.
+	Simulator *AtomFeedSimulator
+
+	// ClientTLSConfig is a client TLS configuration that trusts the
+	// certificate authority that signed the certificate of a TLS server. It
+	// is nil for servers that do not use TLS.
+	ClientTLSConfig *tls.Config
+
+	// CACertPEM is the PEM encoded certificate of the certificate authority
+	// that signed the certificate of a TLS server, for clients that are
+	// configured with a CA file.
+	CACertPEM []byte
+}
+
+// NewTLSSimulatorServer starts an HTTPS test server serving a simulator
+// configured by opts. The certificate of the server is signed by a certificate
+// authority generated for the server, which clients must trust to connect, so
+// TLS verification and custom CA code paths can be tested.
+//
+//	s, err := feedsim.NewTLSSimulatorServer(feedsim.WithEvents(es...))
+//	client := &http.Client{Transport: &http.Transport{TLSClientConfig: s.ClientTLSConfig}}
+//
+// As the address of the server is not known until it is started, the base url
+// of the simulator is set to the url of the server and links are derived from
+// the request as with WithRequestHostLinks, so events can be created with any
+// server url.
+func NewTLSSimulatorServer(opts ...Option) (*SimulatorServer, error) {
+	return NewTLSSimulatorServerAt("", opts...)
+}
+
+// NewTLSSimulatorServerAt is NewTLSSimulatorServer listening on the fixed
+// address addr rather than an ephemeral port, as with StartServerAt. An empty
+// addr listens on an ephemeral port.
+func NewTLSSimulatorServerAt(addr string, opts ...Option) (*SimulatorServer, error) {
+	cert, caPEM, pool, err := generateCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := newUnstartedServer(addr)
+	if err != nil {
+		return nil, err
+	}
+	u := &url.URL{Scheme: "https", Host: srv.Listener.Addr().String()}
+
+	o := []Option{WithBaseURL(u), WithRequestHostLinks()}
+	sim, err := NewAtomFeedSimulator(append(o, opts...)...)
+	if err != nil {
+		srv.Listener.Close()
+		return nil, err
+	}
+
+	srv.Config.Handler = sim
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.EnableHTTP2 = sim.http2
+	srv.StartTLS()
+
+	return &SimulatorServer{
+		Server:          srv,
+		Simulator:       sim,
+		ClientTLSConfig: &tls.Config{RootCAs: pool},
+		CACertPEM:       caPEM,
+	}, nil
+}
+
+// Close shuts down the simulator and then the server.
+func (s *SimulatorServer) Close() {
+	s.Simulator.Shutdown(context.Background())
+	s.Server.Close()
+}
+
+// Restart simulates a restart of the server. It closes the listener and the
+// connections of clients, waits for downtime, during which connections are
+// refused, and then serves the simulator again on the same address. The state
+// of the simulator is preserved, so clients that reconnect and resume reading
+// can be tested.
+//
+// An error is returned if the address cannot be listened on again.
+func (s *SimulatorServer) Restart(downtime time.Duration) error {
+	addr := s.Listener.Addr().String()
+	old := s.Server
+	old.CloseClientConnections()
+	old.Close()
+
+	time.Sleep(downtime)
+	l, err := listen(addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &httptest.Server{
+		Listener:    l,
+		Config:      &http.Server{Handler: old.Config.Handler},
+		TLS:         old.TLS,
+		EnableHTTP2: old.EnableHTTP2,
+	}
+	if s.ClientTLSConfig != nil {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	s.Server = srv
+	return nil
+}
+
+// newUnstartedServer returns an unstarted test server listening on addr, or
+// on an ephemeral port of the loopback interface if addr is empty.
+func newUnstartedServer(addr string) (*httptest.Server, error) {
+	if addr == "" {
+		return httptest.NewUnstartedServer(nil), nil
+	}
+	l, err := listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &httptest.Server{Listener: l, Config: &http.Server{}}, nil
+}
+
+// listenTimeout is how long listen retries an address that is in use.
+const listenTimeout = 5 * time.Second
+
+// listen listens on addr, retrying for up to listenTimeout while the address
+// is still in use.
+func listen(addr string) (net.Listener, error) {
+	deadline := time.Now().Add(listenTimeout)
+	for {
+		l, err := net.Listen("tcp", addr)
+		if err == nil || !time.Now().Before(deadline) {
+			return l, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// SelfSignedTLS returns a server TLS configuration whose certificate, for the
+// loopback addresses and localhost, is signed by a newly generated certificate
+// authority, and the PEM encoded certificate of the authority for clients to
+// trust. It can be used to serve a simulator over TLS without a test server.
+func SelfSignedTLS() (*tls.Config, []byte, error) {
+	cert, caPEM, _, err := generateCertificates()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, caPEM, nil
+}
+
+// generateCertificates returns a server certificate for the loopback
+// addresses, the PEM encoded certificate of the authority that signed it and
+// a pool containing the authority.
+func generateCertificates() (tls.Certificate, []byte, *x509.CertPool, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "go.geteventstore.testfeed CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{der, caDER}, PrivateKey: key}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	return cert, caPEM, pool, nil
+}
@@ -0,0 +1,76 @@
+package feedsim
+
+import (
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestTLSSimulatorServer(c *C) {
+	stream := "tls-stream"
+	es := eventdata.CreateTestEvents(5, stream, "http://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithEvents(es...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+	c.Assert(strings.HasPrefix(srv.URL, "https://"), Equals, true)
+
+	_, err = http.Get(fmt.Sprintf("%s/streams/%s", srv.URL, stream))
+	c.Assert(err, NotNil)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: srv.ClientTLSConfig}}
+	resp, err := client.Get(fmt.Sprintf("%s/streams/%s", srv.URL, stream))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	f := &atom.Feed{}
+	c.Assert(xml.NewDecoder(resp.Body).Decode(f), IsNil)
+	c.Assert(f.Entry, HasLen, 5)
+	c.Assert(f.Link[0].Href, Equals, fmt.Sprintf("%s/streams/%s", srv.URL, stream))
+	c.Assert(f.Entry[0].Link[0].Href, Equals, fmt.Sprintf("%s/streams/%s/4/", srv.URL, stream))
+
+	pool := x509.NewCertPool()
+	c.Assert(pool.AppendCertsFromPEM(srv.CACertPEM), Equals, true)
+}
+
+func (s *MockSuite) TestTLSSimulatorServerRequiresEvents(c *C) {
+	srv, err := NewTLSSimulatorServer()
+	c.Assert(srv, IsNil)
+	c.Assert(err, Equals, ErrNoEvents)
+}
+
+func (s *MockSuite) TestSimulatorServerRestart(c *C) {
+	stream := "restarted-stream"
+	srv, err := NewTLSSimulatorServer(WithEvents(eventdata.CreateTestEvents(3, stream, "http://localhost:2113", "EventTypeX")...))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+	url := srv.URL
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: srv.ClientTLSConfig}}
+	streamURL := fmt.Sprintf("%s/streams/%s", url, stream)
+
+	resp, err := client.Get(streamURL)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+
+	restarted := make(chan error)
+	go func() { restarted <- srv.Restart(200 * time.Millisecond) }()
+	time.Sleep(50 * time.Millisecond)
+	_, err = client.Get(streamURL)
+	c.Assert(err, NotNil)
+
+	srv.Simulator.Append(offsetEvents(stream, 3, 2)...)
+	c.Assert(<-restarted, IsNil)
+	c.Assert(srv.URL, Equals, url)
+
+	resp, err = client.Get(streamURL)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	f := &atom.Feed{}
+	c.Assert(xml.NewDecoder(resp.Body).Decode(f), IsNil)
+	c.Assert(f.Entry, HasLen, 5)
+}
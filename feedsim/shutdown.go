@@ -0,0 +1,105 @@
+package feedsim
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Shutdown stops the simulator so that a test server using it can be closed
+// promptly and no goroutines are left running between test cases.
+//
+// Requests held by long polls or latencies return immediately, the goroutine
+// consuming the channel of a simulator created with
+// NewAtomFeedSimulatorFromChannel stops, calls to WaitForRequest and
+// WaitUntilReadThrough return ErrShutdown and requests received afterwards
+// receive 503 Service Unavailable. Shutdown then waits for the requests in
+// flight to complete. It returns the error of the context if the context is
+// done first.
+//
+// Shutdown may be called more than once.
+func (h *AtomFeedSimulator) Shutdown(ctx context.Context) error {
+	h.Lock()
+	select {
+	case <-h.done:
+	default:
+		close(h.done)
+	}
+	if h.active == 0 {
+		h.Unlock()
+		return nil
+	}
+	if h.idle == nil {
+		h.idle = make(chan struct{})
+	}
+	idle := h.idle
+	h.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin registers a request in flight. It returns false if the simulator has
+// been shut down, in which case the request must not be served.
+func (h *AtomFeedSimulator) begin() bool {
+	h.Lock()
+	defer h.Unlock()
+	if h.isShutdown() {
+		return false
+	}
+	h.active++
+	return true
+}
+
+// end unregisters a request in flight and wakes Shutdown once there are none.
+func (h *AtomFeedSimulator) end() {
+	h.Lock()
+	defer h.Unlock()
+	h.active--
+	if h.active == 0 && h.idle != nil {
+		close(h.idle)
+		h.idle = nil
+	}
+}
+
+// isShutdown reports whether Shutdown has been called.
+func (h *AtomFeedSimulator) isShutdown() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeShutdown writes the response for requests to a simulator that has been
+// shut down and reports whether a response was written.
+func (h *AtomFeedSimulator) writeShutdown(w http.ResponseWriter) bool {
+	if !h.isShutdown() {
+		return false
+	}
+	h.writeError(w, "Service Unavailable", http.StatusServiceUnavailable)
+	return true
+}
+
+// sleep waits for d. It returns false if ctx is done or the simulator is shut
+// down first.
+func (h *AtomFeedSimulator) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-h.done:
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+package feedsim
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestShutdownReleasesLongPoll(c *C) {
+	stream := "shutdown-stream"
+	es := eventdata.CreateTestEvents(2, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	ch := make(chan *eventdata.Event)
+	h, err := NewAtomFeedSimulatorFromChannel(ch, WithBaseURL(u), WithEvents(es...))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	statuses := make(chan int)
+	go func() {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s/2/forward/20", server.URL, stream), nil)
+		req.Header.Set("ES-LongPoll", "30")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			statuses <- 0
+			return
+		}
+		resp.Body.Close()
+		statuses <- resp.StatusCode
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = h.WaitForRequest(ctx, "/2/forward/20")
+	c.Assert(err, IsNil)
+
+	start := time.Now()
+	c.Assert(h.Shutdown(ctx), IsNil)
+	c.Assert(<-statuses, Equals, http.StatusOK)
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s", server.URL, stream)), Equals, http.StatusServiceUnavailable)
+
+	_, err = h.WaitForRequest(context.Background(), "never-requested")
+	c.Assert(err, Equals, ErrShutdown)
+	c.Assert(h.WaitUntilReadThrough(context.Background(), stream, 10), Equals, ErrShutdown)
+	c.Assert(h.Shutdown(ctx), IsNil)
+}
+
+func (s *MockSuite) TestShutdownReturnsContextError(c *C) {
+	stream := "shutdown-stream"
+	es := eventdata.CreateTestEvents(2, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+
+	release := make(chan struct{})
+	h.Override("/metadata$", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	mux.Handle("/", h)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := http.Get(fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = h.WaitForRequest(ctx, "/metadata$")
+	c.Assert(err, IsNil)
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	c.Assert(h.Shutdown(short), Equals, context.DeadlineExceeded)
+
+	close(release)
+	<-done
+	c.Assert(h.Shutdown(ctx), IsNil)
+}
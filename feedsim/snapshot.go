@@ -0,0 +1,165 @@
+package feedsim
+
+import (
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// Snapshot is a copy of the state of an AtomFeedSimulator. It is created by
+// Snapshot and applied by Restore.
+//
+// A snapshot captures the events of the stream and how many of them are
+// visible, the stream metadata, the progress of the append schedule, the
+// state of the streams, the recorded requests, the read positions, the route
+// overrides, the tokens issued, the persistent subscription groups, the
+// recorded schema violations and the events of the $settings stream.
+// Configuration set by options is not part of a snapshot.
+type Snapshot struct {
+	events        []*eventdata.Event
+	metaData      *eventdata.Event
+	trickleAfter  int
+	nextStep      int
+	untilAppend   time.Duration
+	streamStates  map[string]streamState
+	requests      []RecordedRequest
+	readPositions map[string]int
+	overrides     []override
+	tokens        map[string]time.Time
+	persistent    map[string]persistentGroupState
+	violations    []SchemaViolation
+	settings      *Snapshot
+}
+
+// Snapshot captures the current state of the simulator.
+//
+// A suite can capture a baseline once in SetUpSuite and restore it in
+// SetUpTest, which is much cheaper than creating the events of a large stream
+// for every test.
+func (h *AtomFeedSimulator) Snapshot() Snapshot {
+	h.Lock()
+	defer h.Unlock()
+	return h.snapshot()
+}
+
+// Restore replaces the state of the simulator with the state captured in s.
+//
+// The append schedule resumes from the point at which the snapshot was
+// taken, so a step that was due 100ms after the snapshot is appended 100ms
+// after the restore. Long polls waiting on the simulator are re-evaluated
+// against the restored state.
+//
+// Persistent subscription groups are replaced by the groups in s, so groups
+// created since the snapshot was taken are gone. Consumers connected to a
+// group are dropped, as they are when a group is deleted, and the events in
+// flight to them when the snapshot was taken are retried.
+func (h *AtomFeedSimulator) Restore(s Snapshot) {
+	h.Lock()
+	defer h.Unlock()
+	h.restore(s)
+}
+
+// Reset restores the simulator to the state it had when it was constructed.
+func (h *AtomFeedSimulator) Reset() {
+	h.Lock()
+	defer h.Unlock()
+	h.restore(h.initial)
+}
+
+// snapshot returns a copy of the state. The caller must hold the lock.
+func (h *AtomFeedSimulator) snapshot() Snapshot {
+	var untilAppend time.Duration
+	if now, ok := h.pendingStep(); ok {
+		h.advanceSchedule(now)
+		untilAppend = h.nextAppend.Sub(now)
+	}
+	s := Snapshot{
+		events:        append([]*eventdata.Event{}, h.Events...),
+		metaData:      h.MetaData,
+		trickleAfter:  h.TrickleAfter,
+		nextStep:      h.nextStep,
+		untilAppend:   untilAppend,
+		streamStates:  make(map[string]streamState, len(h.streamStates)),
+		requests:      append([]RecordedRequest{}, h.requests...),
+		readPositions: make(map[string]int, len(h.readPositions)),
+		overrides:     append([]override{}, h.overrides...),
+		tokens:        make(map[string]time.Time, len(h.tokens)),
+		persistent:    make(map[string]persistentGroupState, len(h.persistent)),
+		violations:    append([]SchemaViolation{}, h.violations...),
+	}
+	if h.settings != nil {
+		h.settings.sim.Lock()
+		settings := h.settings.sim.snapshot()
+		h.settings.sim.Unlock()
+		s.settings = &settings
+	}
+	for k, v := range h.tokens {
+		s.tokens[k] = v
+	}
+	for k, g := range h.persistent {
+		s.persistent[k] = g.state()
+	}
+	for k, v := range h.streamStates {
+		s.streamStates[k] = v
+	}
+	for k, v := range h.readPositions {
+		s.readPositions[k] = v
+	}
+	return s
+}
+
+// restore replaces the state with a copy of s. The caller must hold the lock.
+func (h *AtomFeedSimulator) restore(s Snapshot) {
+	c := s.copy()
+	h.pages.clear()
+	h.Events = c.events
+	h.MetaData = c.metaData
+	h.TrickleAfter = c.trickleAfter
+	h.nextStep = c.nextStep
+	if now, ok := h.pendingStep(); ok {
+		h.nextAppend = now.Add(c.untilAppend)
+	}
+	h.streamStates = c.streamStates
+	h.requests = c.requests
+	h.readPositions = c.readPositions
+	h.overrides = c.overrides
+	h.tokens = c.tokens
+	h.violations = c.violations
+	for _, g := range h.persistent {
+		close(g.deleted)
+	}
+	h.persistent = make(map[string]*persistentGroup, len(c.persistent))
+	for k, g := range c.persistent {
+		h.persistent[k] = g.group(h)
+	}
+	if h.settings != nil && c.settings != nil {
+		h.settings.sim.Lock()
+		h.settings.sim.restore(*c.settings)
+		h.settings.sim.Unlock()
+	}
+	h.notifyAppend()
+	h.notifyObservers()
+}
+
+// copy returns a deep copy of the snapshot so that a snapshot can be restored
+// any number of times.
+func (s Snapshot) copy() Snapshot {
+	c := s
+	c.events = append([]*eventdata.Event{}, s.events...)
+	c.requests = append([]RecordedRequest{}, s.requests...)
+	c.overrides = append([]override{}, s.overrides...)
+	c.violations = append([]SchemaViolation{}, s.violations...)
+	c.tokens = make(map[string]time.Time, len(s.tokens))
+	for k, v := range s.tokens {
+		c.tokens[k] = v
+	}
+	c.streamStates = make(map[string]streamState, len(s.streamStates))
+	for k, v := range s.streamStates {
+		c.streamStates[k] = v
+	}
+	c.readPositions = make(map[string]int, len(s.readPositions))
+	for k, v := range s.readPositions {
+		c.readPositions[k] = v
+	}
+	return c
+}
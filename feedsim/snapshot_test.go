@@ -0,0 +1,89 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestSnapshotAndRestore(c *C) {
+	stream := "snapshot-stream"
+	es := eventdata.CreateTestEvents(8, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es[:5]...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream), nil)
+	baseline := h.Snapshot()
+
+	h.Append(es[5:]...)
+	h.DeleteStream(stream, true)
+	getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, "other-stream"), nil)
+	c.Assert(len(h.Requests()), Equals, 2)
+
+	for i := 0; i < 2; i++ {
+		h.Restore(baseline)
+		c.Assert(h.StreamEvents(stream), DeepEquals, es[:5])
+		c.Assert(h.StreamDeleted(stream), Equals, false)
+		c.Assert(len(h.Requests()), Equals, 1)
+		c.Assert(h.ReadPositions(), DeepEquals, map[string]int{stream: 4})
+		h.Append(es[5])
+	}
+
+	h.Reset()
+	c.Assert(h.StreamEvents(stream), DeepEquals, es[:5])
+	c.Assert(len(h.Requests()), Equals, 0)
+	c.Assert(h.ReadPositions(), HasLen, 0)
+}
+
+func (s *MockSuite) TestResetRestartsAppendSchedule(c *C) {
+	stream := "snapshot-stream"
+	es := eventdata.CreateTestEvents(4, stream, server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithTrickle(2),
+		WithAppendSchedule([]AppendStep{{After: 100 * time.Millisecond, Count: 2}}))
+	c.Assert(err, IsNil)
+
+	time.Sleep(150 * time.Millisecond)
+	c.Assert(h.HeadVersion(stream), Equals, 3)
+
+	h.Reset()
+	c.Assert(h.HeadVersion(stream), Equals, 1)
+	time.Sleep(150 * time.Millisecond)
+	c.Assert(h.HeadVersion(stream), Equals, 3)
+}
+
+func (s *MockSuite) TestResetRemovesPersistentGroupsAndTokens(c *C) {
+	stream := "snapshot-persistent"
+	es := eventdata.CreateTestEvents(3, stream, "https://localhost:2113", "EventTypeX")
+	srv, err := NewTLSSimulatorServer(WithGRPC(), WithEvents(es...),
+		WithPersistentSubscription(stream, "kept", PersistentSubscriptionSettings{}))
+	c.Assert(err, IsNil)
+	defer srv.Close()
+	h := srv.Simulator
+
+	var so []byte
+	so = appendStreamIdentifier(so, 1, stream)
+	so = protowire.AppendBytes(so, 3, empty)
+	var o []byte
+	o = protowire.AppendString(o, 2, "added")
+	o = protowire.AppendBytes(o, 4, so)
+	c.Assert(persistentCall(c, srv, "Create", protowire.AppendBytes(nil, 1, o)).Get("Grpc-Status"), Equals, "0")
+	c.Assert(h.persistentGroup(stream, "added"), NotNil)
+	h.IssueToken()
+	c.Assert(h.tokens, HasLen, 1)
+
+	h.Reset()
+	c.Assert(h.persistentGroup(stream, "added"), IsNil)
+	c.Assert(h.persistentGroup(stream, "kept"), NotNil)
+	c.Assert(h.tokens, HasLen, 0)
+
+	var d []byte
+	d = appendStreamIdentifier(d, 1, stream)
+	d = protowire.AppendString(d, 2, "added")
+	c.Assert(persistentCall(c, srv, "Delete", protowire.AppendBytes(nil, 1, d)).Get("Exception"), Equals, "persistent-subscription-does-not-exist")
+}
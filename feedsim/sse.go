@@ -0,0 +1,144 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mediaTypeEventStream = "text/event-stream"
+
+// WithServerSentEvents serves the stream as server-sent events to GET requests
+// for the head of the stream that accept text/event-stream, as a proxy in
+// front of GetEventStore might, so that clients consuming a stream by push can
+// be tested.
+//
+// Each event of the stream is sent as a message whose id is the event number,
+// whose event is the event type and whose data is the event as JSON on a single
+// line. A request with a Last-Event-ID header receives the events after that
+// number, so a reconnecting client resumes where it left off, and a
+// Last-Event-ID of -1 receives the stream from the start. Otherwise only the
+// events appended after the request are sent. The response stays open,
+// pushing events as they are appended, until the client disconnects, the
+// stream is deleted or the simulator shuts down.
+//
+// If keepAlive is greater than zero a comment is sent whenever no event has
+// been sent for that long, so that idle connections are kept open by
+// proxies.
+func WithServerSentEvents(keepAlive time.Duration) Option {
+	return func(h *AtomFeedSimulator) error {
+		if keepAlive < 0 {
+			return fmt.Errorf("keep alive interval %v must not be negative", keepAlive)
+		}
+		h.sse = true
+		h.sseKeepAlive = keepAlive
+		return nil
+	}
+}
+
+// isServerSentEvents reports whether r asks for the stream as server-sent
+// events.
+func isServerSentEvents(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		if strings.ToLower(strings.TrimSpace(strings.Split(v, ";")[0])) == mediaTypeEventStream {
+			return true
+		}
+	}
+	return false
+}
+
+// serveServerSentEvents pushes the events of the stream addressed by the
+// request as server-sent events.
+func (h *AtomFeedSimulator) serveServerSentEvents(w http.ResponseWriter, r *http.Request, d RequestDetails) {
+	fr, err := ParseStreamURL(d.URL)
+	if err != nil {
+		h.writeFeedError(w, d, err)
+		return
+	}
+
+	next := -1
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < -1 {
+			h.writeError(w, fmt.Sprintf("Last-Event-ID %q is not an event number", v), http.StatusBadRequest)
+			return
+		}
+		next = n + 1
+	}
+	if next < 0 {
+		next = 0
+		if es := h.streamEvents(fr.Stream); len(es) > 0 {
+			next = es[len(es)-1].EventNumber + 1
+		}
+	}
+
+	w.Header().Set("Content-Type", mediaTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flushSSE(w)
+
+	sent := time.Now()
+	for {
+		appended := h.appendNotification()
+
+		if _, ok := h.streamErr(fr.Stream).(StreamDeletedError); ok {
+			return
+		}
+		for _, e := range h.streamEvents(fr.Stream) {
+			if e.EventNumber < next {
+				continue
+			}
+			b, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.EventNumber, e.EventType, b)
+			h.served(fr.Stream, e.EventNumber)
+			next = e.EventNumber + 1
+			sent = time.Now()
+		}
+		flushSSE(w)
+
+		var wake time.Time
+		if h.sseKeepAlive > 0 {
+			wake = sent.Add(h.sseKeepAlive)
+		}
+		if at, ok := h.nextScheduledAppend(); ok && (wake.IsZero() || at.Before(wake)) {
+			wake = at
+		}
+		var timeout <-chan time.Time
+		var t *time.Timer
+		if !wake.IsZero() {
+			t = time.NewTimer(time.Until(wake))
+			timeout = t.C
+		}
+		select {
+		case <-appended:
+		case <-timeout:
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		}
+		if t != nil {
+			t.Stop()
+		}
+		if h.sseKeepAlive > 0 && time.Since(sent) >= h.sseKeepAlive {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			sent = time.Now()
+		}
+	}
+}
+
+// flushSSE sends what has been written to w to the client.
+func flushSSE(w http.ResponseWriter) {
+	if fl, ok := w.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
@@ -0,0 +1,97 @@
+package feedsim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// readServerSentEvent returns the fields of the next message or comment read
+// from r.
+func readServerSentEvent(c *C, r *bufio.Reader) []string {
+	var fields []string
+	for {
+		line, err := r.ReadString('\n')
+		c.Assert(err, IsNil)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		fields = append(fields, line)
+	}
+}
+
+func getServerSentEvents(c *C, streamURL, lastEventID string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, streamURL, nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "text/event-stream")
+	return resp
+}
+
+func (s *MockSuite) TestServerSentEventsPushAppendedEvents(c *C) {
+	stream := "sse-stream"
+	es := eventdata.CreateTestEvents(4, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es[:2]...), WithBaseURL(u), WithServerSentEvents(0))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	resp := getServerSentEvents(c, streamURL, "")
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+
+	h.Append(es[2])
+	fields := readServerSentEvent(c, r)
+	c.Assert(fields, HasLen, 3)
+	c.Assert(fields[0], Equals, "id: 2")
+	c.Assert(fields[1], Equals, "event: EventTypeX")
+	var e eventdata.Event
+	c.Assert(json.Unmarshal([]byte(strings.TrimPrefix(fields[2], "data: ")), &e), IsNil)
+	c.Assert(e.EventID, Equals, es[2].EventID)
+
+	replay := getServerSentEvents(c, streamURL, "0")
+	defer replay.Body.Close()
+	rr := bufio.NewReader(replay.Body)
+	c.Assert(readServerSentEvent(c, rr)[0], Equals, "id: 1")
+	c.Assert(readServerSentEvent(c, rr)[0], Equals, "id: 2")
+
+	h.Append(es[3])
+	c.Assert(readServerSentEvent(c, r)[0], Equals, "id: 3")
+	c.Assert(readServerSentEvent(c, rr)[0], Equals, "id: 3")
+
+	f := getFeed(c, streamURL, nil)
+	c.Assert(f.Entry, HasLen, 4)
+}
+
+func (s *MockSuite) TestServerSentEventsKeepAlive(c *C) {
+	stream := "sse-keepalive"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithServerSentEvents(20*time.Millisecond))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	resp := getServerSentEvents(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), "-1")
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+	c.Assert(readServerSentEvent(c, r)[0], Equals, "id: 0")
+	c.Assert(readServerSentEvent(c, r), DeepEquals, []string{": keep-alive"})
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithServerSentEvents(-time.Second))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,62 @@
+package feedsim
+
+import "github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+
+// StreamEvents returns a copy of the events of stream that are currently
+// visible to readers. It returns no events for a stream that is empty,
+// missing or deleted.
+func (h *AtomFeedSimulator) StreamEvents(stream string) []*eventdata.Event {
+	if h.streamErr(stream) != nil {
+		return []*eventdata.Event{}
+	}
+	es := h.streamEvents(stream)
+	return append(make([]*eventdata.Event, 0, len(es)), es...)
+}
+
+// HeadVersion returns the event number of the last event of stream that is
+// visible to readers, or -1 if the stream has no visible events.
+func (h *AtomFeedSimulator) HeadVersion(stream string) int {
+	es := h.StreamEvents(stream)
+	if len(es) == 0 {
+		return -1
+	}
+	return es[len(es)-1].EventNumber
+}
+
+// StreamMetaData returns the metadata served for stream, or nil if there is
+// no metadata or the stream cannot be read.
+func (h *AtomFeedSimulator) StreamMetaData(stream string) *eventdata.Event {
+	if h.streamErr(stream) != nil {
+		return nil
+	}
+	h.RLock()
+	defer h.RUnlock()
+	return h.MetaData
+}
+
+// StreamExists reports whether stream can be read, including streams that
+// exist but contain no events.
+func (h *AtomFeedSimulator) StreamExists(stream string) bool {
+	return h.streamErr(stream) == nil
+}
+
+// StreamDeleted reports whether stream has been hard deleted, either with
+// WithDeletedStream or DeleteStream. Soft deleted streams are reported as not
+// existing by StreamExists.
+func (h *AtomFeedSimulator) StreamDeleted(stream string) bool {
+	_, ok := h.streamErr(stream).(StreamDeletedError)
+	return ok
+}
+
+// ReadPositions returns a copy of the read position of each stream that has
+// been read, keyed by stream name. The read positions act as the checkpoints
+// of the subscriptions of the clients under test. See ReadPosition.
+func (h *AtomFeedSimulator) ReadPositions() map[string]int {
+	h.RLock()
+	defer h.RUnlock()
+	p := make(map[string]int, len(h.readPositions))
+	for k, v := range h.readPositions {
+		p[k] = v
+	}
+	return p
+}
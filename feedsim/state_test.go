@@ -0,0 +1,43 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestStateInspection(c *C) {
+	stream := "inspected-stream"
+	es := eventdata.CreateTestEvents(6, stream, server.URL, "EventTypeX")
+	m := eventdata.CreateTestEvents(1, stream, server.URL, "metadata")[0]
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithMetaData(m), WithTrickle(4),
+		WithEmptyStream("empty-stream"), WithDeletedStream("deleted-stream"))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	c.Assert(h.StreamEvents(stream), DeepEquals, es[:4])
+	c.Assert(h.HeadVersion(stream), Equals, 3)
+	c.Assert(h.StreamMetaData(stream), Equals, m)
+	c.Assert(h.StreamExists(stream), Equals, true)
+	c.Assert(h.StreamDeleted(stream), Equals, false)
+
+	c.Assert(h.StreamEvents("empty-stream"), HasLen, 0)
+	c.Assert(h.HeadVersion("empty-stream"), Equals, -1)
+	c.Assert(h.StreamExists("empty-stream"), Equals, true)
+
+	c.Assert(h.StreamEvents("deleted-stream"), HasLen, 0)
+	c.Assert(h.StreamMetaData("deleted-stream"), IsNil)
+	c.Assert(h.StreamExists("deleted-stream"), Equals, false)
+	c.Assert(h.StreamDeleted("deleted-stream"), Equals, true)
+
+	h.DeleteStream(stream, false)
+	c.Assert(h.StreamExists(stream), Equals, false)
+	c.Assert(h.StreamDeleted(stream), Equals, false)
+
+	c.Assert(h.ReadPositions(), HasLen, 0)
+	getFeed(c, fmt.Sprintf("%s/streams/%s/0/forward/2", server.URL, "other-stream"), nil)
+	c.Assert(h.ReadPositions(), DeepEquals, map[string]int{"other-stream": 1})
+}
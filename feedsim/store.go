@@ -0,0 +1,122 @@
+package feedsim
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// eventIDPattern matches the event ids, uuids, by which events can be
+// addressed in place of their event numbers.
+const eventIDPattern = "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"
+
+var eventIDSuffix = regexp.MustCompile("/(" + eventIDPattern + ")/?$")
+
+// eventIDFromURL returns the event id addressed by url, if the url addresses
+// an event by its id rather than its event number.
+func eventIDFromURL(url string) (string, bool) {
+	m := eventIDSuffix.FindStringSubmatch(url)
+	if m == nil {
+		return "", false
+	}
+	return strings.ToLower(m[1]), true
+}
+
+// eventStore indexes the events of the stream by event number, by event id and
+// by the canonical uri of the event, the uri of its edit link, so that events
+// can be looked up in constant time however long the stream grows.
+//
+// The index is brought up to date with the events of the simulator before it
+// is used. Events appended to the stream are added to the index and the index
+// is rebuilt if the events have been replaced.
+type eventStore struct {
+	events   []*eventdata.Event
+	byNumber map[int]int
+	byID     map[string]int
+	byURI    map[string]int
+}
+
+// sync brings the index up to date with the events es.
+func (s *eventStore) sync(es []*eventdata.Event) {
+	n := len(s.events)
+	if n > len(es) || (n > 0 && (s.events[0] != es[0] || s.events[n-1] != es[n-1])) {
+		n = 0
+	}
+	if n == 0 {
+		s.byNumber = make(map[int]int, len(es))
+		s.byID = make(map[string]int, len(es))
+		s.byURI = make(map[string]int, len(es))
+	}
+	for i := n; i < len(es); i++ {
+		e := es[i]
+		s.byNumber[e.EventNumber] = i
+		s.byID[strings.ToLower(e.EventID)] = i
+		if len(e.Links) > 0 {
+			s.byURI[canonicalURI(e.Links[0].URI)] = i
+		}
+	}
+	s.events = es
+}
+
+// resolve returns the event addressed by url among the events of the stream
+// from index from up to but excluding index visible, the events that can be
+// read. The url is looked up as the uri of an event and otherwise by the
+// event id or event number it ends with.
+func (s *eventStore) resolve(from, visible int, url string) (*eventdata.Event, error) {
+	if i, ok := s.byURI[canonicalURI(url)]; ok && i >= from && i < visible {
+		return s.events[i], nil
+	}
+	if id, ok := eventIDFromURL(url); ok {
+		if i, ok := s.byID[id]; ok && i >= from && i < visible {
+			return s.events[i], nil
+		}
+		return nil, EventIDNotFoundError(id)
+	}
+	n, head, err := parseEventURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if head {
+		if visible <= from {
+			return nil, EventNotFoundError(0)
+		}
+		return s.events[visible-1], nil
+	}
+	if i, ok := s.byNumber[n]; ok && i >= from && i < visible {
+		return s.events[i], nil
+	}
+	return nil, EventNotFoundError(n)
+}
+
+// stripQuery returns the url u without its query string.
+func stripQuery(u string) string {
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		return u[:i]
+	}
+	return u
+}
+
+// canonicalURI returns the uri u without a trailing slash, so that the uris
+// of events match whether or not a request ends with a slash.
+func canonicalURI(u string) string {
+	return strings.TrimRight(u, "/")
+}
+
+// resolveEvent returns the event of stream addressed by url, ignoring its
+// query.
+func (h *AtomFeedSimulator) resolveEvent(stream, url string) (*eventdata.Event, error) {
+	url = stripQuery(url)
+	if h.virtual != nil {
+		return h.virtual.resolveEvent(url)
+	}
+	es := h.streamEvents(stream)
+	h.Lock()
+	defer h.Unlock()
+	h.store.sync(h.Events)
+	from := 0
+	if len(es) > 0 {
+		from = h.store.byNumber[es[0].EventNumber]
+	}
+	return h.store.resolve(from, from+len(es), url)
+}
@@ -0,0 +1,80 @@
+package feedsim
+
+import (
+	"fmt"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+// offsetEvents returns count events of stream numbered from first.
+func offsetEvents(stream string, first, count int) []*eventdata.Event {
+	es := []*eventdata.Event{}
+	for i := first; i < first+count; i++ {
+		es = append(es, eventdata.CreateTestEvent(stream, "http://localhost:2113", "EventTypeX", i, nil, nil))
+	}
+	return es
+}
+
+func (s *MockSuite) TestEventStoreResolvesByURIAndNumber(c *C) {
+	es := offsetEvents("truncated", 100, 10)
+	store := &eventStore{}
+	store.sync(es)
+
+	e, err := store.resolve(0, len(es), "http://localhost:2113/streams/truncated/103/")
+	c.Assert(err, IsNil)
+	c.Assert(e, Equals, es[3])
+
+	e, err = store.resolve(0, len(es), "http://other-host/streams/truncated/104")
+	c.Assert(err, IsNil)
+	c.Assert(e, Equals, es[4])
+
+	e, err = store.resolve(0, len(es), "http://localhost:2113/streams/truncated/head")
+	c.Assert(err, IsNil)
+	c.Assert(e, Equals, es[9])
+
+	_, err = store.resolve(0, 5, "http://localhost:2113/streams/truncated/107/")
+	c.Assert(err, Equals, EventNotFoundError(107))
+	_, err = store.resolve(0, len(es), "http://localhost:2113/streams/truncated/3")
+	c.Assert(err, Equals, EventNotFoundError(3))
+}
+
+func (s *MockSuite) TestEventStoreSyncsWithEvents(c *C) {
+	es := offsetEvents("astream", 0, 5)
+	store := &eventStore{}
+	store.sync(es)
+
+	es = append(es, offsetEvents("astream", 5, 5)...)
+	store.sync(es)
+	e, err := store.resolve(0, len(es), "http://localhost:2113/streams/astream/7")
+	c.Assert(err, IsNil)
+	c.Assert(e, Equals, es[7])
+
+	replaced := offsetEvents("astream", 0, 3)
+	store.sync(replaced)
+	e, err = store.resolve(0, len(replaced), "http://localhost:2113/streams/astream/2")
+	c.Assert(err, IsNil)
+	c.Assert(e, Equals, replaced[2])
+	_, err = store.resolve(0, len(replaced), "http://localhost:2113/streams/astream/7")
+	c.Assert(err, NotNil)
+}
+
+func (s *MockSuite) TestPagingStreamNotStartingAtZero(c *C) {
+	es := offsetEvents("truncated", 100, 10)
+
+	w := getSliceSection(es, 104, 3, "forward", LegacyPaging)
+	c.Assert(w.Events, DeepEquals, es[4:7])
+	c.Assert(w.IsLastPage, Equals, false)
+	c.Assert(w.IsHead, Equals, false)
+	c.Assert(w.NextVersion, Equals, 103)
+	c.Assert(w.PrevVersion, Equals, 107)
+
+	w = getSliceSection(es, 102, 5, "backward", LegacyPaging)
+	c.Assert(w.Events, DeepEquals, es[0:3])
+	c.Assert(w.IsLastPage, Equals, true)
+	c.Assert(w.NextVersion, Equals, -1)
+
+	e, err := resolveEvent(es, fmt.Sprintf("http://localhost:2113/streams/truncated/%d", 105))
+	c.Assert(err, IsNil)
+	c.Assert(e, Equals, es[5])
+}
@@ -0,0 +1,50 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestUnusualStreamNames(c *C) {
+	for _, stream := range []string{
+		"$ce-orders",
+		"my stream",
+		"ünïcödé-stream",
+		"tenant/orders",
+		"order-3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	} {
+		mux = http.NewServeMux()
+		server.Config.Handler = mux
+		es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+		u, _ := url.Parse(server.URL)
+		h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithStream(stream))
+		c.Assert(err, IsNil)
+		mux.Handle("/", h)
+
+		base := fmt.Sprintf("%s/streams/%s", server.URL, url.PathEscape(stream))
+		c.Assert(es[0].Links[0].URI, Equals, base+"/0/", Commentf(stream))
+
+		f := getFeed(c, base, nil)
+		c.Assert(f.Entry, HasLen, 3, Commentf(stream))
+		c.Assert(f.Entry[0].Title, Equals, "2@"+stream)
+		c.Assert(f.Link[0].Href, Equals, base)
+
+		f = getFeed(c, f.Link[len(f.Link)-2].Href, nil)
+		c.Assert(f.Entry, HasLen, 0, Commentf(stream))
+
+		resp, err := http.Get(f.Link[0].Href + "/1")
+		c.Assert(err, IsNil)
+		var e eventdata.EventAtomResponse
+		c.Assert(json.NewDecoder(resp.Body).Decode(&e), IsNil)
+		resp.Body.Close()
+		c.Assert(e.Title, Equals, "1@"+stream)
+
+		c.Assert(h.ReadPosition(stream), Equals, 2)
+		c.Assert(getStatus(c, base+"/metadata"), Equals, http.StatusOK)
+	}
+}
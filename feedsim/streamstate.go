@@ -0,0 +1,144 @@
+package feedsim
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+type streamState int
+
+const (
+	streamExists streamState = iota
+	streamMissing
+	streamDeleted
+	streamEmpty
+)
+
+// WithMissingStream makes the simulator respond to every request for the
+// stream with 404 Not Found as the server does for a stream that has never
+// existed.
+func WithMissingStream(stream string) Option {
+	return withStreamState(stream, streamMissing)
+}
+
+// WithDeletedStream makes the simulator respond to every request for the
+// stream with 410 Gone as the server does for a stream that has been hard
+// deleted.
+func WithDeletedStream(stream string) Option {
+	return withStreamState(stream, streamDeleted)
+}
+
+// WithEmptyStream makes the simulator serve the stream as an existing stream
+// containing no events.
+func WithEmptyStream(stream string) Option {
+	return withStreamState(stream, streamEmpty)
+}
+
+func withStreamState(stream string, state streamState) Option {
+	return func(h *AtomFeedSimulator) error {
+		if stream == "" {
+			return ErrEmptyStreamName
+		}
+		h.setStreamState(stream, state)
+		return nil
+	}
+}
+
+// writeStreamState writes the response for requests to a stream that is
+// missing or deleted and reports whether a response was written.
+func (h *AtomFeedSimulator) writeStreamState(w http.ResponseWriter, stream string) bool {
+	switch h.streamErr(stream).(type) {
+	case StreamNotFoundError:
+		h.writeError(w, "Not Found", http.StatusNotFound)
+		return true
+	case StreamDeletedError:
+		h.writeError(w, "Stream deleted", http.StatusGone)
+		return true
+	}
+	return false
+}
+
+// servesStream reports whether stream is the stream served by the simulator,
+// named with WithStream or otherwise by the events it was created with.
+func (h *AtomFeedSimulator) servesStream(stream string) bool {
+	h.RLock()
+	defer h.RUnlock()
+	name := h.stream
+	if name == "" && len(h.Events) > 0 {
+		name = h.Events[0].EventStreamID
+	}
+	return name == "" || stream == name
+}
+
+// streamErr returns a StreamNotFoundError or StreamDeletedError if the stream
+// cannot be read.
+func (h *AtomFeedSimulator) streamErr(stream string) error {
+	state := h.stateOf(stream)
+	if state == streamExists && h.stream != "" && stream != h.stream {
+		state = streamMissing
+	}
+
+	switch state {
+	case streamMissing:
+		return StreamNotFoundError(stream)
+	case streamDeleted:
+		return StreamDeletedError(stream)
+	}
+	return nil
+}
+
+// streamEvents returns the events currently visible in the stream.
+func (h *AtomFeedSimulator) streamEvents(stream string) []*eventdata.Event {
+	if h.stateOf(stream) == streamEmpty {
+		return []*eventdata.Event{}
+	}
+	return h.truncate(h.visibleEvents())
+}
+
+// stateOf returns the state of the stream.
+func (h *AtomFeedSimulator) stateOf(stream string) streamState {
+	h.RLock()
+	defer h.RUnlock()
+	return h.streamStates[stream]
+}
+
+// DeleteStream deletes the stream at runtime. Subsequent requests for the
+// stream receive 410 Gone if hard is true, as the server responds for a hard
+// deleted stream, or 404 Not Found otherwise.
+//
+// A soft deleted stream is revived by a write to it. The events written are
+// numbered on from the events written before the delete, which are hidden by
+// setting the $tb of the stream metadata to the first event written.
+func (h *AtomFeedSimulator) DeleteStream(stream string, hard bool) {
+	state := streamMissing
+	if hard {
+		state = streamDeleted
+	}
+	h.Lock()
+	defer h.Unlock()
+	h.setStreamState(stream, state)
+}
+
+// setStreamState sets the state of the stream. The caller must hold the lock.
+func (h *AtomFeedSimulator) setStreamState(stream string, state streamState) {
+	if h.streamStates == nil {
+		h.streamStates = make(map[string]streamState)
+	}
+	h.streamStates[stream] = state
+	h.pages.clear()
+}
+
+// streamFromURL returns the name of the stream addressed by u.
+func streamFromURL(u *url.URL) string {
+	split, err := pathSegments(u)
+	if err != nil {
+		return ""
+	}
+	i := streamsSegment(split, 2, 3, 5)
+	if i < 0 {
+		return ""
+	}
+	return split[i+1]
+}
@@ -0,0 +1,59 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestMissingAndDeletedStreamResponses(c *C) {
+	es := eventdata.CreateTestEvents(5, "existing-stream", server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithMissingStream("missing-stream"),
+		WithDeletedStream("deleted-stream"))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	tests := []struct {
+		stream string
+		status int
+	}{
+		{"existing-stream", http.StatusOK},
+		{"missing-stream", http.StatusNotFound},
+		{"deleted-stream", http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		for _, path := range []string{"", "/head/backward/20", "/0/forward/20", "/1", "/metadata"} {
+			resp, err := http.Get(fmt.Sprintf("%s/streams/%s%s", server.URL, tt.stream, path))
+			c.Assert(err, IsNil)
+			resp.Body.Close()
+			c.Assert(resp.StatusCode, Equals, tt.status, Commentf("%s%s", tt.stream, path))
+		}
+	}
+
+	c.Assert(h.streamErr("existing-stream"), IsNil)
+	c.Assert(h.streamErr("missing-stream"), Equals, StreamNotFoundError("missing-stream"))
+	c.Assert(h.streamErr("deleted-stream"), Equals, StreamDeletedError("deleted-stream"))
+}
+
+func (s *MockSuite) TestEmptyStreamServesEmptyFeed(c *C) {
+	stream := "empty-stream"
+	es := eventdata.CreateTestEvents(5, "existing-stream", server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithEmptyStream(stream))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 0)
+	c.Assert(f.HeadOfStream, Equals, true)
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/0", server.URL, stream))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+}
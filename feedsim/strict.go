@@ -0,0 +1,77 @@
+package feedsim
+
+import (
+	"net/http"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// WithStrictHeadOfStream makes the simulator reproduce exactly how EventStore
+// behaves at the head of a stream, which clients polling for new events rely
+// on:
+//
+//   - An empty page read forward from beyond the last event carries a
+//     previous link to itself, so that readers can keep polling it.
+//   - Every feed carries an eTag and an ETag header, whatever the server
+//     version, and a request with a matching If-None-Match header receives
+//     304 Not Modified.
+//   - Pages at the head of the stream are served with
+//     Cache-Control: max-age=0, no-cache, must-revalidate and pages that can
+//     no longer change with Cache-Control: max-age=31536000, public.
+//
+// By default empty pages have no previous link and feeds only carry an eTag
+// when the server version has one.
+func WithStrictHeadOfStream() Option {
+	return func(h *AtomFeedSimulator) error {
+		h.strictHead = true
+		return nil
+	}
+}
+
+// applyStrictHead adds the links and eTag of the feed f over the events es
+// requested by r that EventStore returns at the head of a stream.
+func (h *AtomFeedSimulator) applyStrictHead(f *atom.Feed, es []*eventdata.Event, r *StreamURL) {
+	if !h.strictHead {
+		return
+	}
+	if f.ETag == "" {
+		f.ETag = feedETag(es)
+	}
+	if len(f.Entry) > 0 || r.Direction != "forward" || hasLink(f, "previous") {
+		return
+	}
+
+	prev := atom.Link{Href: NewLinkBuilder(r).Previous(r.Version), Rel: "previous"}
+	for i, l := range f.Link {
+		if l.Rel == "metadata" {
+			f.Link = append(f.Link[:i], append([]atom.Link{prev}, f.Link[i:]...)...)
+			return
+		}
+	}
+	f.Link = append(f.Link, prev)
+}
+
+// writeCacheControl writes the Cache-Control header EventStore sends with the
+// feed f.
+func (h *AtomFeedSimulator) writeCacheControl(w http.ResponseWriter, f *atom.Feed) {
+	if !h.strictHead {
+		return
+	}
+	w.Header().Add("Vary", "Accept")
+	if f.HeadOfStream {
+		w.Header().Set("Cache-Control", "max-age=0, no-cache, must-revalidate")
+		return
+	}
+	w.Header().Set("Cache-Control", "max-age=31536000, public")
+}
+
+// hasLink reports whether the feed f has a link with the relation rel.
+func hasLink(f *atom.Feed, rel string) bool {
+	for _, l := range f.Link {
+		if l.Rel == rel {
+			return true
+		}
+	}
+	return false
+}
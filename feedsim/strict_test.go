@@ -0,0 +1,183 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+// linkPaths returns the relations and paths of the links of the feed f.
+func linkPaths(c *C, f *atom.Feed) map[string]string {
+	links := map[string]string{}
+	for _, l := range f.Link {
+		u, err := url.Parse(l.Href)
+		c.Assert(err, IsNil)
+		links[l.Rel] = u.Path
+	}
+	return links
+}
+
+// entryTitles returns the titles of the entries of the feed f.
+func entryTitles(f *atom.Feed) []string {
+	titles := []string{}
+	for _, e := range f.Entry {
+		titles = append(titles, e.Title)
+	}
+	return titles
+}
+
+// feedLinks returns the relations and paths of the links of the feed served
+// for path by h, and the feed.
+func feedLinks(c *C, h http.Handler, path string) (map[string]string, *atom.Feed, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest("GET", path, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	f := &atom.Feed{}
+	c.Assert(xml.Unmarshal(rec.Body.Bytes(), f), IsNil)
+	return linkPaths(c, f), f, rec
+}
+
+func (s *MockSuite) TestStrictHeadOfStream(c *C) {
+	es := eventdata.CreateTestEvents(5, "newstream", "http://127.0.0.1:2113", "EventTypeX")
+	u, _ := url.Parse("http://127.0.0.1:2113")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithStrictHeadOfStream())
+	c.Assert(err, IsNil)
+
+	tests := []struct {
+		path         string
+		links        map[string]string
+		titles       []string
+		cacheControl string
+	}{
+		{
+			"/streams/newstream",
+			map[string]string{
+				"self":     "/streams/newstream",
+				"first":    "/streams/newstream/head/backward/20",
+				"previous": "/streams/newstream/5/forward/20",
+				"metadata": "/streams/newstream/metadata",
+			},
+			[]string{"4@newstream", "3@newstream", "2@newstream", "1@newstream", "0@newstream"},
+			"max-age=0, no-cache, must-revalidate",
+		},
+		{
+			"/streams/newstream/5/forward/20",
+			map[string]string{
+				"self":     "/streams/newstream",
+				"first":    "/streams/newstream/head/backward/20",
+				"last":     "/streams/newstream/0/forward/20",
+				"next":     "/streams/newstream/4/backward/20",
+				"previous": "/streams/newstream/5/forward/20",
+				"metadata": "/streams/newstream/metadata",
+			},
+			[]string{},
+			"max-age=0, no-cache, must-revalidate",
+		},
+		{
+			"/streams/newstream/0/forward/2",
+			map[string]string{
+				"self":     "/streams/newstream",
+				"first":    "/streams/newstream/head/backward/2",
+				"previous": "/streams/newstream/2/forward/2",
+				"metadata": "/streams/newstream/metadata",
+			},
+			[]string{"1@newstream", "0@newstream"},
+			"max-age=31536000, public",
+		},
+	}
+
+	for _, tt := range tests {
+		links, f, rec := feedLinks(c, h, "http://127.0.0.1:2113"+tt.path)
+		c.Assert(links, DeepEquals, tt.links, Commentf(tt.path))
+		c.Assert(entryTitles(f), DeepEquals, tt.titles, Commentf(tt.path))
+		c.Assert(rec.Header().Get("ETag"), Equals, fmt.Sprintf("%q", feedETag(es)))
+		c.Assert(rec.Header().Get("Cache-Control"), Equals, tt.cacheControl, Commentf(tt.path))
+	}
+}
+
+// withoutTimestamps returns a copy of the feed f with the updated and
+// published times of the feed and its entries cleared.
+func withoutTimestamps(f *atom.Feed) *atom.Feed {
+	g := *f
+	g.Updated = ""
+	g.Entry = nil
+	for _, e := range f.Entry {
+		e := *e
+		e.Updated, e.Published = "", ""
+		g.Entry = append(g.Entry, &e)
+	}
+	return &g
+}
+
+// TestStrictHeadOfStreamMatchesCapture compares the feeds served in strict
+// mode, field by field apart from timestamps, with the feed documents
+// captured from a server into testdata/strict by the
+// TestCaptureStrictDocuments test of package conformance. The provenance.json
+// written alongside the documents records the server, its version and the
+// time of the capture. The test is skipped until documents are captured.
+func (s *MockSuite) TestStrictHeadOfStreamMatchesCapture(c *C) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "strict", "provenance.json"))
+	if os.IsNotExist(err) {
+		c.Skip("no feed documents captured in testdata/strict")
+	}
+	c.Assert(err, IsNil)
+	var p struct {
+		Server    string `json:"server"`
+		Stream    string `json:"stream"`
+		EventType string `json:"eventType"`
+		Events    int    `json:"events"`
+		Documents []struct {
+			Path         string `json:"path"`
+			File         string `json:"file"`
+			ETag         string `json:"etag"`
+			CacheControl string `json:"cacheControl"`
+		} `json:"documents"`
+	}
+	c.Assert(json.Unmarshal(b, &p), IsNil)
+
+	es := eventdata.CreateTestEvents(p.Events, p.Stream, p.Server, p.EventType)
+	u, err := url.Parse(p.Server)
+	c.Assert(err, IsNil)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithStrictHeadOfStream())
+	c.Assert(err, IsNil)
+
+	for _, d := range p.Documents {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "strict", d.File))
+		c.Assert(err, IsNil)
+		want := &atom.Feed{}
+		c.Assert(xml.Unmarshal(b, want), IsNil)
+
+		path := "/streams/" + p.Stream
+		if d.Path != "" {
+			path += "/" + d.Path
+		}
+		_, got, rec := feedLinks(c, h, p.Server+path)
+		c.Assert(withoutTimestamps(got), DeepEquals, withoutTimestamps(want), Commentf(d.File))
+		c.Assert(rec.Header().Get("ETag"), Equals, d.ETag, Commentf(d.File))
+		c.Assert(rec.Header().Get("Cache-Control"), Equals, d.CacheControl, Commentf(d.File))
+	}
+}
+
+func (s *MockSuite) TestLenientHeadOfStream(c *C) {
+	es := eventdata.CreateTestEvents(5, "newstream", "http://127.0.0.1:2113", "EventTypeX")
+	u, _ := url.Parse("http://127.0.0.1:2113")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+
+	links, _, rec := feedLinks(c, h, "http://127.0.0.1:2113/streams/newstream/5/forward/20")
+	_, ok := links["previous"]
+	c.Assert(ok, Equals, false)
+	c.Assert(rec.Header().Get("ETag"), Equals, "")
+	c.Assert(rec.Header().Get("Cache-Control"), Equals, "")
+}
@@ -0,0 +1,450 @@
+package feedsim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+)
+
+// Commands of the legacy TCP protocol.
+const (
+	tcpHeartbeatRequest                 = 0x01
+	tcpHeartbeatResponse                = 0x02
+	tcpPing                             = 0x03
+	tcpPong                             = 0x04
+	tcpWriteEvents                      = 0x82
+	tcpWriteEventsCompleted             = 0x83
+	tcpReadStreamEventsForward          = 0xB2
+	tcpReadStreamEventsForwardCompleted = 0xB3
+	tcpBadRequest                       = 0xF0
+	tcpNotAuthenticated                 = 0xF4
+)
+
+// Flags of a package of the legacy TCP protocol.
+const tcpAuthenticated = 0x01
+
+// Results of writes and reads of the legacy TCP protocol.
+const (
+	tcpOperationSuccess              = 0
+	tcpOperationWrongExpectedVersion = 4
+	tcpOperationStreamDeleted        = 5
+
+	tcpReadSuccess       = 0
+	tcpReadNoStream      = 1
+	tcpReadStreamDeleted = 2
+)
+
+// tcpMaxPackage is the largest package the TCP server accepts.
+const tcpMaxPackage = 64 << 20
+
+// TCPServer serves the events of a simulator over the legacy EventStore TCP
+// protocol used by the ClientAPI of EventStore before version 20, so that
+// clients still using the TCP API can be tested in process.
+//
+// Support for the protocol is experimental and partial. The server answers
+// heartbeats and pings, and serves WriteEvents and ReadStreamEventsForward
+// from and to the same events as the atom feeds of the simulator. Other
+// commands receive BadRequest. Packages must carry the credentials of a user
+// if the simulator requires basic authentication. Event data must be JSON.
+type TCPServer struct {
+	// Addr is the address the server listens on.
+	Addr string
+
+	sim       *AtomFeedSimulator
+	listener  net.Listener
+	heartbeat time.Duration
+	wg        sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// StartTCPServer starts serving the simulator sim over the legacy TCP protocol
+// on addr, such as "127.0.0.1:1113", or on an ephemeral port of the loopback
+// interface if addr is empty.
+//
+// If heartbeat is greater than zero the server sends a heartbeat request to
+// each client that has been silent for that long, and closes the connection
+// of a client that remains silent for as long again, as EventStore does, so
+// that clients' heartbeat handling can be tested.
+func StartTCPServer(sim *AtomFeedSimulator, addr string, heartbeat time.Duration) (*TCPServer, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &TCPServer{
+		Addr:      l.Addr().String(),
+		sim:       sim,
+		listener:  l,
+		heartbeat: heartbeat,
+		conns:     map[net.Conn]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server, closing its listener and the connections of its
+// clients, and waits for their goroutines to finish.
+func (s *TCPServer) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// serve accepts connections until the listener is closed.
+func (s *TCPServer) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+// tcpPackage is a package of the legacy TCP protocol.
+type tcpPackage struct {
+	command     byte
+	flags       byte
+	correlation [16]byte
+	login       string
+	password    string
+	payload     []byte
+}
+
+// readTCPPackage reads the next length prefixed package from r.
+func readTCPPackage(r io.Reader) (*tcpPackage, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(prefix[:])
+	if n < 18 || n > tcpMaxPackage {
+		return nil, fmt.Errorf("invalid package length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	p := &tcpPackage{command: b[0], flags: b[1]}
+	copy(p.correlation[:], b[2:18])
+	b = b[18:]
+	if p.flags&tcpAuthenticated != 0 {
+		var ok bool
+		if p.login, b, ok = tcpString(b); !ok {
+			return nil, errors.New("invalid package credentials")
+		}
+		if p.password, b, ok = tcpString(b); !ok {
+			return nil, errors.New("invalid package credentials")
+		}
+	}
+	p.payload = b
+	return p, nil
+}
+
+// tcpString reads a string prefixed by its length in a byte from b.
+func tcpString(b []byte) (string, []byte, bool) {
+	if len(b) < 1 || len(b) < 1+int(b[0]) {
+		return "", nil, false
+	}
+	return string(b[1 : 1+b[0]]), b[1+b[0]:], true
+}
+
+// writeTCPPackage writes the package with the command, correlation id and
+// payload to w.
+func writeTCPPackage(w io.Writer, command byte, correlation [16]byte, payload []byte) error {
+	b := make([]byte, 4, 4+18+len(payload))
+	binary.LittleEndian.PutUint32(b, uint32(18+len(payload)))
+	b = append(b, command, 0)
+	b = append(b, correlation[:]...)
+	b = append(b, payload...)
+	_, err := w.Write(b)
+	return err
+}
+
+// serveConn serves the packages of the connection c until it is closed.
+func (s *TCPServer) serveConn(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	r := bufio.NewReader(c)
+	awaiting := false
+	for {
+		if s.heartbeat > 0 {
+			c.SetReadDeadline(time.Now().Add(s.heartbeat))
+		}
+		p, err := readTCPPackage(r)
+		if ne, ok := err.(net.Error); ok && ne.Timeout() && !awaiting {
+			awaiting = true
+			if writeTCPPackage(c, tcpHeartbeatRequest, tcpCorrelationID(), nil) != nil {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+		awaiting = false
+
+		command, payload := s.handle(p)
+		if command == 0 {
+			continue
+		}
+		if writeTCPPackage(c, command, p.correlation, payload) != nil {
+			return
+		}
+	}
+}
+
+// tcpCorrelationID returns a new correlation id.
+func tcpCorrelationID() [16]byte {
+	return uuid.NewV4()
+}
+
+// handle returns the command and payload of the response to the package p, or
+// a command of zero if p needs no response.
+func (s *TCPServer) handle(p *tcpPackage) (byte, []byte) {
+	switch p.command {
+	case tcpHeartbeatRequest:
+		return tcpHeartbeatResponse, nil
+	case tcpHeartbeatResponse:
+		return 0, nil
+	case tcpPing:
+		return tcpPong, nil
+	}
+
+	if s.sim.users != nil {
+		if p.flags&tcpAuthenticated == 0 || !s.sim.validCredentials(p.login, p.password) {
+			return tcpNotAuthenticated, []byte("Not Authenticated")
+		}
+	}
+
+	m, err := protowire.Parse(p.payload)
+	if err != nil {
+		return tcpBadRequest, []byte(err.Error())
+	}
+	switch p.command {
+	case tcpWriteEvents:
+		return s.writeEvents(m)
+	case tcpReadStreamEventsForward:
+		return s.readStreamEventsForward(m)
+	}
+	return tcpBadRequest, []byte(fmt.Sprintf("command 0x%02X is not supported by the simulator", p.command))
+}
+
+// writeEvents serves WriteEvents, writing the events as though they had been
+// POSTed to the stream.
+func (s *TCPServer) writeEvents(m protowire.Message) (byte, []byte) {
+	h := s.sim
+	stream := m.String(1)
+	if stream == "" {
+		return tcpBadRequest, []byte("stream name must not be empty")
+	}
+	expected := int(int64(m.Uint(2)))
+
+	events, err := m.Messages(3)
+	if err != nil {
+		return tcpBadRequest, []byte(err.Error())
+	}
+	var body []writeEvent
+	for i, ne := range events {
+		e := writeEvent{EventID: tcpGUID(ne.Bytes(1)), EventType: ne.String(2)}
+		if e.EventType == "" || ne.Uint(3) != 1 || !json.Valid(ne.Bytes(5)) {
+			return tcpBadRequest, []byte(fmt.Sprintf("event %d must have a type and json data", i))
+		}
+		e.Data = append(json.RawMessage{}, ne.Bytes(5)...)
+		if meta := ne.Bytes(6); len(meta) > 0 && json.Valid(meta) {
+			e.MetaData = append(json.RawMessage{}, meta...)
+		}
+		body = append(body, e)
+	}
+	if len(body) == 0 {
+		return tcpBadRequest, []byte("a write must hold one or more events")
+	}
+	if !h.servesStream(stream) {
+		return tcpBadRequest, []byte("Not Found")
+	}
+
+	var resp []byte
+	if _, ok := h.streamErr(stream).(StreamDeletedError); ok {
+		resp = protowire.AppendUint(resp, 1, tcpOperationStreamDeleted)
+		resp = protowire.AppendInt(resp, 3, -1)
+		resp = protowire.AppendInt(resp, 4, -1)
+		return tcpWriteEventsCompleted, resp
+	}
+	if err := h.checkWrite(stream, body); err != nil {
+		return tcpBadRequest, []byte(err.Error())
+	}
+
+	host := "http://" + s.Addr
+	if h.BaseURL != nil {
+		host = strings.TrimRight(h.BaseURL.String(), "/")
+	}
+	first, current, ok := h.write(&StreamURL{Host: host, Stream: stream}, expected, body)
+	if !ok {
+		resp = protowire.AppendUint(resp, 1, tcpOperationWrongExpectedVersion)
+		resp = protowire.AppendString(resp, 2, "Wrong expected version.")
+		resp = protowire.AppendInt(resp, 3, -1)
+		resp = protowire.AppendInt(resp, 4, -1)
+		resp = protowire.AppendInt(resp, 7, int64(current))
+		return tcpWriteEventsCompleted, resp
+	}
+	resp = protowire.AppendUint(resp, 1, tcpOperationSuccess)
+	resp = protowire.AppendInt(resp, 3, int64(first))
+	resp = protowire.AppendInt(resp, 4, int64(current))
+	resp = protowire.AppendInt(resp, 5, int64(current))
+	resp = protowire.AppendInt(resp, 6, int64(current))
+	resp = protowire.AppendInt(resp, 7, int64(current))
+	return tcpWriteEventsCompleted, resp
+}
+
+// readStreamEventsForward serves ReadStreamEventsForward, reading up to
+// max_count events of the stream from from_event_number.
+func (s *TCPServer) readStreamEventsForward(m protowire.Message) (byte, []byte) {
+	h := s.sim
+	stream := m.String(1)
+	from := int(int64(m.Uint(2)))
+	count := int(int32(m.Uint(3)))
+
+	var resp []byte
+	es, err := h.grpcEvents(stream)
+	switch err.(type) {
+	case StreamNotFoundError:
+		resp = protowire.AppendUint(resp, 2, tcpReadNoStream)
+		resp = protowire.AppendInt(resp, 3, -1)
+		resp = protowire.AppendInt(resp, 4, -1)
+		resp = protowire.AppendUint(resp, 5, 1)
+		resp = protowire.AppendInt(resp, 6, -1)
+		return tcpReadStreamEventsForwardCompleted, resp
+	case StreamDeletedError:
+		resp = protowire.AppendUint(resp, 2, tcpReadStreamDeleted)
+		resp = protowire.AppendInt(resp, 3, -1)
+		resp = protowire.AppendInt(resp, 4, -1)
+		resp = protowire.AppendUint(resp, 5, 1)
+		resp = protowire.AppendInt(resp, 6, -1)
+		return tcpReadStreamEventsForwardCompleted, resp
+	}
+
+	last := -1
+	if len(es) > 0 {
+		last = es[len(es)-1].EventNumber
+	}
+	next := from
+	for _, e := range es {
+		if e.EventNumber < from || count <= 0 {
+			continue
+		}
+		rec, err := tcpEventRecord(e, stream)
+		if err != nil {
+			return tcpBadRequest, []byte(err.Error())
+		}
+		resp = protowire.AppendBytes(resp, 1, protowire.AppendBytes(nil, 1, rec))
+		h.served(stream, e.EventNumber)
+		next = e.EventNumber + 1
+		count--
+	}
+	if next <= last {
+		resp = protowire.AppendUint(resp, 2, tcpReadSuccess)
+		resp = protowire.AppendInt(resp, 3, int64(next))
+		resp = protowire.AppendInt(resp, 4, int64(last))
+		resp = protowire.AppendUint(resp, 5, 0)
+	} else {
+		resp = protowire.AppendUint(resp, 2, tcpReadSuccess)
+		resp = protowire.AppendInt(resp, 3, int64(last+1))
+		resp = protowire.AppendInt(resp, 4, int64(last))
+		resp = protowire.AppendUint(resp, 5, 1)
+	}
+	resp = protowire.AppendInt(resp, 6, int64(last))
+	return tcpReadStreamEventsForwardCompleted, resp
+}
+
+// tcpEventRecord returns the EventRecord message of the event e of stream.
+func tcpEventRecord(e *eventdata.Event, stream string) ([]byte, error) {
+	data, err := marshalEventData(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := marshalEventData(e.MetaData)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	b = protowire.AppendString(b, 1, stream)
+	b = protowire.AppendInt(b, 2, int64(e.EventNumber))
+	b = protowire.AppendBytes(b, 3, tcpGUIDBytes(e.EventID))
+	b = protowire.AppendString(b, 4, e.EventType)
+	if isBinaryData(e.Data) {
+		b = protowire.AppendUint(b, 5, 0)
+	} else {
+		b = protowire.AppendUint(b, 5, 1)
+	}
+	b = protowire.AppendUint(b, 6, 1)
+	b = protowire.AppendBytes(b, 7, data)
+	b = protowire.AppendBytes(b, 8, meta)
+	b = protowire.AppendInt(b, 10, e.Created.UnixNano()/int64(time.Millisecond))
+	return b, nil
+}
+
+// tcpGUID returns the uuid of the 16 bytes of a .NET Guid, whose first three
+// groups are little endian.
+func tcpGUID(b []byte) string {
+	if len(b) != 16 {
+		return ""
+	}
+	u := append([]byte(nil), b...)
+	reverse(u[0:4])
+	reverse(u[4:6])
+	reverse(u[6:8])
+	return formatUUID(u)
+}
+
+// tcpGUIDBytes returns the 16 bytes of the .NET Guid of the uuid id.
+func tcpGUIDBytes(id string) []byte {
+	u, err := hex.DecodeString(strings.Replace(id, "-", "", -1))
+	if err != nil || len(u) != 16 {
+		return make([]byte, 16)
+	}
+	reverse(u[0:4])
+	reverse(u[4:6])
+	reverse(u[6:8])
+	return u
+}
+
+// reverse reverses b in place.
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
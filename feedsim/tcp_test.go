@@ -0,0 +1,143 @@
+package feedsim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/protowire"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+	. "gopkg.in/check.v1"
+)
+
+// tcpClient is a client of the legacy TCP protocol.
+type tcpClient struct {
+	c    *C
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialTCP(c *C, s *TCPServer) *tcpClient {
+	conn, err := net.Dial("tcp", s.Addr)
+	c.Assert(err, IsNil)
+	return &tcpClient{c: c, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// send sends the package with the command and payload, carrying the
+// credentials login and password unless login is empty, and returns its
+// correlation id.
+func (t *tcpClient) send(command byte, login, password string, payload []byte) [16]byte {
+	id := tcpCorrelationID()
+	b := []byte{command, 0}
+	if login != "" {
+		b[1] = tcpAuthenticated
+	}
+	b = append(b, id[:]...)
+	if login != "" {
+		b = append(b, byte(len(login)))
+		b = append(b, login...)
+		b = append(b, byte(len(password)))
+		b = append(b, password...)
+	}
+	b = append(b, payload...)
+	prefix := make([]byte, 4)
+	binary.LittleEndian.PutUint32(prefix, uint32(len(b)))
+	_, err := t.conn.Write(append(prefix, b...))
+	t.c.Assert(err, IsNil)
+	return id
+}
+
+// receive returns the next package received.
+func (t *tcpClient) receive() *tcpPackage {
+	t.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	p, err := readTCPPackage(t.r)
+	t.c.Assert(err, IsNil)
+	return p
+}
+
+func (s *MockSuite) TestTCPWriteAndReadForward(c *C) {
+	stream := "tcp-stream"
+	es := eventdata.CreateTestEvents(3, stream, "http://localhost:2113", "EventTypeX")
+	sim, err := NewAtomFeedSimulator(WithEvents(es...), WithBasicAuth("admin", "changeit"))
+	c.Assert(err, IsNil)
+	srv, err := StartTCPServer(sim, "", 0)
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	client := dialTCP(c, srv)
+	defer client.conn.Close()
+
+	id := client.send(tcpHeartbeatRequest, "", "", nil)
+	p := client.receive()
+	c.Assert(p.command, Equals, byte(tcpHeartbeatResponse))
+	c.Assert(p.correlation, Equals, id)
+
+	var ne []byte
+	ne = protowire.AppendBytes(ne, 1, tcpGUIDBytes(uuid.NewUUID()))
+	ne = protowire.AppendString(ne, 2, "Written")
+	ne = protowire.AppendUint(ne, 3, 1)
+	ne = protowire.AppendUint(ne, 4, 0)
+	ne = protowire.AppendBytes(ne, 5, []byte(`{"a":1}`))
+	var write []byte
+	write = protowire.AppendString(write, 1, stream)
+	write = protowire.AppendInt(write, 2, 2)
+	write = protowire.AppendBytes(write, 3, ne)
+	write = protowire.AppendBytes(write, 3, ne)
+	write = protowire.AppendUint(write, 4, 0)
+
+	client.send(tcpWriteEvents, "", "", write)
+	c.Assert(client.receive().command, Equals, byte(tcpNotAuthenticated))
+
+	client.send(tcpWriteEvents, "admin", "changeit", write)
+	p = client.receive()
+	c.Assert(p.command, Equals, byte(tcpWriteEventsCompleted))
+	m, err := protowire.Parse(p.payload)
+	c.Assert(err, IsNil)
+	c.Assert(m.Uint(1), Equals, uint64(tcpOperationSuccess))
+	c.Assert(m.Uint(3), Equals, uint64(3))
+	c.Assert(m.Uint(4), Equals, uint64(4))
+	c.Assert(sim.StreamEvents(stream), HasLen, 5)
+
+	var read []byte
+	read = protowire.AppendString(read, 1, stream)
+	read = protowire.AppendInt(read, 2, 2)
+	read = protowire.AppendInt(read, 3, 10)
+	client.send(tcpReadStreamEventsForward, "admin", "changeit", read)
+	p = client.receive()
+	c.Assert(p.command, Equals, byte(tcpReadStreamEventsForwardCompleted))
+	m, err = protowire.Parse(p.payload)
+	c.Assert(err, IsNil)
+	events, err := m.Messages(1)
+	c.Assert(err, IsNil)
+	c.Assert(events, HasLen, 3)
+	rec, err := events[2].Message(1)
+	c.Assert(err, IsNil)
+	c.Assert(rec.Uint(2), Equals, uint64(4))
+	c.Assert(rec.String(4), Equals, "Written")
+	c.Assert(string(rec.Bytes(7)), Equals, `{"a":1}`)
+	c.Assert(m.Uint(5), Equals, uint64(1))
+}
+
+func (s *MockSuite) TestTCPHeartbeatTimeout(c *C) {
+	es := eventdata.CreateTestEvents(1, "tcp-heartbeat", "http://localhost:2113", "EventTypeX")
+	sim, err := NewAtomFeedSimulator(WithEvents(es...))
+	c.Assert(err, IsNil)
+	srv, err := StartTCPServer(sim, "", 50*time.Millisecond)
+	c.Assert(err, IsNil)
+	defer srv.Close()
+
+	client := dialTCP(c, srv)
+	defer client.conn.Close()
+
+	p := client.receive()
+	c.Assert(p.command, Equals, byte(tcpHeartbeatRequest))
+	client.send(tcpHeartbeatResponse, "", "", nil)
+	p = client.receive()
+	c.Assert(p.command, Equals, byte(tcpHeartbeatRequest))
+
+	client.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = readTCPPackage(client.r)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,70 @@
+package feedsim
+
+import (
+	"net/url"
+	"testing"
+)
+
+// StartServer starts a test server serving a simulator configured by opts and
+// closes it when the test t and its subtests have completed, so tests need no
+// set up or tear down code of their own.
+//
+//	func TestReader(t *testing.T) {
+//		s := feedsim.StartServer(t, feedsim.WithEvents(es...))
+//		r := NewReader(s.URL)
+//		...
+//	}
+//
+// The test is failed immediately if the simulator cannot be created, and is
+// marked as failed with the details of the request if the simulator fails to
+// serve a request because of an internal error.
+//
+// As the address of the server is not known until it is started, the base url
+// of the simulator is set to the url of the server and links are derived from
+// the request as with WithRequestHostLinks, so events can be created with any
+// server url.
+func StartServer(t testing.TB, opts ...Option) *SimulatorServer {
+	t.Helper()
+	return startServer(t, "", opts...)
+}
+
+// StartServerAt is StartServer listening on the fixed address addr, such as
+// "127.0.0.1:2113", rather than an ephemeral port, so fixtures and recorded
+// responses holding absolute urls remain valid from one run to the next. If
+// the address is in use, as it can be briefly after a previous run, listening
+// is retried for a few seconds before the test is failed.
+func StartServerAt(t testing.TB, addr string, opts ...Option) *SimulatorServer {
+	t.Helper()
+	return startServer(t, addr, opts...)
+}
+
+func startServer(t testing.TB, addr string, opts ...Option) *SimulatorServer {
+	t.Helper()
+
+	srv, err := newUnstartedServer(addr)
+	if err != nil {
+		t.Fatalf("listening on %s: %v", addr, err)
+	}
+	u := &url.URL{Scheme: "http", Host: srv.Listener.Addr().String()}
+
+	o := []Option{
+		WithBaseURL(u),
+		WithRequestHostLinks(),
+		OnError(func(d RequestDetails, err error) {
+			t.Errorf("simulator failed to serve %s %s: %v", d.Request.Method, d.URL, err)
+		}),
+	}
+	sim, err := NewAtomFeedSimulator(append(o, opts...)...)
+	if err != nil {
+		srv.Listener.Close()
+		t.Fatalf("creating simulator: %v", err)
+	}
+
+	srv.Config.Handler = sim
+	srv.EnableHTTP2 = sim.http2
+	srv.Start()
+
+	s := &SimulatorServer{Server: srv, Simulator: sim}
+	t.Cleanup(s.Close)
+	return s
+}
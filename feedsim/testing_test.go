@@ -0,0 +1,96 @@
+package feedsim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+)
+
+// recordingT records the failures of a test instead of failing it.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestStartServer(t *testing.T) {
+	stream := "started-stream"
+	es := eventdata.CreateTestEvents(3, stream, "http://localhost:2113", "EventTypeX")
+	s := StartServer(t, WithEvents(es...))
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s", s.URL, stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	f := &atom.Feed{}
+	if err := xml.NewDecoder(resp.Body).Decode(f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entry) != 3 {
+		t.Fatalf("got %d entries, want 3", len(f.Entry))
+	}
+	if want := fmt.Sprintf("%s/streams/%s/2/", s.URL, stream); f.Entry[0].Link[0].Href != want {
+		t.Errorf("got link %s, want %s", f.Entry[0].Link[0].Href, want)
+	}
+}
+
+func TestStartServerReportsSimulatorErrors(t *testing.T) {
+	stream := "broken-stream"
+	es := eventdata.CreateTestEvents(1, stream, "http://localhost:2113", "EventTypeX")
+	es[0].Data = func() {}
+
+	rt := &recordingT{TB: t}
+	s := StartServer(rt, WithEvents(es...))
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/0", s.URL, stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+	if len(rt.errors) != 1 {
+		t.Fatalf("got %d errors, want 1: %q", len(rt.errors), rt.errors)
+	}
+	want := fmt.Sprintf("simulator failed to serve GET %s/streams/%s/0: json: unsupported type: func()", s.URL, stream)
+	if rt.errors[0] != want {
+		t.Errorf("got error %q, want %q", rt.errors[0], want)
+	}
+}
+
+func TestStartServerAt(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	stream := "fixed-port-stream"
+	es := eventdata.CreateTestEvents(3, stream, "http://"+addr, "EventTypeX")
+	for i := 0; i < 2; i++ {
+		s := StartServerAt(t, addr, WithEvents(es...))
+		if want := "http://" + addr; s.URL != want {
+			t.Fatalf("got url %s, want %s", s.URL, want)
+		}
+		resp, err := http.Get(es[1].Links[0].URI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		s.Close()
+	}
+}
@@ -0,0 +1,103 @@
+package feedsim
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WithTokenAuth requires requests to the simulator to carry a bearer token,
+// as EventStore deployments behind an authenticating proxy do. Tokens expire
+// ttl after they are issued, by the clock of the simulator, after which
+// requests carrying them receive 401 Unauthorized, so clients that refresh
+// their tokens can be tested.
+//
+// Tokens are issued by IssueToken or by a POST to /token, which responds with
+// a json object holding the access_token, its token_type, Bearer, and the
+// number of seconds it expires_in. When users are given with WithBasicAuth
+// the request to /token must carry the credentials of one of them, which are
+// not accepted by any other request.
+func WithTokenAuth(ttl time.Duration) Option {
+	return func(h *AtomFeedSimulator) error {
+		if ttl <= 0 {
+			return errors.New("token ttl must be positive")
+		}
+		h.tokenTTL = ttl
+		return nil
+	}
+}
+
+// IssueToken issues a bearer token that expires after the ttl given to
+// WithTokenAuth.
+func (h *AtomFeedSimulator) IssueToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	h.Lock()
+	defer h.Unlock()
+	if h.tokens == nil {
+		h.tokens = make(map[string]time.Time)
+	}
+	h.tokens[token] = h.clock.Now().Add(h.tokenTTL)
+	return token
+}
+
+// ExpireTokens expires every token issued so far.
+func (h *AtomFeedSimulator) ExpireTokens() {
+	h.Lock()
+	defer h.Unlock()
+	h.tokens = nil
+}
+
+// serveToken issues a token if u is the url of the token endpoint of the
+// simulator and reports whether it responded to the request.
+func (h *AtomFeedSimulator) serveToken(w http.ResponseWriter, r *http.Request, u *url.URL) bool {
+	if h.tokenTTL <= 0 || !strings.HasSuffix(strings.TrimRight(u.Path, "/"), "/token") || h.route(u.String()) != "" {
+		return false
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		h.writeError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return true
+	}
+	if !h.authenticateBasic(w, r) {
+		return true
+	}
+
+	token := h.IssueToken()
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{token, "Bearer", int(h.tokenTTL / time.Second)})
+	return true
+}
+
+// authenticateToken writes a 401 response unless the request r carries a
+// bearer token that has been issued and has not expired. It reports whether
+// the request may proceed.
+func (h *AtomFeedSimulator) authenticateToken(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	h.RLock()
+	expires, found := h.tokens[token]
+	h.RUnlock()
+
+	switch {
+	case !found:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ES", error="invalid_token"`)
+	case !h.clock.Now().Before(expires):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ES", error="invalid_token", error_description="The access token expired"`)
+	default:
+		return true
+	}
+	h.writeError(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return false
+}
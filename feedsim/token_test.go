@@ -0,0 +1,86 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestTokenAuthExpiry(c *C) {
+	var mu sync.Mutex
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	})
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	stream := "token-stream"
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")...),
+		WithBasicAuth("admin", "changeit"), WithTokenAuth(time.Minute), WithClock(clock))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", streamURL, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	req := httptest.NewRequest("POST", server.URL+"/token", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusUnauthorized)
+
+	req.SetBasicAuth("admin", "changeit")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	var t struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	c.Assert(json.NewDecoder(rec.Body).Decode(&t), IsNil)
+	c.Assert(t.TokenType, Equals, "Bearer")
+	c.Assert(t.ExpiresIn, Equals, 60)
+
+	c.Assert(get(t.AccessToken).Code, Equals, http.StatusOK)
+	c.Assert(get("").Code, Equals, http.StatusUnauthorized)
+	c.Assert(get("not-a-token").Header().Get("WWW-Authenticate"), Equals, `Bearer realm="ES", error="invalid_token"`)
+
+	req = httptest.NewRequest("GET", streamURL, nil)
+	req.SetBasicAuth("admin", "changeit")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusUnauthorized)
+
+	advance(59 * time.Second)
+	c.Assert(get(t.AccessToken).Code, Equals, http.StatusOK)
+	advance(time.Second)
+	rec = get(t.AccessToken)
+	c.Assert(rec.Code, Equals, http.StatusUnauthorized)
+	c.Assert(rec.Header().Get("WWW-Authenticate"), Matches, `.*The access token expired.*`)
+
+	refreshed := h.IssueToken()
+	c.Assert(get(refreshed).Code, Equals, http.StatusOK)
+	h.ExpireTokens()
+	c.Assert(get(refreshed).Code, Equals, http.StatusUnauthorized)
+
+	_, err = NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")...), WithTokenAuth(0))
+	c.Assert(err, ErrorMatches, "token ttl must be positive")
+}
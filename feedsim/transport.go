@@ -0,0 +1,73 @@
+package feedsim
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// transport is an http.RoundTripper that serves requests in process.
+type transport struct {
+	handler http.Handler
+}
+
+// NewTransport returns an http.RoundTripper that answers requests by calling
+// the handler h, typically an AtomFeedSimulator or a faults.FaultInjector
+// wrapping one, in process without opening a socket. Clients can be tested by
+// setting the transport of their http.Client, which is faster than a test
+// server, needs no ports and works where listening on the network is not
+// allowed.
+//
+//	client := &http.Client{Transport: feedsim.NewTransport(sim)}
+//
+// The handler receives the request as a server would, with a path only url
+// and the host of the request url. Responses are returned once the handler
+// has completed, so long polls hold the round trip until they end. Responses
+// truncated by DropConnectionMatching or DropConnectionNth return
+// io.ErrUnexpectedEOF from their body once the bytes written have been read.
+func NewTransport(h http.Handler) http.RoundTripper {
+	return &transport{handler: h}
+}
+
+// RoundTrip serves the request req using the handler of the transport.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	u := *req.URL
+	u.Scheme, u.Host = "", ""
+	r.URL = &u
+	r.RequestURI = u.RequestURI()
+	if r.Host == "" {
+		r.Host = req.URL.Host
+	}
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/1.1", 1, 1
+	if req.URL.Scheme == "https" {
+		r.TLS = &tls.ConnectionState{HandshakeComplete: true, ServerName: req.URL.Hostname()}
+	}
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	defer r.Body.Close()
+
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+
+	resp := rec.Result()
+	resp.Request = req
+	if n, err := strconv.Atoi(rec.Header().Get("Content-Length")); err == nil && n > rec.Body.Len() && req.Method != http.MethodHead {
+		resp.Body = ioutil.NopCloser(io.MultiReader(resp.Body, errReader{io.ErrUnexpectedEOF}))
+	}
+	return resp, nil
+}
+
+// errReader is an io.Reader that always fails with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
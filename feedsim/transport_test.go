@@ -0,0 +1,78 @@
+package feedsim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestTransportServesInProcess(c *C) {
+	stream := "transport-stream"
+	es := eventdata.CreateTestEvents(5, stream, "http://eventstore.local:2113", "EventTypeX")
+	u, _ := url.Parse("http://eventstore.local:2113")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	client := &http.Client{Transport: NewTransport(h)}
+
+	resp, err := client.Get(fmt.Sprintf("http://eventstore.local:2113/streams/%s", stream))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	f := &atom.Feed{}
+	c.Assert(xml.NewDecoder(resp.Body).Decode(f), IsNil)
+	c.Assert(f.Entry, HasLen, 5)
+	c.Assert(f.Link[0].Href, Equals, fmt.Sprintf("http://eventstore.local:2113/streams/%s", stream))
+
+	resp, err = client.Head(fmt.Sprintf("http://eventstore.local:2113/streams/%s/1", stream))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	c.Assert(h.RequestCount("/streams/"+stream), Equals, 2)
+}
+
+func (s *MockSuite) TestTransportRequestHostLinks(c *C) {
+	stream := "transport-stream"
+	es := eventdata.CreateTestEvents(2, stream, "http://localhost:2113", "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithRequestHostLinks())
+	c.Assert(err, IsNil)
+	client := &http.Client{Transport: NewTransport(h)}
+
+	resp, err := client.Get(fmt.Sprintf("https://es.example.com/streams/%s", stream))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	f := &atom.Feed{}
+	c.Assert(xml.NewDecoder(resp.Body).Decode(f), IsNil)
+	c.Assert(f.Entry[0].Link[0].Href, Equals, fmt.Sprintf("https://es.example.com/streams/%s/1/", stream))
+}
+
+func (s *MockSuite) TestTransportDroppedConnection(c *C) {
+	stream := "transport-stream"
+	es := eventdata.CreateTestEvents(5, stream, "http://localhost:2113", "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...))
+	c.Assert(err, IsNil)
+	fi := faults.NewFaultInjector(h)
+	fi.FailNth(2, http.StatusServiceUnavailable)
+	c.Assert(fi.DropConnectionMatching("/streams/", 10), IsNil)
+	client := &http.Client{Transport: NewTransport(fi)}
+
+	resp, err := client.Get(fmt.Sprintf("http://localhost:2113/streams/%s", stream))
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+	c.Assert(b, HasLen, 10)
+
+	resp, err = client.Get(fmt.Sprintf("http://localhost:2113/streams/%s", stream))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusServiceUnavailable)
+}
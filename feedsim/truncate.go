@@ -0,0 +1,57 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+)
+
+// truncateBefore returns the $tb of the stream metadata, the number of the
+// first event of the stream that can be read, or 0 if the stream is not
+// truncated.
+func (h *AtomFeedSimulator) truncateBefore() int {
+	h.RLock()
+	meta := h.MetaData
+	h.RUnlock()
+	if meta == nil {
+		return 0
+	}
+	var m struct {
+		TruncateBefore int `json:"$tb"`
+	}
+	if unmarshalData(meta.Data, &m) != nil {
+		return 0
+	}
+	return m.TruncateBefore
+}
+
+// truncate returns the events of es that can be read given the $tb of the
+// stream metadata.
+func (h *AtomFeedSimulator) truncate(es []*eventdata.Event) []*eventdata.Event {
+	tb := h.truncateBefore()
+	if tb <= 0 {
+		return es
+	}
+	return es[sort.Search(len(es), func(i int) bool { return es[i].EventNumber >= tb }):]
+}
+
+// reviveStream sets the $tb of the metadata of stream to first, hiding the
+// events written before the stream was deleted while numbering continues
+// from them, as the server does when a soft deleted stream is written to.
+// The caller must hold the lock.
+func (h *AtomFeedSimulator) reviveStream(stream, server string, first int) {
+	m := map[string]interface{}{}
+	n := 0
+	if h.MetaData != nil {
+		unmarshalData(h.MetaData.Data, &m)
+		n = h.MetaData.EventNumber + 1
+	}
+	m["$tb"] = first
+	b, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	data := json.RawMessage(b)
+	h.MetaData = eventdata.CreateTestEvent("$$"+stream, server, "$metadata", n, &data, nil)
+}
@@ -0,0 +1,67 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestSoftDeletedStreamRevivedByWrite(c *C) {
+	stream := "revived-stream"
+	meta := json.RawMessage(`{"$maxCount": 50}`)
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")...),
+		WithMetaData(eventdata.CreateTestEvent("$$"+stream, server.URL, "$metadata", 0, &meta, nil)), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	h.DeleteStream(stream, false)
+	c.Assert(h.StreamExists(stream), Equals, false)
+
+	rec := postEvents(h, streamURL, "-1", `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("Location"), Equals, streamURL+"/5")
+	c.Assert(rec.Header().Get("ES-CurrentVersion"), Equals, "5")
+
+	es := h.StreamEvents(stream)
+	c.Assert(es, HasLen, 1)
+	c.Assert(es[0].EventNumber, Equals, 5)
+	c.Assert(h.HeadVersion(stream), Equals, 5)
+
+	var m map[string]interface{}
+	c.Assert(unmarshalData(h.StreamMetaData(stream).Data, &m), IsNil)
+	c.Assert(m, DeepEquals, map[string]interface{}{"$maxCount": 50.0, "$tb": 5.0})
+	c.Assert(h.StreamMetaData(stream).EventNumber, Equals, 1)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", streamURL+"/4", nil))
+	c.Assert(rec.Code, Equals, http.StatusNotFound)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", streamURL+"/5", nil))
+	c.Assert(rec.Code, Equals, http.StatusOK)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", streamURL+"/0/forward/20", nil))
+	c.Assert(rec.Code, Equals, http.StatusOK)
+	f, err := DecodeFeed(rec.Body)
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 1)
+	c.Assert(f.Entry[0].Title, Equals, "5@"+stream)
+}
+
+func (s *MockSuite) TestTruncateBeforeMetadata(c *C) {
+	stream := "truncated-stream"
+	meta := json.RawMessage(`{"$tb": 3}`)
+	h, err := NewAtomFeedSimulator(WithEvents(eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")...),
+		WithMetaData(eventdata.CreateTestEvent("$$"+stream, server.URL, "$metadata", 0, &meta, nil)))
+	c.Assert(err, IsNil)
+
+	es := h.StreamEvents(stream)
+	c.Assert(es, HasLen, 2)
+	c.Assert(es[0].EventNumber, Equals, 3)
+}
@@ -0,0 +1,81 @@
+package feedsim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestServerVersionTogglesFeedFields(c *C) {
+	stream := "versioned-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		version      string
+		headOfStream bool
+		streamID     bool
+		eTag         bool
+	}{
+		{"", true, true, false},
+		{"3.9", false, false, true},
+		{"4.x", true, false, true},
+		{"5.x", true, true, true},
+	}
+
+	for _, tt := range tests {
+		opts := []Option{WithEvents(es...), WithBaseURL(u)}
+		if tt.version != "" {
+			opts = append(opts, WithServerVersion(tt.version))
+		}
+		h, err := NewAtomFeedSimulator(opts...)
+		c.Assert(err, IsNil)
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		body := rec.Body.String()
+
+		c.Assert(strings.Contains(body, "<headOfStream>"), Equals, tt.headOfStream, Commentf("version %q", tt.version))
+		c.Assert(strings.Contains(body, "<streamId>"), Equals, tt.streamID, Commentf("version %q", tt.version))
+		c.Assert(strings.Contains(body, "<eTag>"), Equals, tt.eTag, Commentf("version %q", tt.version))
+		c.Assert(rec.Header().Get("ETag") != "", Equals, tt.eTag, Commentf("version %q", tt.version))
+	}
+}
+
+func (s *MockSuite) TestServerVersionETagNotModified(c *C) {
+	stream := "versioned-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithServerVersion("5.x"))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s", server.URL, stream))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	c.Assert(strings.HasPrefix(etag, "\"4;"), Equals, true)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotModified)
+}
+
+func (s *MockSuite) TestUnsupportedServerVersion(c *C) {
+	es := eventdata.CreateTestEvents(1, "astream", server.URL, "EventTypeX")
+
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithServerVersion("2.0"))
+
+	c.Assert(h, IsNil)
+	c.Assert(err, ErrorMatches, "unsupported server version \"2.0\"")
+	c.Assert(err, Equals, UnsupportedServerVersionError("2.0"))
+}
@@ -0,0 +1,108 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/atom"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/internal/uuid"
+)
+
+// virtualStream is a stream whose events are generated when they are read.
+type virtualStream struct {
+	count int
+	gen   func(eventNumber int) *eventdata.Event
+}
+
+// WithVirtualStream makes the simulator serve a stream of count events which
+// are generated by gen as they are read rather than held in memory, so that
+// clients can be tested against streams of millions of events. gen must
+// return the same event every time it is called with the same event number.
+//
+//	feedsim.WithVirtualStream(50000000, feedsim.VirtualEvents("huge", server.URL, "EventTypeX"))
+//
+// A virtual stream is served in place of any events provided by WithEvents. It
+// is read only: the events of the stream are not affected by Append, by append
+// schedules or by trickling, and are not included in snapshots, fixtures or
+// StreamEvents.
+func WithVirtualStream(count int, gen func(eventNumber int) *eventdata.Event) Option {
+	return func(h *AtomFeedSimulator) error {
+		if count < 1 {
+			return errors.New("a virtual stream must have at least 1 event")
+		}
+		if gen == nil {
+			return errors.New("a virtual stream must have a generator")
+		}
+		h.virtual = &virtualStream{count: count, gen: gen}
+		return nil
+	}
+}
+
+// VirtualEvents returns a generator for WithVirtualStream creating events of
+// stream whose event types cycle through eventTypes. The id of each event is
+// derived from the stream and event number and the data and metadata contain
+// the id, as for eventdata.CreateTestEvents.
+func VirtualEvents(stream, server string, eventTypes ...string) func(eventNumber int) *eventdata.Event {
+	ns := uuid.NewV5(uuid.NamespaceURL, server)
+	if len(eventTypes) == 0 {
+		eventTypes = []string{"EventType"}
+	}
+	return func(eventNumber int) *eventdata.Event {
+		id := uuid.NewV5(ns, fmt.Sprintf("%s/%d", stream, eventNumber)).String()
+		data := json.RawMessage(fmt.Sprintf("{ \"foo\" : \"%s\" }", id))
+		meta := json.RawMessage(fmt.Sprintf("{\"bar\": \"%s\"}", id))
+		return &eventdata.Event{
+			EventStreamID: stream,
+			EventNumber:   eventNumber,
+			EventType:     eventTypes[eventNumber%len(eventTypes)],
+			EventID:       id,
+			Data:          &data,
+			MetaData:      &meta,
+			Links:         eventdata.EventLinks(stream, server, eventNumber),
+		}
+	}
+}
+
+// events returns the events [start, end) of the stream.
+func (v *virtualStream) events(start, end int) []*eventdata.Event {
+	es := make([]*eventdata.Event, 0, end-start)
+	for i := start; i < end; i++ {
+		es = append(es, v.gen(i))
+	}
+	return es
+}
+
+// head returns the last event of the stream in a slice, which is all that
+// the eTag of a feed depends on.
+func (v *virtualStream) head() []*eventdata.Event {
+	return v.events(v.count-1, v.count)
+}
+
+// createFeed creates the feed page of the stream requested by r, generating
+// only the events of the page.
+func (v *virtualStream) createFeed(r *StreamURL, now time.Time, mode PagingMode) (*atom.Feed, []*eventdata.Event, error) {
+	head := v.count - 1
+	version := r.Version
+	if r.Head {
+		version = head
+	}
+	start, end, w := getSliceBounds(v.count, 0, head, version, r.PageSize, r.Direction, mode)
+	w.Events = v.events(start, end)
+	return createFeedPage(w, 0, r, now)
+}
+
+// resolveEvent returns the event of the stream addressed by url. Events of a
+// virtual stream cannot be addressed by event id.
+func (v *virtualStream) resolveEvent(url string) (*eventdata.Event, error) {
+	if id, ok := eventIDFromURL(url); ok {
+		return nil, EventIDNotFoundError(id)
+	}
+	i, err := eventIndex(v.count, url)
+	if err != nil {
+		return nil, err
+	}
+	return v.gen(i), nil
+}
@@ -0,0 +1,64 @@
+package feedsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestVirtualStream(c *C) {
+	stream := "virtual-stream"
+	count := 10000000
+	var generated int64
+	events := VirtualEvents(stream, server.URL, "EventTypeA", "EventTypeB")
+	gen := func(n int) *eventdata.Event {
+		atomic.AddInt64(&generated, 1)
+		return events(n)
+	}
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithBaseURL(u), WithVirtualStream(count, gen))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	f := getFeed(c, fmt.Sprintf("%s/streams/%s", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 20)
+	c.Assert(f.Entry[0].Title, Equals, fmt.Sprintf("%d@%s", count-1, stream))
+	c.Assert(f.HeadOfStream, Equals, true)
+	c.Assert(f.GetLink("last").Href, Equals, fmt.Sprintf("%s/streams/%s/0/forward/20", server.URL, stream))
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s/5000000/forward/50", server.URL, stream), nil)
+	c.Assert(f.Entry, HasLen, 50)
+	c.Assert(f.Entry[49].Title, Equals, fmt.Sprintf("5000000@%s", stream))
+	c.Assert(f.Entry[0].Summary.Body, Equals, "EventTypeB")
+	c.Assert(f.GetLink("previous").Href, Equals, fmt.Sprintf("%s/streams/%s/5000050/forward/50", server.URL, stream))
+	c.Assert(f.HeadOfStream, Equals, false)
+
+	f = getFeed(c, fmt.Sprintf("%s/streams/%s/%d/forward/20", server.URL, stream, count), nil)
+	c.Assert(f.Entry, HasLen, 0)
+
+	resp, err := http.Get(fmt.Sprintf("%s/streams/%s/1234567", server.URL, stream))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	var e eventdata.EventAtomResponse
+	c.Assert(json.NewDecoder(resp.Body).Decode(&e), IsNil)
+	c.Assert(e.Title, Equals, fmt.Sprintf("1234567@%s", stream))
+
+	c.Assert(getStatus(c, fmt.Sprintf("%s/streams/%s/%d", server.URL, stream, count)), Equals, http.StatusNotFound)
+	c.Assert(atomic.LoadInt64(&generated) < 200, Equals, true, Commentf("generated %d events", generated))
+}
+
+func (s *MockSuite) TestVirtualEventsAreStable(c *C) {
+	gen := VirtualEvents("virtual-stream", "http://localhost:2113", "EventTypeA", "EventTypeB")
+	c.Assert(gen(42), DeepEquals, gen(42))
+	c.Assert(gen(42).EventID, Not(Equals), gen(43).EventID)
+	c.Assert(gen(43).EventType, Equals, "EventTypeB")
+	c.Assert(gen(43).Links[0].URI, Equals, "http://localhost:2113/streams/virtual-stream/43/")
+
+	_, err := NewAtomFeedSimulator(WithVirtualStream(0, gen))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,95 @@
+package feedsim
+
+import "context"
+
+// WaitForRequest blocks until the simulator has received a request whose url
+// matches the regular expression pattern and returns the first such request.
+// Requests received before the call are taken into account.
+//
+// It returns the error of the context if the context is done first and
+// ErrShutdown if the simulator is shut down first.
+func (h *AtomFeedSimulator) WaitForRequest(ctx context.Context, pattern string) (RecordedRequest, error) {
+	m := MatchURL(pattern)
+	for {
+		observed := h.observation()
+		for _, r := range h.Requests() {
+			if m(r) {
+				return r, nil
+			}
+		}
+		select {
+		case <-observed:
+		case <-ctx.Done():
+			return RecordedRequest{}, ctx.Err()
+		case <-h.done:
+			return RecordedRequest{}, ErrShutdown
+		}
+	}
+}
+
+// WaitUntilReadThrough blocks until the simulator has served the event
+// eventNumber of stream, or a later event, either as an entry in a feed page
+// or as a single event.
+//
+// It returns the error of the context if the context is done first and
+// ErrShutdown if the simulator is shut down first.
+func (h *AtomFeedSimulator) WaitUntilReadThrough(ctx context.Context, stream string, eventNumber int) error {
+	for {
+		observed := h.observation()
+		if h.ReadPosition(stream) >= eventNumber {
+			return nil
+		}
+		select {
+		case <-observed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-h.done:
+			return ErrShutdown
+		}
+	}
+}
+
+// ReadPosition returns the highest event number of stream that the simulator
+// has served, or -1 if no events of the stream have been served.
+func (h *AtomFeedSimulator) ReadPosition(stream string) int {
+	h.RLock()
+	defer h.RUnlock()
+	if p, ok := h.readPositions[stream]; ok {
+		return p
+	}
+	return -1
+}
+
+// served records that the event eventNumber of stream has been served.
+func (h *AtomFeedSimulator) served(stream string, eventNumber int) {
+	h.metrics.served(1)
+	h.Lock()
+	defer h.Unlock()
+	if h.readPositions == nil {
+		h.readPositions = make(map[string]int)
+	}
+	if p, ok := h.readPositions[stream]; !ok || eventNumber > p {
+		h.readPositions[stream] = eventNumber
+	}
+	h.notifyObservers()
+}
+
+// observation returns a channel that is closed the next time the simulator
+// receives a request or serves events.
+func (h *AtomFeedSimulator) observation() <-chan struct{} {
+	h.Lock()
+	defer h.Unlock()
+	if h.observed == nil {
+		h.observed = make(chan struct{})
+	}
+	return h.observed
+}
+
+// notifyObservers wakes any goroutines waiting for an observation.
+// The caller must hold the lock.
+func (h *AtomFeedSimulator) notifyObservers() {
+	if h.observed != nil {
+		close(h.observed)
+		h.observed = nil
+	}
+}
@@ -0,0 +1,72 @@
+package feedsim
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MockSuite) TestWaitForRequest(c *C) {
+	stream := "waited-stream"
+	es := eventdata.CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		if resp, err := http.Get(fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream)); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, err := h.WaitForRequest(ctx, "/metadata$")
+	c.Assert(err, IsNil)
+	c.Assert(r.URL, Equals, fmt.Sprintf("%s/streams/%s/metadata", server.URL, stream))
+}
+
+func (s *MockSuite) TestWaitForRequestContextDone(c *C) {
+	es := eventdata.CreateTestEvents(5, "astream", server.URL, "EventTypeX")
+	h, err := NewAtomFeedSimulator(WithEvents(es...))
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = h.WaitForRequest(ctx, "/metadata$")
+	c.Assert(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *MockSuite) TestWaitUntilReadThrough(c *C) {
+	stream := "waited-stream"
+	es := eventdata.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	mux.Handle("/", h)
+	c.Assert(h.ReadPosition(stream), Equals, -1)
+
+	go func() {
+		for _, p := range []string{"0/forward/20", "25"} {
+			if resp, err := http.Get(fmt.Sprintf("%s/streams/%s/%s", server.URL, stream, p)); err == nil {
+				resp.Body.Close()
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = h.WaitUntilReadThrough(ctx, stream, 25)
+	c.Assert(err, IsNil)
+	c.Assert(h.ReadPosition(stream), Equals, 25)
+	c.Assert(h.RequestCount(fmt.Sprintf("/streams/%s/25$", stream)), Equals, 1)
+	c.Assert(h.ReadPosition("other-stream"), Equals, -1)
+}
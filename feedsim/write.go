@@ -0,0 +1,341 @@
+package feedsim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+)
+
+// Expected versions understood by the ES-ExpectedVersion header of a write.
+const (
+	ExpectedVersionAny          = -2
+	ExpectedVersionNoStream     = -1
+	ExpectedVersionStreamExists = -4
+)
+
+const mediaTypeEventsJSON = "application/vnd.eventstore.events+json"
+
+// writeEvent is an event in the body of a write.
+type writeEvent struct {
+	EventID   string          `json:"eventId"`
+	EventType string          `json:"eventType"`
+	Data      json.RawMessage `json:"data"`
+	MetaData  json.RawMessage `json:"metadata"`
+}
+
+// writeLimits are the largest writes the simulator accepts. Zero values are
+// unlimited.
+type writeLimits struct {
+	maxBytes  int64
+	maxEvents int
+}
+
+// WithWriteLimits sets the largest body, in bytes, and the largest number of
+// events the simulator accepts in a single write. Larger writes receive 413
+// Payload Too Large and change nothing, so clients that split large appends
+// into batches can be tested. EventStore limits the size of an append to 1MB
+// by default. A zero limit is unlimited, which is the default.
+//
+// The body of a request recorded by the simulator is cut off at maxBytes, so
+// an oversized write is not held in memory by Requests or snapshots.
+func WithWriteLimits(maxBytes int64, maxEvents int) Option {
+	return func(h *AtomFeedSimulator) error {
+		if maxBytes < 0 || maxEvents < 0 {
+			return errors.New("write limits must not be negative")
+		}
+		h.writeLimits = writeLimits{maxBytes: maxBytes, maxEvents: maxEvents}
+		return nil
+	}
+}
+
+// commitDelay is the time writes take to commit.
+type commitDelay struct {
+	latency faults.Latency
+	timeout time.Duration
+	commit  bool
+}
+
+// WithWriteLatency delays the commit of each write by the duration returned
+// by l. Writes that take longer than timeout to commit receive 408 Request
+// Timeout once timeout has elapsed. If commit is true a timed out write still
+// takes effect when its commit completes, reproducing the case of a write
+// reported as timed out that was in fact committed, which idempotent clients
+// must handle by retrying with the same event ids. A zero timeout never times
+// out.
+func WithWriteLatency(l faults.Latency, timeout time.Duration, commit bool) Option {
+	return func(h *AtomFeedSimulator) error {
+		if l == nil {
+			return errors.New("latency must not be nil")
+		}
+		h.commitDelay = &commitDelay{latency: l, timeout: timeout, commit: commit}
+		return nil
+	}
+}
+
+// awaitCommit waits for the commit of the write r. It reports whether the
+// write should be applied and the response written by the caller. Timed out
+// writes receive 408 Request Timeout and are applied by calling apply when
+// their commit completes if the simulator is configured to commit them.
+func (h *AtomFeedSimulator) awaitCommit(w http.ResponseWriter, r *http.Request, apply func()) bool {
+	c := h.commitDelay
+	if c == nil {
+		return true
+	}
+	d := c.latency(r)
+	if c.timeout <= 0 || d <= c.timeout {
+		if !h.sleep(r.Context(), d) {
+			h.writeShutdown(w)
+			return false
+		}
+		return true
+	}
+
+	if !h.sleep(r.Context(), c.timeout) {
+		h.writeShutdown(w)
+		return false
+	}
+	if c.commit {
+		go func() {
+			if h.sleep(context.Background(), d-c.timeout) {
+				apply()
+			}
+		}()
+	}
+	h.writeError(w, http.StatusText(http.StatusRequestTimeout), http.StatusRequestTimeout)
+	return false
+}
+
+// serveWrite appends the events POSTed to the stream addressed by the request
+// as EventStore does. The body is either an array of events, sent as
+// application/vnd.eventstore.events+json, or the data of a single event sent
+// as application/json with its type and id in the ES-EventType and ES-EventId
+// headers. Requests missing either header receive 400 Bad Request.
+//
+// The write is checked against the ES-ExpectedVersion header, which defaults
+// to any version. Successful writes receive 201 Created with the Location of
+// the first event written. Writes with the wrong expected version receive
+// 400 Bad Request and change nothing. Both carry the version of the stream
+// after the request in the ES-CurrentVersion header. Writing events whose ids
+// were already written at the expected position succeeds without appending
+// them again, so retried writes are idempotent.
+//
+// The version of the stream includes events that have yet to trickle in to
+// readers. Writing to a stream that does not exist creates it, numbering the
+// events on from the last event held by the simulator. As with a soft deleted
+// stream revived by a write, the $tb of the stream metadata is set to the
+// first event written so the events before it can no longer be read. The
+// simulator serves a single stream, named with WithStream or otherwise by the
+// events it was created with, so writes to any other stream receive 404 Not
+// Found.
+func (h *AtomFeedSimulator) serveWrite(w http.ResponseWriter, r *http.Request, d RequestDetails) {
+	fr, err := ParseStreamURL(d.URL)
+	if err != nil || !isStreamRoot(d.URL) {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.writeError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.servesStream(fr.Stream) {
+		h.writeError(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if _, ok := h.streamErr(fr.Stream).(StreamDeletedError); ok {
+		h.writeError(w, "Stream deleted", http.StatusGone)
+		return
+	}
+
+	expected := ExpectedVersionAny
+	if v := r.Header.Get("ES-ExpectedVersion"); v != "" {
+		if expected, err = strconv.Atoi(v); err != nil {
+			h.writeError(w, fmt.Sprintf("Invalid ES-ExpectedVersion header %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if l := h.writeLimits.maxBytes; l > 0 {
+		if r.ContentLength > l {
+			h.writeTooLarge(w)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, l)
+	}
+
+	var body []writeEvent
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mt {
+	case mediaTypeEventsJSON:
+		body, err = readEvents(r)
+	case mediaTypeJSON:
+		body, err = readRawEvent(r)
+	default:
+		h.writeError(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (h.writeLimits.maxEvents > 0 && len(body) > h.writeLimits.maxEvents) {
+		h.writeTooLarge(w)
+		return
+	}
+	if err == nil {
+		err = h.checkWrite(fr.Stream, body)
+	}
+	if err != nil {
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.awaitCommit(w, r, func() { h.write(fr, expected, body) }) {
+		return
+	}
+	first, current, ok := h.write(fr, expected, body)
+	w.Header().Set("ES-CurrentVersion", strconv.Itoa(current))
+	if !ok {
+		h.writeError(w, "Wrong expected version", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", NewLinkBuilder(fr).Self(), first))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// writeTooLarge writes the response to a write larger than the limits of the
+// simulator.
+func (h *AtomFeedSimulator) writeTooLarge(w http.ResponseWriter) {
+	h.writeError(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+}
+
+// readEvents reads the events of a write of
+// application/vnd.eventstore.events+json, a json array of events each with an
+// eventId, eventType, data and optional metadata.
+func readEvents(r *http.Request) ([]writeEvent, error) {
+	var body []writeEvent
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("Write request body invalid: %w", err)
+	}
+	if len(body) == 0 {
+		return nil, errors.New("Write request body invalid: no events")
+	}
+	for i, v := range body {
+		if v.EventID == "" || v.EventType == "" {
+			return nil, fmt.Errorf("Write request body invalid: event %d must have an eventId and an eventType", i)
+		}
+	}
+	return body, nil
+}
+
+// readRawEvent reads the event of a write of application/json, whose body is
+// the data of a single event described by the ES-EventType and ES-EventId
+// headers.
+func readRawEvent(r *http.Request) ([]writeEvent, error) {
+	e := writeEvent{
+		EventType: r.Header.Get("ES-EventType"),
+		EventID:   r.Header.Get("ES-EventId"),
+	}
+	if e.EventType == "" {
+		return nil, errors.New("Must include an event type with the request either in body or as ES-EventType header.")
+	}
+	if e.EventID == "" {
+		return nil, errors.New("Must include an event id with the request as ES-EventId header.")
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(b) {
+		return nil, errors.New("Write request body invalid: data is not valid json")
+	}
+	e.Data = b
+	return []writeEvent{e}, nil
+}
+
+// write appends the events of body to the stream addressed by fr if its
+// version is expected. It returns the number of the first event of body and
+// the version of the stream after the write, and reports whether the write
+// succeeded.
+func (h *AtomFeedSimulator) write(fr *StreamURL, expected int, body []writeEvent) (first, current int, ok bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	state := h.streamStates[fr.Stream]
+	last := -1
+	if len(h.Events) > 0 {
+		last = h.Events[len(h.Events)-1].EventNumber
+	}
+	current = -1
+	if state == streamExists {
+		current = last
+	}
+
+	if n, ok := h.written(expected, body); ok {
+		return n, current, true
+	}
+	switch {
+	case expected == ExpectedVersionAny:
+	case expected == ExpectedVersionStreamExists && current >= 0:
+	case expected == current:
+	default:
+		return 0, current, false
+	}
+
+	first = last + 1
+	if state != streamExists {
+		h.setStreamState(fr.Stream, streamExists)
+		if last >= 0 {
+			h.reviveStream(fr.Stream, fr.Host, first)
+		}
+	}
+	es := make([]*eventdata.Event, len(body))
+	for i, v := range body {
+		e := eventdata.CreateTestEvent(fr.Stream, fr.Host, v.EventType, first+i, rawMessage(v.Data), rawMessage(v.MetaData))
+		e.EventID = v.EventID
+		es[i] = e
+	}
+	h.appendEvents(es)
+	return first, first + len(es) - 1, true
+}
+
+// written returns the number of the first event of body if the events of
+// body were already written, in order, at the position given by expected.
+// The caller must hold the lock.
+func (h *AtomFeedSimulator) written(expected int, body []writeEvent) (int, bool) {
+	if len(body) == 0 {
+		return 0, false
+	}
+	for i, e := range h.Events {
+		if e.EventID != body[0].EventID {
+			continue
+		}
+		if expected >= 0 && e.EventNumber != expected+1 {
+			return 0, false
+		}
+		if len(h.Events)-i < len(body) {
+			return 0, false
+		}
+		for j, v := range body {
+			if h.Events[i+j].EventID != v.EventID {
+				return 0, false
+			}
+		}
+		return e.EventNumber, true
+	}
+	return 0, false
+}
+
+// isStreamRoot reports whether the url u addresses a stream rather than a page
+// of a stream.
+func isStreamRoot(u string) bool {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	split, err := pathSegments(pu)
+	return err == nil && streamsSegment(split, 2) >= 0
+}
@@ -0,0 +1,290 @@
+package feedsim
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed/eventdata"
+	"github.com/jetbasrawi/go.geteventstore.testfeed/faults"
+	. "gopkg.in/check.v1"
+)
+
+func postEvents(h http.Handler, u, expected, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", u, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/vnd.eventstore.events+json")
+	if expected != "" {
+		req.Header.Set("ES-ExpectedVersion", expected)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *MockSuite) TestWriteAppendsEvents(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	body := `[
+		{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {"total": 10}},
+		{"eventId": "0f9fad5b-d9cb-469f-a165-70867728950e", "eventType": "OrderPaid", "data": {"total": 10}, "metadata": {"user": "bob"}}
+	]`
+	rec := postEvents(h, streamURL, "2", body)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("Location"), Equals, streamURL+"/3")
+	c.Assert(rec.Header().Get("ES-CurrentVersion"), Equals, "4")
+
+	got := h.StreamEvents(stream)
+	c.Assert(got, HasLen, 5)
+	c.Assert(got[3].EventID, Equals, "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4")
+	c.Assert(got[3].EventType, Equals, "OrderPlaced")
+	c.Assert(got[4].EventNumber, Equals, 4)
+	c.Assert(got[4].Links[0].URI, Equals, streamURL+"/4/")
+
+	rec = postEvents(h, streamURL, "2", body)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("Location"), Equals, streamURL+"/3")
+	c.Assert(h.StreamEvents(stream), HasLen, 5)
+
+	rec = postEvents(h, streamURL, "", `[{"eventId": "8e0bb0c9-5d8d-4ad4-9c1c-3c43a4f0ec1d", "eventType": "OrderShipped", "data": {}}]`)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("ES-CurrentVersion"), Equals, "5")
+}
+
+func (s *MockSuite) TestWriteToForeignStreamNotFound(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+
+	body := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+	rec := postEvents(h, fmt.Sprintf("%s/streams/%s", server.URL, "other-stream"), "", body)
+	c.Assert(rec.Code, Equals, http.StatusNotFound)
+	c.Assert(rec.Header().Get("Location"), Equals, "")
+	c.Assert(h.StreamEvents(stream), HasLen, 3)
+}
+
+func (s *MockSuite) TestWriteWrongExpectedVersion(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	body := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+
+	tests := []struct {
+		opts     []Option
+		expected string
+		status   int
+		current  string
+	}{
+		{nil, "1", http.StatusBadRequest, "2"},
+		{nil, "-1", http.StatusBadRequest, "2"},
+		{nil, "-4", http.StatusCreated, "3"},
+		{[]Option{WithMissingStream(stream)}, "-4", http.StatusBadRequest, "-1"},
+		{[]Option{WithMissingStream(stream)}, "-1", http.StatusCreated, "3"},
+		{[]Option{WithEmptyStream(stream)}, "-1", http.StatusCreated, "3"},
+	}
+
+	for _, tt := range tests {
+		h, err := NewAtomFeedSimulator(append([]Option{WithEvents(es...), WithBaseURL(u)}, tt.opts...)...)
+		c.Assert(err, IsNil)
+		rec := postEvents(h, fmt.Sprintf("%s/streams/%s", server.URL, stream), tt.expected, body)
+		c.Assert(rec.Code, Equals, tt.status, Commentf("expected version %s", tt.expected))
+		c.Assert(rec.Header().Get("ES-CurrentVersion"), Equals, tt.current, Commentf("expected version %s", tt.expected))
+	}
+}
+
+func (s *MockSuite) TestWriteRejectsInvalidRequests(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(3, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithStream(stream))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	event := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+
+	c.Assert(postEvents(h, streamURL, "x", event).Code, Equals, http.StatusBadRequest)
+	c.Assert(postEvents(h, streamURL, "", `{}`).Code, Equals, http.StatusBadRequest)
+	c.Assert(postEvents(h, streamURL, "", `[]`).Code, Equals, http.StatusBadRequest)
+	c.Assert(postEvents(h, streamURL, "", `[{"eventType": "OrderPlaced"}]`).Code, Equals, http.StatusBadRequest)
+	c.Assert(postEvents(h, streamURL+"/0/forward/20", "", event).Code, Equals, http.StatusMethodNotAllowed)
+	c.Assert(postEvents(h, fmt.Sprintf("%s/streams/other", server.URL), "", event).Code, Equals, http.StatusNotFound)
+
+	req := httptest.NewRequest("POST", streamURL, strings.NewReader(event))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusUnsupportedMediaType)
+
+	h.DeleteStream(stream, true)
+	c.Assert(postEvents(h, streamURL, "", event).Code, Equals, http.StatusGone)
+	c.Assert(h.Events, HasLen, 3)
+}
+
+func (s *MockSuite) TestWriteRawEvent(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	post := func(eventType, eventID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", streamURL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("ES-ExpectedVersion", "0")
+		if eventType != "" {
+			req.Header.Set("ES-EventType", eventType)
+		}
+		if eventID != "" {
+			req.Header.Set("ES-EventId", eventID)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("", "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", `{"total": 10}`)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	c.Assert(rec.Body.String(), Matches, "Must include an event type.*\n")
+
+	rec = post("OrderPlaced", "", `{"total": 10}`)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	c.Assert(rec.Body.String(), Matches, "Must include an event id.*\n")
+
+	rec = post("OrderPlaced", "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", `{"total":`)
+	c.Assert(rec.Code, Equals, http.StatusBadRequest)
+	c.Assert(h.StreamEvents(stream), HasLen, 1)
+
+	rec = post("OrderPlaced", "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", `{"total": 10}`)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("Location"), Equals, streamURL+"/1")
+
+	e := h.StreamEvents(stream)[1]
+	c.Assert(e.EventType, Equals, "OrderPlaced")
+	c.Assert(e.EventID, Equals, "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4")
+	c.Assert(e.Data, DeepEquals, rawMessage([]byte(`{"total": 10}`)))
+}
+
+func (s *MockSuite) TestWriteLimits(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithWriteLimits(512, 2))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	batch := func(n, size int) string {
+		events := make([]string, n)
+		for i := range events {
+			events[i] = fmt.Sprintf(`{"eventId": "%s", "eventType": "X", "data": {"pad": "%s"}}`, eventdata.NameBasedUUIDs("limits")(stream, len(h.Events)+i), strings.Repeat("x", size))
+		}
+		return "[" + strings.Join(events, ",") + "]"
+	}
+
+	c.Assert(postEvents(h, streamURL, "", batch(3, 1)).Code, Equals, http.StatusRequestEntityTooLarge)
+	c.Assert(postEvents(h, streamURL, "", batch(1, 600)).Code, Equals, http.StatusRequestEntityTooLarge)
+	c.Assert(h.Events, HasLen, 1)
+	c.Assert(postEvents(h, streamURL, "", batch(2, 100)).Code, Equals, http.StatusCreated)
+	c.Assert(h.Events, HasLen, 3)
+
+	req := httptest.NewRequest("POST", streamURL, strings.NewReader(strings.Repeat("1", 600)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ES-EventType", "X")
+	req.Header.Set("ES-EventId", "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4")
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusRequestEntityTooLarge)
+
+	_, err = NewAtomFeedSimulator(WithEvents(es...), WithWriteLimits(-1, 0))
+	c.Assert(err, ErrorMatches, "write limits must not be negative")
+}
+
+func (s *MockSuite) TestWriteLimitsCapRecordedBody(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithWriteLimits(512, 0))
+	c.Assert(err, IsNil)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+
+	req := httptest.NewRequest("POST", streamURL, strings.NewReader(strings.Repeat("1", 4096)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ES-EventType", "X")
+	req.Header.Set("ES-EventId", "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4")
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	c.Assert(rec.Code, Equals, http.StatusRequestEntityTooLarge)
+	c.Assert(h.Requests(), HasLen, 1)
+	c.Assert(len(h.Requests()[0].Body), Equals, 512)
+	c.Assert(h.Events, HasLen, 1)
+}
+
+func (s *MockSuite) TestWriteCommitTimeout(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	event := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithWriteLatency(faults.FixedLatency(100*time.Millisecond), 20*time.Millisecond, true))
+	c.Assert(err, IsNil)
+	defer h.Shutdown(context.Background())
+
+	start := time.Now()
+	c.Assert(postEvents(h, streamURL, "0", event).Code, Equals, http.StatusRequestTimeout)
+	c.Assert(time.Since(start) < 100*time.Millisecond, Equals, true)
+	c.Assert(h.StreamEvents(stream), HasLen, 1)
+
+	time.Sleep(150 * time.Millisecond)
+	c.Assert(h.StreamEvents(stream), HasLen, 2)
+
+	h, err = NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithWriteLatency(faults.FixedLatency(50*time.Millisecond), 20*time.Millisecond, false))
+	c.Assert(err, IsNil)
+	c.Assert(postEvents(h, streamURL, "0", event).Code, Equals, http.StatusRequestTimeout)
+	time.Sleep(75 * time.Millisecond)
+	c.Assert(h.StreamEvents(stream), HasLen, 1)
+
+	h, err = NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithWriteLatency(faults.FixedLatency(20*time.Millisecond), 50*time.Millisecond, false))
+	c.Assert(err, IsNil)
+	start = time.Now()
+	c.Assert(postEvents(h, streamURL, "0", event).Code, Equals, http.StatusCreated)
+	c.Assert(time.Since(start) >= 20*time.Millisecond, Equals, true)
+	c.Assert(h.StreamEvents(stream), HasLen, 2)
+}
+
+func (s *MockSuite) TestWriteRetriedAfterCommitTimeoutIsIdempotent(c *C) {
+	stream := "written-stream"
+	es := eventdata.CreateTestEvents(1, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	streamURL := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	event := `[{"eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4", "eventType": "OrderPlaced", "data": {}}]`
+
+	slow := true
+	latency := func(r *http.Request) time.Duration {
+		if slow {
+			slow = false
+			return 50 * time.Millisecond
+		}
+		return 0
+	}
+	h, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithWriteLatency(latency, 10*time.Millisecond, true))
+	c.Assert(err, IsNil)
+
+	c.Assert(postEvents(h, streamURL, "0", event).Code, Equals, http.StatusRequestTimeout)
+	time.Sleep(75 * time.Millisecond)
+	rec := postEvents(h, streamURL, "0", event)
+	c.Assert(rec.Code, Equals, http.StatusCreated)
+	c.Assert(rec.Header().Get("Location"), Equals, streamURL+"/1")
+	c.Assert(h.StreamEvents(stream), HasLen, 2)
+}
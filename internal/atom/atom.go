@@ -11,12 +11,47 @@ type Feed struct {
 	XMLName      xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
 	Title        string   `xml:"title"`
 	ID           string   `xml:"id"`
-	StreamID     string   `xml:"streamId"`
+	StreamID     string   `xml:"streamId,omitempty"`
 	HeadOfStream bool     `xml:"headOfStream"`
+	ETag         string   `xml:"eTag,omitempty"`
 	Link         []Link   `xml:"link"`
 	Updated      TimeStr  `xml:"updated"`
 	Author       *Person  `xml:"author"`
 	Entry        []*Entry `xml:"entry"`
+
+	// OmitHeadOfStream removes the headOfStream element from the
+	// marshalled feed.
+	OmitHeadOfStream bool `xml:"-"`
+}
+
+// MarshalXML marshals the feed omitting the headOfStream element when
+// OmitHeadOfStream is set.
+func (f Feed) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	v := struct {
+		Title        string   `xml:"title"`
+		ID           string   `xml:"id"`
+		StreamID     string   `xml:"streamId,omitempty"`
+		HeadOfStream *bool    `xml:"headOfStream,omitempty"`
+		ETag         string   `xml:"eTag,omitempty"`
+		Link         []Link   `xml:"link"`
+		Updated      TimeStr  `xml:"updated"`
+		Author       *Person  `xml:"author"`
+		Entry        []*Entry `xml:"entry"`
+	}{
+		Title:    f.Title,
+		ID:       f.ID,
+		StreamID: f.StreamID,
+		ETag:     f.ETag,
+		Link:     f.Link,
+		Updated:  f.Updated,
+		Author:   f.Author,
+		Entry:    f.Entry,
+	}
+	if !f.OmitHeadOfStream {
+		v.HeadOfStream = &f.HeadOfStream
+	}
+	start.Name = xml.Name{Space: "http://www.w3.org/2005/Atom", Local: "feed"}
+	return e.EncodeElement(v, start)
 }
 
 // GetLink gets the link with the name specified by the link argument.
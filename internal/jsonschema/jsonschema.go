@@ -0,0 +1,454 @@
+// Package jsonschema validates JSON documents against JSON Schemas, as much of
+// the specification as is needed to check the data of simulated events
+// without a dependency.
+//
+// The keywords type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, allOf, anyOf
+// and oneOf are supported. Other keywords, including $ref and format, are
+// ignored, so a schema using them validates more documents than it should
+// but never fewer.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	always *bool
+
+	types      []string
+	enum       []interface{}
+	constant   *interface{}
+	properties map[string]*Schema
+	required   []string
+	additional *Schema
+	items      *Schema
+	minItems   *int
+	maxItems   *int
+	minLength  *int
+	maxLength  *int
+	pattern    *regexp.Regexp
+	minimum    *float64
+	maximum    *float64
+	exclMin    *float64
+	exclMax    *float64
+	allOf      []*Schema
+	anyOf      []*Schema
+	oneOf      []*Schema
+}
+
+// ValidationError reports the first part of a document that does not
+// validate. Path is a JSON Pointer to that part.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
+
+// Compile compiles the JSON Schema b.
+func Compile(b []byte) (*Schema, error) {
+	v, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: %v", err)
+	}
+	return compile(v, "")
+}
+
+// Validate validates the JSON document b against s, returning a
+// *ValidationError if it does not validate.
+func (s *Schema) Validate(b []byte) error {
+	v, err := decode(b)
+	if err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	return s.validate(v, "")
+}
+
+// decode decodes the JSON document b, keeping numbers as json.Number.
+func decode(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("invalid JSON: data after the document")
+	}
+	return v, nil
+}
+
+func compile(v interface{}, path string) (*Schema, error) {
+	if b, ok := v.(bool); ok {
+		return &Schema{always: &b}, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: %s: a schema must be an object or a boolean", pointer(path))
+	}
+	s := &Schema{}
+	var err error
+	fail := func(keyword, want string) error {
+		return fmt.Errorf("jsonschema: %s: %s must be %s", pointer(path+"/"+keyword), keyword, want)
+	}
+
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fail("type", "a string or an array of strings")
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fail("type", "a string or an array of strings")
+	}
+	for _, t := range s.types {
+		switch t {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, fmt.Errorf("jsonschema: %s: unknown type %q", pointer(path+"/type"), t)
+		}
+	}
+
+	if e, ok := m["enum"]; ok {
+		if s.enum, ok = e.([]interface{}); !ok {
+			return nil, fail("enum", "an array")
+		}
+	}
+	if c, ok := m["const"]; ok {
+		s.constant = &c
+	}
+
+	if p, ok := m["properties"]; ok {
+		props, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fail("properties", "an object")
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, ps := range props {
+			if s.properties[name], err = compile(ps, path+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r, ok := m["required"]; ok {
+		names, ok := r.([]interface{})
+		if !ok {
+			return nil, fail("required", "an array of strings")
+		}
+		for _, n := range names {
+			name, ok := n.(string)
+			if !ok {
+				return nil, fail("required", "an array of strings")
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if a, ok := m["additionalProperties"]; ok {
+		if s.additional, err = compile(a, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if i, ok := m["items"]; ok {
+		if s.items, err = compile(i, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+
+	for keyword, p := range map[string]**int{
+		"minItems": &s.minItems, "maxItems": &s.maxItems,
+		"minLength": &s.minLength, "maxLength": &s.maxLength,
+	} {
+		if v, ok := m[keyword]; ok {
+			n, ok := integer(v)
+			if !ok || n < 0 {
+				return nil, fail(keyword, "a non-negative integer")
+			}
+			*p = &n
+		}
+	}
+	for keyword, p := range map[string]**float64{
+		"minimum": &s.minimum, "maximum": &s.maximum,
+		"exclusiveMinimum": &s.exclMin, "exclusiveMaximum": &s.exclMax,
+	} {
+		if v, ok := m[keyword]; ok {
+			f, ok := number(v)
+			if !ok {
+				return nil, fail(keyword, "a number")
+			}
+			*p = &f
+		}
+	}
+	if p, ok := m["pattern"]; ok {
+		expr, ok := p.(string)
+		if !ok {
+			return nil, fail("pattern", "a string")
+		}
+		if s.pattern, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("jsonschema: %s: %v", pointer(path+"/pattern"), err)
+		}
+	}
+
+	for keyword, p := range map[string]*[]*Schema{"allOf": &s.allOf, "anyOf": &s.anyOf, "oneOf": &s.oneOf} {
+		v, ok := m[keyword]
+		if !ok {
+			continue
+		}
+		subs, ok := v.([]interface{})
+		if !ok || len(subs) == 0 {
+			return nil, fail(keyword, "a non-empty array of schemas")
+		}
+		for i, sub := range subs {
+			c, err := compile(sub, fmt.Sprintf("%s/%s/%d", path, keyword, i))
+			if err != nil {
+				return nil, err
+			}
+			*p = append(*p, c)
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) validate(v interface{}, path string) error {
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if s.always != nil {
+		if !*s.always {
+			return fail("no value is allowed")
+		}
+		return nil
+	}
+
+	if len(s.types) > 0 {
+		ok := false
+		for _, t := range s.types {
+			if isType(v, t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fail("expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+		}
+	}
+	if s.enum != nil {
+		ok := false
+		for _, e := range s.enum {
+			if equal(v, e) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fail("value is not one of the enumerated values")
+		}
+	}
+	if s.constant != nil && !equal(v, *s.constant) {
+		return fail("value is not the constant value")
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := path + "/" + escape(name)
+			if ps, ok := s.properties[name]; ok {
+				if err := ps.validate(v[name], p); err != nil {
+					return err
+				}
+			} else if s.additional != nil {
+				if err := s.additional.validate(v[name], p); err != nil {
+					if s.additional.always != nil {
+						return fail("property %q is not allowed", name)
+					}
+					return err
+				}
+			}
+		}
+
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fail("expected at least %d items, got %d", *s.minItems, len(v))
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fail("expected at most %d items, got %d", *s.maxItems, len(v))
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if err := s.items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			return fail("expected at least %d characters, got %d", *s.minLength, n)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fail("expected at most %d characters, got %d", *s.maxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fail("%q does not match the pattern %q", v, s.pattern.String())
+		}
+
+	case json.Number:
+		f, _ := v.Float64()
+		switch {
+		case s.minimum != nil && f < *s.minimum:
+			return fail("%v is less than the minimum %v", v, *s.minimum)
+		case s.maximum != nil && f > *s.maximum:
+			return fail("%v is greater than the maximum %v", v, *s.maximum)
+		case s.exclMin != nil && f <= *s.exclMin:
+			return fail("%v is not greater than %v", v, *s.exclMin)
+		case s.exclMax != nil && f >= *s.exclMax:
+			return fail("%v is not less than %v", v, *s.exclMax)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		if err := sub.validate(v, path); err != nil {
+			return err
+		}
+	}
+	if s.anyOf != nil {
+		ok := false
+		for _, sub := range s.anyOf {
+			if sub.validate(v, path) == nil {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fail("value does not match any of the schemas of anyOf")
+		}
+	}
+	if s.oneOf != nil {
+		n := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(v, path) == nil {
+				n++
+			}
+		}
+		if n != 1 {
+			return fail("value matches %d of the schemas of oneOf, not exactly one", n)
+		}
+	}
+	return nil
+}
+
+// isType reports whether the decoded value v is of the JSON Schema type t.
+func isType(v interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return typeOf(v) == t
+}
+
+// typeOf returns the JSON Schema type of the decoded value v, taking numbers
+// to be of type number.
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal reports whether the decoded values a and b are equal, comparing
+// numbers by value.
+func equal(a, b interface{}) bool {
+	na, aok := a.(json.Number)
+	nb, bok := b.(json.Number)
+	if aok && bok {
+		fa, _ := na.Float64()
+		fb, _ := nb.Float64()
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// integer returns the value of the decoded number v if it is an integer.
+func integer(v interface{}) (int, bool) {
+	f, ok := number(v)
+	if !ok || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// number returns the value of the decoded number v.
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// escape escapes name for use as a reference token of a JSON Pointer.
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// pointer returns the JSON Pointer path, or / for the root.
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
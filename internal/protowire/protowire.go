@@ -0,0 +1,239 @@
+// Package protowire encodes and decodes messages in the protocol buffers wire
+// format, as much of it as is needed to simulate the gRPC services of
+// EventStoreDB without generated code.
+//
+// Messages are encoded by appending fields to a byte slice and decoded into a
+// Message, the list of its fields, from which fields are read by number.
+package protowire
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Wire types of fields.
+const (
+	VarintType  = 0
+	Fixed64Type = 1
+	BytesType   = 2
+	Fixed32Type = 5
+)
+
+// ErrTruncated is returned when a message ends part way through a field.
+var ErrTruncated = errors.New("protowire: message truncated")
+
+// AppendVarint appends v to b as a base 128 varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// AppendTag appends the tag of the field num of wire type typ to b.
+func AppendTag(b []byte, num, typ int) []byte {
+	return AppendVarint(b, uint64(num)<<3|uint64(typ))
+}
+
+// AppendUint appends the varint field num with the value v to b.
+func AppendUint(b []byte, num int, v uint64) []byte {
+	return AppendVarint(AppendTag(b, num, VarintType), v)
+}
+
+// AppendInt appends the int64 field num with the value v to b.
+func AppendInt(b []byte, num int, v int64) []byte {
+	return AppendUint(b, num, uint64(v))
+}
+
+// AppendFixed64 appends the 64 bit field num with the value v to b, as
+// double, fixed64 and sfixed64 fields are encoded.
+func AppendFixed64(b []byte, num int, v uint64) []byte {
+	b = AppendTag(b, num, Fixed64Type)
+	for i := 0; i < 8; i++ {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
+
+// AppendFixed32 appends the 32 bit field num with the value v to b, as float,
+// fixed32 and sfixed32 fields are encoded.
+func AppendFixed32(b []byte, num int, v uint32) []byte {
+	b = AppendTag(b, num, Fixed32Type)
+	for i := 0; i < 4; i++ {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
+
+// AppendBytes appends the length delimited field num with the value v to b.
+// Embedded messages are appended as their encoding.
+func AppendBytes(b []byte, num int, v []byte) []byte {
+	b = AppendVarint(AppendTag(b, num, BytesType), uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendString appends the string field num with the value v to b.
+func AppendString(b []byte, num int, v string) []byte {
+	b = AppendVarint(AppendTag(b, num, BytesType), uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendMapEntry appends an entry of the map<string, string> field num to b.
+func AppendMapEntry(b []byte, num int, key, value string) []byte {
+	var e []byte
+	e = AppendString(e, 1, key)
+	e = AppendString(e, 2, value)
+	return AppendBytes(b, num, e)
+}
+
+// Field is a decoded field of a message. Varint holds the value of varint and
+// fixed width fields and Bytes the value of length delimited fields.
+type Field struct {
+	Num    int
+	Type   int
+	Varint uint64
+	Bytes  []byte
+}
+
+// Message is a decoded message, the list of its fields in the order they were
+// encoded.
+type Message []Field
+
+// Parse decodes the fields of the message b. The values of length delimited
+// fields refer to b.
+func Parse(b []byte) (Message, error) {
+	var m Message
+	for len(b) > 0 {
+		tag, n := consumeVarint(b)
+		if n == 0 {
+			return nil, ErrTruncated
+		}
+		b = b[n:]
+		f := Field{Num: int(tag >> 3), Type: int(tag & 7)}
+		if f.Num <= 0 || tag>>3 > math.MaxInt32 {
+			return nil, fmt.Errorf("protowire: invalid field number %d", tag>>3)
+		}
+		switch f.Type {
+		case VarintType:
+			if f.Varint, n = consumeVarint(b); n == 0 {
+				return nil, ErrTruncated
+			}
+		case Fixed64Type:
+			if n = 8; len(b) < n {
+				return nil, ErrTruncated
+			}
+			for i := 7; i >= 0; i-- {
+				f.Varint = f.Varint<<8 | uint64(b[i])
+			}
+		case Fixed32Type:
+			if n = 4; len(b) < n {
+				return nil, ErrTruncated
+			}
+			for i := 3; i >= 0; i-- {
+				f.Varint = f.Varint<<8 | uint64(b[i])
+			}
+		case BytesType:
+			l, ln := consumeVarint(b)
+			if ln == 0 || uint64(len(b)-ln) < l {
+				return nil, ErrTruncated
+			}
+			f.Bytes = b[ln : ln+int(l)]
+			n = ln + int(l)
+		default:
+			return nil, fmt.Errorf("protowire: unsupported wire type %d of field %d", f.Type, f.Num)
+		}
+		b = b[n:]
+		m = append(m, f)
+	}
+	return m, nil
+}
+
+// consumeVarint decodes the varint at the start of b, returning its value and
+// length, or a length of zero if b does not start with a valid varint.
+func consumeVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// Get returns the field num of m. As in protocol buffers the last occurrence
+// of a field wins.
+func (m Message) Get(num int) (Field, bool) {
+	for i := len(m) - 1; i >= 0; i-- {
+		if m[i].Num == num {
+			return m[i], true
+		}
+	}
+	return Field{}, false
+}
+
+// Has reports whether m has the field num, such as the member of a oneof.
+func (m Message) Has(num int) bool {
+	_, ok := m.Get(num)
+	return ok
+}
+
+// Uint returns the value of the varint field num of m, or zero if m does not
+// have the field.
+func (m Message) Uint(num int) uint64 {
+	f, _ := m.Get(num)
+	return f.Varint
+}
+
+// Bytes returns the value of the length delimited field num of m, or nil if
+// m does not have the field.
+func (m Message) Bytes(num int) []byte {
+	f, _ := m.Get(num)
+	return f.Bytes
+}
+
+// String returns the value of the string field num of m.
+func (m Message) String(num int) string {
+	return string(m.Bytes(num))
+}
+
+// Message decodes the embedded message field num of m. A missing field is
+// decoded as an empty message.
+func (m Message) Message(num int) (Message, error) {
+	return Parse(m.Bytes(num))
+}
+
+// Messages decodes every occurrence of the repeated embedded message field
+// num of m.
+func (m Message) Messages(num int) ([]Message, error) {
+	var ms []Message
+	for _, f := range m {
+		if f.Num != num {
+			continue
+		}
+		v, err := Parse(f.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, v)
+	}
+	return ms, nil
+}
+
+// Map decodes the map<string, string> field num of m.
+func (m Message) Map(num int) (map[string]string, error) {
+	v := map[string]string{}
+	for _, f := range m {
+		if f.Num != num {
+			continue
+		}
+		e, err := Parse(f.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		v[e.String(1)] = e.String(2)
+	}
+	return v, nil
+}
@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+type MockSuite struct{}
+
+var _ = Suite(&MockSuite{})
+
+func Test(t *testing.T) { TestingT(t) }
+
+// Test that code written against the single package still serves a stream
+// through faults using the re-exported names.
+func (s *MockSuite) TestReexportsServeStreamThroughFaults(c *C) {
+	stream := "compat-stream"
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	es := CreateTestEvents(5, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	sim, err := NewAtomFeedSimulator(WithEvents(es...), WithBaseURL(u), WithMetrics())
+	c.Assert(err, IsNil)
+	fi := NewFaultInjector(sim)
+	fi.FailNth(1, http.StatusServiceUnavailable)
+	mux.Handle("/", fi)
+
+	su := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusOK} {
+		resp, err := http.Get(su)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, want)
+	}
+	c.Assert(sim.StreamEvents(stream), HasLen, 5)
+}